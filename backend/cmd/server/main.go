@@ -11,14 +11,22 @@ import (
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
 
+	"wikikeeper-backend/internal/cache"
 	"wikikeeper-backend/internal/config"
 	"wikikeeper-backend/internal/database"
+	"wikikeeper-backend/internal/dataset"
 	"wikikeeper-backend/internal/handlers"
 	applogger "wikikeeper-backend/internal/logger"
 	appmiddleware "wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/migrate"
+	"wikikeeper-backend/internal/notify"
+	"wikikeeper-backend/internal/ratelimit"
+	"wikikeeper-backend/internal/repository"
 	"wikikeeper-backend/internal/services"
+	"wikikeeper-backend/internal/webhook"
+	"wikikeeper-backend/migrations"
 )
 
 func main() {
@@ -42,6 +50,14 @@ func main() {
 
 	applogger.Log.Info("database connection successful")
 
+	if err := checkSchema(context.Background(), db, cfg); err != nil {
+		applogger.Log.Error("database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize response cache
+	cache.Init(time.Duration(cfg.ResponseCacheTTL * float64(time.Second)))
+
 	// Initialize services
 	mwService := services.NewMediaWikiService(
 		time.Duration(cfg.HTTPTimeout)*time.Second,
@@ -51,8 +67,26 @@ func main() {
 		time.Duration(cfg.HTTPTimeout)*time.Second,
 		cfg.HTTPUserAgent,
 		cfg.ArchiveCheckDelay,
+		cfg.WikiArchiveCheckInterval,
 	)
 
+	// Start chat notifiers before anything records events
+	if cfg.NotifyConfig != "" {
+		notifyCfg, err := notify.LoadConfig(cfg.NotifyConfig)
+		if err != nil {
+			applogger.Log.Error("failed to load notifier config", "error", err)
+			os.Exit(1)
+		}
+		notifier, err := notify.New(db, notifyCfg, time.Duration(cfg.HTTPTimeout*float64(time.Second)), cfg.HTTPUserAgent)
+		if err != nil {
+			applogger.Log.Error("failed to create notifiers", "error", err)
+			os.Exit(1)
+		}
+		notifier.Start(context.Background())
+		defer notifier.Stop()
+		services.AddEventListener(notifier.Handle)
+	}
+
 	// Start collection scheduler
 	scheduler := services.NewCollectionScheduler(db, mwService, archiveService, cfg)
 	ctx := context.Background()
@@ -66,77 +100,164 @@ func main() {
 	applogger.Log.Info("archive check scheduler started")
 	defer archiveScheduler.Stop()
 
+	// Start maintenance jobs
+	jobScheduler := services.NewJobScheduler()
+	if cfg.DatasetDir != "" && cfg.DatasetInterval > 0 {
+		interval := time.Duration(cfg.DatasetInterval * float64(time.Minute))
+		exporter := dataset.NewExporter(cfg.DatasetDir, cfg.DatasetKeep,
+			fmt.Sprintf("%s %s", cfg.AppName, cfg.AppVersion), dataset.DefaultSources(db)...)
+		jobScheduler.Register(services.Job{
+			Name:     "dataset_export",
+			Interval: interval,
+			Run: func(ctx context.Context) error {
+				_, err := exporter.ExportIfStale(ctx, interval)
+				return err
+			},
+		})
+	}
+	if cfg.CatalogSnapshotInterval > 0 {
+		snapshotRepo := repository.NewCatalogSnapshotRepository(db)
+		jobScheduler.Register(services.Job{
+			Name:     "catalog_snapshot",
+			Interval: time.Duration(cfg.CatalogSnapshotInterval * float64(time.Minute)),
+			Run: func(ctx context.Context) error {
+				_, err := snapshotRepo.Capture(ctx, time.Now())
+				return err
+			},
+		})
+	}
+	if cfg.WebhookDeliveryInterval > 0 {
+		dispatcher := webhook.NewDispatcher(db, webhook.NewSender(
+			time.Duration(cfg.WebhookTimeout*float64(time.Second)), cfg.HTTPUserAgent))
+		jobScheduler.Register(services.Job{
+			Name:     "webhook_delivery",
+			Interval: time.Duration(cfg.WebhookDeliveryInterval * float64(time.Second)),
+			Run:      dispatcher.DeliverDue,
+		})
+	}
+	if cfg.TrashRetentionDays > 0 {
+		wikiRepo := repository.NewWikiRepository(db)
+		jobScheduler.Register(services.Job{
+			Name:     "trash_purge",
+			Interval: time.Hour,
+			Run: func(ctx context.Context) error {
+				purged, err := wikiRepo.PurgeTrashedBefore(ctx, time.Now().AddDate(0, 0, -cfg.TrashRetentionDays))
+				if purged > 0 {
+					cache.Invalidate()
+					applogger.Log.Info("purged wikis from trash", "count", purged, "retention_days", cfg.TrashRetentionDays)
+				}
+				return err
+			},
+		})
+	}
+	if cfg.StatsRawRetentionDays > 0 {
+		rollupRepo := repository.NewStatsRollupRepository(db)
+		checkRepo := repository.NewCheckRepository(db)
+		retention := repository.StatsRetention{RawDays: cfg.StatsRawRetentionDays, DailyDays: cfg.StatsDailyRetentionDays}
+		jobScheduler.Register(services.Job{
+			Name:     "stats_rollup",
+			Interval: time.Hour,
+			Run: func(ctx context.Context) error {
+				now := time.Now().UTC()
+				result, err := rollupRepo.Apply(ctx, retention, now)
+				if result.RawRows > 0 || result.DailyRows > 0 {
+					applogger.Log.Info("rolled up stats", "raw_rows", result.RawRows, "daily_rows", result.DailyRows)
+				}
+				if err != nil {
+					return err
+				}
+				// Checks only fill gaps between raw rows, so they expire with them
+				deleted, err := checkRepo.DeleteBefore(ctx, retention.RawCutoff(now))
+				if deleted > 0 {
+					applogger.Log.Info("deleted old checks", "count", deleted)
+				}
+				return err
+			},
+		})
+	}
+	// Pre-check submitted wikis whose check failed or was cut short by a restart
+	jobScheduler.Register(services.Job{
+		Name:     "submission_precheck",
+		Interval: time.Minute,
+		Run:      handlers.NewSubmissionQueue(db, cfg).CheckPending,
+	})
+	sessionRepo := repository.NewAuthSessionRepository(db)
+	jobScheduler.Register(services.Job{
+		Name:     "auth_session_cleanup",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := sessionRepo.DeleteExpired(ctx, time.Now().UTC())
+			return err
+		},
+	})
+
+	// Rate limits, sharing buckets through the database when RATE_LIMIT_STORE=postgres
+	rateLimits := map[string]string{
+		"api":    cfg.RateLimitAPI,
+		"submit": cfg.RateLimitSubmit,
+		"export": cfg.RateLimitExport,
+		"admin":  cfg.RateLimitAdmin,
+	}
+	limits := make(map[string]ratelimit.Limit, len(rateLimits))
+	var longestFill time.Duration
+	for group, value := range rateLimits {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			applogger.Log.Error("invalid rate limit", "group", group, "error", err)
+			os.Exit(1)
+		}
+		limits[group] = limit
+		longestFill = max(longestFill, limit.FillTime())
+	}
+	var rateLimitStore ratelimit.Store
+	switch cfg.RateLimitStore {
+	case "memory":
+		rateLimitStore = ratelimit.NewMemoryStore()
+	case "postgres":
+		dbStore := ratelimit.NewDBStore(db)
+		rateLimitStore = dbStore
+		jobScheduler.Register(services.Job{
+			Name:     "rate_limit_cleanup",
+			Interval: time.Hour,
+			Run: func(ctx context.Context) error {
+				_, err := dbStore.DeleteIdle(ctx, time.Now().Add(-longestFill))
+				return err
+			},
+		})
+	default:
+		applogger.Log.Error("invalid RATE_LIMIT_STORE, want memory or postgres", "store", cfg.RateLimitStore)
+		os.Exit(1)
+	}
+	limiter := appmiddleware.NewRateLimiter(rateLimitStore, appmiddleware.NewAuthenticator(db, cfg), limits)
+
+	jobScheduler.Start(ctx)
+	defer jobScheduler.Stop()
+
 	// Create Echo instance
 	e := echo.New()
+	ipExtractor, err := appmiddleware.IPExtractor(cfg.TrustedProxies)
+	if err != nil {
+		applogger.Log.Error("invalid TRUSTED_PROXIES", "error", err)
+		os.Exit(1)
+	}
+	e.IPExtractor = ipExtractor
 
 	// Middleware
 	e.Use(middleware.Recover())
+	if cfg.AuthDisabled {
+		applogger.Log.Warn("AUTH_DISABLED is set, the admin API is open to everyone")
+	}
 	applogger.Log.Info("CORS allowed origins", "origins", cfg.AllowOrigins)
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
 		AllowOrigins:     cfg.AllowOrigins,
 		AllowMethods:     []string{echo.GET, echo.POST, echo.DELETE, echo.PUT, echo.OPTIONS},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization},
-		ExposeHeaders:    []string{echo.HeaderContentLength},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, appmiddleware.HeaderCSRFToken},
+		ExposeHeaders:    append([]string{echo.HeaderContentLength}, appmiddleware.RateLimitHeaders...),
 		AllowCredentials: true,
 	}))
 	e.Use(appmiddleware.PrometheusMiddleware())
 
-	// Initialize handlers with database
-	healthHandler := handlers.NewHealthHandler(cfg)
-	wikiHandler := handlers.NewWikiHandler(db, cfg)
-	statsHandler := handlers.NewStatsHandler(db, cfg)
-	adminHandler := handlers.NewAdminHandler(db, cfg)
-	authHandler := handlers.NewAuthHandler(cfg)
-
-	// Routes
-	e.GET("/", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{
-			"name":    cfg.AppName,
-			"version": cfg.AppVersion,
-			"docs":    "/docs",
-			"health":  "/health",
-		})
-	})
-
-	e.GET("/health", healthHandler.Check)
-
-	// API routes
-	api := e.Group("/api")
-
-	// Auth callback endpoint (for cross-domain cookie setting)
-	api.GET("/auth/callback", authHandler.Callback)
-	// Auth check endpoint (for verifying authentication status)
-	api.GET("/auth/check", authHandler.Check)
-
-	// Public stats endpoint (no auth required)
-	api.GET("/stats/summary", statsHandler.Summary)
-
-	// Wiki routes - public (GET requests for viewing data)
-	api.GET("/wikis", wikiHandler.List)
-	api.GET("/wikis/:id", wikiHandler.Get)
-	api.GET("/wikis/:id/stats", wikiHandler.GetStats)
-	api.GET("/wikis/:id/archives", wikiHandler.GetArchives)
-	api.GET("/wikis/:id/thumbnail", wikiHandler.GetThumbnail)
-
-	// Wiki routes - public POST with rate limiting
-	api.POST("/wikis", wikiHandler.Create)
-	api.POST("/wikis/:id/check", wikiHandler.TriggerCheck)
-	api.POST("/wikis/:id/check-archive", wikiHandler.CheckArchive)
-
-	// Admin routes - require admin token
-	admin := api.Group("/admin")
-	admin.Use(appmiddleware.AdminAuth(cfg))
-
-	// Admin wiki management
-	admin.DELETE("/wikis/:id", adminHandler.DeleteWiki)
-	admin.GET("/wikis/:id/stats", adminHandler.GetWikiStats)
-
-	// Admin bulk operations
-	admin.POST("/collect-all", adminHandler.CollectAll)
-	admin.POST("/check-all-archives", adminHandler.CheckAllArchives)
-
-	// Prometheus metrics endpoint
-	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+	registerRoutes(e, db, cfg, limiter)
 
 	// Start server
 	address := fmt.Sprintf("%s:%d", cfg.Host, cfg.Port)
@@ -165,3 +286,22 @@ func main() {
 
 	applogger.Log.Info("server exited")
 }
+
+// checkSchema refuses to serve a database whose schema version differs from the
+// migrations built into the binary, applying pending ones first when configured to
+func checkSchema(ctx context.Context, db *gorm.DB, cfg *config.Config) error {
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+	if cfg.DBMigrateOnStart {
+		applied, err := m.Up(ctx, 0)
+		for _, migration := range applied {
+			applogger.Log.Info("[Migrate] applied migration", "version", migration.Version, "name", migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return m.Check(ctx)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/handlers"
+	appmiddleware "wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+)
+
+// registerRoutes registers all HTTP routes.
+// Every route must also be described in handlers.BuildOpenAPISpec.
+// A nil limiter disables rate limiting.
+func registerRoutes(e *echo.Echo, db *gorm.DB, cfg *config.Config, limiter *appmiddleware.RateLimiter) {
+	// Initialize handlers with database
+	healthHandler := handlers.NewHealthHandler(cfg)
+	wikiHandler := handlers.NewWikiHandler(db, cfg)
+	statsHandler := handlers.NewStatsHandler(db, cfg)
+	adminHandler := handlers.NewAdminHandler(db, cfg)
+	authHandler := handlers.NewAuthHandler(db, cfg)
+	exportHandler := handlers.NewExportHandler(db, cfg)
+	datasetHandler := handlers.NewDatasetHandler(cfg)
+	docsHandler := handlers.NewDocsHandler(cfg)
+	rankingsHandler := handlers.NewRankingsHandler(db, cfg)
+	compareHandler := handlers.NewCompareHandler(db, cfg)
+	feedHandler := handlers.NewFeedHandler(db, cfg)
+	badgeHandler := handlers.NewBadgeHandler(db, cfg)
+	tagHandler := handlers.NewTagHandler(db, cfg)
+	noteHandler := handlers.NewNoteHandler(db, cfg)
+	policyHandler := handlers.NewPolicyHandler(db, cfg)
+	webhookHandler := handlers.NewWebhookHandler(db, cfg)
+	tokenHandler := handlers.NewTokenHandler(db, cfg)
+	auditHandler := handlers.NewAuditHandler(db, cfg)
+	submissionHandler := handlers.NewSubmissionHandler(db, cfg)
+	authenticator := appmiddleware.NewAuthenticator(db, cfg)
+	auditor := appmiddleware.NewAuditor(db, authenticator)
+	audit := auditor.Record
+
+	// Routes
+	e.GET("/", func(c echo.Context) error {
+		return c.JSON(200, map[string]string{
+			"name":    cfg.AppName,
+			"version": cfg.AppVersion,
+			"docs":    "/docs",
+			"health":  "/health",
+		})
+	})
+
+	e.GET("/health", healthHandler.Check)
+
+	// API documentation
+	e.GET("/openapi.json", docsHandler.OpenAPI)
+	e.GET("/docs", docsHandler.Docs)
+
+	// API routes - rate limited per token or client IP, CSRF-protected for session cookies
+	api := e.Group("/api", limiter.Group("api"), appmiddleware.CSRFProtect(authenticator))
+
+	// Auth login code and callback endpoints (for cross-domain cookie setting), and OIDC login
+	api.POST("/auth/login-code", authHandler.LoginCode)
+	api.GET("/auth/callback", authHandler.Callback)
+	api.GET("/auth/oidc/login", authHandler.OIDCLogin)
+	api.GET("/auth/oidc/callback", authHandler.OIDCCallback)
+	api.POST("/auth/logout", authHandler.Logout)
+	// Auth check endpoint (for verifying authentication status)
+	api.GET("/auth/check", authHandler.Check)
+
+	// Public stats endpoint (no auth required)
+	api.GET("/stats/summary", statsHandler.Summary)
+	api.GET("/stats/history", statsHandler.History)
+
+	// Public leaderboards
+	api.GET("/rankings", rankingsHandler.List)
+
+	// Public tag list
+	api.GET("/tags", tagHandler.List)
+
+	// Wiki routes - public (GET requests for viewing data)
+	api.GET("/wikis", wikiHandler.List)
+	api.GET("/wikis/lookup", wikiHandler.Lookup)
+	api.GET("/wikis/:id", wikiHandler.Get)
+	api.GET("/wikis/:id/stats", wikiHandler.GetStats)
+	api.GET("/wikis/:id/archives", wikiHandler.GetArchives)
+	api.GET("/wikis/:id/thumbnail", wikiHandler.GetThumbnail)
+	api.GET("/wikis/:id/feed.atom", feedHandler.Wiki)
+	api.GET("/wikis/:id/badge.svg", badgeHandler.ByID)
+	api.GET("/badge.svg", badgeHandler.ByURL)
+	api.GET("/compare", compareHandler.Compare)
+
+	// Atom feeds of catalog events
+	api.GET("/feeds/archives.atom", feedHandler.Archives)
+	api.GET("/feeds/offline.atom", feedHandler.Offline)
+	api.GET("/feeds/wikis.atom", feedHandler.Wikis)
+
+	// Dataset export routes - streamed and gzip-compressible, public unless EXPORT_REQUIRE_TOKEN is set
+	export := api.Group("/export", middleware.Gzip())
+	if cfg.ExportRequireToken {
+		export.Use(appmiddleware.TokenAuth(authenticator), appmiddleware.RequireScope(models.ScopeExport))
+	}
+	export.Use(limiter.Group("export"))
+	export.GET("/wikis", exportHandler.Wikis)
+	export.GET("/stats", exportHandler.Stats)
+	export.GET("/archives", exportHandler.Archives)
+
+	// Submission challenge and status, for anonymous submitters
+	api.GET("/submissions/challenge", submissionHandler.Challenge)
+	api.GET("/submissions/:id", submissionHandler.Get)
+
+	// Published dataset snapshots
+	api.GET("/datasets", datasetHandler.List)
+	api.GET("/datasets/:version", datasetHandler.Get)
+	api.GET("/datasets/:version/:file", datasetHandler.GetFile)
+
+	// Wiki routes - public POST with rate limiting, recorded in the audit log.
+	// Wikis submitted without the wikis:write scope go to the moderation queue.
+	submit := limiter.Group("submit")
+	api.POST("/wikis", wikiHandler.Create, submit, appmiddleware.OptionalAuth(authenticator), audit("wiki.create"))
+	api.POST("/wikis/:id/check", wikiHandler.TriggerCheck, submit, audit("wiki.check"))
+	api.POST("/wikis/:id/check-archive", wikiHandler.CheckArchive, submit, audit("wiki.check_archive"))
+
+	// Admin routes - require a token with the scope named on each route; changes,
+	// and attempts refused for a missing scope, are recorded in the audit log
+	admin := api.Group("/admin")
+	admin.Use(appmiddleware.TokenAuth(authenticator), limiter.Group("admin"))
+	canRead := appmiddleware.RequireScope(models.ScopeAdminRead)
+	canDelete := appmiddleware.RequireScope(models.ScopeAdminDelete)
+	canCollect := appmiddleware.RequireScope(models.ScopeAdminCollect)
+	canWrite := appmiddleware.RequireScope(models.ScopeWikisWrite)
+	isAdmin := appmiddleware.RequireScope(models.ScopeAdmin)
+
+	// Admin wiki management
+	admin.DELETE("/wikis/:id", adminHandler.DeleteWiki, audit("wiki.delete"), canDelete)
+	admin.POST("/wikis/:id/restore", adminHandler.RestoreWiki, audit("wiki.restore"), canDelete)
+	admin.GET("/trash", adminHandler.ListTrash, canRead)
+	admin.GET("/wikis/:id/stats", adminHandler.GetWikiStats, canRead)
+	admin.POST("/wikis/:id/merge", adminHandler.MergeWiki, audit("wiki.merge"), canDelete)
+	admin.GET("/wikis/:id/merges", adminHandler.ListMerges, canRead)
+	admin.GET("/wikis/:id/policy", policyHandler.Get, canRead)
+	admin.PUT("/wikis/:id/policy", policyHandler.Update, audit("wiki.policy.update"), canCollect)
+
+	// Admin curation: tags and notes
+	admin.PUT("/tags/:tag", tagHandler.Upsert, audit("tag.upsert"), canWrite)
+	admin.DELETE("/tags/:tag", tagHandler.Delete, audit("tag.delete"), canWrite)
+	admin.POST("/wikis/:id/tags", tagHandler.AddWikiTags, audit("wiki.tags.add"), canWrite)
+	admin.PUT("/wikis/:id/tags", tagHandler.SetWikiTags, audit("wiki.tags.set"), canWrite)
+	admin.DELETE("/wikis/:id/tags/:tag", tagHandler.RemoveWikiTag, audit("wiki.tags.remove"), canWrite)
+	admin.GET("/wikis/:id/notes", noteHandler.List, canRead)
+	admin.POST("/wikis/:id/notes", noteHandler.Create, audit("wiki.note.create"), canWrite)
+	admin.DELETE("/wikis/:id/notes/:note_id", noteHandler.Delete, audit("wiki.note.delete"), canWrite)
+
+	// Admin moderation of submitted wikis
+	admin.GET("/submissions", submissionHandler.List, canRead)
+	admin.GET("/submissions/:id", submissionHandler.AdminGet, canRead)
+	admin.POST("/submissions/:id/approve", submissionHandler.Approve, audit("submission.approve"), canWrite)
+	admin.POST("/submissions/:id/reject", submissionHandler.Reject, audit("submission.reject"), canWrite)
+
+	// Admin webhooks
+	admin.GET("/webhooks", webhookHandler.List, isAdmin)
+	admin.POST("/webhooks", webhookHandler.Create, audit("webhook.create"), isAdmin)
+	admin.GET("/webhooks/:id", webhookHandler.Get, isAdmin)
+	admin.PUT("/webhooks/:id", webhookHandler.Update, audit("webhook.update"), isAdmin)
+	admin.DELETE("/webhooks/:id", webhookHandler.Delete, audit("webhook.delete"), isAdmin)
+	admin.GET("/webhooks/:id/deliveries", webhookHandler.ListDeliveries, isAdmin)
+	admin.POST("/webhooks/:id/deliveries/:delivery_id/retry", webhookHandler.RetryDelivery, audit("webhook.delivery.retry"), isAdmin)
+	admin.POST("/webhooks/:id/test", webhookHandler.Test, audit("webhook.test"), isAdmin)
+
+	// Admin API tokens
+	admin.GET("/tokens", tokenHandler.List, isAdmin)
+	admin.POST("/tokens", tokenHandler.Create, audit("token.create"), isAdmin)
+	admin.DELETE("/tokens/:id", tokenHandler.Revoke, audit("token.revoke"), isAdmin)
+
+	// Admin bulk operations
+	admin.POST("/collect-all", adminHandler.CollectAll, audit("collect_all"), canCollect)
+	admin.POST("/check-all-archives", adminHandler.CheckAllArchives, audit("check_all_archives"), canCollect)
+
+	// Admin audit log
+	admin.GET("/audit", auditHandler.List, isAdmin)
+
+	// Prometheus metrics endpoint
+	e.GET("/metrics", echo.WrapHandler(promhttp.Handler()))
+}
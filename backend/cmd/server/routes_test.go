@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/handlers"
+	"wikikeeper-backend/internal/openapi"
+)
+
+// TestRoutesDocumented fails when a route is registered without being
+// described in the OpenAPI spec, or the spec describes a missing route.
+func TestRoutesDocumented(t *testing.T) {
+	cfg := &config.Config{AppName: "WikiKeeper", AppVersion: "test"}
+	e := echo.New()
+	registerRoutes(e, nil, cfg, nil)
+	spec := handlers.BuildOpenAPISpec(cfg)
+
+	registered := map[string]bool{}
+	for _, route := range e.Routes() {
+		if route.Method == echo.RouteNotFound {
+			continue
+		}
+		path, _ := openapi.ConvertPath(route.Path)
+		registered[strings.ToLower(route.Method)+" "+path] = true
+		assert.True(t, spec.HasOperation(route.Method, route.Path),
+			"route %s %s is not documented in the OpenAPI spec", route.Method, route.Path)
+	}
+
+	for path, item := range spec.Paths {
+		for method := range item {
+			assert.True(t, registered[method+" "+path],
+				"documented operation %s %s is not registered", strings.ToUpper(method), path)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Command wikikeeper administers a WikiKeeper database from the command line.
+//
+//	wikikeeper token create -name NAME -scopes SCOPE[,SCOPE...] [-expires 90d] [-by WHO]
+//	wikikeeper token list [-all]
+//	wikikeeper token revoke ID
+//	wikikeeper migrate up [-steps N]
+//	wikikeeper migrate down [-steps N]
+//	wikikeeper migrate status
+//	wikikeeper migrate force VERSION
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/database"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+const usage = `Usage:
+  wikikeeper token create -name NAME -scopes SCOPE[,SCOPE...] [-expires 90d] [-by WHO]
+  wikikeeper token list [-all]
+  wikikeeper token revoke ID
+  wikikeeper migrate up [-steps N]
+  wikikeeper migrate down [-steps N]
+  wikikeeper migrate status
+  wikikeeper migrate force VERSION
+
+Scopes: %s
+`
+
+func main() {
+	if len(os.Args) < 3 || (os.Args[1] != "token" && os.Args[1] != "migrate") {
+		fmt.Fprintf(os.Stderr, usage, strings.Join(models.APIScopes, ", "))
+		os.Exit(2)
+	}
+
+	cfg := config.Load()
+	applogger.Init(cfg.LogLevel)
+
+	db, err := database.Connect()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to connect to database: %v\n", err)
+		os.Exit(1)
+	}
+	defer database.Close()
+
+	ctx := context.Background()
+	args := os.Args[3:]
+	if os.Args[1] == "migrate" {
+		err = runMigrate(ctx, db, os.Args[2], args)
+	} else {
+		switch os.Args[2] {
+		case "create":
+			err = tokenCreate(ctx, db, args)
+		case "list":
+			err = tokenList(ctx, db, args)
+		case "revoke":
+			err = tokenRevoke(ctx, db, args)
+		default:
+			err = fmt.Errorf("unknown token command %q", os.Args[2])
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func tokenCreate(ctx context.Context, db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("token create", flag.ExitOnError)
+	name := fs.String("name", "", "name of the person or bot the token is for")
+	scopes := fs.String("scopes", "", "comma-separated scopes")
+	expires := fs.String("expires", "", "lifetime such as 90d or 12h; empty never expires")
+	by := fs.String("by", "cli", "recorded as the creator")
+	fs.Parse(args)
+
+	var expiresAt *time.Time
+	if *expires != "" {
+		lifetime, err := parseLifetime(*expires)
+		if err != nil {
+			return err
+		}
+		t := time.Now().UTC().Add(lifetime)
+		expiresAt = &t
+	}
+
+	var scopeList []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopeList = append(scopeList, scope)
+		}
+	}
+
+	token, raw, err := models.NewAPIToken(*name, scopeList, expiresAt, *by)
+	if err != nil {
+		return err
+	}
+	if err := repository.NewAPITokenRepository(db).Create(ctx, token); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created token %s (%s) with scopes %s\n", token.ID, token.Name, strings.Join(token.Scopes, ","))
+	fmt.Println("Store it now, it is not shown again:")
+	fmt.Println(raw)
+	return nil
+}
+
+func tokenList(ctx context.Context, db *gorm.DB, args []string) error {
+	fs := flag.NewFlagSet("token list", flag.ExitOnError)
+	all := fs.Bool("all", false, "include revoked tokens")
+	fs.Parse(args)
+
+	tokens, err := repository.NewAPITokenRepository(db).List(ctx, *all)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tEXPIRES\tLAST USED\tSTATUS")
+	now := time.Now()
+	for _, t := range tokens {
+		status := "active"
+		switch {
+		case t.RevokedAt != nil:
+			status = "revoked"
+		case !t.Usable(now):
+			status = "expired"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			t.ID, t.Name, t.Prefix, strings.Join(t.Scopes, ","), formatTime(t.ExpiresAt), formatTime(t.LastUsedAt), status)
+	}
+	return w.Flush()
+}
+
+func tokenRevoke(ctx context.Context, db *gorm.DB, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: wikikeeper token revoke ID")
+	}
+	id, err := uuid.Parse(args[0])
+	if err != nil {
+		return fmt.Errorf("invalid token ID: %w", err)
+	}
+
+	found, err := repository.NewAPITokenRepository(db).Revoke(ctx, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("token %s not found or already revoked", id)
+	}
+	fmt.Printf("Revoked token %s\n", id)
+	return nil
+}
+
+// parseLifetime parses a Go duration or a number of days such as 90d
+func parseLifetime(s string) (time.Duration, error) {
+	var d time.Duration
+	var err error
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		var n int
+		n, err = strconv.Atoi(days)
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err = time.ParseDuration(s)
+	}
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid lifetime %q: want a positive duration such as 90d or 12h", s)
+	}
+	return d, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
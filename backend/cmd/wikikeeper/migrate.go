@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/migrate"
+	"wikikeeper-backend/migrations"
+)
+
+func runMigrate(ctx context.Context, db *gorm.DB, command string, args []string) error {
+	m, err := migrate.New(db, migrations.FS)
+	if err != nil {
+		return err
+	}
+	switch command {
+	case "up":
+		return migrateUp(ctx, m, args)
+	case "down":
+		return migrateDown(ctx, m, args)
+	case "status":
+		return migrateStatus(ctx, m)
+	case "force":
+		return migrateForce(ctx, m, args)
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
+
+func migrateUp(ctx context.Context, m *migrate.Migrator, args []string) error {
+	fs := flag.NewFlagSet("migrate up", flag.ExitOnError)
+	steps := fs.Int("steps", 0, "number of migrations to apply; 0 applies all")
+	fs.Parse(args)
+
+	applied, err := m.Up(ctx, *steps)
+	for _, migration := range applied {
+		fmt.Printf("Applied %d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Println("Schema is up to date")
+	}
+	return nil
+}
+
+func migrateDown(ctx context.Context, m *migrate.Migrator, args []string) error {
+	fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	fs.Parse(args)
+	if *steps < 1 {
+		return fmt.Errorf("-steps must be at least 1")
+	}
+
+	reverted, err := m.Down(ctx, *steps)
+	for _, migration := range reverted {
+		fmt.Printf("Reverted %d_%s\n", migration.Version, migration.Name)
+	}
+	if err != nil {
+		return err
+	}
+	if len(reverted) == 0 {
+		fmt.Println("No migration to revert")
+	}
+	return nil
+}
+
+func migrateStatus(ctx context.Context, m *migrate.Migrator) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Current version: %d\n", status.Current)
+	fmt.Printf("Latest version:  %d\n", status.Latest)
+	if status.Dirty {
+		fmt.Println("State:           dirty, repair the schema and run 'wikikeeper migrate force VERSION'")
+	}
+	switch {
+	case status.Current > status.Latest:
+		fmt.Println("The database is newer than this binary")
+	case len(status.Pending) == 0:
+		fmt.Println("Schema is up to date")
+	default:
+		fmt.Println("Pending:")
+		for _, migration := range status.Pending {
+			fmt.Printf("  %d_%s\n", migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+func migrateForce(ctx context.Context, m *migrate.Migrator, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: wikikeeper migrate force VERSION")
+	}
+	version, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || version < 0 {
+		return fmt.Errorf("invalid version %q", args[0])
+	}
+	if err := m.Force(ctx, version); err != nil {
+		return err
+	}
+	fmt.Printf("Recorded schema version %d\n", version)
+	return nil
+}
@@ -0,0 +1,129 @@
+// Package badge renders flat, shields.io-style SVG badges.
+package badge
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"text/template"
+)
+
+// ContentType is the media type of rendered badges
+const ContentType = "image/svg+xml; charset=utf-8"
+
+// Badge colors
+const (
+	ColorGreen     = "#4c1"
+	ColorYellow    = "#dfb317"
+	ColorOrange    = "#fe7d37"
+	ColorRed       = "#e05d44"
+	ColorBlue      = "#007ec6"
+	ColorLightGrey = "#9f9f9f"
+)
+
+// horizontalPadding is added on both sides of each half of the badge
+const horizontalPadding = 5
+
+var svgTemplate = template.Must(template.New("badge").Parse(`<svg xmlns="http://www.w3.org/2000/svg" width="{{.Width}}" height="20" role="img" aria-label="{{.Label}}: {{.Message}}">` +
+	`<title>{{.Label}}: {{.Message}}</title>` +
+	`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>` +
+	`<clipPath id="r"><rect width="{{.Width}}" height="20" rx="3" fill="#fff"/></clipPath>` +
+	`<g clip-path="url(#r)">` +
+	`<rect width="{{.LabelWidth}}" height="20" fill="#555"/>` +
+	`<rect x="{{.LabelWidth}}" width="{{.MessageWidth}}" height="20" fill="{{.Color}}"/>` +
+	`<rect width="{{.Width}}" height="20" fill="url(#s)"/>` +
+	`</g>` +
+	`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` +
+	`<text x="{{.LabelX}}" y="15" fill="#010101" fill-opacity=".3">{{.Label}}</text>` +
+	`<text x="{{.LabelX}}" y="14">{{.Label}}</text>` +
+	`<text x="{{.MessageX}}" y="15" fill="#010101" fill-opacity=".3">{{.Message}}</text>` +
+	`<text x="{{.MessageX}}" y="14">{{.Message}}</text>` +
+	`</g></svg>`))
+
+// layout holds the escaped texts and computed geometry passed to svgTemplate
+type layout struct {
+	Label, Message, Color  string
+	Width                  int
+	LabelWidth, LabelX     int
+	MessageWidth, MessageX int
+}
+
+// Render returns the SVG of a badge with label on the left and message on the right
+func Render(label, message, color string) []byte {
+	labelWidth := TextWidth(label) + 2*horizontalPadding
+	messageWidth := TextWidth(message) + 2*horizontalPadding
+
+	l := layout{
+		// text/template does not escape, so XML-escape texts up front
+		Label:        html.EscapeString(label),
+		Message:      html.EscapeString(message),
+		Color:        html.EscapeString(color),
+		Width:        labelWidth + messageWidth,
+		LabelWidth:   labelWidth,
+		LabelX:       labelWidth / 2,
+		MessageWidth: messageWidth,
+		MessageX:     labelWidth + messageWidth/2,
+	}
+
+	var buf bytes.Buffer
+	if err := svgTemplate.Execute(&buf, l); err != nil {
+		// The template is static and only receives strings and ints
+		panic(fmt.Sprintf("badge: render template: %v", err))
+	}
+	return buf.Bytes()
+}
+
+// TextWidth approximates the rendered width in pixels of s in 11px Verdana
+func TextWidth(s string) int {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case r == ' ':
+			width += 3.9
+		case r == 'i' || r == 'l' || r == 'j' || r == '.' || r == ',' || r == ':' || r == ';' || r == '|' || r == '!' || r == '\'':
+			width += 3.4
+		case r == 'f' || r == 't' || r == 'r' || r == 'I' || r == '(' || r == ')' || r == '-':
+			width += 4.8
+		case r == 'm' || r == 'w' || r == 'M' || r == 'W':
+			width += 10.5
+		case r >= 'A' && r <= 'Z':
+			width += 7.6
+		case r >= '0' && r <= '9':
+			width += 7.0
+		case r < 0x80:
+			width += 6.6
+		default:
+			// Wide scripts such as CJK
+			width += 11
+		}
+	}
+	return int(width + 0.5)
+}
+
+// FormatCount abbreviates a count the way badges usually do: 950, 12k, 1.5M
+func FormatCount(n int64) string {
+	abs := n
+	if abs < 0 {
+		abs = -abs
+	}
+	switch {
+	case abs >= 1_000_000_000:
+		return trimZero(fmt.Sprintf("%.1f", float64(n)/1e9)) + "G"
+	case abs >= 1_000_000:
+		return trimZero(fmt.Sprintf("%.1f", float64(n)/1e6)) + "M"
+	case abs >= 10_000:
+		return fmt.Sprintf("%.0fk", float64(n)/1e3)
+	case abs >= 1_000:
+		return trimZero(fmt.Sprintf("%.1f", float64(n)/1e3)) + "k"
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// trimZero drops a trailing ".0"
+func trimZero(s string) string {
+	if len(s) > 2 && s[len(s)-2:] == ".0" {
+		return s[:len(s)-2]
+	}
+	return s
+}
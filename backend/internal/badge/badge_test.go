@@ -0,0 +1,78 @@
+package badge
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	svg := string(Render("archived", "2024-05-01", ColorGreen))
+
+	var doc struct {
+		XMLName xml.Name `xml:"svg"`
+		Width   int      `xml:"width,attr"`
+		Title   string   `xml:"title"`
+	}
+	if err := xml.Unmarshal([]byte(svg), &doc); err != nil {
+		t.Fatalf("Expected valid XML, got %v\n%s", err, svg)
+	}
+	if doc.Title != "archived: 2024-05-01" {
+		t.Errorf("Expected title 'archived: 2024-05-01', got %q", doc.Title)
+	}
+	if doc.Width != TextWidth("archived")+TextWidth("2024-05-01")+4*horizontalPadding {
+		t.Errorf("Unexpected width %d", doc.Width)
+	}
+	if !strings.Contains(svg, `fill="#4c1"`) {
+		t.Errorf("Expected message color in output\n%s", svg)
+	}
+}
+
+func TestRenderEscapes(t *testing.T) {
+	svg := Render(`<script>`, `"a" & b`, ColorRed)
+
+	var doc struct {
+		Title string `xml:"title"`
+	}
+	if err := xml.Unmarshal(svg, &doc); err != nil {
+		t.Fatalf("Expected valid XML, got %v\n%s", err, svg)
+	}
+	if doc.Title != `<script>: "a" & b` {
+		t.Errorf("Expected texts to round-trip, got %q", doc.Title)
+	}
+	if strings.Contains(string(svg), "<script>") {
+		t.Errorf("Expected label to be escaped\n%s", svg)
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	if TextWidth("") != 0 {
+		t.Errorf("Expected empty text to have no width")
+	}
+	if TextWidth("iii") >= TextWidth("mmm") {
+		t.Errorf("Expected narrow glyphs to be narrower than wide glyphs")
+	}
+	if TextWidth("online") >= TextWidth("online, 12k pages") {
+		t.Errorf("Expected longer text to be wider")
+	}
+}
+
+func TestFormatCount(t *testing.T) {
+	tests := map[int64]string{
+		0:             "0",
+		950:           "950",
+		1000:          "1k",
+		1500:          "1.5k",
+		12345:         "12k",
+		999_499:       "999k",
+		1_000_000:     "1M",
+		2_345_678:     "2.3M",
+		3_000_000_000: "3G",
+		-1500:         "-1.5k",
+	}
+	for n, want := range tests {
+		if got := FormatCount(n); got != want {
+			t.Errorf("FormatCount(%d) = %q, expected %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"wikikeeper-backend/internal/metrics"
+)
+
+// Cache is an in-process TTL cache for expensive query results.
+// A zero TTL disables caching: Get always misses and Set is a no-op.
+type Cache struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]entry
+	now     func() time.Time
+}
+
+type entry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+// New creates a cache whose entries expire after ttl
+func New(ttl time.Duration) *Cache {
+	return &Cache{
+		ttl:     ttl,
+		entries: make(map[string]entry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache) Get(key string) (interface{}, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.RLock()
+	e, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || c.now().After(e.expiresAt) {
+		metrics.CacheRequestsTotal.WithLabelValues("miss").Inc()
+		return nil, false
+	}
+	metrics.CacheRequestsTotal.WithLabelValues("hit").Inc()
+	return e.value, true
+}
+
+// Set stores value under key
+func (c *Cache) Set(key string, value interface{}) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	// Drop expired entries so keys derived from query strings cannot grow unbounded
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry{value: value, expiresAt: now.Add(c.ttl)}
+}
+
+// Invalidate removes all entries
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]entry)
+}
+
+// Len returns the number of stored entries, including expired ones
+func (c *Cache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+var shared = New(0)
+
+// Init configures the shared cache used by handlers and invalidated by collectors
+func Init(ttl time.Duration) {
+	shared = New(ttl)
+}
+
+// Shared returns the shared cache
+func Shared() *Cache {
+	return shared
+}
+
+// Invalidate clears the shared cache. Called whenever collectors write new data.
+func Invalidate() {
+	shared.Invalidate()
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache_GetSet(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New(time.Minute)
+	c.now = func() time.Time { return now }
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+
+	c.Set("key", 42)
+	value, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, 42, value)
+
+	// Expired after the TTL
+	now = now.Add(2 * time.Minute)
+	_, ok = c.Get("key")
+	assert.False(t, ok)
+
+	// Expired entries are dropped on the next Set
+	c.Set("other", 1)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	c := New(time.Minute)
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	c.Invalidate()
+
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestCache_Disabled(t *testing.T) {
+	c := New(0)
+	c.Set("key", 1)
+
+	_, ok := c.Get("key")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
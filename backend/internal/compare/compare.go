@@ -0,0 +1,240 @@
+// Package compare builds side-by-side comparisons of wikis: stats series aligned
+// on a common time axis, differences in site metadata and archive coverage.
+package compare
+
+import (
+	"sort"
+	"time"
+
+	"wikikeeper-backend/internal/models"
+)
+
+// Metrics lists the stats columns included in a comparison
+var Metrics = []string{"pages", "articles", "edits", "images", "users", "active_users"}
+
+// Series maps a metric to one value per bucket; nil where a wiki has no sample in the bucket
+type Series map[string][]*int64
+
+// Axis is a common time axis split into equal buckets
+type Axis struct {
+	Start  time.Time
+	End    time.Time
+	Width  time.Duration
+	Points int
+}
+
+// NewAxis splits [start, end] into points buckets
+func NewAxis(start, end time.Time, points int) Axis {
+	if points < 1 {
+		points = 1
+	}
+	width := end.Sub(start) / time.Duration(points)
+	if width <= 0 {
+		width = time.Second
+	}
+	return Axis{Start: start, End: end, Width: width, Points: points}
+}
+
+// Timestamps returns the end of every bucket
+func (a Axis) Timestamps() []time.Time {
+	timestamps := make([]time.Time, a.Points)
+	for i := range timestamps {
+		timestamps[i] = a.Start.Add(time.Duration(i+1) * a.Width)
+	}
+	timestamps[a.Points-1] = a.End
+	return timestamps
+}
+
+// bucket returns the bucket index of t, or -1 if t lies outside the axis
+func (a Axis) bucket(t time.Time) int {
+	if t.Before(a.Start) || t.After(a.End) {
+		return -1
+	}
+	i := int(t.Sub(a.Start) / a.Width)
+	if i >= a.Points {
+		// The end of the axis, and any remainder of the integer division, belong to the last bucket
+		return a.Points - 1
+	}
+	return i
+}
+
+// Downsample aligns stats to the axis, keeping the newest sample of each bucket.
+// Stats may be in any order.
+func Downsample(stats []*models.WikiStats, axis Axis) Series {
+	newest := make([]*models.WikiStats, axis.Points)
+	for _, s := range stats {
+		i := axis.bucket(s.Time)
+		if i < 0 {
+			continue
+		}
+		if newest[i] == nil || s.Time.After(newest[i].Time) {
+			newest[i] = s
+		}
+	}
+
+	series := make(Series, len(Metrics))
+	for _, metric := range Metrics {
+		values := make([]*int64, axis.Points)
+		for i, s := range newest {
+			if s != nil {
+				v := metricValue(s, metric)
+				values[i] = &v
+			}
+		}
+		series[metric] = values
+	}
+	return series
+}
+
+func metricValue(s *models.WikiStats, metric string) int64 {
+	switch metric {
+	case "pages":
+		return int64(s.Pages)
+	case "articles":
+		return int64(s.Articles)
+	case "edits":
+		return int64(s.Edits)
+	case "images":
+		return int64(s.Images)
+	case "users":
+		return int64(s.Users)
+	case "active_users":
+		return int64(s.ActiveUsers)
+	}
+	return 0
+}
+
+// ArchiveCoverage summarizes the Archive.org dumps of a wiki
+type ArchiveCoverage struct {
+	Count               int        `json:"count"`
+	FirstDumpDate       *time.Time `json:"first_dump_date"`
+	LatestDumpDate      *time.Time `json:"latest_dump_date"`
+	DaysSinceLatestDump *int       `json:"days_since_latest_dump"`
+	TotalSize           int64      `json:"total_size"`
+	HasXMLCurrent       bool       `json:"has_xml_current"`
+	HasXMLHistory       bool       `json:"has_xml_history"`
+	HasImagesDump       bool       `json:"has_images_dump"`
+}
+
+// Coverage summarizes archives. Dumps without a dump date are dated by when they were added.
+func Coverage(archives []*models.WikiArchive, now time.Time) ArchiveCoverage {
+	coverage := ArchiveCoverage{Count: len(archives)}
+	for _, archive := range archives {
+		date := archive.DumpDate
+		if date == nil {
+			date = archive.AddedDate
+		}
+		if date != nil {
+			if coverage.FirstDumpDate == nil || date.Before(*coverage.FirstDumpDate) {
+				coverage.FirstDumpDate = date
+			}
+			if coverage.LatestDumpDate == nil || date.After(*coverage.LatestDumpDate) {
+				coverage.LatestDumpDate = date
+			}
+		}
+		if archive.ItemSize != nil {
+			coverage.TotalSize += *archive.ItemSize
+		}
+		coverage.HasXMLCurrent = coverage.HasXMLCurrent || archive.HasXMLCurrent
+		coverage.HasXMLHistory = coverage.HasXMLHistory || archive.HasXMLHistory
+		coverage.HasImagesDump = coverage.HasImagesDump || archive.HasImagesDump
+	}
+	if coverage.LatestDumpDate != nil {
+		days := int(now.Sub(*coverage.LatestDumpDate).Hours() / 24)
+		coverage.DaysSinceLatestDump = &days
+	}
+	return coverage
+}
+
+// FieldDiff compares one metadata field across wikis
+type FieldDiff struct {
+	Same   bool               `json:"same"`
+	Values map[string]*string `json:"values"` // By wiki ID
+}
+
+// ExtensionDiff compares installed extensions across wikis
+type ExtensionDiff struct {
+	Common []string            `json:"common"` // Installed on every wiki
+	Only   map[string][]string `json:"only"`   // By wiki ID: installed there but not on every wiki
+}
+
+// MetadataDiff compares the current site metadata of wikis
+type MetadataDiff struct {
+	MediaWikiVersion FieldDiff     `json:"mediawiki_version"`
+	License          FieldDiff     `json:"license"`
+	Lang             FieldDiff     `json:"lang"`
+	Extensions       ExtensionDiff `json:"extensions"`
+}
+
+// DiffMetadata compares the metadata of wikis
+func DiffMetadata(wikis []*models.Wiki) MetadataDiff {
+	return MetadataDiff{
+		MediaWikiVersion: diffField(wikis, func(w *models.Wiki) *string { return w.MediaWikiVersion }),
+		License:          diffField(wikis, func(w *models.Wiki) *string { return w.License }),
+		Lang:             diffField(wikis, func(w *models.Wiki) *string { return w.Lang }),
+		Extensions:       diffExtensions(wikis),
+	}
+}
+
+func diffField(wikis []*models.Wiki, field func(*models.Wiki) *string) FieldDiff {
+	diff := FieldDiff{Same: true, Values: make(map[string]*string, len(wikis))}
+	var first *string
+	for i, wiki := range wikis {
+		value := field(wiki)
+		diff.Values[wiki.ID.String()] = value
+		if i == 0 {
+			first = value
+		} else if !equalStrings(first, value) {
+			diff.Same = false
+		}
+	}
+	return diff
+}
+
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func diffExtensions(wikis []*models.Wiki) ExtensionDiff {
+	diff := ExtensionDiff{Common: []string{}, Only: make(map[string][]string, len(wikis))}
+
+	counts := make(map[string]int)
+	for _, wiki := range wikis {
+		for _, name := range uniqueNames(wiki.Extensions) {
+			counts[name]++
+		}
+	}
+	for name, count := range counts {
+		if count == len(wikis) {
+			diff.Common = append(diff.Common, name)
+		}
+	}
+	sort.Strings(diff.Common)
+
+	for _, wiki := range wikis {
+		only := []string{}
+		for _, name := range uniqueNames(wiki.Extensions) {
+			if counts[name] < len(wikis) {
+				only = append(only, name)
+			}
+		}
+		sort.Strings(only)
+		diff.Only[wiki.ID.String()] = only
+	}
+	return diff
+}
+
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	result := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
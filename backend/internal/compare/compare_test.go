@@ -0,0 +1,86 @@
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/models"
+)
+
+func TestDownsample(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	axis := NewAxis(start, start.Add(4*time.Hour), 4)
+
+	timestamps := axis.Timestamps()
+	require.Len(t, timestamps, 4)
+	assert.Equal(t, start.Add(time.Hour), timestamps[0])
+	assert.Equal(t, start.Add(4*time.Hour), timestamps[3])
+
+	stats := []*models.WikiStats{
+		{Time: start.Add(10 * time.Minute), Pages: 1},
+		{Time: start.Add(50 * time.Minute), Pages: 2}, // Newest in bucket 0
+		{Time: start.Add(150 * time.Minute), Pages: 3},
+		{Time: start.Add(4 * time.Hour), Pages: 4}, // End belongs to the last bucket
+		{Time: start.Add(-time.Minute), Pages: 99}, // Outside the axis
+		{Time: start.Add(5 * time.Hour), Pages: 99},
+	}
+
+	series := Downsample(stats, axis)
+	require.Len(t, series, len(Metrics))
+	pages := series["pages"]
+	require.Len(t, pages, 4)
+	require.NotNil(t, pages[0])
+	assert.Equal(t, int64(2), *pages[0])
+	assert.Nil(t, pages[1])
+	assert.Equal(t, int64(3), *pages[2])
+	assert.Equal(t, int64(4), *pages[3])
+}
+
+func TestCoverage(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	older := now.AddDate(0, 0, -100)
+	newer := now.AddDate(0, 0, -10)
+	size := int64(1000)
+
+	coverage := Coverage([]*models.WikiArchive{
+		{DumpDate: &older, ItemSize: &size, HasXMLCurrent: true},
+		{AddedDate: &newer, ItemSize: &size, HasXMLHistory: true},
+	}, now)
+
+	assert.Equal(t, 2, coverage.Count)
+	assert.Equal(t, &older, coverage.FirstDumpDate)
+	assert.Equal(t, &newer, coverage.LatestDumpDate)
+	require.NotNil(t, coverage.DaysSinceLatestDump)
+	assert.Equal(t, 10, *coverage.DaysSinceLatestDump)
+	assert.Equal(t, int64(2000), coverage.TotalSize)
+	assert.True(t, coverage.HasXMLCurrent)
+	assert.True(t, coverage.HasXMLHistory)
+	assert.False(t, coverage.HasImagesDump)
+
+	empty := Coverage(nil, now)
+	assert.Equal(t, 0, empty.Count)
+	assert.Nil(t, empty.DaysSinceLatestDump)
+}
+
+func TestDiffMetadata(t *testing.T) {
+	v1, v2 := "MediaWiki 1.39.0", "MediaWiki 1.41.0"
+	license := "CC BY-SA"
+	a := &models.Wiki{ID: uuid.New(), MediaWikiVersion: &v1, License: &license,
+		Extensions: models.StringList{"Cite", "ParserFunctions", "Portable Infobox"}}
+	b := &models.Wiki{ID: uuid.New(), MediaWikiVersion: &v2, License: &license,
+		Extensions: models.StringList{"Cite", "ParserFunctions", "TemplateStyles"}}
+
+	diff := DiffMetadata([]*models.Wiki{a, b})
+
+	assert.False(t, diff.MediaWikiVersion.Same)
+	assert.Equal(t, &v2, diff.MediaWikiVersion.Values[b.ID.String()])
+	assert.True(t, diff.License.Same)
+	assert.True(t, diff.Lang.Same, "both unknown")
+	assert.Equal(t, []string{"Cite", "ParserFunctions"}, diff.Extensions.Common)
+	assert.Equal(t, []string{"Portable Infobox"}, diff.Extensions.Only[a.ID.String()])
+	assert.Equal(t, []string{"TemplateStyles"}, diff.Extensions.Only[b.ID.String()])
+}
@@ -21,6 +21,7 @@ type Config struct {
 	DBUser          string
 	DBPassword      string
 	DBName          string
+	DBMigrateOnStart bool // Apply pending schema migrations when the server starts
 
 	// MongoDB (for migration read-only)
 	MongoDBURI      string
@@ -40,8 +41,69 @@ type Config struct {
 	ArchiveCheckDelay    float64 // Seconds between archive checks
 	ArchiveCheckBatchSize int     // Number of wikis to check per cycle
 
+	// Per-wiki check intervals, unless a wiki's policy sets its own
+	WikiCollectInterval      float64 // Minutes between collections of one wiki
+	WikiArchiveCheckInterval float64 // Minutes between archive checks of one wiki
+
+	// Dataset snapshot settings
+	DatasetDir      string  // Directory for published snapshots (empty disables snapshots)
+	DatasetInterval float64 // Minutes between snapshot exports
+	DatasetKeep     int     // Number of snapshot versions to keep
+
+	// Catalog history
+	CatalogSnapshotInterval float64 // Minutes between refreshes of today's catalog snapshot (0 disables)
+
+	// Trash
+	TrashRetentionDays int // Days deleted wikis stay in the trash before they are purged (0 keeps them forever)
+
+	// Stats retention
+	StatsHeartbeatInterval  float64 // Minutes after which unchanged stats are stored again (0 stores every collection)
+	StatsRawRetentionDays   int // Days raw stats rows are kept before they are rolled up into daily rows (0 keeps them forever)
+	StatsDailyRetentionDays int // Days daily rollups are kept before they are rolled up into monthly rows (0 keeps them forever)
+
+	// Webhooks
+	WebhookDeliveryInterval float64 // Seconds between sweeps of the webhook delivery queue
+	WebhookTimeout          float64 // Seconds before a webhook request is abandoned
+
+	// Chat notifiers
+	NotifyConfig string // Path of the Matrix/Discord/IRC notifier configuration (empty disables notifiers)
+
+	// Response cache
+	ResponseCacheTTL float64 // Seconds to cache expensive aggregates (0 disables the cache)
+
 	// Authentication
-	AdminToken string // Token for admin access
+	AdminToken         string // Shared token with every scope, alongside the API tokens stored in the database
+	ExportRequireToken bool   // Require a token with the export scope for /api/export
+	AuthDisabled       bool   // Open the protected routes to everyone, for development only
+
+	// OpenID Connect login, enabled by setting the issuer and client ID
+	OIDCIssuer        string
+	OIDCClientID      string
+	OIDCClientSecret  string   // Empty for public clients
+	OIDCRedirectURL   string   // Public URL of /api/auth/oidc/callback, registered with the provider
+	OIDCScopes        []string // Scopes requested from the provider
+	OIDCGroupsClaim   string   // ID token claim listing the user's groups
+	OIDCViewerGroups  []string // Groups granted the viewer role
+	OIDCCuratorGroups []string // Groups granted the curator role
+	OIDCAdminGroups   []string // Groups granted the admin role
+
+	// Moderation of wikis submitted without the wikis:write scope
+	SubmissionChallenge       string   // Bot check for submissions: pow (proof of work), captcha or none
+	SubmissionPoWDifficulty   int      // Leading zero bits the proof-of-work hash needs
+	SubmissionChallengeSecret string   // Key signing proof-of-work challenges (random per process if empty)
+	SubmissionCaptchaVerify   string   // siteverify URL of the captcha provider (hCaptcha, Turnstile, reCAPTCHA)
+	SubmissionCaptchaSecret   string   // Secret key for the siteverify request
+	SubmissionCaptchaSiteKey  string   // Public site key the frontend renders the captcha with
+	SubmissionAutoApprove     bool     // Approve submissions whose pre-check finds a new, reachable MediaWiki
+	SubmissionBlockedDomains  []string // Domains (and their subdomains) whose submissions are rejected
+
+	// Rate limits, written as requests/period[:burst] such as 60/1m:20 ("0" disables)
+	RateLimitStore  string   // memory, or postgres to share buckets between instances
+	RateLimitAPI    string   // Every /api request
+	RateLimitSubmit string   // Submitting wikis and triggering checks
+	RateLimitExport string   // Dataset exports
+	RateLimitAdmin  string   // Admin routes
+	TrustedProxies  []string // IPs or CIDR ranges whose X-Forwarded-For is honored ("none" trusts no proxy)
 
 	// CORS
 	AllowOrigins []string // CORS allowed origins
@@ -73,6 +135,7 @@ func Load() *Config {
 		DBUser:          getEnv("DB_USER", "wikikeeper"),
 		DBPassword:      getEnv("DB_PASSWORD", "wikikeeper123"),
 		DBName:          getEnv("DB_NAME", "wikikeeper"),
+		DBMigrateOnStart: getEnvBool("DB_MIGRATE_ON_START", false),
 		MongoDBURI:      getEnv("MONGODB_URI", "mongodb://localhost:27017"),
 		MongoDBDBName:   getEnv("MONGODB_DB_NAME", "wikikeeper"),
 		HTTPTimeout:     getEnvFloat("HTTP_TIMEOUT", 30.0),
@@ -83,7 +146,46 @@ func Load() *Config {
 		ArchiveCheckInterval: getEnvFloat("ARCHIVE_CHECK_INTERVAL", 720.0), // 720 minutes = 12 hours
 		ArchiveCheckDelay:    getEnvFloat("ARCHIVE_CHECK_DELAY", 1.0), // 1 second between checks
 		ArchiveCheckBatchSize: getEnvInt("ARCHIVE_CHECK_BATCH_SIZE", 100), // Check 100 wikis per cycle
-		AdminToken:      getEnv("ADMIN_TOKEN", ""), // Empty means no admin protection
+		WikiCollectInterval:      getEnvFloat("WIKI_COLLECT_INTERVAL", 4320.0), // 4320 minutes = 3 days
+		WikiArchiveCheckInterval: getEnvFloat("WIKI_ARCHIVE_CHECK_INTERVAL", 4320.0),
+		DatasetDir:      getEnv("DATASET_DIR", ""), // Empty means snapshots are disabled
+		DatasetInterval: getEnvFloat("DATASET_INTERVAL", 1440.0), // 1440 minutes = 1 day
+		DatasetKeep:     getEnvInt("DATASET_KEEP", 7),
+		CatalogSnapshotInterval: getEnvFloat("CATALOG_SNAPSHOT_INTERVAL", 60.0),
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+		StatsHeartbeatInterval:  getEnvFloat("STATS_HEARTBEAT_INTERVAL", 1440.0), // 1440 minutes = 1 day
+		StatsRawRetentionDays:   getEnvInt("STATS_RAW_RETENTION_DAYS", 90),
+		StatsDailyRetentionDays: getEnvInt("STATS_DAILY_RETENTION_DAYS", 730),
+		WebhookDeliveryInterval: getEnvFloat("WEBHOOK_DELIVERY_INTERVAL", 10.0),
+		WebhookTimeout:          getEnvFloat("WEBHOOK_TIMEOUT", 10.0),
+		NotifyConfig:            getEnv("NOTIFY_CONFIG", ""),
+		ResponseCacheTTL: getEnvFloat("RESPONSE_CACHE_TTL", 60.0),
+		AdminToken:      getEnv("ADMIN_TOKEN", ""),
+		ExportRequireToken: getEnvBool("EXPORT_REQUIRE_TOKEN", false),
+		AuthDisabled:       getEnvBool("AUTH_DISABLED", false),
+		OIDCIssuer:         getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:    getEnv("OIDC_REDIRECT_URL", "http://localhost:8000/api/auth/oidc/callback"),
+		OIDCScopes:         getEnvStringSlice("OIDC_SCOPES", []string{"openid", "profile", "email", "groups"}),
+		OIDCGroupsClaim:    getEnv("OIDC_GROUPS_CLAIM", "groups"),
+		OIDCViewerGroups:   getEnvStringSlice("OIDC_VIEWER_GROUPS", nil),
+		OIDCCuratorGroups:  getEnvStringSlice("OIDC_CURATOR_GROUPS", nil),
+		OIDCAdminGroups:    getEnvStringSlice("OIDC_ADMIN_GROUPS", nil),
+		SubmissionChallenge:       getEnv("SUBMISSION_CHALLENGE", "pow"),
+		SubmissionPoWDifficulty:   getEnvInt("SUBMISSION_POW_DIFFICULTY", 16),
+		SubmissionChallengeSecret: getEnv("SUBMISSION_CHALLENGE_SECRET", ""),
+		SubmissionCaptchaVerify:   getEnv("SUBMISSION_CAPTCHA_VERIFY_URL", ""),
+		SubmissionCaptchaSecret:   getEnv("SUBMISSION_CAPTCHA_SECRET", ""),
+		SubmissionCaptchaSiteKey:  getEnv("SUBMISSION_CAPTCHA_SITE_KEY", ""),
+		SubmissionAutoApprove:     getEnvBool("SUBMISSION_AUTO_APPROVE", true),
+		SubmissionBlockedDomains:  getEnvStringSlice("SUBMISSION_BLOCKED_DOMAINS", nil),
+		RateLimitStore:  getEnv("RATE_LIMIT_STORE", "memory"),
+		RateLimitAPI:    getEnv("RATE_LIMIT_API", "300/1m:100"),
+		RateLimitSubmit: getEnv("RATE_LIMIT_SUBMIT", "30/1h:10"),
+		RateLimitExport: getEnv("RATE_LIMIT_EXPORT", "30/1h:5"),
+		RateLimitAdmin:  getEnv("RATE_LIMIT_ADMIN", "0"),
+		TrustedProxies:  getEnvStringSlice("TRUSTED_PROXIES", []string{"127.0.0.0/8", "::1", "10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"}),
 		AllowOrigins:    getEnvStringSlice("ALLOW_ORIGINS", []string{"http://localhost:5173", "http://localhost:3000", "http://localhost:8000"}),
 		LogLevel:        getEnv("LOG_LEVEL", "INFO"),
 	}
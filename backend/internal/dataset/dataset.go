@@ -0,0 +1,364 @@
+package dataset
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// FormatVersion is bumped whenever the layout or record format of a snapshot changes
+const FormatVersion = 1
+
+const (
+	// ManifestFile describes a snapshot and every file in it
+	ManifestFile = "manifest.json"
+	// ChecksumFile lists SHA-256 checksums in sha256sum(1) format
+	ChecksumFile = "SHA256SUMS"
+
+	versionLayout = "20060102T150405Z"
+	tmpPrefix     = ".tmp-"
+)
+
+var versionPattern = regexp.MustCompile(`^\d{8}T\d{6}Z$`)
+
+// ErrNotFound is returned when a snapshot version or file does not exist
+var ErrNotFound = errors.New("dataset not found")
+
+// FileInfo describes one compressed JSONL file of a snapshot
+type FileInfo struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Records     int64    `json:"records"`
+	Bytes       int64    `json:"bytes"`
+	SHA256      string   `json:"sha256"`
+	Fields      []string `json:"fields"`
+}
+
+// Manifest describes a published snapshot
+type Manifest struct {
+	FormatVersion int        `json:"format_version"`
+	Version       string     `json:"version"`
+	GeneratedAt   time.Time  `json:"generated_at"`
+	Generator     string     `json:"generator"`
+	Files         []FileInfo `json:"files"`
+}
+
+// Source produces the records of one snapshot file
+type Source struct {
+	Name        string      // File name, e.g. "wikis.jsonl.gz"
+	Description string      // Human-readable description for the manifest
+	Record      interface{} // Zero value of the record type, used to list fields
+	Stream      func(ctx context.Context, emit func(interface{}) error) error
+}
+
+// Exporter writes versioned snapshots into a directory
+type Exporter struct {
+	dir       string
+	keep      int
+	generator string
+	sources   []Source
+}
+
+// NewExporter creates a new exporter writing into dir and keeping the newest keep versions
+func NewExporter(dir string, keep int, generator string, sources ...Source) *Exporter {
+	return &Exporter{
+		dir:       dir,
+		keep:      keep,
+		generator: generator,
+		sources:   sources,
+	}
+}
+
+// DefaultSources returns the sources of a full WikiKeeper snapshot:
+// all wikis, the latest stats of every wiki and all archives
+func DefaultSources(db *gorm.DB) []Source {
+	return []Source{
+		{
+			Name:        "wikis.jsonl.gz",
+			Description: "All tracked wikis",
+			Record:      models.Wiki{},
+			Stream: func(ctx context.Context, emit func(interface{}) error) error {
+				return repository.NewWikiRepository(db).StreamForExport(ctx, repository.ExportOptions{},
+					func(w *models.Wiki) error { return emit(w) })
+			},
+		},
+		{
+			Name:        "stats_latest.jsonl.gz",
+			Description: "Latest statistics of every wiki",
+			Record:      models.WikiStats{},
+			Stream: func(ctx context.Context, emit func(interface{}) error) error {
+				return repository.NewStatsRepository(db).StreamLatest(ctx,
+					func(s *models.WikiStats) error { return emit(s) })
+			},
+		},
+		{
+			Name:        "archives.jsonl.gz",
+			Description: "All known Internet Archive dumps",
+			Record:      models.WikiArchive{},
+			Stream: func(ctx context.Context, emit func(interface{}) error) error {
+				return repository.NewArchiveRepository(db).StreamForExport(ctx, repository.ExportOptions{},
+					func(a *models.WikiArchive) error { return emit(a) })
+			},
+		},
+	}
+}
+
+// ExportIfStale exports a new snapshot unless the newest one is younger than maxAge
+func (e *Exporter) ExportIfStale(ctx context.Context, maxAge time.Duration) (*Manifest, error) {
+	manifests, err := List(e.dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifests) > 0 && time.Since(manifests[0].GeneratedAt) < maxAge {
+		return nil, nil
+	}
+	return e.Export(ctx)
+}
+
+// Export writes a new snapshot. Files are written into a temporary directory
+// which is renamed into place once complete, so readers never see partial snapshots.
+func (e *Exporter) Export(ctx context.Context) (*Manifest, error) {
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create dataset dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	manifest := &Manifest{
+		FormatVersion: FormatVersion,
+		Version:       now.Format(versionLayout),
+		GeneratedAt:   now,
+		Generator:     e.generator,
+	}
+
+	tmpDir := filepath.Join(e.dir, tmpPrefix+manifest.Version)
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create snapshot dir: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for _, source := range e.sources {
+		info, err := writeSource(ctx, tmpDir, source)
+		if err != nil {
+			return nil, fmt.Errorf("export %s: %w", source.Name, err)
+		}
+		manifest.Files = append(manifest.Files, *info)
+	}
+
+	if err := writeManifest(tmpDir, manifest); err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmpDir, filepath.Join(e.dir, manifest.Version)); err != nil {
+		return nil, fmt.Errorf("publish snapshot: %w", err)
+	}
+
+	if err := e.prune(); err != nil {
+		return manifest, fmt.Errorf("prune snapshots: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// writeSource streams one source into a gzip-compressed JSONL file
+func writeSource(ctx context.Context, dir string, source Source) (*FileInfo, error) {
+	f, err := os.Create(filepath.Join(dir, source.Name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	counter := &countingWriter{}
+	gz := gzip.NewWriter(io.MultiWriter(f, hasher, counter))
+	enc := json.NewEncoder(gz)
+
+	var records int64
+	err = source.Stream(ctx, func(record interface{}) error {
+		records++
+		return enc.Encode(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+
+	return &FileInfo{
+		Name:        source.Name,
+		Description: source.Description,
+		Records:     records,
+		Bytes:       counter.n,
+		SHA256:      hex.EncodeToString(hasher.Sum(nil)),
+		Fields:      jsonFields(source.Record),
+	}, nil
+}
+
+// writeManifest writes the manifest and the checksum file
+func writeManifest(dir string, manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), data, 0o644); err != nil {
+		return err
+	}
+
+	var sums strings.Builder
+	for _, file := range manifest.Files {
+		fmt.Fprintf(&sums, "%s  %s\n", file.SHA256, file.Name)
+	}
+	manifestSum := sha256.Sum256(data)
+	fmt.Fprintf(&sums, "%s  %s\n", hex.EncodeToString(manifestSum[:]), ManifestFile)
+
+	return os.WriteFile(filepath.Join(dir, ChecksumFile), []byte(sums.String()), 0o644)
+}
+
+// prune removes the oldest snapshots beyond the retention count
+func (e *Exporter) prune() error {
+	if e.keep <= 0 {
+		return nil
+	}
+	versions, err := listVersions(e.dir)
+	if err != nil {
+		return err
+	}
+	for i := e.keep; i < len(versions); i++ {
+		if err := os.RemoveAll(filepath.Join(e.dir, versions[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List returns the manifests of all published snapshots, newest first
+func List(dir string) ([]*Manifest, error) {
+	versions, err := listVersions(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := make([]*Manifest, 0, len(versions))
+	for _, version := range versions {
+		manifest, err := Load(dir, version)
+		if err != nil {
+			continue // Skip snapshots with a missing or unreadable manifest
+		}
+		manifests = append(manifests, manifest)
+	}
+	return manifests, nil
+}
+
+// Load reads the manifest of a snapshot version. "latest" resolves to the newest snapshot.
+func Load(dir, version string) (*Manifest, error) {
+	if version == "latest" {
+		versions, err := listVersions(dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, ErrNotFound
+		}
+		version = versions[0]
+	}
+	if !versionPattern.MatchString(version) {
+		return nil, ErrNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, version, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// FilePath resolves a file of a snapshot to a path on disk. Only the manifest,
+// the checksum file and files listed in the manifest can be resolved.
+func FilePath(dir string, manifest *Manifest, name string) (string, error) {
+	if name == ManifestFile || name == ChecksumFile {
+		return filepath.Join(dir, manifest.Version, name), nil
+	}
+	for _, file := range manifest.Files {
+		if file.Name == name {
+			return filepath.Join(dir, manifest.Version, name), nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+// listVersions returns snapshot version directories, newest first
+func listVersions(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var versions []string
+	for _, entry := range entries {
+		if entry.IsDir() && versionPattern.MatchString(entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
+	}
+	// Version names are UTC timestamps, so lexical order is chronological
+	sort.Sort(sort.Reverse(sort.StringSlice(versions)))
+	return versions, nil
+}
+
+// jsonFields lists the JSON field names of a struct value
+func jsonFields(v interface{}) []string {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+// countingWriter counts bytes written through it
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
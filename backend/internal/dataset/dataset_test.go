@@ -0,0 +1,163 @@
+package dataset
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRecord struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Secret string `json:"-"`
+}
+
+func testSource(n int) Source {
+	return Source{
+		Name:        "records.jsonl.gz",
+		Description: "Test records",
+		Record:      testRecord{},
+		Stream: func(ctx context.Context, emit func(interface{}) error) error {
+			for i := 0; i < n; i++ {
+				if err := emit(testRecord{ID: i, Name: "record"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func TestExporter_Export(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewExporter(dir, 3, "WikiKeeper test", testSource(5))
+
+	manifest, err := exporter.Export(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, FormatVersion, manifest.FormatVersion)
+	require.Len(t, manifest.Files, 1)
+
+	file := manifest.Files[0]
+	assert.Equal(t, int64(5), file.Records)
+	assert.Equal(t, []string{"id", "name"}, file.Fields)
+
+	// Checksum and size match the file on disk
+	path, err := FilePath(dir, manifest, file.Name)
+	require.NoError(t, err)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	sum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256)
+	assert.Equal(t, int64(len(data)), file.Bytes)
+
+	// File contains one JSON record per line
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	scanner := bufio.NewScanner(gz)
+	lines := 0
+	for scanner.Scan() {
+		var rec testRecord
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &rec))
+		lines++
+	}
+	assert.Equal(t, 5, lines)
+
+	// Checksum file lists data files and the manifest
+	sums, err := os.ReadFile(filepath.Join(dir, manifest.Version, ChecksumFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(sums), file.SHA256+"  "+file.Name)
+	assert.Contains(t, string(sums), ManifestFile)
+
+	// No temporary directories left behind
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	for _, entry := range entries {
+		assert.False(t, strings.HasPrefix(entry.Name(), tmpPrefix))
+	}
+}
+
+func TestLoad_Latest(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "20240101T000000Z")
+	writeTestManifest(t, dir, "20240301T000000Z")
+	writeTestManifest(t, dir, "20240201T000000Z")
+
+	manifest, err := Load(dir, "latest")
+	require.NoError(t, err)
+	assert.Equal(t, "20240301T000000Z", manifest.Version)
+
+	manifests, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+	assert.Equal(t, "20240301T000000Z", manifests[0].Version)
+	assert.Equal(t, "20240101T000000Z", manifests[2].Version)
+
+	_, err = Load(dir, "../etc")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFilePath_RejectsUnlistedFiles(t *testing.T) {
+	manifest := &Manifest{Version: "20240101T000000Z", Files: []FileInfo{{Name: "wikis.jsonl.gz"}}}
+
+	_, err := FilePath("/data", manifest, "wikis.jsonl.gz")
+	assert.NoError(t, err)
+	_, err = FilePath("/data", manifest, ChecksumFile)
+	assert.NoError(t, err)
+	_, err = FilePath("/data", manifest, "../../etc/passwd")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestExporter_Prune(t *testing.T) {
+	dir := t.TempDir()
+	writeTestManifest(t, dir, "20240101T000000Z")
+	writeTestManifest(t, dir, "20240201T000000Z")
+
+	exporter := NewExporter(dir, 2, "WikiKeeper test", testSource(1))
+	manifest, err := exporter.Export(context.Background())
+	require.NoError(t, err)
+
+	manifests, err := List(dir)
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.Equal(t, manifest.Version, manifests[0].Version)
+	assert.Equal(t, "20240201T000000Z", manifests[1].Version)
+}
+
+func TestExporter_ExportIfStale(t *testing.T) {
+	dir := t.TempDir()
+	exporter := NewExporter(dir, 0, "WikiKeeper test", testSource(1))
+
+	first, err := exporter.ExportIfStale(context.Background(), time.Hour)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+
+	second, err := exporter.ExportIfStale(context.Background(), time.Hour)
+	require.NoError(t, err)
+	assert.Nil(t, second)
+}
+
+func writeTestManifest(t *testing.T, dir, version string) {
+	t.Helper()
+	generatedAt, err := time.Parse(versionLayout, version)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, version), 0o755))
+	require.NoError(t, writeManifest(filepath.Join(dir, version), &Manifest{
+		FormatVersion: FormatVersion,
+		Version:       version,
+		GeneratedAt:   generatedAt,
+	}))
+}
@@ -0,0 +1,86 @@
+// Package feed renders Atom 1.0 (RFC 4287) documents.
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// ContentType is the media type of Atom documents
+const ContentType = "application/atom+xml; charset=utf-8"
+
+const namespace = "http://www.w3.org/2005/Atom"
+
+// Feed is an Atom feed document
+type Feed struct {
+	XMLName  xml.Name `xml:"feed"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	ID       string   `xml:"id"`
+	Title    string   `xml:"title"`
+	Subtitle string   `xml:"subtitle,omitempty"`
+	Updated  Time     `xml:"updated"`
+	Links    []Link   `xml:"link"`
+	Author   *Person  `xml:"author,omitempty"`
+	Entries  []Entry  `xml:"entry"`
+}
+
+// Entry is a single Atom entry
+type Entry struct {
+	ID         string     `xml:"id"`
+	Title      string     `xml:"title"`
+	Updated    Time       `xml:"updated"`
+	Published  *Time      `xml:"published,omitempty"`
+	Links      []Link     `xml:"link"`
+	Categories []Category `xml:"category"`
+	Summary    *Text      `xml:"summary,omitempty"`
+}
+
+// Link is an Atom link element
+type Link struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+// Category is an Atom category element
+type Category struct {
+	Term string `xml:"term,attr"`
+}
+
+// Person is an Atom person construct
+type Person struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+// Text is a plain text construct
+type Text struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// Time marshals as an RFC 3339 timestamp in UTC
+type Time time.Time
+
+// MarshalText implements encoding.TextMarshaler
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(time.Time(t).UTC().Format(time.RFC3339)), nil
+}
+
+// PlainText returns a plain text construct, or nil for an empty string
+func PlainText(s string) *Text {
+	if s == "" {
+		return nil
+	}
+	return &Text{Type: "text", Body: s}
+}
+
+// Marshal renders the feed including the XML declaration
+func Marshal(f *Feed) ([]byte, error) {
+	f.Xmlns = namespace
+	body, err := xml.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,64 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshal(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*3600))
+	f := &Feed{
+		ID:      "https://example.org/api/feeds/wikis.atom",
+		Title:   "New wikis",
+		Updated: Time(updated),
+		Links:   []Link{{Href: "https://example.org/api/feeds/wikis.atom", Rel: "self"}},
+		Entries: []Entry{{
+			ID:         "urn:uuid:5a1c6e0e-0000-0000-0000-000000000000",
+			Title:      "Added <Example> & co",
+			Updated:    Time(updated),
+			Links:      []Link{{Href: "https://wiki.example.com/"}},
+			Categories: []Category{{Term: "wiki_added"}},
+			Summary:    PlainText("summary"),
+		}},
+	}
+
+	body, err := Marshal(f)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out := string(body)
+
+	if !strings.HasPrefix(out, xml.Header) {
+		t.Errorf("Expected XML declaration, got %q", out)
+	}
+	for _, want := range []string{
+		`<feed xmlns="http://www.w3.org/2005/Atom">`,
+		`<updated>2024-05-01T10:00:00Z</updated>`,
+		`<link href="https://example.org/api/feeds/wikis.atom" rel="self"></link>`,
+		`<title>Added &lt;Example&gt; &amp; co</title>`,
+		`<category term="wiki_added"></category>`,
+		`<summary type="text">summary</summary>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %s\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<published>") || strings.Contains(out, "<author>") {
+		t.Errorf("Expected optional elements to be omitted\n%s", out)
+	}
+
+	// The document must round-trip through a generic decoder
+	var decoded struct {
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if len(decoded.Entries) != 1 || decoded.Entries[0].Title != "Added <Example> & co" {
+		t.Errorf("Unexpected decoded entries: %+v", decoded.Entries)
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -11,7 +12,10 @@ import (
 
 	applogger "wikikeeper-backend/internal/logger"
 
+	"wikikeeper-backend/internal/cache"
 	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
 	"wikikeeper-backend/internal/repository"
 	"wikikeeper-backend/internal/services"
 )
@@ -27,7 +31,33 @@ func NewAdminHandler(db *gorm.DB, cfg *config.Config) *AdminHandler {
 	return &AdminHandler{db: db, config: cfg}
 }
 
+// WikiDeleteRequest represents the request of DELETE /api/admin/wikis/:id
+type WikiDeleteRequest struct {
+	Reason string `json:"reason" query:"reason"`
+}
+
+// TrashListRequest represents query parameters for GET /api/admin/trash
+type TrashListRequest struct {
+	Page     int `query:"page"`
+	PageSize int `query:"page_size"`
+}
+
+// trashEntry is a wiki in the trash with the time it becomes eligible for purging
+type trashEntry struct {
+	*models.Wiki
+	PurgeAfter *time.Time `json:"purge_after,omitempty"` // Absent when purging is disabled
+}
+
+// trashListResponse is the JSON body of GET /api/admin/trash
+type trashListResponse struct {
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+	Data     []trashEntry `json:"data"`
+}
+
 // DeleteWiki handles DELETE /api/admin/wikis/:id
+// Moves the wiki to the trash; it is purged after the configured retention period.
 func (h *AdminHandler) DeleteWiki(c echo.Context) error {
 	idStr := c.Param("id")
 	id, err := uuid.Parse(idStr)
@@ -35,29 +65,88 @@ func (h *AdminHandler) DeleteWiki(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
 	}
 
-	wikiRepo := repository.NewWikiRepository(h.db)
-	ctx := c.Request().Context()
+	var req WikiDeleteRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	var reason *string
+	if r := strings.TrimSpace(req.Reason); r != "" {
+		reason = &r
+	}
 
-	// Check if exists
-	_, err = wikiRepo.GetByID(ctx, id)
-	if err != nil {
+	actor := middleware.Actor(c)
+	if err := repository.NewWikiRepository(h.db).Trash(c.Request().Context(), id, actor, reason); err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
 		}
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
+	cache.Invalidate()
+
+	applogger.Log.Info("[Admin] Wiki moved to trash", "wiki_id", id, "actor", actor, "reason", req.Reason)
+
+	response := map[string]string{
+		"detail":  "Wiki moved to trash",
+		"wiki_id": idStr,
+	}
+	if purgeAfter := h.purgeAfter(time.Now()); purgeAfter != nil {
+		response["purge_after"] = purgeAfter.UTC().Format(time.RFC3339)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListTrash handles GET /api/admin/trash
+func (h *AdminHandler) ListTrash(c echo.Context) error {
+	var req TrashListRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 10
+	}
 
-	// Delete wiki (cascades to stats and archives)
-	if err := wikiRepo.Delete(ctx, id); err != nil {
+	wikis, total, err := repository.NewWikiRepository(h.db).ListTrash(c.Request().Context(), req.Page, req.PageSize)
+	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
-	applogger.Log.Info("[Admin] Wiki %s deleted", id)
+	entries := make([]trashEntry, len(wikis))
+	for i, wiki := range wikis {
+		entries[i] = trashEntry{Wiki: wiki, PurgeAfter: h.purgeAfter(wiki.DeletedAt.Time)}
+	}
+	return c.JSON(http.StatusOK, trashListResponse{Total: total, Page: req.Page, PageSize: req.PageSize, Data: entries})
+}
 
-	return c.JSON(http.StatusOK, map[string]string{
-		"detail":  "Wiki deleted",
-		"wiki_id": idStr,
-	})
+// RestoreWiki handles POST /api/admin/wikis/:id/restore
+func (h *AdminHandler) RestoreWiki(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	wiki, err := repository.NewWikiRepository(h.db).Restore(c.Request().Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found in trash"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	cache.Invalidate()
+
+	applogger.Log.Info("[Admin] Wiki restored from trash", "wiki_id", id, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, wiki)
+}
+
+// purgeAfter returns when a wiki deleted at deletedAt becomes eligible for purging, or nil if purging is disabled
+func (h *AdminHandler) purgeAfter(deletedAt time.Time) *time.Time {
+	if h.config.TrashRetentionDays <= 0 {
+		return nil
+	}
+	t := deletedAt.AddDate(0, 0, h.config.TrashRetentionDays)
+	return &t
 }
 
 // CollectAll handles POST /api/admin/collect-all
@@ -89,7 +178,7 @@ func (h *AdminHandler) CollectAll(c echo.Context) error {
 		errorCount := 0
 
 		for i, wiki := range wikis {
-			if !wiki.IsActive {
+			if !wiki.IsActive || wiki.IsPaused(time.Now()) {
 				continue
 			}
 
@@ -139,6 +228,7 @@ func (h *AdminHandler) CheckAllArchives(c echo.Context) error {
 			time.Duration(h.config.HTTPTimeout)*time.Second,
 			h.config.HTTPUserAgent,
 			h.config.ArchiveCheckDelay,
+			h.config.WikiArchiveCheckInterval,
 		)
 
 		successCount := 0
@@ -146,6 +236,11 @@ func (h *AdminHandler) CheckAllArchives(c echo.Context) error {
 		skippedCount := 0
 
 		for i, wiki := range wikis {
+			if !wiki.IsActive || wiki.IsPaused(time.Now()) {
+				skippedCount++
+				continue
+			}
+
 			applogger.Log.Info("[Admin] Checking wiki %d/%d: %s", i+1, len(wikis), wiki.URL)
 
 			// Skip wikis without API URL
@@ -224,3 +319,64 @@ func (h *AdminHandler) GetWikiStats(c echo.Context) error {
 		"api_available":          wiki.APIAvailable,
 	})
 }
+
+// WikiMergeRequest represents the request body of POST /api/admin/wikis/:id/merge
+type WikiMergeRequest struct {
+	DuplicateID string  `json:"duplicate_id"` // Wiki merged into :id and deleted
+	Reason      *string `json:"reason"`
+}
+
+// wikiMergeListResponse is the JSON body of GET /api/admin/wikis/:id/merges
+type wikiMergeListResponse struct {
+	WikiID string              `json:"wiki_id"`
+	Data   []*models.WikiMerge `json:"data"`
+}
+
+// MergeWiki handles POST /api/admin/wikis/:id/merge
+// Moves the stats, archives, notes and tags of a duplicate onto this wiki and deletes the duplicate.
+func (h *AdminHandler) MergeWiki(c echo.Context) error {
+	survivorID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	var req WikiMergeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	duplicateID, err := uuid.Parse(req.DuplicateID)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid duplicate_id format"})
+	}
+
+	merge, err := services.MergeWikis(c.Request().Context(), h.db, survivorID, duplicateID, middleware.Actor(c), req.Reason)
+	if err != nil {
+		switch err {
+		case repository.ErrMergeSameWiki:
+			return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		case gorm.ErrRecordNotFound:
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, merge)
+}
+
+// ListMerges handles GET /api/admin/wikis/:id/merges
+func (h *AdminHandler) ListMerges(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	merges, err := repository.NewMergeRepository(h.db).ListByWikiID(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if merges == nil {
+		merges = []*models.WikiMerge{}
+	}
+
+	return c.JSON(http.StatusOK, wikiMergeListResponse{WikiID: id.String(), Data: merges})
+}
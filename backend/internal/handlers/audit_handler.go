@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// AuditHandler serves the audit log
+type AuditHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(db *gorm.DB, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{db: db, config: cfg}
+}
+
+// AuditListRequest represents query parameters for GET /api/admin/audit
+type AuditListRequest struct {
+	Actor    string `query:"actor"`     // Token name, or "anonymous"
+	Action   string `query:"action"`    // Exact action, or a prefix ending in "." such as "wiki."
+	TargetID string `query:"target_id"` // e.g. a wiki ID
+	IP       string `query:"ip"`
+	Outcome  string `query:"outcome"` // success or failure
+	Since    string `query:"since"`   // RFC3339, inclusive
+	Until    string `query:"until"`   // RFC3339, exclusive
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+}
+
+// auditListResponse is the JSON body of GET /api/admin/audit
+type auditListResponse struct {
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	Data     []*models.AuditEntry `json:"data"`
+}
+
+// List handles GET /api/admin/audit
+func (h *AuditHandler) List(c echo.Context) error {
+	var req AuditListRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+
+	opts := repository.AuditListOptions{
+		Actor:    req.Actor,
+		Action:   req.Action,
+		TargetID: req.TargetID,
+		IP:       req.IP,
+		Outcome:  models.AuditOutcome(req.Outcome),
+	}
+	switch opts.Outcome {
+	case "", models.AuditOutcomeSuccess, models.AuditOutcomeFailure:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "outcome must be success or failure"})
+	}
+	var err error
+	if opts.Since, err = parseExportTime("since", req.Since); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if opts.Until, err = parseExportTime("until", req.Until); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	opts.Page, opts.PageSize = req.Page, req.PageSize
+
+	entries, total, err := repository.NewAuditRepository(h.db).List(c.Request().Context(), opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, auditListResponse{Total: total, Page: req.Page, PageSize: req.PageSize, Data: entries})
+}
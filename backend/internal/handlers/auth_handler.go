@@ -1,82 +1,281 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
 
 	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/oidc"
 )
 
 // AuthHandler handles authentication HTTP requests
 type AuthHandler struct {
-	config *config.Config
+	db         *gorm.DB
+	config     *config.Config
+	oidcClient *oidc.Client // Nil when OIDC login is not configured
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(cfg *config.Config) *AuthHandler {
-	return &AuthHandler{config: cfg}
+func NewAuthHandler(db *gorm.DB, cfg *config.Config) *AuthHandler {
+	h := &AuthHandler{db: db, config: cfg}
+	if oidcConfig := middleware.OIDCConfig(cfg); oidcConfig.Enabled() {
+		h.oidcClient = oidc.NewClient(oidcConfig, nil)
+	}
+	return h
+}
+
+// LoginCodeRequest represents the request body of POST /api/auth/login-code
+type LoginCodeRequest struct {
+	Token string `json:"token"` // API token or ADMIN_TOKEN; may be sent as Authorization: Bearer instead
+}
+
+// loginCodeResponse is the JSON body of POST /api/auth/login-code
+type loginCodeResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // CallbackRequest represents query parameters for auth callback
 type CallbackRequest struct {
-	Token      string `query:"token"`
-	RedirectTo string `query:"redirect_to"`
+	Code       string `query:"code"`        // From POST /api/auth/login-code
+	RedirectTo string `query:"redirect_to"` // Must be on one of the allowed CORS origins
+}
+
+// OIDCLoginRequest represents query parameters for OIDC login
+type OIDCLoginRequest struct {
+	RedirectTo string `query:"redirect_to"` // Must be on one of the allowed CORS origins
+}
+
+// OIDCCallbackRequest represents the query parameters the provider redirects back with
+type OIDCCallbackRequest struct {
+	Code             string `query:"code"`
+	State            string `query:"state"`
+	Error            string `query:"error"`
+	ErrorDescription string `query:"error_description"`
+}
+
+// LoginCode handles POST /api/auth/login-code
+// It exchanges a token for a single-use code, valid for a minute, so the token
+// itself never travels in the callback URL
+func (h *AuthHandler) LoginCode(c echo.Context) error {
+	var req LoginCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	token := strings.TrimSpace(req.Token)
+	if token == "" {
+		token = middleware.BearerToken(c)
+	}
+	if token == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Token is required"})
+	}
+
+	code, expiresAt, err := middleware.NewAuthenticator(h.db, h.config).CreateLoginCode(c.Request().Context(), token)
+	if err != nil {
+		if errors.Is(err, middleware.ErrInvalidToken) {
+			return c.JSON(http.StatusUnauthorized, map[string]string{"detail": "Invalid token"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, loginCodeResponse{Code: code, ExpiresAt: expiresAt})
 }
 
 // Callback handles GET /api/auth/callback
 // This endpoint is used for cross-domain cookie setting
 // Flow:
-// 1. Frontend redirects to API domain: https://api.example.com/api/auth/callback?token=xxx&redirect_to=xxx
-// 2. API validates token and sets cookie (same domain)
-// 3. API redirects back to frontend
+// 1. Frontend exchanges the token for a code: POST https://api.example.com/api/auth/login-code
+// 2. Frontend redirects to API domain: https://api.example.com/api/auth/callback?code=xxx&redirect_to=xxx
+// 3. API consumes the code, opens a session and sets its cookie (same domain)
+// 4. API redirects back to frontend
 func (h *AuthHandler) Callback(c echo.Context) error {
 	var req CallbackRequest
 	if err := c.Bind(&req); err != nil {
 		return c.String(http.StatusBadRequest, "Invalid request parameters")
 	}
 
-	// Validate token
-	if h.config.AdminToken == "" {
-		return c.String(http.StatusInternalServerError, "Admin authentication is not configured")
+	// Validate redirect_to before consuming the code
+	if req.RedirectTo != "" && !middleware.AllowedRedirect(req.RedirectTo, h.config.AllowOrigins) {
+		return c.String(http.StatusBadRequest, "redirect_to must be on an allowed origin")
 	}
-
-	if req.Token == "" {
-		return c.String(http.StatusBadRequest, "Token is required")
+	if req.Code == "" {
+		return c.String(http.StatusBadRequest, "Code is required")
 	}
 
-	if req.Token != h.config.AdminToken {
-		return c.String(http.StatusUnauthorized, "Invalid token")
+	secret, session, err := middleware.NewAuthenticator(h.db, h.config).ExchangeLoginCode(c.Request().Context(), req.Code)
+	if err != nil {
+		if errors.Is(err, middleware.ErrInvalidLoginCode) {
+			return c.String(http.StatusUnauthorized, "Invalid, used or expired code")
+		}
+		return c.String(http.StatusInternalServerError, err.Error())
 	}
 
 	// Set cookie on API domain
-	cookie := &http.Cookie{
-		Name:     "admintoken",
-		Value:    req.Token,
-		Path:     "/",
-		MaxAge:   int(30 * 24 * time.Hour / time.Second), // 30 days
+	c.SetCookie(h.sessionCookie(c, secret, session.ExpiresAt))
+
+	if req.RedirectTo == "" {
+		return c.String(http.StatusOK, "Signed in")
+	}
+	return c.Redirect(http.StatusSeeOther, req.RedirectTo)
+}
+
+// OIDCLogin handles GET /api/auth/oidc/login
+// It sends the browser to the OpenID Connect provider, which returns it to OIDCCallback
+func (h *AuthHandler) OIDCLogin(c echo.Context) error {
+	if h.oidcClient == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "OIDC login is not configured"})
+	}
+	var req OIDCLoginRequest
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, "Invalid request parameters")
+	}
+	if req.RedirectTo != "" && !middleware.AllowedRedirect(req.RedirectTo, h.config.AllowOrigins) {
+		return c.String(http.StatusBadRequest, "redirect_to must be on an allowed origin")
+	}
+
+	authURL, state, err := middleware.NewAuthenticator(h.db, h.config).BeginOIDCLogin(c.Request().Context(), h.oidcClient, req.RedirectTo)
+	if err != nil {
+		applogger.Log.Error("[Auth] Failed to start OIDC login", "error", err)
+		return c.String(http.StatusBadGateway, "The identity provider is unavailable")
+	}
+
+	// The provider redirects back with a top-level GET, which sends Lax cookies
+	c.SetCookie(&http.Cookie{
+		Name:     middleware.OIDCStateCookie,
+		Value:    state,
+		Path:     "/api/auth/oidc",
+		MaxAge:   int((10 * time.Minute).Seconds()),
 		HttpOnly: true,
-		Secure:   c.Request().TLS != nil || c.Request().Header.Get("X-Forwarded-Proto") == "https", // Secure only if using HTTPS
-		SameSite: http.SameSiteNoneMode,                                                            // None for cross-origin
+		Secure:   isSecure(c),
+		SameSite: http.SameSiteLaxMode,
+	})
+	return c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback handles GET /api/auth/oidc/callback
+// It redeems the provider's code, opens a session with the roles of the user's
+// groups and redirects back to the page the login started from
+func (h *AuthHandler) OIDCCallback(c echo.Context) error {
+	if h.oidcClient == nil {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "OIDC login is not configured"})
+	}
+	var req OIDCCallbackRequest
+	if err := c.Bind(&req); err != nil {
+		return c.String(http.StatusBadRequest, "Invalid request parameters")
+	}
+
+	stateCookie := &http.Cookie{Name: middleware.OIDCStateCookie, Path: "/api/auth/oidc", MaxAge: -1, HttpOnly: true, Secure: isSecure(c)}
+	browserState := ""
+	if cookie, err := c.Cookie(middleware.OIDCStateCookie); err == nil {
+		browserState = cookie.Value
+	}
+	c.SetCookie(stateCookie)
+
+	if req.Error != "" {
+		detail := req.Error
+		if req.ErrorDescription != "" {
+			detail += ": " + req.ErrorDescription
+		}
+		return c.String(http.StatusUnauthorized, "Sign-in failed at the identity provider ("+detail+")")
+	}
+	if req.Code == "" {
+		return c.String(http.StatusBadRequest, "Code is required")
+	}
+
+	secret, session, redirectTo, err := middleware.NewAuthenticator(h.db, h.config).FinishOIDCLogin(
+		c.Request().Context(), h.oidcClient, req.State, browserState, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, middleware.ErrInvalidOIDCState):
+			return c.String(http.StatusBadRequest, "Invalid or expired login, please sign in again")
+		case errors.Is(err, middleware.ErrNoRole):
+			return c.String(http.StatusForbidden, err.Error())
+		case errors.Is(err, oidc.ErrInvalidToken):
+			applogger.Log.Warn("[Auth] Rejected OIDC ID token", "error", err)
+			return c.String(http.StatusUnauthorized, "The identity provider returned an invalid ID token")
+		}
+		applogger.Log.Error("[Auth] Failed to finish OIDC login", "error", err)
+		return c.String(http.StatusBadGateway, "Sign-in failed, please try again")
+	}
+
+	applogger.Log.Info("[Auth] OIDC login", "name", session.Name, "subject", *session.Subject, "roles", session.Roles)
+	c.SetCookie(h.sessionCookie(c, secret, session.ExpiresAt))
+	if redirectTo == "" {
+		return c.String(http.StatusOK, "Signed in")
+	}
+	return c.Redirect(http.StatusSeeOther, redirectTo)
+}
+
+// Logout handles POST /api/auth/logout
+// It ends the session of the cookie and clears the cookie
+func (h *AuthHandler) Logout(c echo.Context) error {
+	auth := middleware.NewAuthenticator(h.db, h.config)
+	if middleware.BearerToken(c) == "" {
+		if identity, err := auth.Authenticate(c); err == nil {
+			if err := auth.EndSession(c.Request().Context(), identity); err != nil {
+				return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+			}
+		}
 	}
-	c.SetCookie(cookie)
 
-	return c.HTML(http.StatusOK, `<html><head><meta http-equiv="refresh" content="2;url=`+req.RedirectTo+`"></head><body></body></html>`)
+	cookie := h.sessionCookie(c, "", time.Unix(0, 0))
+	cookie.MaxAge = -1
+	c.SetCookie(cookie)
+	return c.JSON(http.StatusOK, map[string]string{"detail": "Signed out"})
 }
 
 // Check handles GET /api/auth/check
-// This endpoint checks if the user has a valid admin token cookie
+// This endpoint checks if the request carries a valid token or session and returns
+// the caller's identity, roles and scopes, plus the CSRF token state-changing
+// requests of a session must send
 func (h *AuthHandler) Check(c echo.Context) error {
-	if h.config.AdminToken == "" {
-		return c.JSON(http.StatusOK, map[string]bool{"authenticated": false})
+	oidcEnabled := h.oidcClient != nil
+	identity, err := middleware.NewAuthenticator(h.db, h.config).Authenticate(c)
+	if err != nil || identity == nil {
+		return c.JSON(http.StatusOK, authCheckResponse{Authenticated: false, OIDCEnabled: oidcEnabled})
 	}
+	roles := identity.Roles
+	if roles == nil {
+		roles = middleware.RolesForScopes(identity.Scopes)
+	}
+	return c.JSON(http.StatusOK, authCheckResponse{
+		Authenticated: true,
+		Name:          identity.Name,
+		Email:         identity.Email,
+		Subject:       identity.Subject,
+		Roles:         roles,
+		Scopes:        identity.Scopes,
+		CSRFToken:     identity.CSRFToken,
+		OIDCEnabled:   oidcEnabled,
+	})
+}
 
-	cookie, err := c.Cookie("admintoken")
-	if err != nil {
-		return c.JSON(http.StatusOK, map[string]bool{"authenticated": false})
+// sessionCookie builds the session cookie. Over HTTPS it is SameSite=None so the
+// frontend on another site can send it; CSRF tokens protect it from forged requests.
+func (h *AuthHandler) sessionCookie(c echo.Context, value string, expires time.Time) *http.Cookie {
+	secure := isSecure(c)
+	sameSite := http.SameSiteLaxMode // Browsers reject SameSite=None without Secure
+	if secure {
+		sameSite = http.SameSiteNoneMode
 	}
+	return &http.Cookie{
+		Name:     middleware.TokenCookie,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: sameSite,
+	}
+}
 
-	isAuthenticated := cookie.Value == h.config.AdminToken
-	return c.JSON(http.StatusOK, map[string]bool{"authenticated": isAuthenticated})
+// isSecure reports whether the request reached the API over HTTPS
+func isSecure(c echo.Context) bool {
+	return c.Request().TLS != nil || c.Request().Header.Get("X-Forwarded-Proto") == "https"
 }
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/badge"
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// badgeCacheControl lets image proxies such as GitHub's camo serve badges for a few minutes
+const badgeCacheControl = "public, max-age=300"
+
+// Badge types
+const (
+	badgeTypeStatus  = "status"
+	badgeTypeArchive = "archive"
+	badgeTypePages   = "pages"
+)
+
+// BadgeHandler renders SVG badges for embedding on wikis and talk pages
+type BadgeHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewBadgeHandler creates a new badge handler
+func NewBadgeHandler(db *gorm.DB, cfg *config.Config) *BadgeHandler {
+	return &BadgeHandler{db: db, config: cfg}
+}
+
+// BadgeRequest represents query parameters for the badge endpoints
+type BadgeRequest struct {
+	Type  string `query:"type"`  // status (default), archive or pages
+	Label string `query:"label"` // Overrides the left-hand text
+}
+
+// BadgeByURLRequest represents query parameters for GET /api/badge.svg
+type BadgeByURLRequest struct {
+	BadgeRequest
+	URL string `query:"url"` // Wiki URL or API URL
+}
+
+// ByID handles GET /api/wikis/:id/badge.svg
+func (h *BadgeHandler) ByID(c echo.Context) error {
+	var req BadgeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	if err := validateBadgeType(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	wiki, err := repository.NewWikiRepository(h.db).GetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return h.notTracked(c, req)
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return h.render(c, req, wiki)
+}
+
+// ByURL handles GET /api/badge.svg?url=
+func (h *BadgeHandler) ByURL(c echo.Context) error {
+	var req BadgeByURLRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	if err := validateBadgeType(&req.BadgeRequest); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "url is required"})
+	}
+
+	wiki, err := findWikiByURL(c.Request().Context(), h.db, req.URL)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return h.notTracked(c, req.BadgeRequest)
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return h.render(c, req.BadgeRequest, wiki)
+}
+
+// render writes the badge of the requested type for wiki
+func (h *BadgeHandler) render(c echo.Context, req BadgeRequest, wiki *models.Wiki) error {
+	// Collectors and archive checks bump updated_at, so it versions every badge type
+	notModified := checkNotModified(c, weakETag("badge", req.Type, req.Label, wiki.ID, wiki.UpdatedAt.UnixNano()), wiki.UpdatedAt)
+	c.Response().Header().Set("Cache-Control", badgeCacheControl)
+	if notModified {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	ctx := c.Request().Context()
+	var label, message, color string
+	switch req.Type {
+	case badgeTypeArchive:
+		label, message, color = "archived", "never", badge.ColorRed
+		archive, err := repository.NewArchiveRepository(h.db).GetLatestByWikiID(ctx, wiki.ID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+		if archive != nil {
+			message, color = "yes", badge.ColorGreen
+			if date := archiveDate(archive); date != nil {
+				message = date.Format("2006-01-02")
+			}
+		}
+	case badgeTypePages:
+		label, message, color = "pages", "unknown", badge.ColorLightGrey
+		stats, err := repository.NewStatsRepository(h.db).GetLatestByWikiID(ctx, wiki.ID)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+		if stats != nil {
+			message, color = badge.FormatCount(int64(stats.Pages)), badge.ColorBlue
+		}
+	default:
+		label = "WikiKeeper"
+		message, color = statusBadge(wiki)
+	}
+	if req.Label != "" {
+		label = req.Label
+	}
+
+	return c.Blob(http.StatusOK, badge.ContentType, badge.Render(label, message, color))
+}
+
+// notTracked renders a grey badge for unknown wikis, so embeds show a hint instead of a broken image
+func (h *BadgeHandler) notTracked(c echo.Context, req BadgeRequest) error {
+	label := req.Label
+	if label == "" {
+		label = "WikiKeeper"
+	}
+	c.Response().Header().Set("Cache-Control", badgeCacheControl)
+	return c.Blob(http.StatusNotFound, badge.ContentType, badge.Render(label, "not tracked", badge.ColorLightGrey))
+}
+
+// validateBadgeType applies the default badge type and rejects unknown ones
+func validateBadgeType(req *BadgeRequest) error {
+	switch req.Type {
+	case "":
+		req.Type = badgeTypeStatus
+	case badgeTypeStatus, badgeTypeArchive, badgeTypePages:
+	default:
+		return errors.New("type must be one of status, archive, pages")
+	}
+	if len(req.Label) > 64 {
+		return errors.New("label must be at most 64 characters")
+	}
+	return nil
+}
+
+// statusBadge returns the message and color of a status badge
+func statusBadge(wiki *models.Wiki) (string, string) {
+	switch wiki.Status {
+	case models.WikiStatusOK:
+		if wiki.ReadOnly {
+			return "read-only", badge.ColorYellow
+		}
+		return "online", badge.ColorGreen
+	case models.WikiStatusOffline:
+		return "offline", badge.ColorRed
+	case models.WikiStatusError:
+		return "error", badge.ColorOrange
+	default:
+		return "pending", badge.ColorLightGrey
+	}
+}
+
+// archiveDate returns the dump date of an archive, falling back to its upload date
+func archiveDate(archive *models.WikiArchive) *time.Time {
+	if archive.DumpDate != nil {
+		return archive.DumpDate
+	}
+	return archive.AddedDate
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/compare"
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+const (
+	maxCompareWikis  = 10
+	maxComparePoints = 1000
+)
+
+// CompareHandler serves side-by-side comparisons of wikis
+type CompareHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewCompareHandler creates a new compare handler
+func NewCompareHandler(db *gorm.DB, cfg *config.Config) *CompareHandler {
+	return &CompareHandler{db: db, config: cfg}
+}
+
+// CompareRequest represents query parameters for GET /api/compare
+type CompareRequest struct {
+	IDs    string `query:"ids"`    // Comma-separated wiki IDs, 2 to 10
+	Days   *int   `query:"days"`   // History window, default 90, 0 for all
+	Points int    `query:"points"` // Buckets per series, default 100, max 1000
+}
+
+// compareWiki is one wiki of a comparison
+type compareWiki struct {
+	Wiki     *models.Wiki            `json:"wiki"`
+	Series   compare.Series          `json:"series"`
+	Archives compare.ArchiveCoverage `json:"archives"`
+}
+
+// compareResponse is the JSON body of GET /api/compare
+type compareResponse struct {
+	Days       int                  `json:"days"`
+	Points     int                  `json:"points"`
+	Timestamps []time.Time          `json:"timestamps"`
+	Wikis      []compareWiki        `json:"wikis"`
+	Metadata   compare.MetadataDiff `json:"metadata"`
+}
+
+// Compare handles GET /api/compare
+func (h *CompareHandler) Compare(c echo.Context) error {
+	var req CompareRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+
+	ids, err := parseCompareIDs(req.IDs)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	days := 90
+	if req.Days != nil {
+		days = *req.Days
+	}
+	if days < 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid days parameter"})
+	}
+	points := req.Points
+	if points < 1 || points > maxComparePoints {
+		points = 100
+	}
+
+	wikiRepo := repository.NewWikiRepository(h.db)
+	statsRepo := repository.NewStatsRepository(h.db)
+	archiveRepo := repository.NewArchiveRepository(h.db)
+	ctx := c.Request().Context()
+
+	wikis := make([]*models.Wiki, len(ids))
+	stats := make([][]*models.WikiStats, len(ids))
+	for i, id := range ids {
+		wiki, err := wikiRepo.GetByID(ctx, id)
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found", "wiki_id": id.String()})
+			}
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+		wikis[i] = wiki
+
+		stats[i], err = statsRepo.GetByWikiID(ctx, id, days)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+	}
+
+	// All series share one axis: the requested window, or the full history of all wikis
+	now := time.Now().UTC()
+	start := now.AddDate(0, 0, -days)
+	if days == 0 {
+		start = now
+		for _, series := range stats {
+			for _, s := range series {
+				if s.Time.Before(start) {
+					start = s.Time
+				}
+			}
+		}
+	}
+	axis := compare.NewAxis(start, now, points)
+
+	response := compareResponse{
+		Days:       days,
+		Points:     points,
+		Timestamps: axis.Timestamps(),
+		Wikis:      make([]compareWiki, len(wikis)),
+		Metadata:   compare.DiffMetadata(wikis),
+	}
+	for i, wiki := range wikis {
+		archives, err := archiveRepo.GetByWikiID(ctx, wiki.ID)
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+		response.Wikis[i] = compareWiki{
+			Wiki:     wiki,
+			Series:   compare.Downsample(stats[i], axis),
+			Archives: compare.Coverage(archives, now),
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseCompareIDs parses a comma-separated list of distinct wiki IDs
+func parseCompareIDs(raw string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := uuid.Parse(part)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid wiki ID format: %s", part)
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < 2 || len(ids) > maxCompareWikis {
+		return nil, fmt.Errorf("Between 2 and %d distinct wiki IDs are required", maxCompareWikis)
+	}
+	return ids, nil
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/dataset"
+)
+
+// DatasetHandler serves published dataset snapshots
+type DatasetHandler struct {
+	config *config.Config
+}
+
+// NewDatasetHandler creates a new dataset handler
+func NewDatasetHandler(cfg *config.Config) *DatasetHandler {
+	return &DatasetHandler{config: cfg}
+}
+
+// List handles GET /api/datasets
+func (h *DatasetHandler) List(c echo.Context) error {
+	if h.config.DatasetDir == "" {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Dataset snapshots are not enabled"})
+	}
+
+	manifests, err := dataset.List(h.config.DatasetDir)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"format_version": dataset.FormatVersion,
+		"data":           manifests,
+	})
+}
+
+// Get handles GET /api/datasets/:version
+// The version "latest" resolves to the newest snapshot.
+func (h *DatasetHandler) Get(c echo.Context) error {
+	manifest, err := h.load(c.Param("version"))
+	if err != nil {
+		return h.error(c, err)
+	}
+	return c.JSON(http.StatusOK, manifest)
+}
+
+// GetFile handles GET /api/datasets/:version/:file
+func (h *DatasetHandler) GetFile(c echo.Context) error {
+	version := c.Param("version")
+	manifest, err := h.load(version)
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	path, err := dataset.FilePath(h.config.DatasetDir, manifest, c.Param("file"))
+	if err != nil {
+		return h.error(c, err)
+	}
+
+	// Published versions never change; "latest" moves with each export
+	if version == "latest" {
+		c.Response().Header().Set("Cache-Control", "no-cache")
+	} else {
+		c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	}
+	return c.File(path)
+}
+
+func (h *DatasetHandler) load(version string) (*dataset.Manifest, error) {
+	if h.config.DatasetDir == "" {
+		return nil, dataset.ErrNotFound
+	}
+	return dataset.Load(h.config.DatasetDir, version)
+}
+
+func (h *DatasetHandler) error(c echo.Context, err error) error {
+	if errors.Is(err, dataset.ErrNotFound) {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Dataset not found"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+}
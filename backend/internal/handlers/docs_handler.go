@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/openapi"
+)
+
+// DocsHandler serves the OpenAPI document and the interactive viewer
+type DocsHandler struct {
+	spec *openapi.Document
+}
+
+// NewDocsHandler creates a new docs handler
+func NewDocsHandler(cfg *config.Config) *DocsHandler {
+	return &DocsHandler{spec: BuildOpenAPISpec(cfg)}
+}
+
+// OpenAPI handles GET /openapi.json
+func (h *DocsHandler) OpenAPI(c echo.Context) error {
+	return c.JSON(http.StatusOK, h.spec)
+}
+
+// Docs handles GET /docs
+func (h *DocsHandler) Docs(c echo.Context) error {
+	return c.HTML(http.StatusOK, openapi.DocsHTML)
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// Export formats
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+)
+
+// exportFlushEvery is the number of rows written between flushes to the client
+const exportFlushEvery = 500
+
+// ExportHandler streams the dataset as CSV or NDJSON
+type ExportHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(db *gorm.DB, cfg *config.Config) *ExportHandler {
+	return &ExportHandler{db: db, config: cfg}
+}
+
+// ExportRequest represents query parameters for export endpoints
+type ExportRequest struct {
+	Format     string `query:"format"`
+	Status     string `query:"status"`
+	HasArchive *bool  `query:"has_archive"`
+	Search     string `query:"search"`
+	Since      string `query:"since"` // RFC3339, resume point for incremental pulls
+	From       string `query:"from"`  // RFC3339, stats only
+	To         string `query:"to"`    // RFC3339, stats only
+}
+
+// Wikis handles GET /api/export/wikis
+func (h *ExportHandler) Wikis(c echo.Context) error {
+	format, opts, err := h.parseRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	stream := newExportStream(c, format, "wikis", wikiExportHeader)
+	wikiRepo := repository.NewWikiRepository(h.db)
+	err = wikiRepo.StreamForExport(c.Request().Context(), opts, func(wiki *models.Wiki) error {
+		return stream.write(wiki, wikiExportRow(wiki))
+	})
+	return stream.finish(err)
+}
+
+// Stats handles GET /api/export/stats
+func (h *ExportHandler) Stats(c echo.Context) error {
+	format, opts, err := h.parseRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	stream := newExportStream(c, format, "stats", statsExportHeader)
+	statsRepo := repository.NewStatsRepository(h.db)
+	err = statsRepo.StreamForExport(c.Request().Context(), opts, func(stats *models.WikiStats) error {
+		return stream.write(stats, statsExportRow(stats))
+	})
+	return stream.finish(err)
+}
+
+// Archives handles GET /api/export/archives
+func (h *ExportHandler) Archives(c echo.Context) error {
+	format, opts, err := h.parseRequest(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	stream := newExportStream(c, format, "archives", archiveExportHeader)
+	archiveRepo := repository.NewArchiveRepository(h.db)
+	err = archiveRepo.StreamForExport(c.Request().Context(), opts, func(archive *models.WikiArchive) error {
+		return stream.write(archive, archiveExportRow(archive))
+	})
+	return stream.finish(err)
+}
+
+// parseRequest validates export query parameters and converts them to repository options
+func (h *ExportHandler) parseRequest(c echo.Context) (string, repository.ExportOptions, error) {
+	var req ExportRequest
+	var opts repository.ExportOptions
+	if err := c.Bind(&req); err != nil {
+		return "", opts, fmt.Errorf("Invalid query parameters")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = exportFormatNDJSON
+	}
+	if format != exportFormatNDJSON && format != exportFormatCSV {
+		return "", opts, fmt.Errorf("Invalid format, expected 'ndjson' or 'csv'")
+	}
+
+	if req.Status != "" {
+		status := models.WikiStatus(req.Status)
+		opts.Status = &status
+	}
+	opts.HasArchive = req.HasArchive
+	opts.Search = req.Search
+
+	var err error
+	if opts.Since, err = parseExportTime("since", req.Since); err != nil {
+		return "", opts, err
+	}
+	if opts.From, err = parseExportTime("from", req.From); err != nil {
+		return "", opts, err
+	}
+	if opts.To, err = parseExportTime("to", req.To); err != nil {
+		return "", opts, err
+	}
+
+	return format, opts, nil
+}
+
+// parseExportTime parses an optional RFC3339 query parameter
+func parseExportTime(name, value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid %s parameter, expected RFC3339 timestamp", name)
+	}
+	return &t, nil
+}
+
+// exportStream writes export rows to the response as they are read from the database
+type exportStream struct {
+	c       echo.Context
+	format  string
+	csv     *csv.Writer
+	json    *json.Encoder
+	name    string
+	header  []string
+	started bool
+	count   int
+}
+
+func newExportStream(c echo.Context, format, name string, header []string) *exportStream {
+	return &exportStream{c: c, format: format, name: name, header: header}
+}
+
+// start writes response headers, deferred until the first row so that
+// query errors can still be reported with a proper status code
+func (s *exportStream) start() error {
+	s.started = true
+	res := s.c.Response()
+
+	if s.format == exportFormatCSV {
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+	} else {
+		res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+	}
+	res.Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf(`attachment; filename="wikikeeper-%s.%s"`, s.name, s.format))
+	res.WriteHeader(http.StatusOK)
+
+	if s.format == exportFormatCSV {
+		s.csv = csv.NewWriter(res)
+		return s.csv.Write(s.header)
+	}
+	s.json = json.NewEncoder(res)
+	return nil
+}
+
+// write emits one record, as JSON for NDJSON or as the prepared row for CSV
+func (s *exportStream) write(record interface{}, row []string) error {
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if s.format == exportFormatCSV {
+		err = s.csv.Write(row)
+	} else {
+		err = s.json.Encode(record)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.count++
+	if s.count%exportFlushEvery == 0 {
+		s.flush()
+	}
+	return nil
+}
+
+func (s *exportStream) flush() {
+	if s.csv != nil {
+		s.csv.Flush()
+	}
+	s.c.Response().Flush()
+}
+
+// finish completes the response. Errors after the first row can only be logged,
+// as the status code has already been sent.
+func (s *exportStream) finish(err error) error {
+	if err != nil && !s.started {
+		return s.c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if err != nil {
+		applogger.Log.Error("export aborted", "path", s.c.Path(), "rows", s.count, "error", err)
+		s.flush()
+		return nil
+	}
+
+	// Empty result: still send the CSV header so clients get a valid file
+	if !s.started {
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	s.flush()
+	return nil
+}
+
+var wikiExportHeader = []string{
+	"id", "url", "api_url", "index_url", "wiki_name", "sitename", "lang",
+	"dbtype", "dbversion", "mediawiki_version", "max_page_id", "status",
+	"has_archive", "api_available", "last_error", "last_error_at",
+	"archive_last_check_at", "created_at", "updated_at", "last_check_at", "is_active",
+}
+
+func wikiExportRow(w *models.Wiki) []string {
+	return []string{
+		w.ID.String(), w.URL, csvString(w.APIURL), csvString(w.IndexURL), csvString(w.WikiName),
+		csvString(w.Sitename), csvString(w.Lang), csvString(w.DBType), csvString(w.DBVersion),
+		csvString(w.MediaWikiVersion), csvIntPtr(w.MaxPageID), string(w.Status),
+		strconv.FormatBool(w.HasArchive), strconv.FormatBool(w.APIAvailable),
+		csvString(w.LastError), csvTimePtr(w.LastErrorAt), csvTimePtr(w.ArchiveLastCheckAt),
+		csvTime(w.CreatedAt), csvTime(w.UpdatedAt), csvTimePtr(w.LastCheckAt),
+		strconv.FormatBool(w.IsActive),
+	}
+}
+
+var statsExportHeader = []string{
+	"wiki_id", "time", "pages", "articles", "edits", "images", "users",
+	"active_users", "admins", "jobs", "response_time_ms", "http_status",
+}
+
+func statsExportRow(s *models.WikiStats) []string {
+	return []string{
+		s.WikiID.String(), csvTime(s.Time), strconv.Itoa(s.Pages), strconv.Itoa(s.Articles),
+		strconv.Itoa(s.Edits), strconv.Itoa(s.Images), strconv.Itoa(s.Users),
+		strconv.Itoa(s.ActiveUsers), strconv.Itoa(s.Admins), strconv.Itoa(s.Jobs),
+		csvIntPtr(s.ResponseTimeMs), csvIntPtr(s.HTTPStatus),
+	}
+}
+
+var archiveExportHeader = []string{
+	"id", "wiki_id", "ia_identifier", "added_date", "dump_date", "item_size",
+	"uploader", "scanner", "upload_state", "has_xml_current", "has_xml_history",
+	"has_images_dump", "has_titles_list", "has_images_list", "has_legacy_wikidump",
+	"removed_at", "created_at", "updated_at",
+}
+
+func archiveExportRow(a *models.WikiArchive) []string {
+	itemSize := ""
+	if a.ItemSize != nil {
+		itemSize = strconv.FormatInt(*a.ItemSize, 10)
+	}
+	return []string{
+		a.ID.String(), a.WikiID.String(), a.IAIdentifier, csvTimePtr(a.AddedDate),
+		csvTimePtr(a.DumpDate), itemSize, csvString(a.Uploader), csvString(a.Scanner),
+		csvString(a.UploadState), strconv.FormatBool(a.HasXMLCurrent),
+		strconv.FormatBool(a.HasXMLHistory), strconv.FormatBool(a.HasImagesDump),
+		strconv.FormatBool(a.HasTitlesList), strconv.FormatBool(a.HasImagesList),
+		strconv.FormatBool(a.HasLegacyWikidump), csvTimePtr(a.RemovedAt), csvTime(a.CreatedAt),
+		csvTime(a.UpdatedAt),
+	}
+}
+
+func csvString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func csvIntPtr(i *int) string {
+	if i == nil {
+		return ""
+	}
+	return strconv.Itoa(*i)
+}
+
+func csvTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func csvTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return csvTime(*t)
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/feed"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// feedLimit is the number of entries in every feed
+const feedLimit = 50
+
+// FeedHandler serves Atom feeds built from recorded wiki events
+type FeedHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewFeedHandler creates a new feed handler
+func NewFeedHandler(db *gorm.DB, cfg *config.Config) *FeedHandler {
+	return &FeedHandler{db: db, config: cfg}
+}
+
+// Archives handles GET /api/feeds/archives.atom
+func (h *FeedHandler) Archives(c echo.Context) error {
+	return h.serve(c, "Newly archived wikis", repository.EventListOptions{
+		Types: []models.WikiEventType{models.WikiEventArchiveFound},
+	})
+}
+
+// Offline handles GET /api/feeds/offline.atom
+func (h *FeedHandler) Offline(c echo.Context) error {
+	return h.serve(c, "Wikis that went offline or read-only", repository.EventListOptions{
+		Types: []models.WikiEventType{models.WikiEventWentOffline, models.WikiEventReadOnly},
+	})
+}
+
+// Wikis handles GET /api/feeds/wikis.atom
+func (h *FeedHandler) Wikis(c echo.Context) error {
+	return h.serve(c, "Newly added wikis", repository.EventListOptions{
+		Types: []models.WikiEventType{models.WikiEventAdded},
+	})
+}
+
+// Wiki handles GET /api/wikis/:id/feed.atom
+func (h *FeedHandler) Wiki(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	wikiRepo := repository.NewWikiRepository(h.db)
+	wiki, err := wikiRepo.GetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	return h.serve(c, "Changes of "+wikiDisplayName(wiki), repository.EventListOptions{WikiID: &id})
+}
+
+// serve renders the newest events matching opts as an Atom feed
+func (h *FeedHandler) serve(c echo.Context, title string, opts repository.EventListOptions) error {
+	opts.Limit = feedLimit
+	eventRepo := repository.NewEventRepository(h.db)
+	events, err := eventRepo.List(c.Request().Context(), opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	// An empty feed is dated at the epoch so clients still get a stable validator
+	updated := time.Unix(0, 0).UTC()
+	newest := ""
+	if len(events) > 0 {
+		updated = events[0].CreatedAt
+		newest = events[0].ID.String()
+	}
+	if checkNotModified(c, weakETag("feed", c.Request().URL.Path, newest), updated) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	req := c.Request()
+	self := fmt.Sprintf("%s://%s%s", c.Scheme(), req.Host, req.URL.Path)
+	doc := &feed.Feed{
+		ID:      self,
+		Title:   fmt.Sprintf("%s - %s", h.config.AppName, title),
+		Updated: feed.Time(updated),
+		Links:   []feed.Link{{Href: self, Rel: "self", Type: "application/atom+xml"}},
+		Author:  &feed.Person{Name: h.config.AppName},
+		Entries: make([]feed.Entry, 0, len(events)),
+	}
+	for _, event := range events {
+		doc.Entries = append(doc.Entries, feedEntry(event))
+	}
+
+	body, err := feed.Marshal(doc)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.Blob(http.StatusOK, feed.ContentType, body)
+}
+
+// feedEntry converts an event to an Atom entry linking to the wiki or the archived item
+func feedEntry(event *models.WikiEvent) feed.Entry {
+	name := event.WikiID.String()
+	link := ""
+	if event.Wiki != nil {
+		name = wikiDisplayName(event.Wiki)
+		link = event.Wiki.URL
+	}
+
+	var title, summary string
+	switch event.Type {
+	case models.WikiEventAdded:
+		title = name + " was added"
+	case models.WikiEventWentOffline:
+		title = name + " went offline"
+	case models.WikiEventRecovered:
+		title = name + " is back online"
+	case models.WikiEventReadOnly:
+		title = name + " became read-only"
+	case models.WikiEventWritable:
+		title = name + " is no longer read-only"
+	case models.WikiEventArchiveFound:
+		title = name + " was archived"
+		if event.IAIdentifier != nil {
+			link = "https://archive.org/details/" + *event.IAIdentifier
+			summary = "Archive.org item " + *event.IAIdentifier
+		}
+	default:
+		title = name + " changed status"
+	}
+	if event.FromStatus != nil && event.ToStatus != nil {
+		title = fmt.Sprintf("%s (%s → %s)", title, *event.FromStatus, *event.ToStatus)
+	}
+	if event.Message != nil {
+		summary = *event.Message
+	}
+
+	entry := feed.Entry{
+		ID:         "urn:uuid:" + event.ID.String(),
+		Title:      title,
+		Updated:    feed.Time(event.CreatedAt),
+		Categories: []feed.Category{{Term: string(event.Type)}},
+		Summary:    feed.PlainText(summary),
+	}
+	if link != "" {
+		entry.Links = []feed.Link{{Href: link, Rel: "alternate"}}
+	}
+	return entry
+}
+
+// wikiDisplayName returns the sitename of a wiki, falling back to its URL
+func wikiDisplayName(wiki *models.Wiki) string {
+	if wiki.Sitename != nil && *wiki.Sitename != "" {
+		return *wiki.Sitename
+	}
+	return wiki.URL
+}
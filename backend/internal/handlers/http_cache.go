@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// revalidateCacheControl lets clients and proxies store public responses
+// but makes them revalidate with ETag/Last-Modified on every use
+const revalidateCacheControl = "public, no-cache"
+
+// weakETag derives a weak entity tag from the values identifying the data behind a response
+func weakETag(parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v\x00", part)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// checkNotModified sets the ETag, Last-Modified and Cache-Control headers and reports
+// whether the request's conditional headers match, in which case 304 should be returned.
+// A zero lastModified omits Last-Modified.
+func checkNotModified(c echo.Context, etag string, lastModified time.Time) bool {
+	header := c.Response().Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", revalidateCacheControl)
+	if !lastModified.IsZero() {
+		header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-None-Match takes precedence over If-Modified-Since (RFC 9110, section 13.2.2)
+	if ifNoneMatch := c.Request().Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+	if ifModifiedSince := c.Request().Header.Get("If-Modified-Since"); ifModifiedSince != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ifModifiedSince)
+		if err != nil {
+			return false
+		}
+		// HTTP dates have second precision
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// etagMatches performs the weak comparison used for If-None-Match
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// latestTime returns the later of a and b
+func latestTime(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
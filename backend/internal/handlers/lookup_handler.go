@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+	"wikikeeper-backend/internal/services"
+)
+
+// WikiLookupRequest represents query parameters for GET /api/wikis/lookup
+type WikiLookupRequest struct {
+	URL string `query:"url"` // Wiki URL, API URL or index URL, current or former
+}
+
+// wikiLookupResult is the JSON body of GET /api/wikis/lookup
+type wikiLookupResult struct {
+	Wiki        *models.Wiki       `json:"wiki"`
+	MatchedURL  string             `json:"matched_url"`
+	MatchedKind models.WikiURLKind `json:"matched_kind"`
+	// IsAlias is true when the URL is no longer the wiki's current URL, API URL or index URL
+	IsAlias bool `json:"is_alias"`
+}
+
+// Lookup handles GET /api/wikis/lookup?url=
+func (h *WikiHandler) Lookup(c echo.Context) error {
+	var req WikiLookupRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	if strings.TrimSpace(req.URL) == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "url is required"})
+	}
+
+	result, err := lookupWiki(c.Request().Context(), h.db, req.URL)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, result)
+}
+
+// findWikiByURL looks a wiki up by any URL, API URL or index URL it has used
+func findWikiByURL(ctx context.Context, db *gorm.DB, rawURL string) (*models.Wiki, error) {
+	result, err := lookupWiki(ctx, db, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return result.Wiki, nil
+}
+
+// lookupWiki resolves a URL to a wiki. Current URLs win over the wiki_urls history,
+// which also holds the URLs of merged duplicates and of wikis that moved.
+func lookupWiki(ctx context.Context, db *gorm.DB, rawURL string, extra ...string) (*wikiLookupResult, error) {
+	wikiRepo := repository.NewWikiRepository(db)
+	candidates := wikiURLCandidates(rawURL, extra...)
+
+	for _, candidate := range candidates {
+		wiki, err := wikiRepo.GetByURL(ctx, candidate)
+		if err == nil {
+			return &wikiLookupResult{Wiki: wiki, MatchedURL: candidate, MatchedKind: models.WikiURLKindURL}, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+	for _, candidate := range candidates {
+		if !strings.HasSuffix(candidate, "/api.php") {
+			continue
+		}
+		wiki, err := wikiRepo.GetByAPIURL(ctx, candidate)
+		if err == nil {
+			return &wikiLookupResult{Wiki: wiki, MatchedURL: candidate, MatchedKind: models.WikiURLKindAPI}, nil
+		}
+		if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+	}
+
+	alias, err := repository.NewWikiURLRepository(db).Resolve(ctx, candidates)
+	if err != nil {
+		return nil, err
+	}
+	wiki, err := wikiRepo.GetByID(ctx, alias.WikiID)
+	if err != nil {
+		return nil, err
+	}
+	return &wikiLookupResult{
+		Wiki:        wiki,
+		MatchedURL:  alias.URL,
+		MatchedKind: alias.Kind,
+		IsAlias:     !isCurrentWikiURL(wiki, alias.URL),
+	}, nil
+}
+
+// wikiURLCandidates returns the forms a URL may be stored in: as given, normalized,
+// with a trailing slash (wikis added by API URL) and, for API URLs, with a scheme
+func wikiURLCandidates(rawURL string, extra ...string) []string {
+	rawURL = strings.TrimSpace(rawURL)
+	normalized := services.NormalizeURL(rawURL)
+	values := []string{rawURL, normalized, normalized + "/"}
+
+	if apiURL := strings.TrimSuffix(rawURL, "/"); strings.HasSuffix(apiURL, "/api.php") {
+		if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
+			apiURL = "https://" + apiURL
+		}
+		values = append(values, apiURL)
+	}
+	values = append(values, extra...)
+
+	seen := make(map[string]bool, len(values))
+	candidates := make([]string, 0, len(values))
+	for _, value := range values {
+		if value != "" && value != "/" && !seen[value] {
+			seen[value] = true
+			candidates = append(candidates, value)
+		}
+	}
+	return candidates
+}
+
+// isCurrentWikiURL reports whether url is the wiki's current URL, API URL or index URL
+func isCurrentWikiURL(wiki *models.Wiki, url string) bool {
+	return wiki.URL == url ||
+		(wiki.APIURL != nil && *wiki.APIURL == url) ||
+		(wiki.IndexURL != nil && *wiki.IndexURL == url)
+}
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// maxNoteLength bounds the size of a note body
+const maxNoteLength = 10000
+
+// NoteHandler manages admin notes on wikis
+type NoteHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewNoteHandler creates a new note handler
+func NewNoteHandler(db *gorm.DB, cfg *config.Config) *NoteHandler {
+	return &NoteHandler{db: db, config: cfg}
+}
+
+// NoteCreateRequest represents the request body of POST /api/admin/wikis/:id/notes
+type NoteCreateRequest struct {
+	Body string `json:"body"`
+	// Author defaults to the authenticated caller. Only holders of the shared admin token,
+	// which names no one, may name themselves; it is ignored for API tokens and OIDC users.
+	Author string `json:"author"`
+}
+
+// noteListResponse is the JSON body of GET /api/admin/wikis/:id/notes
+type noteListResponse struct {
+	Data []*models.WikiNote `json:"data"`
+}
+
+// List handles GET /api/admin/wikis/:id/notes
+func (h *NoteHandler) List(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := repository.NewWikiRepository(h.db).GetByID(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	notes, err := repository.NewNoteRepository(h.db).ListByWikiID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if notes == nil {
+		notes = []*models.WikiNote{}
+	}
+	return c.JSON(http.StatusOK, noteListResponse{Data: notes})
+}
+
+// Create handles POST /api/admin/wikis/:id/notes
+func (h *NoteHandler) Create(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	var req NoteCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "body is required"})
+	}
+	if len(body) > maxNoteLength {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "body is too long"})
+	}
+
+	author := middleware.Actor(c)
+	if identity := middleware.CurrentIdentity(c); identity != nil && identity.SharedToken() {
+		if name := strings.TrimSpace(req.Author); name != "" && len(name) <= 255 {
+			author = name
+		}
+	}
+
+	ctx := c.Request().Context()
+	if _, err := repository.NewWikiRepository(h.db).GetByID(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	note := &models.WikiNote{WikiID: id, Author: author, Body: body}
+	if err := repository.NewNoteRepository(h.db).Create(ctx, note); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	applogger.Log.Info("[Admin] Note added", "wiki_id", id, "note_id", note.ID, "author", author)
+	return c.JSON(http.StatusCreated, note)
+}
+
+// Delete handles DELETE /api/admin/wikis/:id/notes/:note_id
+func (h *NoteHandler) Delete(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+	noteID, err := uuid.Parse(c.Param("note_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid note ID format"})
+	}
+
+	deleted, err := repository.NewNoteRepository(h.db).Delete(c.Request().Context(), id, noteID)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Note not found"})
+	}
+
+	applogger.Log.Info("[Admin] Note deleted", "wiki_id", id, "note_id", noteID, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, map[string]string{"detail": "Note deleted", "note_id": noteID.String()})
+}
@@ -0,0 +1,1015 @@
+package handlers
+
+import (
+	"time"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/dataset"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/openapi"
+	"wikikeeper-backend/internal/submission"
+)
+
+// Response shapes documented in the OpenAPI spec. Handlers build these as maps;
+// the structs only describe the resulting JSON.
+type errorResponse struct {
+	Detail string `json:"detail"`
+}
+
+type detailResponse struct {
+	Detail string `json:"detail"`
+	WikiID string `json:"wiki_id,omitempty"`
+}
+
+type wikiListResponse struct {
+	Total    int64         `json:"total"`
+	Page     int           `json:"page"`
+	PageSize int           `json:"page_size"`
+	Data     []models.Wiki `json:"data"`
+}
+
+type wikiStatsResponse struct {
+	WikiID string             `json:"wiki_id"`
+	Days   int                `json:"days"`
+	Data   []models.WikiStats `json:"data"`
+}
+
+type wikiArchivesResponse struct {
+	WikiID string               `json:"wiki_id"`
+	Data   []models.WikiArchive `json:"data"`
+}
+
+type adminWikiStatsResponse struct {
+	WikiID             string            `json:"wiki_id"`
+	URL                string            `json:"url"`
+	Sitename           *string           `json:"sitename"`
+	Status             models.WikiStatus `json:"status"`
+	IsActive           bool              `json:"is_active"`
+	LastCheckAt        *time.Time        `json:"last_check_at"`
+	LastError          *string           `json:"last_error"`
+	LastErrorAt        *time.Time        `json:"last_error_at"`
+	ArchiveLastCheckAt *time.Time        `json:"archive_last_check_at"`
+	ArchiveLastError   *string           `json:"archive_last_error"`
+	ArchiveLastErrorAt *time.Time        `json:"archive_last_error_at"`
+	HasArchive         bool              `json:"has_archive"`
+	APIAvailable       bool              `json:"api_available"`
+}
+
+type statsHistoryResponse struct {
+	Interval string                   `json:"interval"`
+	Data     []models.CatalogSnapshot `json:"data"`
+}
+
+type datasetListResponse struct {
+	FormatVersion int                `json:"format_version"`
+	Data          []dataset.Manifest `json:"data"`
+}
+
+type rootResponse struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Docs    string `json:"docs"`
+	Health  string `json:"health"`
+}
+
+type healthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+type authCheckResponse struct {
+	Authenticated bool     `json:"authenticated"`
+	Name          string   `json:"name,omitempty"`    // Token name, or the OIDC user's username
+	Email         string   `json:"email,omitempty"`   // OIDC users only
+	Subject       string   `json:"subject,omitempty"` // OIDC issuer and subject
+	Roles         []string `json:"roles,omitempty"`   // viewer, curator and/or admin; for tokens, the roles their scopes cover
+	Scopes        []string `json:"scopes,omitempty"`
+	CSRFToken     string   `json:"csrf_token,omitempty"` // Send as X-CSRF-Token on state-changing requests authenticated by the session cookie
+	OIDCEnabled   bool     `json:"oidc_enabled"`         // Whether /api/auth/oidc/login is available
+}
+
+// adminSecurity marks an operation as requiring a token, sent as bearer header or cookie
+var adminSecurity = []map[string][]string{{"bearerToken": {}}, {"adminToken": {}}}
+
+// BuildOpenAPISpec describes every route registered in cmd/server.
+// Routes added there must be added here too; a test enforces this.
+func BuildOpenAPISpec(cfg *config.Config) *openapi.Document {
+	doc := openapi.New(cfg.AppName, cfg.AppVersion,
+		"Wiki statistics tracker and Archive.org backup status checker. "+
+			"Requests are rate limited per API token, or per client IP without one; responses carry "+
+			"RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers, and 429 responses Retry-After.")
+	doc.Tags = []openapi.Tag{
+		{Name: "meta", Description: "Service information"},
+		{Name: "wikis", Description: "Tracked wikis, their statistics and archives"},
+		{Name: "stats", Description: "Catalog-wide statistics"},
+		{Name: "feeds", Description: "Atom feeds of catalog events"},
+		{Name: "badges", Description: "Embeddable SVG badges"},
+		{Name: "curation", Description: "Tags and admin notes"},
+		{Name: "export", Description: "Dataset exports and snapshots"},
+		{Name: "auth", Description: "Admin authentication"},
+		{Name: "admin", Description: "Admin-only operations"},
+	}
+	doc.Components.SecuritySchemes = map[string]openapi.SecurityScheme{
+		"bearerToken": {
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "wkp_...",
+			Description:  "API token from POST /api/admin/tokens or `wikikeeper token create`, or the shared ADMIN_TOKEN",
+		},
+		"adminToken": {
+			Type:        "apiKey",
+			In:          "cookie",
+			Name:        "admintoken",
+			Description: "Session cookie set by /api/auth/callback; state-changing requests must also send X-CSRF-Token",
+		},
+	}
+
+	wiki := doc.AddSchema("Wiki", models.Wiki{})
+	stats := doc.AddSchema("WikiStats", models.WikiStats{})
+	archive := doc.AddSchema("WikiArchive", models.WikiArchive{})
+	errSchema := doc.AddSchema("Error", errorResponse{})
+	detail := doc.AddSchema("Detail", detailResponse{})
+	manifest := doc.AddSchema("DatasetManifest", dataset.Manifest{})
+	submissionView := doc.AddSchema("SubmissionStatus", submissionStatus{})
+	submissionFull := doc.AddSchema("Submission", models.Submission{})
+
+	badRequest := openapi.JSONResponse("Invalid request", errSchema)
+	notFound := openapi.JSONResponse("Not found", errSchema)
+	unauthorized := openapi.JSONResponse("Token missing, invalid, revoked or expired", errSchema)
+	forbidden := func(scope string) openapi.Response {
+		return openapi.JSONResponse("Token lacks the "+scope+" scope, or a state-changing session request lacks X-CSRF-Token", errSchema)
+	}
+	csrfFailed := openapi.JSONResponse("Session cookie sent without its X-CSRF-Token header", errSchema)
+	tooMany := openapi.JSONResponse("Rate limit exceeded", errSchema)
+	notModified := openapi.Response{Description: "Not modified since the ETag or Last-Modified validator sent by the client"}
+	badgeDescription := "type status shows online, read-only, error, offline or pending; archive the date of the newest Archive.org dump; pages the latest page count. " +
+		"Unknown wikis get a grey 'not tracked' badge with status 404. Responses may be cached for five minutes."
+	badgeSVG := openapi.ContentResponse("SVG image", "image/svg+xml", &openapi.Schema{Type: "string"})
+	badgeResponses := map[string]openapi.Response{
+		"200": badgeSVG,
+		"304": notModified,
+		"400": badRequest,
+		"404": openapi.ContentResponse("Grey 'not tracked' SVG image", "image/svg+xml", &openapi.Schema{Type: "string"}),
+	}
+	atomFeed := openapi.ContentResponse("Atom 1.0 feed, newest entries first", "application/atom+xml", &openapi.Schema{Type: "string"})
+
+	// Meta
+	doc.AddOperation("GET", "/", &openapi.Operation{
+		Summary: "API information",
+		Tags:    []string{"meta"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("API information", openapi.SchemaOf(rootResponse{})),
+		},
+	})
+	doc.AddOperation("GET", "/health", &openapi.Operation{
+		Summary: "Health check",
+		Tags:    []string{"meta"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Service is healthy", openapi.SchemaOf(healthResponse{})),
+		},
+	})
+	doc.AddOperation("GET", "/metrics", &openapi.Operation{
+		Summary: "Prometheus metrics",
+		Tags:    []string{"meta"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.ContentResponse("Metrics in Prometheus text format", "text/plain", &openapi.Schema{Type: "string"}),
+		},
+	})
+	doc.AddOperation("GET", "/openapi.json", &openapi.Operation{
+		Summary: "This OpenAPI document",
+		Tags:    []string{"meta"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("OpenAPI document", &openapi.Schema{Type: "object"}),
+		},
+	})
+	doc.AddOperation("GET", "/docs", &openapi.Operation{
+		Summary: "Interactive API documentation",
+		Tags:    []string{"meta"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.ContentResponse("HTML viewer", "text/html", &openapi.Schema{Type: "string"}),
+		},
+	})
+
+	// Auth
+	doc.AddOperation("POST", "/api/auth/login-code", &openapi.Operation{
+		Summary: "Exchange a token for a login code",
+		Description: "Returns a single-use code, valid for a minute, to pass to /api/auth/callback so the token never appears in URLs. " +
+			"The token may be sent in the body or as Authorization: Bearer.",
+		Tags:        []string{"auth"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(LoginCodeRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Login code", openapi.SchemaOf(loginCodeResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+		},
+	})
+	doc.AddOperation("GET", "/api/auth/callback", &openapi.Operation{
+		Summary: "Open a session and set its cookie",
+		Description: "Consumes a login code, sets the admintoken session cookie on the API domain and redirects to redirect_to, " +
+			"which must be on one of the allowed CORS origins.",
+		Tags:       []string{"auth"},
+		Parameters: openapi.QueryParams(CallbackRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.ContentResponse("Cookie set, no redirect_to given", "text/plain", &openapi.Schema{Type: "string"}),
+			"303": {Description: "Cookie set, redirecting to redirect_to"},
+			"400": openapi.ContentResponse("Code missing or redirect_to not allowed", "text/plain", &openapi.Schema{Type: "string"}),
+			"401": openapi.ContentResponse("Invalid, used or expired code", "text/plain", &openapi.Schema{Type: "string"}),
+		},
+	})
+	doc.AddOperation("GET", "/api/auth/oidc/login", &openapi.Operation{
+		Summary: "Sign in with OpenID Connect",
+		Description: "Redirects to the identity provider (authorization code flow with PKCE), which returns to /api/auth/oidc/callback. " +
+			"redirect_to must be on one of the allowed CORS origins.",
+		Tags:       []string{"auth"},
+		Parameters: openapi.QueryParams(OIDCLoginRequest{}),
+		Responses: map[string]openapi.Response{
+			"302": {Description: "Redirecting to the identity provider"},
+			"400": openapi.ContentResponse("redirect_to not allowed", "text/plain", &openapi.Schema{Type: "string"}),
+			"404": openapi.JSONResponse("OIDC login is not configured", detail),
+			"502": openapi.ContentResponse("The identity provider is unavailable", "text/plain", &openapi.Schema{Type: "string"}),
+		},
+	})
+	doc.AddOperation("GET", "/api/auth/oidc/callback", &openapi.Operation{
+		Summary: "Finish an OpenID Connect sign-in",
+		Description: "Redeems the provider's code, opens a session with the roles mapped from the user's groups " +
+			"(viewer, curator, admin), sets the admintoken session cookie and redirects to the redirect_to of the login.",
+		Tags:       []string{"auth"},
+		Parameters: openapi.QueryParams(OIDCCallbackRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.ContentResponse("Cookie set, no redirect_to given", "text/plain", &openapi.Schema{Type: "string"}),
+			"303": {Description: "Cookie set, redirecting to redirect_to"},
+			"400": openapi.ContentResponse("Code missing, or invalid or expired login state", "text/plain", &openapi.Schema{Type: "string"}),
+			"401": openapi.ContentResponse("Sign-in refused by the provider or invalid ID token", "text/plain", &openapi.Schema{Type: "string"}),
+			"403": openapi.ContentResponse("None of the user's groups grants a role", "text/plain", &openapi.Schema{Type: "string"}),
+			"404": openapi.JSONResponse("OIDC login is not configured", detail),
+			"502": openapi.ContentResponse("The identity provider is unavailable", "text/plain", &openapi.Schema{Type: "string"}),
+		},
+	})
+	doc.AddOperation("POST", "/api/auth/logout", &openapi.Operation{
+		Summary: "End the session",
+		Tags:    []string{"auth"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Session ended and cookie cleared", detail),
+			"403": csrfFailed,
+		},
+	})
+	doc.AddOperation("GET", "/api/auth/check", &openapi.Operation{
+		Summary:     "Check authentication",
+		Description: "Returns the caller's identity, roles and scopes, and whether OIDC login is available.",
+		Tags:        []string{"auth"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Authentication status", openapi.SchemaOf(authCheckResponse{})),
+		},
+	})
+
+	// Stats
+	doc.AddOperation("GET", "/api/stats/summary", &openapi.Operation{
+		Summary: "Catalog summary",
+		Tags:    []string{"stats"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Current catalog totals",
+				&openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{Type: "integer", Format: "int64"}}),
+			"304": notModified,
+		},
+	})
+
+	doc.AddOperation("GET", "/api/stats/history", &openapi.Operation{
+		Summary:     "Catalog totals over time",
+		Description: "Daily snapshots of catalog totals. With interval week or month, the last snapshot of each bucket is returned, dated at the bucket start.",
+		Tags:        []string{"stats"},
+		Parameters:  openapi.QueryParams(StatsHistoryRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Snapshots, oldest first", openapi.SchemaOf(statsHistoryResponse{})),
+			"304": notModified,
+			"400": badRequest,
+		},
+	})
+	doc.AddOperation("GET", "/api/rankings", &openapi.Operation{
+		Summary: "Leaderboards",
+		Description: "largest and at_risk rank by the latest pages or edits, at_risk only wikis without archives. " +
+			"fastest_growing ranks by the increase of the metric over the last days. " +
+			"most_active ranks by active users. longest_offline ranks failing wikis by seconds since their last successful collection.",
+		Tags:       []string{"stats"},
+		Parameters: openapi.QueryParams(RankingsRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Ranking", openapi.SchemaOf(rankingsResponse{})),
+			"304": notModified,
+			"400": badRequest,
+		},
+	})
+
+	doc.AddOperation("GET", "/api/tags", &openapi.Operation{
+		Summary: "List tags",
+		Tags:    []string{"curation"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Tags with the number of wikis carrying them", openapi.SchemaOf(tagListResponse{})),
+		},
+	})
+
+	// Wikis
+	doc.AddOperation("GET", "/api/wikis", &openapi.Operation{
+		Summary:    "List wikis",
+		Tags:       []string{"wikis"},
+		Parameters: openapi.QueryParams(ListWikisRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Page of wikis", openapi.SchemaOf(wikiListResponse{})),
+			"304": notModified,
+			"400": badRequest,
+		},
+	})
+	doc.AddOperation("POST", "/api/wikis", &openapi.Operation{
+		Summary: "Add or submit a wiki",
+		Description: "Callers with the wikis:write scope add the wiki directly. Anyone else submits it for moderation, " +
+			"sending the solution of GET /api/submissions/challenge: the submission is pre-checked, approved automatically " +
+			"when it is a new, reachable MediaWiki, and otherwise reviewed by a curator. Follow it with GET /api/submissions/{id}.",
+		Tags:        []string{"wikis"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WikiCreateRequest{})),
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Wiki created", wiki),
+			"202": openapi.JSONResponse("Wiki submitted for moderation, or already in the queue", submissionView),
+			"400": openapi.JSONResponse("Invalid request, missing or wrong challenge solution, or wiki already tracked (with wiki_id)", errSchema),
+			"401": unauthorized,
+			"403": csrfFailed,
+			"409": openapi.JSONResponse("Wiki is in the trash (with wiki_id)", errSchema),
+			"429": tooMany,
+			"502": openapi.JSONResponse("Captcha provider unavailable", errSchema),
+		},
+	})
+	doc.AddOperation("GET", "/api/submissions/challenge", &openapi.Operation{
+		Summary: "Get a submission challenge",
+		Description: "kind pow: find a nonce such that the SHA-256 of challenge followed by nonce starts with difficulty zero bits, " +
+			"and send challenge and nonce before expires_at; each challenge is accepted once. kind captcha: render the provider's " +
+			"widget with site_key and send its response as captcha_response. kind none: no proof is needed.",
+		Tags: []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Challenge", openapi.SchemaOf(submission.Challenge{})),
+		},
+	})
+	doc.AddOperation("GET", "/api/submissions/:id", &openapi.Operation{
+		Summary:     "Get the status of a submitted wiki",
+		Description: "pending and checking while the pre-check runs, review while a curator decides, then approved (with wiki_id) or rejected.",
+		Tags:        []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Submission", submissionView),
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/lookup", &openapi.Operation{
+		Summary: "Look a wiki up by URL",
+		Description: "url may be any URL, API URL or index URL the wiki has used, including URLs from before a move to https " +
+			"or a new domain and URLs of duplicates merged into it. is_alias tells whether the match is a former URL.",
+		Tags:       []string{"wikis"},
+		Parameters: openapi.QueryParams(WikiLookupRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Matching wiki", openapi.SchemaOf(wikiLookupResult{})),
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id", &openapi.Operation{
+		Summary: "Get a wiki",
+		Tags:    []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Wiki", wiki),
+			"304": notModified,
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id/stats", &openapi.Operation{
+		Summary:     "Get historical statistics of a wiki",
+		Description: "One row per successful check. History past the raw retention is returned as one row per day or month holding its last values.",
+		Tags:        []string{"wikis"},
+		Parameters: []openapi.Parameter{{
+			Name:        "days",
+			In:          "query",
+			Description: "Number of days of history (default 30, 0 for all)",
+			Schema:      &openapi.Schema{Type: "integer"},
+		}},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Statistics, newest first", openapi.SchemaOf(wikiStatsResponse{})),
+			"304": notModified,
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id/archives", &openapi.Operation{
+		Summary: "Get Archive.org dumps of a wiki",
+		Tags:    []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Archives, newest first", openapi.SchemaOf(wikiArchivesResponse{})),
+			"304": notModified,
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id/thumbnail", &openapi.Operation{
+		Summary: "Redirect to a thumbnail image of a wiki",
+		Tags:    []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"302": {Description: "Redirect to an Archive.org image"},
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id/feed.atom", &openapi.Operation{
+		Summary:     "Atom feed of a wiki",
+		Description: "The newest status transitions, read-only changes and new Archive.org dumps of the wiki.",
+		Tags:        []string{"feeds"},
+		Responses: map[string]openapi.Response{
+			"200": atomFeed,
+			"304": notModified,
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/wikis/:id/badge.svg", &openapi.Operation{
+		Summary:     "SVG badge of a wiki",
+		Description: badgeDescription,
+		Tags:        []string{"badges"},
+		Parameters:  openapi.QueryParams(BadgeRequest{}),
+		Responses:   badgeResponses,
+	})
+	doc.AddOperation("GET", "/api/badge.svg", &openapi.Operation{
+		Summary:     "SVG badge of a wiki looked up by URL",
+		Description: badgeDescription + " url may be any URL or api.php URL the wiki has used.",
+		Tags:        []string{"badges"},
+		Parameters:  openapi.QueryParams(BadgeByURLRequest{}),
+		Responses:   badgeResponses,
+	})
+	doc.AddOperation("GET", "/api/compare", &openapi.Operation{
+		Summary: "Compare wikis side by side",
+		Description: "Stats series of every wiki downsampled onto a shared time axis (newest sample per bucket, null where there is none), " +
+			"differences in MediaWiki version, license, language and extensions, and Archive.org coverage.",
+		Tags:       []string{"wikis"},
+		Parameters: openapi.QueryParams(CompareRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Comparison", openapi.SchemaOf(compareResponse{})),
+			"400": badRequest,
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/wikis/:id/check", &openapi.Operation{
+		Summary:     "Trigger statistics collection",
+		Description: "Anonymous users may trigger one check per hour per wiki.",
+		Tags:        []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"202": openapi.JSONResponse("Collection started", detail),
+			"400": badRequest,
+			"403": csrfFailed,
+			"404": notFound,
+			"429": tooMany,
+		},
+	})
+	doc.AddOperation("POST", "/api/wikis/:id/check-archive", &openapi.Operation{
+		Summary:     "Trigger an Archive.org check",
+		Description: "Anonymous users may trigger one archive check per hour per wiki.",
+		Tags:        []string{"wikis"},
+		Responses: map[string]openapi.Response{
+			"202": openapi.JSONResponse("Archive check started", detail),
+			"400": badRequest,
+			"403": csrfFailed,
+			"404": notFound,
+			"429": tooMany,
+		},
+	})
+
+	// Feeds
+	doc.AddOperation("GET", "/api/feeds/archives.atom", &openapi.Operation{
+		Summary:   "Atom feed of new archives",
+		Tags:      []string{"feeds"},
+		Responses: map[string]openapi.Response{"200": atomFeed, "304": notModified},
+	})
+	doc.AddOperation("GET", "/api/feeds/offline.atom", &openapi.Operation{
+		Summary:   "Atom feed of wikis that went offline or read-only",
+		Tags:      []string{"feeds"},
+		Responses: map[string]openapi.Response{"200": atomFeed, "304": notModified},
+	})
+	doc.AddOperation("GET", "/api/feeds/wikis.atom", &openapi.Operation{
+		Summary:   "Atom feed of newly added wikis",
+		Tags:      []string{"feeds"},
+		Responses: map[string]openapi.Response{"200": atomFeed, "304": notModified},
+	})
+
+	// Export
+	exportParams := openapi.QueryParams(ExportRequest{})
+	var exportSecurity []map[string][]string
+	if cfg.ExportRequireToken {
+		exportSecurity = adminSecurity
+	}
+	exportResponses := func(what string, record *openapi.Schema) map[string]openapi.Response {
+		responses := map[string]openapi.Response{
+			"200": {
+				Description: what + ", streamed. Gzip-compressed when the client accepts it.",
+				Content: map[string]openapi.MediaType{
+					"application/x-ndjson": {Schema: record},
+					"text/csv":             {Schema: &openapi.Schema{Type: "string"}},
+				},
+			},
+			"400": badRequest,
+			"429": tooMany,
+		}
+		if cfg.ExportRequireToken {
+			responses["401"] = unauthorized
+			responses["403"] = forbidden(models.ScopeExport)
+		}
+		return responses
+	}
+	doc.AddOperation("GET", "/api/export/wikis", &openapi.Operation{
+		Summary:     "Export wikis",
+		Description: "Ordered by updated_at; pass the last updated_at as since to resume.",
+		Tags:        []string{"export"},
+		Security:    exportSecurity,
+		Parameters:  exportParams,
+		Responses:   exportResponses("Wikis", wiki),
+	})
+	doc.AddOperation("GET", "/api/export/stats", &openapi.Operation{
+		Summary:     "Export statistics",
+		Description: "Stored rows, ordered by time; pass the last time as since to resume. from and to limit the time range. A row is only stored when a counter changed or the heartbeat interval passed. History past the raw retention is exported as one row per day or month holding its last values.",
+		Tags:        []string{"export"},
+		Security:    exportSecurity,
+		Parameters:  exportParams,
+		Responses:   exportResponses("Statistics", stats),
+	})
+	doc.AddOperation("GET", "/api/export/archives", &openapi.Operation{
+		Summary:     "Export archives",
+		Description: "Ordered by updated_at; pass the last updated_at as since to resume. Items taken down or darked on Archive.org are kept with removed_at set.",
+		Tags:        []string{"export"},
+		Security:    exportSecurity,
+		Parameters:  exportParams,
+		Responses:   exportResponses("Archives", archive),
+	})
+	doc.AddOperation("GET", "/api/datasets", &openapi.Operation{
+		Summary: "List published dataset snapshots",
+		Tags:    []string{"export"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Snapshot manifests, newest first", openapi.SchemaOf(datasetListResponse{})),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/datasets/:version", &openapi.Operation{
+		Summary:     "Get a snapshot manifest",
+		Description: "The version \"latest\" resolves to the newest snapshot.",
+		Tags:        []string{"export"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Snapshot manifest", manifest),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/datasets/:version/:file", &openapi.Operation{
+		Summary: "Download a snapshot file",
+		Tags:    []string{"export"},
+		Responses: map[string]openapi.Response{
+			"200": openapi.ContentResponse("File contents", "application/octet-stream", &openapi.Schema{Type: "string", Format: "binary"}),
+			"404": notFound,
+		},
+	})
+
+	// Admin
+	doc.AddOperation("DELETE", "/api/admin/wikis/:id", &openapi.Operation{
+		Summary: "Move a wiki to the trash",
+		Description: "The wiki disappears from lists, stats and schedulers but keeps its history. " +
+			"It can be restored until it is purged after the retention period given in purge_after.",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(WikiDeleteRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Wiki moved to trash", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminDelete),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/wikis/:id/restore", &openapi.Operation{
+		Summary:  "Restore a wiki from the trash",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Restored wiki", wiki),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminDelete),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/trash", &openapi.Operation{
+		Summary:    "List wikis in the trash",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(TrashListRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Wikis in the trash, most recently deleted first", openapi.SchemaOf(trashListResponse{})),
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/wikis/:id/stats", &openapi.Operation{
+		Summary:  "Get check status of a wiki",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Check status", openapi.SchemaOf(adminWikiStatsResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/wikis/:id/merge", &openapi.Operation{
+		Summary: "Merge a duplicate into a wiki",
+		Description: "Moves stats, archives, events, notes and tags of duplicate_id onto the wiki, deletes the duplicate " +
+			"and records its URL as an alias. Archives both wikis have are kept once.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WikiMergeRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Merge log entry", openapi.SchemaOf(models.WikiMerge{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminDelete),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/wikis/:id/merges", &openapi.Operation{
+		Summary:  "List duplicates merged into a wiki",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Merges, newest first", openapi.SchemaOf(wikiMergeListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+		},
+	})
+	policy := openapi.JSONResponse("Check policy with effective intervals", openapi.SchemaOf(wikiPolicyResponse{}))
+	doc.AddOperation("GET", "/api/admin/wikis/:id/policy", &openapi.Operation{
+		Summary:  "Get the check policy of a wiki",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": policy,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("PUT", "/api/admin/wikis/:id/policy", &openapi.Operation{
+		Summary: "Replace the check policy of a wiki",
+		Description: "Schedulers pick active, unpaused wikis whose next check is due, highest priority first. " +
+			"Intervals are in minutes; omitted fields reset to their defaults. Next check times are recomputed from the last checks.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WikiPolicyRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": policy,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminCollect),
+			"404": notFound,
+		},
+	})
+	hook := openapi.JSONResponse("Webhook", openapi.SchemaOf(models.Webhook{}))
+	doc.AddOperation("GET", "/api/admin/webhooks", &openapi.Operation{
+		Summary:  "List webhooks",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Webhooks, oldest first", openapi.SchemaOf(webhookListResponse{})),
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/webhooks", &openapi.Operation{
+		Summary: "Register a webhook",
+		Description: "Matching wiki events are POSTed as JSON with the headers X-WikiKeeper-Event, X-WikiKeeper-Delivery, " +
+			"X-WikiKeeper-Timestamp and X-WikiKeeper-Signature, which is sha256= followed by the hex HMAC-SHA256 of " +
+			"\"<timestamp>.<body>\" keyed with the secret. Events are wiki_added, status_changed, went_offline, recovered, " +
+			"read_only, writable, archive_found, archive_removed, anomaly and merged. Failed deliveries are retried " +
+			"with growing delays. The secret is only returned by this call.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WebhookRequest{})),
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Webhook with its secret", openapi.SchemaOf(webhookCreatedResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/webhooks/:id", &openapi.Operation{
+		Summary:  "Get a webhook",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": hook,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("PUT", "/api/admin/webhooks/:id", &openapi.Operation{
+		Summary:     "Replace a webhook",
+		Description: "Omitted fields reset to their defaults. The secret cannot be changed.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WebhookRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": hook,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("DELETE", "/api/admin/webhooks/:id", &openapi.Operation{
+		Summary:  "Delete a webhook and its delivery log",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Webhook deleted", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/webhooks/:id/deliveries", &openapi.Operation{
+		Summary:    "List deliveries of a webhook",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(WebhookDeliveryListRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Deliveries, newest first", openapi.SchemaOf(webhookDeliveryListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/webhooks/:id/deliveries/:delivery_id/retry", &openapi.Operation{
+		Summary:  "Queue a delivery for another attempt",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"202": openapi.JSONResponse("Delivery queued", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/webhooks/:id/test", &openapi.Operation{
+		Summary:     "Send a test ping",
+		Description: "Sends a ping event right away and returns the logged delivery. Pings are not retried.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Delivery", openapi.SchemaOf(models.WebhookDelivery{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/tokens", &openapi.Operation{
+		Summary:    "List API tokens",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(TokenListRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Tokens, newest first", openapi.SchemaOf(tokenListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/tokens", &openapi.Operation{
+		Summary: "Create an API token",
+		Description: "Scopes are admin:read (viewing the trash, merges, policies, notes and collection stats), wikis:write (tags, notes), admin:collect (collections, archive checks, check policies), " +
+			"admin:delete (trash, restore, merge), export (/api/export when EXPORT_REQUIRE_TOKEN is set) and admin (everything). " +
+			"The token is only returned by this call; send it as Authorization: Bearer.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(TokenCreateRequest{})),
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Token record with the token itself", openapi.SchemaOf(tokenCreatedResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+		},
+	})
+	doc.AddOperation("DELETE", "/api/admin/tokens/:id", &openapi.Operation{
+		Summary:  "Revoke an API token",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Token revoked", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/collect-all", &openapi.Operation{
+		Summary:  "Collect statistics for all active wikis",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"202": openapi.JSONResponse("Collection started", detail),
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminCollect),
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/check-all-archives", &openapi.Operation{
+		Summary:  "Check Archive.org for all wikis",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"202": openapi.JSONResponse("Archive check started", detail),
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminCollect),
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/audit", &openapi.Operation{
+		Summary: "List audit log entries",
+		Description: "Admin changes, including attempts refused for a missing scope, and public wiki submissions and checks, " +
+			"newest first. Secrets in recorded parameters are redacted.",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(AuditListRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Audit log entries", openapi.SchemaOf(auditListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdmin),
+		},
+	})
+
+	// Moderation
+	doc.AddOperation("GET", "/api/admin/submissions", &openapi.Operation{
+		Summary: "List submitted wikis",
+		Description: "Oldest first, with the pre-check results: whether the API answered, whether it is a MediaWiki, " +
+			"and the tracked wiki it duplicates. Curators decide submissions with status review.",
+		Tags:       []string{"admin"},
+		Security:   adminSecurity,
+		Parameters: openapi.QueryParams(SubmissionListRequest{}),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Page of submissions", openapi.SchemaOf(submissionListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/submissions/:id", &openapi.Operation{
+		Summary:  "Get a submitted wiki",
+		Tags:     []string{"admin"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Submission", submissionFull),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/submissions/:id/approve", &openapi.Operation{
+		Summary:     "Approve a submitted wiki",
+		Description: "Adds the wiki to the tracked wikis. Rejected submissions may be approved too.",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(SubmissionDecisionRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Approved submission, with the wiki_id of the added wiki", submissionFull),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+			"409": openapi.JSONResponse("Already approved, being checked, changed meanwhile, or the wiki is tracked or trashed (with wiki_id)", errSchema),
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/submissions/:id/reject", &openapi.Operation{
+		Summary:     "Reject a submitted wiki",
+		Tags:        []string{"admin"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(SubmissionDecisionRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Rejected submission", submissionFull),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+			"409": openapi.JSONResponse("Already decided, being checked, or changed meanwhile", errSchema),
+		},
+	})
+
+	// Curation
+	doc.AddOperation("PUT", "/api/admin/tags/:tag", &openapi.Operation{
+		Summary:     "Create a tag or update its description",
+		Tags:        []string{"curation"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(TagUpdateRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Tag", openapi.SchemaOf(models.Tag{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+		},
+	})
+	doc.AddOperation("DELETE", "/api/admin/tags/:tag", &openapi.Operation{
+		Summary:  "Delete a tag and remove it from all wikis",
+		Tags:     []string{"curation"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Tag deleted", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+	wikiTags := openapi.JSONResponse("Tags of the wiki", openapi.SchemaOf(wikiTagsResponse{}))
+	doc.AddOperation("POST", "/api/admin/wikis/:id/tags", &openapi.Operation{
+		Summary:     "Add tags to a wiki",
+		Description: "Unknown tags are created. Tag names are lower-case slugs of a-z, 0-9 and '-'.",
+		Tags:        []string{"curation"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WikiTagsRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": wikiTags,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("PUT", "/api/admin/wikis/:id/tags", &openapi.Operation{
+		Summary:     "Replace the tags of a wiki",
+		Description: "Unknown tags are created. An empty list removes all tags.",
+		Tags:        []string{"curation"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(WikiTagsRequest{})),
+		Responses: map[string]openapi.Response{
+			"200": wikiTags,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("DELETE", "/api/admin/wikis/:id/tags/:tag", &openapi.Operation{
+		Summary:  "Remove a tag from a wiki",
+		Tags:     []string{"curation"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": wikiTags,
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("GET", "/api/admin/wikis/:id/notes", &openapi.Operation{
+		Summary:  "List notes on a wiki",
+		Tags:     []string{"curation"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Notes, newest first", openapi.SchemaOf(noteListResponse{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeAdminRead),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("POST", "/api/admin/wikis/:id/notes", &openapi.Operation{
+		Summary:     "Add a note to a wiki",
+		Description: "The author defaults to the authenticated admin.",
+		Tags:        []string{"curation"},
+		Security:    adminSecurity,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(NoteCreateRequest{})),
+		Responses: map[string]openapi.Response{
+			"201": openapi.JSONResponse("Note created", openapi.SchemaOf(models.WikiNote{})),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+	doc.AddOperation("DELETE", "/api/admin/wikis/:id/notes/:note_id", &openapi.Operation{
+		Summary:  "Delete a note",
+		Tags:     []string{"curation"},
+		Security: adminSecurity,
+		Responses: map[string]openapi.Response{
+			"200": openapi.JSONResponse("Note deleted", detail),
+			"400": badRequest,
+			"401": unauthorized,
+			"403": forbidden(models.ScopeWikisWrite),
+			"404": notFound,
+		},
+	})
+
+	return doc
+}
@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/cache"
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// PolicyHandler manages the per-wiki check policy
+type PolicyHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewPolicyHandler creates a new policy handler
+func NewPolicyHandler(db *gorm.DB, cfg *config.Config) *PolicyHandler {
+	return &PolicyHandler{db: db, config: cfg}
+}
+
+// WikiPolicyRequest represents the request body of PUT /api/admin/wikis/:id/policy.
+// It replaces the whole policy; omitted fields get their defaults.
+type WikiPolicyRequest struct {
+	Priority                    *models.WikiPriority `json:"priority"`                       // -1 low, 0 normal (default), 1 high
+	CollectIntervalMinutes      *int                 `json:"collect_interval_minutes"`       // Omit to use the global default
+	ArchiveCheckIntervalMinutes *int                 `json:"archive_check_interval_minutes"` // Omit to use the global default
+	PausedUntil                 *time.Time           `json:"paused_until"`                   // Omit to resume checks
+	IsActive                    *bool                `json:"is_active"`                      // Defaults to true
+}
+
+// wikiPolicyResponse is the JSON body of the policy endpoints
+type wikiPolicyResponse struct {
+	WikiID                      uuid.UUID           `json:"wiki_id"`
+	Priority                    models.WikiPriority `json:"priority"`
+	CollectIntervalMinutes      int                 `json:"collect_interval_minutes"`
+	ArchiveCheckIntervalMinutes int                 `json:"archive_check_interval_minutes"`
+	CustomCollectInterval       bool                `json:"custom_collect_interval"`
+	CustomArchiveCheckInterval  bool                `json:"custom_archive_check_interval"`
+	PausedUntil                 *time.Time          `json:"paused_until"`
+	IsActive                    bool                `json:"is_active"`
+	NextCheckAt                 *time.Time          `json:"next_check_at"`         // Null means due now
+	NextArchiveCheckAt          *time.Time          `json:"next_archive_check_at"` // Null means due now
+}
+
+// Get handles GET /api/admin/wikis/:id/policy
+func (h *PolicyHandler) Get(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	wiki, err := repository.NewWikiRepository(h.db).GetByID(c.Request().Context(), id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, h.response(wiki))
+}
+
+// Update handles PUT /api/admin/wikis/:id/policy
+func (h *PolicyHandler) Update(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	var req WikiPolicyRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	if err := validatePolicy(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	wikiRepo := repository.NewWikiRepository(h.db)
+	ctx := c.Request().Context()
+	wiki, err := wikiRepo.GetByID(ctx, id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	wiki.Priority = models.WikiPriorityNormal
+	if req.Priority != nil {
+		wiki.Priority = *req.Priority
+	}
+	wiki.CollectIntervalMinutes = req.CollectIntervalMinutes
+	wiki.ArchiveCheckIntervalMinutes = req.ArchiveCheckIntervalMinutes
+	wiki.PausedUntil = req.PausedUntil
+	wiki.IsActive = req.IsActive == nil || *req.IsActive
+
+	// Reschedule from the last checks so new intervals apply right away
+	wiki.ScheduleCheck(wiki.LastCheckAt, h.collectInterval())
+	wiki.ScheduleArchiveCheck(wiki.ArchiveLastCheckAt, h.archiveCheckInterval())
+
+	if err := wikiRepo.UpdatePolicy(ctx, wiki); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	cache.Invalidate()
+
+	applogger.Log.Info("[Admin] Wiki check policy changed", "wiki_id", id, "priority", wiki.Priority,
+		"paused_until", wiki.PausedUntil, "is_active", wiki.IsActive, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, h.response(wiki))
+}
+
+// response builds the policy of wiki with effective intervals
+func (h *PolicyHandler) response(wiki *models.Wiki) wikiPolicyResponse {
+	resp := wikiPolicyResponse{
+		WikiID:                      wiki.ID,
+		Priority:                    wiki.Priority,
+		CollectIntervalMinutes:      int(h.collectInterval() / time.Minute),
+		ArchiveCheckIntervalMinutes: int(h.archiveCheckInterval() / time.Minute),
+		PausedUntil:                 wiki.PausedUntil,
+		IsActive:                    wiki.IsActive,
+		NextCheckAt:                 wiki.NextCheckAt,
+		NextArchiveCheckAt:          wiki.NextArchiveCheckAt,
+	}
+	if wiki.CollectIntervalMinutes != nil {
+		resp.CollectIntervalMinutes = *wiki.CollectIntervalMinutes
+		resp.CustomCollectInterval = true
+	}
+	if wiki.ArchiveCheckIntervalMinutes != nil {
+		resp.ArchiveCheckIntervalMinutes = *wiki.ArchiveCheckIntervalMinutes
+		resp.CustomArchiveCheckInterval = true
+	}
+	return resp
+}
+
+func (h *PolicyHandler) collectInterval() time.Duration {
+	return time.Duration(h.config.WikiCollectInterval * float64(time.Minute))
+}
+
+func (h *PolicyHandler) archiveCheckInterval() time.Duration {
+	return time.Duration(h.config.WikiArchiveCheckInterval * float64(time.Minute))
+}
+
+// validatePolicy rejects unknown priorities and out-of-range intervals
+func validatePolicy(req *WikiPolicyRequest) error {
+	if req.Priority != nil && !req.Priority.Valid() {
+		return fmt.Errorf("priority must be %d (low), %d (normal) or %d (high)",
+			models.WikiPriorityLow, models.WikiPriorityNormal, models.WikiPriorityHigh)
+	}
+	if req.CollectIntervalMinutes != nil {
+		if err := models.ValidateCheckInterval(*req.CollectIntervalMinutes); err != nil {
+			return fmt.Errorf("collect_interval_minutes: %w", err)
+		}
+	}
+	if req.ArchiveCheckIntervalMinutes != nil {
+		if err := models.ValidateCheckInterval(*req.ArchiveCheckIntervalMinutes); err != nil {
+			return fmt.Errorf("archive_check_interval_minutes: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/cache"
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/repository"
+)
+
+// RankingsHandler serves leaderboards
+type RankingsHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewRankingsHandler creates a new rankings handler
+func NewRankingsHandler(db *gorm.DB, cfg *config.Config) *RankingsHandler {
+	return &RankingsHandler{db: db, config: cfg}
+}
+
+// RankingsRequest represents query parameters for GET /api/rankings
+type RankingsRequest struct {
+	Type   string `query:"type"`   // largest, fastest_growing, most_active, at_risk, longest_offline
+	Metric string `query:"metric"` // pages (default) or edits
+	Days   int    `query:"days"`   // fastest_growing window, default 30
+	Limit  int    `query:"limit"`  // default 20, max 100
+}
+
+// rankingsResponse is the JSON body of GET /api/rankings
+type rankingsResponse struct {
+	Type        repository.RankingType    `json:"type"`
+	Metric      string                    `json:"metric,omitempty"`
+	Days        int                       `json:"days,omitempty"`
+	Unit        string                    `json:"unit"`
+	GeneratedAt time.Time                 `json:"generated_at"`
+	Data        []repository.RankingEntry `json:"data"`
+}
+
+// rankingsEntry is a cached ranking together with its ETag
+type rankingsEntry struct {
+	response *rankingsResponse
+	etag     string
+}
+
+// List handles GET /api/rankings
+// Rankings scan the whole stats table, so results are cached until collectors write new data.
+func (h *RankingsHandler) List(c echo.Context) error {
+	var req RankingsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+
+	opts, err := rankingOptions(req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	key := fmt.Sprintf("rankings:%s:%s:%d:%d", opts.Type, opts.Metric, opts.Days, opts.Limit)
+	if cached, ok := cache.Shared().Get(key); ok {
+		entry := cached.(*rankingsEntry)
+		if checkNotModified(c, entry.etag, entry.response.GeneratedAt) {
+			return c.NoContent(http.StatusNotModified)
+		}
+		return c.JSON(http.StatusOK, entry.response)
+	}
+
+	rankingRepo := repository.NewRankingRepository(h.db)
+	data, err := rankingRepo.Get(c.Request().Context(), opts)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	response := &rankingsResponse{
+		Type:        opts.Type,
+		Metric:      opts.Metric,
+		Days:        opts.Days,
+		Unit:        rankingUnit(opts),
+		GeneratedAt: time.Now().UTC(),
+		Data:        data,
+	}
+
+	// The ETag covers the data only, so regenerating an unchanged ranking keeps it valid
+	body, err := json.Marshal(data)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	entry := &rankingsEntry{response: response, etag: weakETag(key, string(body))}
+	cache.Shared().Set(key, entry)
+
+	if checkNotModified(c, entry.etag, time.Time{}) {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// rankingOptions validates a request and applies defaults
+func rankingOptions(req RankingsRequest) (repository.RankingOptions, error) {
+	opts := repository.RankingOptions{
+		Type:  repository.RankingType(req.Type),
+		Limit: req.Limit,
+	}
+	if opts.Type == "" {
+		opts.Type = repository.RankingLargest
+	}
+	if !containsRankingType(opts.Type) {
+		return opts, fmt.Errorf("Invalid type, expected one of %v", repository.RankingTypes)
+	}
+
+	if opts.Limit < 1 || opts.Limit > 100 {
+		opts.Limit = 20
+	}
+
+	switch opts.Type {
+	case repository.RankingLargest, repository.RankingFastestGrowing, repository.RankingAtRisk:
+		opts.Metric = req.Metric
+		if opts.Metric == "" {
+			opts.Metric = "pages"
+		}
+		if opts.Metric != "pages" && opts.Metric != "edits" {
+			return opts, fmt.Errorf("Invalid metric, expected one of %v", repository.RankingMetrics)
+		}
+	}
+
+	if opts.Type == repository.RankingFastestGrowing {
+		opts.Days = req.Days
+		if opts.Days == 0 {
+			opts.Days = 30
+		}
+		if opts.Days < 1 || opts.Days > 365 {
+			return opts, fmt.Errorf("Invalid days parameter, expected 1 to 365")
+		}
+	}
+
+	return opts, nil
+}
+
+func containsRankingType(t repository.RankingType) bool {
+	for _, known := range repository.RankingTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// rankingUnit describes the value column of a ranking
+func rankingUnit(opts repository.RankingOptions) string {
+	switch opts.Type {
+	case repository.RankingMostActive:
+		return "active_users"
+	case repository.RankingLongestOffline:
+		return "seconds"
+	}
+	return opts.Metric
+}
@@ -1,15 +1,22 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
 
+	"wikikeeper-backend/internal/cache"
 	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
 	"wikikeeper-backend/internal/repository"
 )
 
+// summaryCacheKey is the response cache key of GET /api/stats/summary
+const summaryCacheKey = "stats:summary"
+
 // StatsHandler handles stats requests
 type StatsHandler struct {
 	db     *gorm.DB
@@ -21,15 +28,160 @@ func NewStatsHandler(db *gorm.DB, cfg *config.Config) *StatsHandler {
 	return &StatsHandler{db: db, config: cfg}
 }
 
+// summaryEntry is a cached summary together with its validators
+type summaryEntry struct {
+	stats        map[string]int64
+	etag         string
+	lastModified time.Time
+}
+
 // Summary handles GET /api/stats/summary
+// The aggregates are cached until collectors write new data or the cache TTL expires.
 func (h *StatsHandler) Summary(c echo.Context) error {
-	wikiRepo := repository.NewWikiRepository(h.db)
+	if cached, ok := cache.Shared().Get(summaryCacheKey); ok {
+		entry := cached.(*summaryEntry)
+		if checkNotModified(c, entry.etag, entry.lastModified) {
+			return c.NoContent(http.StatusNotModified)
+		}
+		return c.JSON(http.StatusOK, entry.stats)
+	}
+
 	ctx := c.Request().Context()
+	etag, lastModified, err := h.summaryValidators(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if checkNotModified(c, etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
+	wikiRepo := repository.NewWikiRepository(h.db)
 	stats, err := wikiRepo.GetSummaryStats(ctx)
 	if err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
+	cache.Shared().Set(summaryCacheKey, &summaryEntry{
+		stats:        stats,
+		etag:         etag,
+		lastModified: lastModified,
+	})
+
 	return c.JSON(http.StatusOK, stats)
 }
+
+// summaryValidators derives the summary's ETag and Last-Modified from the
+// latest wiki update and the latest stats entry, without computing the aggregates
+func (h *StatsHandler) summaryValidators(ctx context.Context) (string, time.Time, error) {
+	wikiRepo := repository.NewWikiRepository(h.db)
+	statsRepo := repository.NewStatsRepository(h.db)
+
+	version, err := wikiRepo.GetVersion(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	lastModified := version.LastModified
+
+	latestStats, err := statsRepo.GetLatestTime(ctx)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if latestStats != nil {
+		lastModified = latestTime(lastModified, *latestStats)
+	}
+
+	etag := weakETag("summary", version.Count, version.LastModified.UnixNano(), lastModified.UnixNano())
+	return etag, lastModified, nil
+}
+
+// StatsHistoryRequest represents query parameters for GET /api/stats/history
+type StatsHistoryRequest struct {
+	Interval string `query:"interval"` // day (default), week or month
+	From     string `query:"from"`     // First day, YYYY-MM-DD
+	To       string `query:"to"`       // Last day, YYYY-MM-DD
+}
+
+// History handles GET /api/stats/history
+// Returns daily catalog snapshots; for week and month the last snapshot of each bucket is used.
+func (h *StatsHandler) History(c echo.Context) error {
+	var req StatsHistoryRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+
+	if req.Interval == "" {
+		req.Interval = "day"
+	}
+	if req.Interval != "day" && req.Interval != "week" && req.Interval != "month" {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid interval, expected day, week or month"})
+	}
+
+	from, err := parseHistoryDate(req.From)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid from parameter, expected YYYY-MM-DD"})
+	}
+	to, err := parseHistoryDate(req.To)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid to parameter, expected YYYY-MM-DD"})
+	}
+
+	snapshotRepo := repository.NewCatalogSnapshotRepository(h.db)
+	snapshots, err := snapshotRepo.List(c.Request().Context(), from, to)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	var lastModified time.Time
+	for _, snapshot := range snapshots {
+		lastModified = latestTime(lastModified, snapshot.CapturedAt)
+	}
+	etag := weakETag("history", c.QueryString(), len(snapshots), lastModified.UnixNano())
+	if checkNotModified(c, etag, lastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"interval": req.Interval,
+		"data":     bucketSnapshots(snapshots, req.Interval),
+	})
+}
+
+// parseHistoryDate parses an optional YYYY-MM-DD date
+func parseHistoryDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// bucketSnapshots keeps the last snapshot of each week (starting Monday) or month,
+// dated at the start of the bucket. Snapshots must be ordered oldest first.
+func bucketSnapshots(snapshots []*models.CatalogSnapshot, interval string) []*models.CatalogSnapshot {
+	if interval == "day" {
+		return snapshots
+	}
+
+	result := make([]*models.CatalogSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		bucket := *snapshot
+		d := snapshot.Date.UTC()
+		switch interval {
+		case "week":
+			offset := (int(d.Weekday()) + 6) % 7 // Days since Monday
+			bucket.Date = time.Date(d.Year(), d.Month(), d.Day()-offset, 0, 0, 0, 0, time.UTC)
+		case "month":
+			bucket.Date = time.Date(d.Year(), d.Month(), 1, 0, 0, 0, 0, time.UTC)
+		}
+
+		if n := len(result); n > 0 && result[n-1].Date.Equal(bucket.Date) {
+			result[n-1] = &bucket
+		} else {
+			result = append(result, &bucket)
+		}
+	}
+	return result
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+	"wikikeeper-backend/internal/services"
+	"wikikeeper-backend/internal/submission"
+)
+
+// maxReasonLength bounds the reason given for a moderation decision
+const maxReasonLength = 1000
+
+// NewSubmissionQueue creates the moderation queue, probing wikis with the
+// configured HTTP client settings
+func NewSubmissionQueue(db *gorm.DB, cfg *config.Config) *submission.Queue {
+	mw := services.NewMediaWikiService(time.Duration(cfg.HTTPTimeout*float64(time.Second)), cfg.HTTPUserAgent)
+	return submission.NewQueue(db, submission.MediaWikiProbe(mw), submissionLookup(db), submission.RulesFromConfig(cfg))
+}
+
+// submissionLookup finds the tracked or trashed wiki a submission duplicates
+func submissionLookup(db *gorm.DB) submission.LookupFunc {
+	return func(ctx context.Context, wikiURL, apiURL string) (*submission.Match, error) {
+		if trashed, err := repository.NewWikiRepository(db).GetTrashedByURL(ctx, wikiURL); err == nil {
+			return &submission.Match{WikiID: trashed.ID, Trashed: true}, nil
+		} else if err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		found, err := lookupWiki(ctx, db, wikiURL, apiURL)
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &submission.Match{WikiID: found.Wiki.ID, IsAlias: found.IsAlias}, nil
+	}
+}
+
+// SubmissionHandler serves the moderation queue of wikis submitted anonymously
+type SubmissionHandler struct {
+	db     *gorm.DB
+	config *config.Config
+	guard  submission.Guard
+	queue  *submission.Queue
+}
+
+// NewSubmissionHandler creates a new submission handler
+func NewSubmissionHandler(db *gorm.DB, cfg *config.Config) *SubmissionHandler {
+	return &SubmissionHandler{db: db, config: cfg, guard: submission.NewGuard(cfg, nil), queue: NewSubmissionQueue(db, cfg)}
+}
+
+// submissionStatus is the public view of a submission, without the submitter's
+// address and the pre-check details
+type submissionStatus struct {
+	ID          uuid.UUID               `json:"id"`
+	URL         string                  `json:"url"`
+	Status      models.SubmissionStatus `json:"status"`
+	WikiID      *uuid.UUID              `json:"wiki_id,omitempty"`      // Wiki added on approval
+	DuplicateOf *uuid.UUID              `json:"duplicate_of,omitempty"` // Tracked wiki the submission duplicates
+	Reason      *string                 `json:"reason,omitempty"`
+	CreatedAt   time.Time               `json:"created_at"`
+	CheckedAt   *time.Time              `json:"checked_at,omitempty"`
+	ReviewedAt  *time.Time              `json:"reviewed_at,omitempty"`
+}
+
+func newSubmissionStatus(sub *models.Submission) *submissionStatus {
+	return &submissionStatus{
+		ID:          sub.ID,
+		URL:         sub.URL,
+		Status:      sub.Status,
+		WikiID:      sub.WikiID,
+		DuplicateOf: sub.DuplicateOf,
+		Reason:      sub.Reason,
+		CreatedAt:   sub.CreatedAt,
+		CheckedAt:   sub.CheckedAt,
+		ReviewedAt:  sub.ReviewedAt,
+	}
+}
+
+// SubmissionListRequest represents query parameters for GET /api/admin/submissions
+type SubmissionListRequest struct {
+	Status   string `query:"status"` // pending, checking, review, approved or rejected
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+}
+
+// submissionListResponse is the JSON body of GET /api/admin/submissions
+type submissionListResponse struct {
+	Total    int64                `json:"total"`
+	Page     int                  `json:"page"`
+	PageSize int                  `json:"page_size"`
+	Data     []*models.Submission `json:"data"`
+}
+
+// SubmissionDecisionRequest represents the request body of approving or rejecting a submission
+type SubmissionDecisionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Challenge handles GET /api/submissions/challenge
+func (h *SubmissionHandler) Challenge(c echo.Context) error {
+	challenge, err := h.guard.Challenge()
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, challenge)
+}
+
+// Get handles GET /api/submissions/:id, for submitters following their submission
+func (h *SubmissionHandler) Get(c echo.Context) error {
+	sub, err := h.load(c)
+	if err != nil {
+		return submissionError(c, err)
+	}
+	return c.JSON(http.StatusOK, newSubmissionStatus(sub))
+}
+
+// List handles GET /api/admin/submissions
+func (h *SubmissionHandler) List(c echo.Context) error {
+	var req SubmissionListRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	status := models.SubmissionStatus(req.Status)
+	switch status {
+	case "", models.SubmissionPending, models.SubmissionChecking, models.SubmissionReview, models.SubmissionApproved, models.SubmissionRejected:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "status must be pending, checking, review, approved or rejected"})
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	subs, total, err := repository.NewSubmissionRepository(h.db).List(c.Request().Context(), status, req.Page, req.PageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if subs == nil {
+		subs = []*models.Submission{}
+	}
+	return c.JSON(http.StatusOK, submissionListResponse{Total: total, Page: req.Page, PageSize: req.PageSize, Data: subs})
+}
+
+// AdminGet handles GET /api/admin/submissions/:id
+func (h *SubmissionHandler) AdminGet(c echo.Context) error {
+	sub, err := h.load(c)
+	if err != nil {
+		return submissionError(c, err)
+	}
+	return c.JSON(http.StatusOK, sub)
+}
+
+// Approve handles POST /api/admin/submissions/:id/approve. Rejected submissions
+// can be approved too, reversing the decision.
+func (h *SubmissionHandler) Approve(c echo.Context) error {
+	sub, err := h.load(c)
+	if err != nil {
+		return submissionError(c, err)
+	}
+	var req SubmissionDecisionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	reason, err := decisionReason(&req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	switch sub.Status {
+	case models.SubmissionApproved:
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission is already approved"})
+	case models.SubmissionChecking:
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission is being checked, try again shortly"})
+	}
+
+	// The wiki may have been added since the pre-check
+	ctx := c.Request().Context()
+	apiURL := ""
+	if sub.APIURL != nil {
+		apiURL = *sub.APIURL
+	}
+	match, err := submissionLookup(h.db)(ctx, sub.URL, apiURL)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if match != nil {
+		detail := "Wiki already exists"
+		if match.Trashed {
+			detail = "Wiki is in the trash and can be restored"
+		}
+		return c.JSON(http.StatusConflict, map[string]string{"detail": detail, "wiki_id": match.WikiID.String()})
+	}
+
+	if err := h.queue.Approve(ctx, sub, middleware.Actor(c), reason); err != nil {
+		return decisionError(c, err)
+	}
+	middleware.SetAuditTarget(c, sub.WikiID.String())
+	return c.JSON(http.StatusOK, sub)
+}
+
+// Reject handles POST /api/admin/submissions/:id/reject
+func (h *SubmissionHandler) Reject(c echo.Context) error {
+	sub, err := h.load(c)
+	if err != nil {
+		return submissionError(c, err)
+	}
+	var req SubmissionDecisionRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	reason, err := decisionReason(&req)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	switch sub.Status {
+	case models.SubmissionApproved:
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission is already approved; delete the wiki instead"})
+	case models.SubmissionRejected:
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission is already rejected"})
+	case models.SubmissionChecking:
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission is being checked, try again shortly"})
+	}
+
+	if err := h.queue.Reject(c.Request().Context(), sub, middleware.Actor(c), reason); err != nil {
+		return decisionError(c, err)
+	}
+	return c.JSON(http.StatusOK, sub)
+}
+
+// errInvalidSubmissionID is returned by load for malformed :id path parameters
+var errInvalidSubmissionID = errors.New("Invalid submission ID format")
+
+// load fetches the submission named by the :id path parameter
+func (h *SubmissionHandler) load(c echo.Context) (*models.Submission, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, errInvalidSubmissionID
+	}
+	return repository.NewSubmissionRepository(h.db).GetByID(c.Request().Context(), id)
+}
+
+// submissionError writes the response for an error returned by load
+func submissionError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errInvalidSubmissionID):
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Submission not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+}
+
+// decisionReason validates the optional reason of a moderation decision
+func decisionReason(req *SubmissionDecisionRequest) (*string, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > maxReasonLength {
+		return nil, errors.New("reason is too long")
+	}
+	if reason == "" {
+		return nil, nil
+	}
+	return &reason, nil
+}
+
+// decisionError writes the response for an error of Queue.Approve or Queue.Reject
+func decisionError(c echo.Context, err error) error {
+	if errors.Is(err, submission.ErrChanged) {
+		return c.JSON(http.StatusConflict, map[string]string{"detail": "Submission was changed meanwhile, reload it"})
+	}
+	return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/cache"
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// maxTagsPerRequest bounds the number of tags assigned in one request
+const maxTagsPerRequest = 50
+
+// TagHandler manages curation tags
+type TagHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(db *gorm.DB, cfg *config.Config) *TagHandler {
+	return &TagHandler{db: db, config: cfg}
+}
+
+// TagUpdateRequest represents the request body of PUT /api/admin/tags/:tag
+type TagUpdateRequest struct {
+	Description *string `json:"description"`
+}
+
+// WikiTagsRequest represents the request body for assigning tags to a wiki
+type WikiTagsRequest struct {
+	Tags []string `json:"tags"`
+}
+
+// tagListResponse is the JSON body of GET /api/tags
+type tagListResponse struct {
+	Data []repository.TagWithCount `json:"data"`
+}
+
+// wikiTagsResponse is the JSON body of the wiki tag endpoints
+type wikiTagsResponse struct {
+	WikiID uuid.UUID    `json:"wiki_id"`
+	Tags   []models.Tag `json:"tags"`
+}
+
+// List handles GET /api/tags
+func (h *TagHandler) List(c echo.Context) error {
+	tags, err := repository.NewTagRepository(h.db).List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if tags == nil {
+		tags = []repository.TagWithCount{}
+	}
+	return c.JSON(http.StatusOK, tagListResponse{Data: tags})
+}
+
+// Upsert handles PUT /api/admin/tags/:tag
+func (h *TagHandler) Upsert(c echo.Context) error {
+	name, err := models.NormalizeTagName(c.Param("tag"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	var req TagUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+
+	tag, err := repository.NewTagRepository(h.db).Upsert(c.Request().Context(), name, req.Description)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	applogger.Log.Info("[Admin] Tag saved", "tag", name, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, tag)
+}
+
+// Delete handles DELETE /api/admin/tags/:tag
+func (h *TagHandler) Delete(c echo.Context) error {
+	name, err := models.NormalizeTagName(c.Param("tag"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	deleted, err := repository.NewTagRepository(h.db).Delete(c.Request().Context(), name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !deleted {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Tag not found"})
+	}
+	cache.Invalidate()
+
+	applogger.Log.Info("[Admin] Tag deleted", "tag", name, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, map[string]string{"detail": "Tag deleted", "tag": name})
+}
+
+// AddWikiTags handles POST /api/admin/wikis/:id/tags
+func (h *TagHandler) AddWikiTags(c echo.Context) error {
+	return h.assign(c, false)
+}
+
+// SetWikiTags handles PUT /api/admin/wikis/:id/tags
+func (h *TagHandler) SetWikiTags(c echo.Context) error {
+	return h.assign(c, true)
+}
+
+// assign adds tags to a wiki or replaces its tags
+func (h *TagHandler) assign(c echo.Context, replace bool) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+
+	var req WikiTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	names, err := parseTagNames(req.Tags)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if !replace && len(names) == 0 {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "tags is required"})
+	}
+
+	ctx := c.Request().Context()
+	if _, err := repository.NewWikiRepository(h.db).GetByID(ctx, id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	tagRepo := repository.NewTagRepository(h.db)
+	var tags []models.Tag
+	if replace {
+		tags, err = tagRepo.SetWikiTags(ctx, id, names)
+	} else {
+		tags, err = tagRepo.AddToWiki(ctx, id, names)
+	}
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	cache.Invalidate()
+
+	applogger.Log.Info("[Admin] Wiki tags changed", "wiki_id", id, "tags", names, "replace", replace, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, wikiTagsResponse{WikiID: id, Tags: tags})
+}
+
+// RemoveWikiTag handles DELETE /api/admin/wikis/:id/tags/:tag
+func (h *TagHandler) RemoveWikiTag(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid wiki ID format"})
+	}
+	name, err := models.NormalizeTagName(c.Param("tag"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	tagRepo := repository.NewTagRepository(h.db)
+	removed, err := tagRepo.RemoveFromWiki(ctx, id, name)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !removed {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki does not carry this tag"})
+	}
+	cache.Invalidate()
+
+	tags, err := tagRepo.ListByWikiID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	applogger.Log.Info("[Admin] Wiki tag removed", "wiki_id", id, "tag", name, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, wikiTagsResponse{WikiID: id, Tags: tags})
+}
+
+// parseTagNames normalizes and de-duplicates tag names, skipping blanks
+func parseTagNames(values []string) ([]string, error) {
+	seen := make(map[string]bool, len(values))
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		name, err := models.NormalizeTagName(value)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) > maxTagsPerRequest {
+		return nil, fmt.Errorf("at most %d tags are allowed", maxTagsPerRequest)
+	}
+	return names, nil
+}
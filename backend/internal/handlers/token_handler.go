@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// TokenHandler manages API tokens
+type TokenHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewTokenHandler creates a new token handler
+func NewTokenHandler(db *gorm.DB, cfg *config.Config) *TokenHandler {
+	return &TokenHandler{db: db, config: cfg}
+}
+
+// TokenListRequest represents query parameters for GET /api/admin/tokens
+type TokenListRequest struct {
+	IncludeRevoked bool `query:"include_revoked"`
+}
+
+// TokenCreateRequest represents the request body of POST /api/admin/tokens
+type TokenCreateRequest struct {
+	Name          string     `json:"name"`
+	Scopes        []string   `json:"scopes"`          // admin:read, wikis:write, admin:collect, admin:delete, export or admin
+	ExpiresAt     *time.Time `json:"expires_at"`      // Omit, together with expires_in_days, for a token that never expires
+	ExpiresInDays *int       `json:"expires_in_days"` // Alternative to expires_at
+}
+
+// tokenListResponse is the JSON body of GET /api/admin/tokens
+type tokenListResponse struct {
+	Data []models.APIToken `json:"data"`
+}
+
+// tokenCreatedResponse is the JSON body of POST /api/admin/tokens; the token is never shown again
+type tokenCreatedResponse struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+// List handles GET /api/admin/tokens
+func (h *TokenHandler) List(c echo.Context) error {
+	var req TokenListRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+
+	tokens, err := repository.NewAPITokenRepository(h.db).List(c.Request().Context(), req.IncludeRevoked)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	return c.JSON(http.StatusOK, tokenListResponse{Data: tokens})
+}
+
+// Create handles POST /api/admin/tokens
+func (h *TokenHandler) Create(c echo.Context) error {
+	var req TokenCreateRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+
+	expiresAt := req.ExpiresAt
+	if req.ExpiresInDays != nil {
+		if expiresAt != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Set expires_at or expires_in_days, not both"})
+		}
+		if *req.ExpiresInDays <= 0 {
+			return c.JSON(http.StatusBadRequest, map[string]string{"detail": "expires_in_days must be positive"})
+		}
+		t := time.Now().UTC().AddDate(0, 0, *req.ExpiresInDays)
+		expiresAt = &t
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "expires_at must be in the future"})
+	}
+
+	token, raw, err := models.NewAPIToken(req.Name, req.Scopes, expiresAt, middleware.Actor(c))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if err := repository.NewAPITokenRepository(h.db).Create(c.Request().Context(), token); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	middleware.SetAuditTarget(c, token.ID.String())
+	applogger.Log.Info("[Admin] API token created", "token_id", token.ID, "name", token.Name, "scopes", token.Scopes, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusCreated, tokenCreatedResponse{APIToken: *token, Token: raw})
+}
+
+// Revoke handles DELETE /api/admin/tokens/:id
+func (h *TokenHandler) Revoke(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid token ID format"})
+	}
+
+	found, err := repository.NewAPITokenRepository(h.db).Revoke(c.Request().Context(), id, time.Now().UTC())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Token not found or already revoked"})
+	}
+
+	applogger.Log.Info("[Admin] API token revoked", "token_id", id, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, map[string]string{"detail": "Token revoked"})
+}
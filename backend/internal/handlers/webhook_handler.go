@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/middleware"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+	"wikikeeper-backend/internal/webhook"
+)
+
+// WebhookHandler manages outbound webhooks and their delivery log
+type WebhookHandler struct {
+	db     *gorm.DB
+	config *config.Config
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(db *gorm.DB, cfg *config.Config) *WebhookHandler {
+	return &WebhookHandler{db: db, config: cfg}
+}
+
+// WebhookRequest represents the request body of POST /api/admin/webhooks and
+// PUT /api/admin/webhooks/:id. PUT replaces every field but the secret.
+type WebhookRequest struct {
+	URL         string   `json:"url"`       // http or https endpoint
+	Events      []string `json:"events"`    // Event types to deliver; omit for all
+	Tag         *string  `json:"tag"`       // Only wikis carrying this tag
+	Farm        *string  `json:"farm"`      // Only wikis on this domain or its subdomains, e.g. miraheze.org
+	IsActive    *bool    `json:"is_active"` // Defaults to true
+	Description *string  `json:"description"`
+	Secret      *string  `json:"secret,omitempty"` // HMAC key; generated when omitted on create, ignored on update
+}
+
+// webhookCreatedResponse is the JSON body of POST /api/admin/webhooks; the secret is never shown again
+type webhookCreatedResponse struct {
+	models.Webhook
+	Secret string `json:"secret"`
+}
+
+// webhookListResponse is the JSON body of GET /api/admin/webhooks
+type webhookListResponse struct {
+	Data []models.Webhook `json:"data"`
+}
+
+// WebhookDeliveryListRequest represents query parameters for GET /api/admin/webhooks/:id/deliveries
+type WebhookDeliveryListRequest struct {
+	Status   string `query:"status"` // pending, succeeded or failed
+	Page     int    `query:"page"`
+	PageSize int    `query:"page_size"`
+}
+
+// webhookDeliveryListResponse is the JSON body of GET /api/admin/webhooks/:id/deliveries
+type webhookDeliveryListResponse struct {
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+	Data     []*models.WebhookDelivery `json:"data"`
+}
+
+// List handles GET /api/admin/webhooks
+func (h *WebhookHandler) List(c echo.Context) error {
+	hooks, err := repository.NewWebhookRepository(h.db).List(c.Request().Context())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if hooks == nil {
+		hooks = []models.Webhook{}
+	}
+	return c.JSON(http.StatusOK, webhookListResponse{Data: hooks})
+}
+
+// Create handles POST /api/admin/webhooks
+func (h *WebhookHandler) Create(c echo.Context) error {
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+
+	hook := &models.Webhook{CreatedBy: middleware.Actor(c)}
+	if err := applyWebhookRequest(hook, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+	if req.Secret != nil && strings.TrimSpace(*req.Secret) != "" {
+		hook.Secret = strings.TrimSpace(*req.Secret)
+	} else {
+		secret, err := webhook.GenerateSecret()
+		if err != nil {
+			return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+		}
+		hook.Secret = secret
+	}
+
+	if err := repository.NewWebhookRepository(h.db).Create(c.Request().Context(), hook); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	middleware.SetAuditTarget(c, hook.ID.String())
+	applogger.Log.Info("[Admin] Webhook created", "webhook_id", hook.ID, "url", hook.URL, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusCreated, webhookCreatedResponse{Webhook: *hook, Secret: hook.Secret})
+}
+
+// Get handles GET /api/admin/webhooks/:id
+func (h *WebhookHandler) Get(c echo.Context) error {
+	hook, err := h.load(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+	return c.JSON(http.StatusOK, hook)
+}
+
+// Update handles PUT /api/admin/webhooks/:id
+func (h *WebhookHandler) Update(c echo.Context) error {
+	hook, err := h.load(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	var req WebhookRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid request body"})
+	}
+	if err := applyWebhookRequest(hook, &req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	}
+
+	if err := repository.NewWebhookRepository(h.db).Update(c.Request().Context(), hook); err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	applogger.Log.Info("[Admin] Webhook updated", "webhook_id", hook.ID, "is_active", hook.IsActive, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, hook)
+}
+
+// Delete handles DELETE /api/admin/webhooks/:id
+func (h *WebhookHandler) Delete(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid webhook ID format"})
+	}
+
+	found, err := repository.NewWebhookRepository(h.db).Delete(c.Request().Context(), id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Webhook not found"})
+	}
+
+	applogger.Log.Info("[Admin] Webhook deleted", "webhook_id", id, "actor", middleware.Actor(c))
+	return c.JSON(http.StatusOK, map[string]string{"detail": "Webhook deleted"})
+}
+
+// ListDeliveries handles GET /api/admin/webhooks/:id/deliveries
+func (h *WebhookHandler) ListDeliveries(c echo.Context) error {
+	hook, err := h.load(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	var req WebhookDeliveryListRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid query parameters"})
+	}
+	status := models.WebhookDeliveryStatus(req.Status)
+	switch status {
+	case "", models.WebhookDeliveryPending, models.WebhookDeliverySucceeded, models.WebhookDeliveryFailed:
+	default:
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "status must be pending, succeeded or failed"})
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	deliveries, total, err := repository.NewWebhookRepository(h.db).ListDeliveries(c.Request().Context(), hook.ID, status, req.Page, req.PageSize)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, webhookDeliveryListResponse{Total: total, Page: req.Page, PageSize: req.PageSize, Data: deliveries})
+}
+
+// RetryDelivery handles POST /api/admin/webhooks/:id/deliveries/:delivery_id/retry
+func (h *WebhookHandler) RetryDelivery(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid webhook ID format"})
+	}
+	deliveryID, err := uuid.Parse(c.Param("delivery_id"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid delivery ID format"})
+	}
+
+	found, err := repository.NewWebhookRepository(h.db).RetryDelivery(c.Request().Context(), id, deliveryID, time.Now())
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	if !found {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Delivery not found"})
+	}
+	return c.JSON(http.StatusAccepted, map[string]string{"detail": "Delivery queued"})
+}
+
+// Test handles POST /api/admin/webhooks/:id/test
+// Sends a ping right away and returns the logged delivery.
+func (h *WebhookHandler) Test(c echo.Context) error {
+	hook, err := h.load(c)
+	if err != nil {
+		return webhookError(c, err)
+	}
+
+	sender := webhook.NewSender(time.Duration(h.config.WebhookTimeout*float64(time.Second)), h.config.HTTPUserAgent)
+	delivery, err := webhook.NewDispatcher(h.db, sender).Ping(c.Request().Context(), hook)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	return c.JSON(http.StatusOK, delivery)
+}
+
+// errInvalidWebhookID is returned by load for malformed :id path parameters
+var errInvalidWebhookID = errors.New("Invalid webhook ID format")
+
+// load fetches the webhook named by the :id path parameter
+func (h *WebhookHandler) load(c echo.Context) (*models.Webhook, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return nil, errInvalidWebhookID
+	}
+	return repository.NewWebhookRepository(h.db).GetByID(c.Request().Context(), id)
+}
+
+// webhookError writes the response for an error returned by load
+func webhookError(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, errInvalidWebhookID):
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Webhook not found"})
+	default:
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+}
+
+// applyWebhookRequest validates req and copies it onto hook
+func applyWebhookRequest(hook *models.Webhook, req *WebhookRequest) error {
+	endpoint, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	events := models.StringList{}
+	for _, e := range req.Events {
+		if !models.WikiEventType(e).Valid() {
+			return fmt.Errorf("unknown event type %q", e)
+		}
+		events = append(events, e)
+	}
+
+	var tag *string
+	if req.Tag != nil && strings.TrimSpace(*req.Tag) != "" {
+		name, err := models.NormalizeTagName(*req.Tag)
+		if err != nil {
+			return err
+		}
+		tag = &name
+	}
+
+	var farm *string
+	if req.Farm != nil && strings.TrimSpace(*req.Farm) != "" {
+		domain := strings.ToLower(strings.Trim(strings.TrimSpace(*req.Farm), "."))
+		if strings.ContainsAny(domain, "/:@ ") || !strings.Contains(domain, ".") {
+			return errors.New("farm must be a domain such as miraheze.org")
+		}
+		farm = &domain
+	}
+
+	hook.URL = endpoint.String()
+	hook.Events = events
+	hook.Tag = tag
+	hook.Farm = farm
+	hook.IsActive = req.IsActive == nil || *req.IsActive
+	hook.Description = req.Description
+	return nil
+}
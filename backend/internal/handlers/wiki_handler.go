@@ -2,9 +2,9 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -15,21 +15,26 @@ import (
 
 	applogger "wikikeeper-backend/internal/logger"
 
+	"wikikeeper-backend/internal/cache"
 	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/middleware"
 	"wikikeeper-backend/internal/models"
 	"wikikeeper-backend/internal/repository"
 	"wikikeeper-backend/internal/services"
+	"wikikeeper-backend/internal/submission"
 )
 
 // WikiHandler handles wiki HTTP requests
 type WikiHandler struct {
 	db     *gorm.DB
 	config *config.Config
+	guard  submission.Guard  // Bot check for anonymous submissions
+	queue  *submission.Queue // Moderation queue of anonymous submissions
 }
 
 // NewWikiHandler creates a new wiki handler
 func NewWikiHandler(db *gorm.DB, cfg *config.Config) *WikiHandler {
-	return &WikiHandler{db: db, config: cfg}
+	return &WikiHandler{db: db, config: cfg, guard: submission.NewGuard(cfg, nil), queue: NewSubmissionQueue(db, cfg)}
 }
 
 // ListWikisRequest represents query parameters for listing wikis
@@ -39,6 +44,7 @@ type ListWikisRequest struct {
 	Status     string `query:"status"`
 	HasArchive *bool  `query:"has_archive"`
 	Search     string `query:"search"`
+	Tag        string `query:"tag"` // Comma-separated; wikis must carry all tags
 	OrderBy    string `query:"order_by"`
 }
 
@@ -46,6 +52,8 @@ type ListWikisRequest struct {
 type WikiCreateRequest struct {
 	URL      string  `json:"url"`
 	WikiName *string `json:"wiki_name"`
+	// Solution of GET /api/submissions/challenge, needed without the wikis:write scope
+	submission.Proof
 }
 
 // List handles GET /api/wikis
@@ -83,6 +91,24 @@ func (h *WikiHandler) List(c echo.Context) error {
 	if req.Search != "" {
 		opts.Search = req.Search
 	}
+	if req.Tag != "" {
+		tags, err := parseTagNames(strings.Split(req.Tag, ","))
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		}
+		opts.Tags = tags
+	}
+	opts.IncludeTags = true
+
+	// Any change to any wiki invalidates every list page
+	version, err := wikiRepo.GetVersion(ctx)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	etag := weakETag("wikis", c.QueryString(), version.Count, version.LastModified.UnixNano())
+	if checkNotModified(c, etag, version.LastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
 
 	wikis, total, err := wikiRepo.List(ctx, opts)
 	if err != nil {
@@ -116,10 +142,21 @@ func (h *WikiHandler) Get(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
+	if checkNotModified(c, weakETag("wiki", wiki.ID, wiki.UpdatedAt.UnixNano()), wiki.UpdatedAt) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	tags, err := repository.NewTagRepository(h.db).ListByWikiID(ctx, id)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	wiki.Tags = tags
+
 	return c.JSON(http.StatusOK, wiki)
 }
 
-// Create handles POST /api/wikis
+// Create handles POST /api/wikis. Callers with the wikis:write scope add the
+// wiki directly; anyone else submits it to the moderation queue.
 func (h *WikiHandler) Create(c echo.Context) error {
 	var req WikiCreateRequest
 	if err := c.Bind(&req); err != nil {
@@ -130,46 +167,38 @@ func (h *WikiHandler) Create(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "URL is required"})
 	}
 
-	// Check if URL ends with /api.php
+	// A URL ending in /api.php is the wiki's API URL
 	rawURL := strings.TrimSpace(req.URL)
-	var apiURL string
-	var wikiURL string
-
-	if strings.HasSuffix(strings.TrimSuffix(rawURL, "/"), "/api.php") {
-		// User provided API URL directly
-		apiURL = strings.TrimSuffix(rawURL, "/")
-		// Wiki URL is the API URL with /api.php removed
-		wikiURL = strings.TrimSuffix(apiURL, "/api.php")
-		// Add trailing slash to indicate it's a directory
-		if !strings.HasSuffix(wikiURL, "/") {
-			wikiURL = wikiURL + "/"
-		}
-		// Ensure scheme
-		if !strings.HasPrefix(wikiURL, "http://") && !strings.HasPrefix(wikiURL, "https://") {
-			wikiURL = "https://" + wikiURL
-		}
-		// Validate URL format
-		if _, err := url.Parse(wikiURL); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid URL format"})
-		}
-	} else {
-		// Normal wiki URL, use existing logic
-		wikiURL = services.NormalizeURL(req.URL)
-		if wikiURL == "" {
-			return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid URL format"})
-		}
+	wikiURL, apiURL, err := submission.ParseURL(rawURL)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Invalid URL format"})
 	}
 
 	wikiRepo := repository.NewWikiRepository(h.db)
 	ctx := c.Request().Context()
 
-	// Check if already exists
-	exists, err := wikiRepo.ExistsByURL(ctx, wikiURL)
-	if err != nil {
+	// A wiki in the trash keeps its URL until it is restored or purged
+	if trashed, err := wikiRepo.GetTrashedByURL(ctx, wikiURL); err == nil {
+		return c.JSON(http.StatusConflict, map[string]string{
+			"detail":  "Wiki is in the trash and can be restored",
+			"wiki_id": trashed.ID.String(),
+		})
+	} else if err != gorm.ErrRecordNotFound {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
-	if exists {
-		return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Wiki already exists"})
+
+	// Check if already exists, under its current URL or any URL it has used
+	if existing, err := lookupWiki(ctx, h.db, rawURL, wikiURL, apiURL); err == nil {
+		return c.JSON(http.StatusBadRequest, map[string]string{
+			"detail":  "Wiki already exists",
+			"wiki_id": existing.Wiki.ID.String(),
+		})
+	} else if err != gorm.ErrRecordNotFound {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	if identity := middleware.CurrentIdentity(c); identity == nil || !identity.HasScope(models.ScopeWikisWrite) {
+		return h.submit(c, &req, wikiURL, apiURL)
 	}
 
 	// Create wiki
@@ -186,15 +215,75 @@ func (h *WikiHandler) Create(c echo.Context) error {
 		wiki.APIURL = &apiURL
 	}
 
-	if err := wikiRepo.Create(ctx, wiki); err != nil {
+	if err := services.AddWiki(ctx, h.db, wiki); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
 	// TODO: Trigger background initial check (go h.initialWikiCheck(wiki.ID))
 
+	middleware.SetAuditTarget(c, wiki.ID.String())
 	return c.JSON(http.StatusCreated, wiki)
 }
 
+// submit queues a wiki for moderation once the caller solved the submission
+// challenge, and starts its pre-check. A wiki already in the queue is not queued again.
+func (h *WikiHandler) submit(c echo.Context, req *WikiCreateRequest, wikiURL, apiURL string) error {
+	ctx := c.Request().Context()
+	proofKey, err := h.guard.Verify(ctx, req.Proof, c.RealIP())
+	if err != nil {
+		if errors.Is(err, submission.ErrProofRequired) || errors.Is(err, submission.ErrInvalidProof) {
+			return c.JSON(http.StatusBadRequest, map[string]string{"detail": err.Error()})
+		}
+		applogger.Log.Warn("[Submission] Challenge verification failed", "error", err)
+		return c.JSON(http.StatusBadGateway, map[string]string{"detail": "Challenge verification failed, try again later"})
+	}
+
+	subRepo := repository.NewSubmissionRepository(h.db)
+	if queued, err := subRepo.GetOpenByURL(ctx, wikiURL); err == nil {
+		middleware.SetAuditTarget(c, queued.ID.String())
+		return c.JSON(http.StatusAccepted, newSubmissionStatus(queued))
+	} else if err != gorm.ErrRecordNotFound {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	sub := &models.Submission{
+		URL:         wikiURL,
+		WikiName:    req.WikiName,
+		Status:      models.SubmissionPending,
+		SubmitterIP: c.RealIP(),
+	}
+	if apiURL != "" {
+		sub.APIURL = &apiURL
+	}
+	if proofKey != "" {
+		sub.ProofHash = &proofKey
+	}
+	if err := subRepo.Create(ctx, sub); err != nil {
+		if proofKey != "" {
+			if used, usedErr := subRepo.ProofUsed(ctx, proofKey); usedErr == nil && used {
+				return c.JSON(http.StatusBadRequest, map[string]string{"detail": "Challenge already used, request a new one"})
+			}
+		}
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+
+	applogger.Log.Info("[Submission] Wiki submitted", "submission_id", sub.ID, "url", sub.URL, "ip", sub.SubmitterIP)
+	go h.precheck(sub.ID)
+
+	middleware.SetAuditTarget(c, sub.ID.String())
+	return c.JSON(http.StatusAccepted, newSubmissionStatus(sub))
+}
+
+// precheck checks a new submission in the background; the scheduler job retries
+// checks that fail or are cut short by a restart
+func (h *WikiHandler) precheck(id uuid.UUID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	if _, err := h.queue.Check(ctx, id); err != nil && !errors.Is(err, submission.ErrChanged) {
+		applogger.Log.Warn("[Submission] Pre-check failed", "submission_id", id, "error", err)
+	}
+}
+
 // Delete handles DELETE /api/wikis/:id
 func (h *WikiHandler) Delete(c echo.Context) error {
 	idStr := c.Param("id")
@@ -215,10 +304,11 @@ func (h *WikiHandler) Delete(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
-	// Delete (cascade to stats and archives)
-	if err := wikiRepo.Delete(ctx, id); err != nil {
+	// Move to the trash; stats and archives are kept until it is purged
+	if err := wikiRepo.Trash(ctx, id, middleware.Actor(c), nil); err != nil {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
+	cache.Invalidate()
 
 	return c.JSON(http.StatusOK, map[string]string{
 		"detail":  fmt.Sprintf("Wiki %s deleted", idStr),
@@ -312,6 +402,15 @@ func (h *WikiHandler) GetStats(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
+	version, err := statsRepo.GetWindowVersion(ctx, id, days)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+	}
+	etag := weakETag("stats", id, days, version.Count, version.LastModified.UnixNano())
+	if checkNotModified(c, etag, version.LastModified) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	// Get stats
 	stats, err := statsRepo.GetByWikiID(ctx, id, days)
 	if err != nil {
@@ -338,7 +437,7 @@ func (h *WikiHandler) GetArchives(c echo.Context) error {
 	ctx := c.Request().Context()
 
 	// Check if wiki exists
-	_, err = wikiRepo.GetByID(ctx, id)
+	wiki, err := wikiRepo.GetByID(ctx, id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
@@ -346,6 +445,11 @@ func (h *WikiHandler) GetArchives(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 	}
 
+	// Every archive check updates the wiki, so its updated_at covers the archive list
+	if checkNotModified(c, weakETag("archives", id, wiki.UpdatedAt.UnixNano()), wiki.UpdatedAt) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
 	// Get archives
 	archives, err := archiveRepo.GetByWikiID(ctx, id)
 	if err != nil {
@@ -403,6 +507,7 @@ func (h *WikiHandler) CheckArchive(c echo.Context) error {
 		time.Duration(h.config.HTTPTimeout)*time.Second,
 		h.config.HTTPUserAgent,
 		h.config.ArchiveCheckDelay,
+		h.config.WikiArchiveCheckInterval,
 	)
 
 	// Check Archive.org (async)
@@ -483,18 +588,11 @@ func (h *WikiHandler) GetThumbnail(c echo.Context) error {
 
 // normalizeURL function removed - use services.NormalizeURL instead
 
-// isAdmin checks if the request has a valid admin token
+// isAdmin checks if the request carries a token allowed to trigger collections
 func (h *WikiHandler) isAdmin(c echo.Context) bool {
-	// If no admin token configured, no admin protection
-	if h.config.AdminToken == "" {
+	identity, err := middleware.NewAuthenticator(h.db, h.config).Authenticate(c)
+	if err != nil || identity == nil {
 		return false
 	}
-
-	// Check for admin token cookie
-	cookie, err := c.Cookie("admintoken")
-	if err != nil {
-		return false
-	}
-
-	return cookie.Value == h.config.AdminToken
+	return identity.HasScope(models.ScopeAdminCollect)
 }
@@ -133,4 +133,22 @@ var (
 			Help: "Unix timestamp of next archive check run",
 		},
 	)
+
+	// Maintenance job metrics
+	JobRunsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "job_runs_total",
+			Help: "Total number of maintenance job runs",
+		},
+		[]string{"job", "result"},
+	)
+
+	// Response cache metrics
+	CacheRequestsTotal = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "response_cache_requests_total",
+			Help: "Total number of response cache lookups",
+		},
+		[]string{"result"},
+	)
 )
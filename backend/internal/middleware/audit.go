@@ -0,0 +1,254 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+const (
+	auditTargetKey = "audit_target" // Target set by the handler, e.g. the ID of a created wiki
+
+	auditMaxBody  = 64 << 10 // Larger JSON bodies are not recorded
+	auditMaxError = 2 << 10  // Bytes of an error response kept to extract its detail
+
+	anonymousActor = "anonymous"
+	redacted       = "[redacted]"
+)
+
+// auditSecretKeys are suffixes of parameter names whose values are never recorded
+var auditSecretKeys = []string{"secret", "token", "password"}
+
+// SetAuditTarget sets the target of the audited request, for handlers creating
+// resources whose ID is not in the path
+func SetAuditTarget(c echo.Context, target string) {
+	c.Set(auditTargetKey, target)
+}
+
+// Auditor records admin actions and state-changing requests in the audit_log table
+type Auditor struct {
+	db   *gorm.DB
+	auth *Authenticator
+}
+
+// NewAuditor creates an auditor
+func NewAuditor(db *gorm.DB, auth *Authenticator) *Auditor {
+	return &Auditor{db: db, auth: auth}
+}
+
+// Record creates middleware recording each request as action, whatever its
+// outcome. Place it before RequireScope so refused attempts are recorded too.
+// A nil Auditor, or one without a database, records nothing.
+func (a *Auditor) Record(action string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if a == nil || a.db == nil {
+			return next
+		}
+		return func(c echo.Context) error {
+			params := auditParams(c)
+			capture := &auditWriter{ResponseWriter: c.Response().Writer}
+			c.Response().Writer = capture
+
+			err := next(c)
+
+			status := c.Response().Status
+			var message string
+			if err != nil {
+				status = http.StatusInternalServerError
+				message = err.Error()
+				var httpErr *echo.HTTPError
+				if errors.As(err, &httpErr) {
+					status = httpErr.Code
+					message = fmt.Sprint(httpErr.Message)
+				}
+			} else if status >= http.StatusBadRequest {
+				message = errorDetail(capture.body.Bytes())
+			}
+
+			entry := &models.AuditEntry{
+				Actor:   a.actor(c),
+				IP:      c.RealIP(),
+				Action:  action,
+				Params:  params,
+				Outcome: models.AuditOutcomeSuccess,
+				Status:  status,
+			}
+			if identity := CurrentIdentity(c); identity != nil {
+				entry.TokenID = identity.TokenID
+			}
+			if target := auditTarget(c); target != "" {
+				entry.TargetID = &target
+			}
+			if status >= http.StatusBadRequest {
+				entry.Outcome = models.AuditOutcomeFailure
+				if message != "" {
+					entry.Error = &message
+				}
+			}
+
+			// The request may have been cancelled; the entry must still be written
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if dbErr := repository.NewAuditRepository(a.db).Create(ctx, entry); dbErr != nil {
+				applogger.Log.Error("[Audit] Failed to record action", "action", action, "actor", entry.Actor, "error", dbErr)
+			}
+			return err
+		}
+	}
+}
+
+// actor names the caller: the authenticated token, or "anonymous" for public requests
+func (a *Auditor) actor(c echo.Context) string {
+	if actor := Actor(c); actor != "" {
+		return actor
+	}
+	if a.auth != nil && HasCredentials(c) {
+		if identity, err := a.auth.Authenticate(c); err == nil && identity != nil {
+			return identity.Name
+		}
+	}
+	return anonymousActor
+}
+
+// auditTarget returns the target set by the handler, else the resource in the path
+func auditTarget(c echo.Context) string {
+	if target, _ := c.Get(auditTargetKey).(string); target != "" {
+		return target
+	}
+	for _, name := range []string{"id", "tag"} {
+		if value := c.Param(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// auditParams collects the path, query and JSON body parameters of a request.
+// The body is put back for the handler to read.
+func auditParams(c echo.Context) models.JSONMap {
+	params := models.JSONMap{}
+
+	if names := c.ParamNames(); len(names) > 0 {
+		path := map[string]interface{}{}
+		for i, name := range names {
+			path[name] = c.ParamValues()[i]
+		}
+		params["path"] = path
+	}
+
+	if values := c.QueryParams(); len(values) > 0 {
+		query := map[string]interface{}{}
+		for name, value := range values {
+			if len(value) == 1 {
+				query[name] = value[0]
+			} else {
+				query[name] = value
+			}
+		}
+		params["query"] = redact(query)
+	}
+
+	req := c.Request()
+	if req.Body != nil && req.ContentLength != 0 && strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		data, err := io.ReadAll(io.LimitReader(req.Body, auditMaxBody+1))
+		req.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), req.Body))
+		if err == nil && len(data) <= auditMaxBody {
+			var body interface{}
+			if json.Unmarshal(data, &body) == nil {
+				params["body"] = redact(body)
+			}
+		}
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+// redact replaces the values of secret-looking keys, recursively
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSecretKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redact(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redact(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range auditSecretKeys {
+		if strings.HasSuffix(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// errorDetail extracts the "detail" of a JSON error response, else its text
+func errorDetail(body []byte) string {
+	var parsed struct {
+		Detail string `json:"detail"`
+	}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Detail != "" {
+		return parsed.Detail
+	}
+	return strings.TrimSpace(string(body))
+}
+
+// auditWriter keeps the start of the response body so error details can be recorded
+type auditWriter struct {
+	http.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(b []byte) (int, error) {
+	if room := auditMaxError - w.body.Len(); room > 0 {
+		if len(b) < room {
+			room = len(b)
+		}
+		w.body.Write(b[:room])
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *auditWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *auditWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacker, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacker.Hijack()
+	}
+	return nil, nil, errors.New("response does not support hijacking")
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+func TestAuditor_Record(t *testing.T) {
+	db := setupTokenDB(t)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE audit_log (
+			id TEXT PRIMARY KEY,
+			actor TEXT NOT NULL,
+			token_id TEXT,
+			ip TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id TEXT,
+			params TEXT,
+			outcome TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			error TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+
+	auth := NewAuthenticator(db, &config.Config{AdminToken: "secret"})
+	auditor := NewAuditor(db, auth)
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.POST("/wikis", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		SetAuditTarget(c, "new-wiki")
+		return c.String(http.StatusCreated, string(body))
+	}, auditor.Record("wiki.create"))
+	admin := e.Group("/admin", TokenAuth(auth))
+	admin.DELETE("/wikis/:id", func(c echo.Context) error {
+		return c.JSON(http.StatusNotFound, map[string]string{"detail": "Wiki not found"})
+	}, auditor.Record("wiki.delete"), RequireScope(models.ScopeAdminDelete))
+	admin.POST("/collect-all", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusServiceUnavailable, "busy")
+	}, auditor.Record("collect_all"))
+
+	do := func(method, path, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1000"
+		if body != "" {
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		}
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/wikis?force=1", `{"url":"https://example.org","api_token":"hunter2"}`, "")
+	require.Equal(t, http.StatusCreated, rec.Code)
+	assert.Contains(t, rec.Body.String(), "hunter2", "the handler still reads the body")
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/wikis/abc", "", "secret").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/admin/collect-all", "", "secret").Code)
+
+	repo := repository.NewAuditRepository(db)
+	ctx := context.Background()
+	list := func(action string) *models.AuditEntry {
+		entries, _, err := repo.List(ctx, repository.AuditListOptions{Action: action, Page: 1, PageSize: 10})
+		require.NoError(t, err)
+		require.Len(t, entries, 1, action)
+		return entries[0]
+	}
+
+	created := list("wiki.create")
+	assert.Equal(t, "anonymous", created.Actor)
+	assert.Equal(t, "192.0.2.1", created.IP)
+	assert.Equal(t, models.AuditOutcomeSuccess, created.Outcome)
+	assert.Equal(t, http.StatusCreated, created.Status)
+	require.NotNil(t, created.TargetID)
+	assert.Equal(t, "new-wiki", *created.TargetID)
+	assert.Equal(t, map[string]interface{}{"url": "https://example.org", "api_token": "[redacted]"}, created.Params["body"])
+	assert.Equal(t, map[string]interface{}{"force": "1"}, created.Params["query"])
+
+	deleted := list("wiki.delete")
+	assert.Equal(t, "admin", deleted.Actor)
+	assert.Equal(t, models.AuditOutcomeFailure, deleted.Outcome)
+	require.NotNil(t, deleted.TargetID)
+	assert.Equal(t, "abc", *deleted.TargetID)
+	require.NotNil(t, deleted.Error)
+	assert.Equal(t, "Wiki not found", *deleted.Error)
+
+	collected := list("collect_all")
+	assert.Equal(t, http.StatusServiceUnavailable, collected.Status)
+	require.NotNil(t, collected.Error)
+	assert.Equal(t, "busy", *collected.Error)
+
+	// Refused attempts are recorded as well
+	limited, raw, err := models.NewAPIToken("volunteer", []string{models.ScopeWikisWrite}, nil, "test")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewAPITokenRepository(db).Create(ctx, limited))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodDelete, "/admin/wikis/xyz", "", raw).Code)
+	entries, _, err := repo.List(ctx, repository.AuditListOptions{Actor: "volunteer", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, http.StatusForbidden, entries[0].Status)
+	assert.Equal(t, limited.ID, *entries[0].TokenID)
+
+	var disabled *Auditor
+	assert.NotNil(t, disabled.Record("wiki.create")(func(c echo.Context) error { return nil }))
+}
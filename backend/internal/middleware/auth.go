@@ -1,57 +1,238 @@
 package middleware
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
 	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// TokenCookie is the cookie holding the secret of browser sessions
+const TokenCookie = "admintoken"
+
+// Echo context keys
+const (
+	actorKey    = "actor"    // Name of the authenticated caller
+	identityKey = "identity" // *Identity of the authenticated caller
+	authKey     = "auth"     // *authResult of Authenticate, so each request is checked once
 )
 
-// AdminAuth creates middleware that checks for admin token in cookie
-func AdminAuth(cfg *config.Config) echo.MiddlewareFunc {
+// authResult caches the outcome of Authenticate for a request
+type authResult struct {
+	identity *Identity
+	err      error
+}
+
+// sharedTokenActor names the holder of the shared ADMIN_TOKEN
+const sharedTokenActor = "admin"
+
+// ErrInvalidToken is returned for unknown, revoked and expired tokens and sessions
+var ErrInvalidToken = errors.New("invalid, revoked or expired token")
+
+// Identity is an authenticated caller
+type Identity struct {
+	Name      string     `json:"name"`
+	TokenID   *uuid.UUID `json:"token_id,omitempty"` // Nil for the shared ADMIN_TOKEN and open mode
+	Scopes    []string   `json:"scopes"`
+	Roles     []string   `json:"roles,omitempty"`   // OIDC roles; callers with tokens have none
+	Email     string     `json:"email,omitempty"`   // OIDC users only
+	Subject   string     `json:"subject,omitempty"` // OIDC issuer and subject
+	SessionID *uuid.UUID `json:"-"`                 // Set when authenticated by the session cookie
+	CSRFToken string     `json:"-"`                 // Token state-changing requests of the session must send
+}
+
+// HasScope reports whether the caller was granted scope
+func (i *Identity) HasScope(scope string) bool {
+	return models.HasScope(i.Scopes, scope)
+}
+
+// SharedToken reports whether the caller holds the shared ADMIN_TOKEN, which names no one,
+// rather than a named API token or an OIDC login
+func (i *Identity) SharedToken() bool {
+	return i.TokenID == nil && i.Subject == ""
+}
+
+// Actor returns the name of the caller authenticated by TokenAuth, or "" for anonymous requests
+func Actor(c echo.Context) string {
+	actor, _ := c.Get(actorKey).(string)
+	return actor
+}
+
+// CurrentIdentity returns the caller authenticated by TokenAuth, or nil for anonymous requests
+func CurrentIdentity(c echo.Context) *Identity {
+	identity, _ := c.Get(identityKey).(*Identity)
+	return identity
+}
+
+// Authenticator checks API tokens against the api_tokens table and the shared ADMIN_TOKEN
+type Authenticator struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewAuthenticator creates an authenticator
+func NewAuthenticator(db *gorm.DB, cfg *config.Config) *Authenticator {
+	return &Authenticator{db: db, cfg: cfg}
+}
+
+// BearerToken returns the token of the Authorization: Bearer header of a request
+func BearerToken(c echo.Context) string {
+	if header := c.Request().Header.Get(echo.HeaderAuthorization); header != "" {
+		if scheme, token, ok := strings.Cut(header, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return ""
+}
+
+// SessionSecret returns the session secret of the admintoken cookie of a request
+func SessionSecret(c echo.Context) string {
+	if cookie, err := c.Cookie(TokenCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// HasCredentials reports whether a request carries a bearer token or session cookie
+func HasCredentials(c echo.Context) bool {
+	return BearerToken(c) != "" || SessionSecret(c) != ""
+}
+
+// Authenticate returns the caller of a request, or nil when it carries no credentials.
+// A bearer token takes precedence over the session cookie.
+func (a *Authenticator) Authenticate(c echo.Context) (*Identity, error) {
+	if cached, ok := c.Get(authKey).(*authResult); ok {
+		return cached.identity, cached.err
+	}
+
+	var identity *Identity
+	var err error
+	if raw := BearerToken(c); raw != "" {
+		identity, err = a.Verify(c.Request().Context(), raw)
+	} else if secret := SessionSecret(c); secret != "" {
+		identity, err = a.VerifySession(c.Request().Context(), secret)
+	}
+	c.Set(authKey, &authResult{identity: identity, err: err})
+	return identity, err
+}
+
+// Verify checks a token and records its use
+func (a *Authenticator) Verify(ctx context.Context, raw string) (*Identity, error) {
+	if a.cfg.AdminToken != "" && subtle.ConstantTimeCompare([]byte(raw), []byte(a.cfg.AdminToken)) == 1 {
+		return &Identity{Name: sharedTokenActor, Scopes: []string{models.ScopeAdmin}}, nil
+	}
+	if a.db == nil {
+		return nil, ErrInvalidToken
+	}
+
+	hash := models.HashAPIToken(raw)
+	tokenRepo := repository.NewAPITokenRepository(a.db)
+	token, err := tokenRepo.GetByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(token.TokenHash), []byte(hash)) != 1 || !token.Usable(now) {
+		return nil, ErrInvalidToken
+	}
+
+	if err := tokenRepo.Touch(ctx, token.ID, now); err != nil {
+		applogger.Log.Warn("[Auth] Failed to record token use", "token_id", token.ID, "error", err)
+	}
+	return &Identity{Name: token.Name, TokenID: &token.ID, Scopes: token.Scopes}, nil
+}
+
+// OpenMode reports whether AUTH_DISABLED turned authentication off. Protected routes
+// are then open to everyone, as in development. It depends on the configuration only,
+// so revoking or outliving every token never opens the admin API.
+func (a *Authenticator) OpenMode() bool {
+	return a.cfg.AuthDisabled
+}
+
+// TokenAuth creates middleware that requires a valid token as Authorization: Bearer
+// header or admintoken cookie. Routes then check scopes with RequireScope.
+func TokenAuth(auth *Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// If no admin token configured, allow all
-			if cfg.AdminToken == "" {
-				return next(c)
+			identity, err := auth.Authenticate(c)
+			if errors.Is(err, ErrInvalidToken) && BearerToken(c) == "" {
+				// A stale session cookie counts as no credentials
+				identity, err = nil, nil
 			}
-
-			// Get token from cookie
-			cookie, err := c.Cookie("admintoken")
 			if err != nil {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"detail": "Admin token required. Set 'admintoken' cookie.",
-				})
+				if errors.Is(err, ErrInvalidToken) {
+					return c.JSON(http.StatusUnauthorized, map[string]string{"detail": "Invalid, revoked or expired token"})
+				}
+				return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
 			}
 
-			// Validate token
-			if cookie.Value != cfg.AdminToken {
-				return c.JSON(http.StatusUnauthorized, map[string]string{
-					"detail": "Invalid admin token",
-				})
+			if identity == nil {
+				if !auth.OpenMode() {
+					return c.JSON(http.StatusUnauthorized, map[string]string{
+						"detail": "Token required. Send 'Authorization: Bearer <token>' or sign in through /api/auth/login-code.",
+					})
+				}
+				identity = &Identity{Name: sharedTokenActor, Scopes: []string{models.ScopeAdmin}}
 			}
 
+			c.Set(identityKey, identity)
+			c.Set(actorKey, identity.Name)
 			return next(c)
 		}
 	}
 }
 
-// CheckRateLimit creates middleware for rate limiting check endpoints
-// Allows 1 check per hour per wiki for anonymous users
-func CheckRateLimit(checkType string) echo.MiddlewareFunc {
+// OptionalAuth creates middleware that identifies callers with a valid token or
+// session, for public routes that grant those callers more. Anonymous requests
+// pass without an identity; invalid bearer tokens are rejected.
+func OptionalAuth(auth *Authenticator) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
-			// If user has admin token, skip rate limit
-			if cfg := c.Get("config").(*config.Config); cfg != nil {
-				if cookie, err := c.Cookie("admintoken"); err == nil {
-					if cookie.Value == cfg.AdminToken && cfg.AdminToken != "" {
-						return next(c)
-					}
+			identity, err := auth.Authenticate(c)
+			if errors.Is(err, ErrInvalidToken) && BearerToken(c) == "" {
+				identity, err = nil, nil
+			}
+			if err != nil {
+				if errors.Is(err, ErrInvalidToken) {
+					return c.JSON(http.StatusUnauthorized, map[string]string{"detail": "Invalid, revoked or expired token"})
 				}
+				return c.JSON(http.StatusInternalServerError, map[string]string{"detail": err.Error()})
+			}
+			if identity != nil {
+				c.Set(identityKey, identity)
+				c.Set(actorKey, identity.Name)
 			}
+			return next(c)
+		}
+	}
+}
 
-			// For anonymous users, check if rate limited
-			// This is handled in the handler itself using database timestamps
+// RequireScope creates middleware that rejects callers authenticated by TokenAuth
+// whose token lacks scope
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			identity := CurrentIdentity(c)
+			if identity == nil || !identity.HasScope(scope) {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"detail": fmt.Sprintf("Token lacks the %s scope", scope),
+				})
+			}
 			return next(c)
 		}
 	}
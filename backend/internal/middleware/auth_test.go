@@ -0,0 +1,194 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+func setupTokenDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE api_tokens (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL,
+			scopes TEXT NOT NULL,
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			revoked_at DATETIME,
+			created_by TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE auth_sessions (
+			id TEXT PRIMARY KEY,
+			secret_hash TEXT NOT NULL UNIQUE,
+			csrf_token TEXT NOT NULL,
+			token_id TEXT,
+			credential_hash TEXT NOT NULL,
+			subject TEXT,
+			email TEXT,
+			roles TEXT,
+			scopes TEXT,
+			name TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE auth_login_codes (
+			code_hash TEXT PRIMARY KEY,
+			token_id TEXT,
+			credential_hash TEXT NOT NULL,
+			name TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE auth_oidc_states (
+			state_hash TEXT PRIMARY KEY,
+			nonce TEXT NOT NULL,
+			code_verifier TEXT NOT NULL,
+			redirect_to TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+	return db
+}
+
+// serve runs a request through TokenAuth and RequireScope(scope) and returns the status
+func serve(auth *Authenticator, scope string, prepare func(*http.Request)) int {
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		return c.String(http.StatusOK, Actor(c))
+	}, TokenAuth(auth), RequireScope(scope))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if prepare != nil {
+		prepare(req)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func bearer(token string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+}
+
+func TestOptionalAuth(t *testing.T) {
+	auth := NewAuthenticator(nil, &config.Config{AdminToken: "secret"})
+	e := echo.New()
+	e.GET("/", func(c echo.Context) error {
+		if identity := CurrentIdentity(c); identity != nil {
+			return c.String(http.StatusOK, identity.Name)
+		}
+		return c.String(http.StatusOK, "anonymous")
+	}, OptionalAuth(auth))
+
+	for name, tc := range map[string]struct {
+		prepare func(*http.Request)
+		code    int
+		body    string
+	}{
+		"anonymous":     {nil, http.StatusOK, "anonymous"},
+		"token":         {bearer("secret"), http.StatusOK, sharedTokenActor},
+		"invalid token": {bearer("wrong"), http.StatusUnauthorized, ""},
+		"stale session": {func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: TokenCookie, Value: "expired"})
+		}, http.StatusOK, "anonymous"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.prepare != nil {
+			tc.prepare(req)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		assert.Equal(t, tc.code, rec.Code, name)
+		if tc.body != "" {
+			assert.Equal(t, tc.body, rec.Body.String(), name)
+		}
+	}
+}
+
+func TestTokenAuth_SharedToken(t *testing.T) {
+	auth := NewAuthenticator(nil, &config.Config{AdminToken: "secret"})
+
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, models.ScopeAdmin, nil))
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, models.ScopeAdmin, bearer("wrong")))
+	assert.Equal(t, http.StatusOK, serve(auth, models.ScopeAdmin, bearer("secret")))
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, models.ScopeAdminDelete, func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: TokenCookie, Value: "secret"})
+	}), "the cookie holds a session, never the token itself")
+}
+
+func TestTokenAuth_OpenMode(t *testing.T) {
+	db := setupTokenDB(t)
+	assert.Equal(t, http.StatusUnauthorized, serve(NewAuthenticator(db, &config.Config{}), models.ScopeAdmin, nil),
+		"closed without credentials unless AUTH_DISABLED is set")
+	assert.Equal(t, http.StatusOK, serve(NewAuthenticator(db, &config.Config{AuthDisabled: true}), models.ScopeAdmin, nil))
+
+	past := time.Now().Add(-time.Hour)
+	token, _, err := models.NewAPIToken("bot", []string{models.ScopeAdmin}, &past, "test")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewAPITokenRepository(db).Create(context.Background(), token))
+	assert.Equal(t, http.StatusUnauthorized, serve(NewAuthenticator(db, &config.Config{}), models.ScopeAdmin, nil),
+		"expired tokens never reopen the admin API")
+}
+
+func TestTokenAuth_APITokens(t *testing.T) {
+	db := setupTokenDB(t)
+	repo := repository.NewAPITokenRepository(db)
+	ctx := context.Background()
+	auth := NewAuthenticator(db, &config.Config{})
+
+	collector, collectorRaw, err := models.NewAPIToken("collector", []string{models.ScopeAdminCollect}, nil, "test")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, collector))
+
+	past := time.Now().Add(-time.Hour)
+	expired, expiredRaw, err := models.NewAPIToken("expired", []string{models.ScopeAdmin}, &past, "test")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, expired))
+
+	assert.Equal(t, http.StatusOK, serve(auth, models.ScopeAdminCollect, bearer(collectorRaw)))
+	assert.Equal(t, http.StatusForbidden, serve(auth, models.ScopeAdminDelete, bearer(collectorRaw)))
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, models.ScopeAdminCollect, bearer(expiredRaw)))
+
+	used, err := repo.GetByHash(ctx, collector.TokenHash)
+	require.NoError(t, err)
+	assert.NotNil(t, used.LastUsedAt)
+
+	found, err := repo.Revoke(ctx, collector.ID, time.Now())
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, http.StatusUnauthorized, serve(auth, models.ScopeAdminCollect, bearer(collectorRaw)))
+}
+
+func TestIdentity_SharedToken(t *testing.T) {
+	tokenID := uuid.New()
+	assert.True(t, (&Identity{Name: sharedTokenActor}).SharedToken())
+	assert.False(t, (&Identity{Name: "bot", TokenID: &tokenID}).SharedToken())
+	assert.False(t, (&Identity{Name: "alice", Subject: "https://idp.example.org|u1"}).SharedToken())
+}
@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/oidc"
+	"wikikeeper-backend/internal/repository"
+)
+
+// OIDCStateCookie binds a pending OIDC login to the browser that started it
+const OIDCStateCookie = "oidcstate"
+
+const (
+	oidcStateTTL   = 10 * time.Minute
+	oidcSessionTTL = 12 * time.Hour // Group changes at the provider apply at the next login
+)
+
+var (
+	// ErrInvalidOIDCState is returned for unknown, used, expired and foreign login states
+	ErrInvalidOIDCState = errors.New("invalid, used or expired login state")
+	// ErrNoRole is returned when none of the user's groups maps to a role
+	ErrNoRole = errors.New("none of your groups grants a WikiKeeper role")
+)
+
+// roleScopes lists the scopes each OIDC role grants
+var roleScopes = map[string][]string{
+	oidc.RoleViewer:  {models.ScopeAdminRead},
+	oidc.RoleCurator: {models.ScopeWikisWrite, models.ScopeAdminCollect, models.ScopeAdminDelete},
+	oidc.RoleAdmin:   {models.ScopeAdmin},
+}
+
+// OIDCConfig returns the OIDC settings of cfg
+func OIDCConfig(cfg *config.Config) oidc.Config {
+	return oidc.Config{
+		Issuer:       cfg.OIDCIssuer,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       cfg.OIDCScopes,
+		GroupsClaim:  cfg.OIDCGroupsClaim,
+		Roles: oidc.RoleMapping{
+			oidc.RoleViewer:  cfg.OIDCViewerGroups,
+			oidc.RoleCurator: cfg.OIDCCuratorGroups,
+			oidc.RoleAdmin:   cfg.OIDCAdminGroups,
+		},
+	}
+}
+
+// ScopesForRoles returns the scopes granted by roles
+func ScopesForRoles(roles []string) []string {
+	var scopes []string
+	seen := map[string]bool{}
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
+
+// RolesForScopes returns the roles whose scopes are all granted by scopes, for
+// callers authenticated by token rather than OIDC
+func RolesForScopes(scopes []string) []string {
+	var roles []string
+	for _, role := range oidc.Roles {
+		granted := true
+		for _, scope := range roleScopes[role] {
+			granted = granted && models.HasScope(scopes, scope)
+		}
+		if granted {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// BeginOIDCLogin stores a pending login and returns the provider URL to send the
+// browser to, with the state the browser must present again on its return
+func (a *Authenticator) BeginOIDCLogin(ctx context.Context, client *oidc.Client, redirectTo string) (string, string, error) {
+	if a.db == nil {
+		return "", "", errors.New("sessions need a database")
+	}
+	state, err := models.RandomSecret()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := models.RandomSecret()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", err
+	}
+
+	authURL, err := client.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", "", err
+	}
+	err = repository.NewAuthSessionRepository(a.db).CreateOIDCState(ctx, &models.OIDCState{
+		StateHash:    models.HashAPIToken(state),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectTo:   redirectTo,
+		ExpiresAt:    time.Now().UTC().Add(oidcStateTTL),
+	})
+	return authURL, state, err
+}
+
+// FinishOIDCLogin redeems the code the provider returned for a pending login and
+// opens a session with the roles of the user's groups. browserState is the state
+// of the browser's OIDCStateCookie. It returns the secret to store in the session
+// cookie and where the login asked to return to.
+func (a *Authenticator) FinishOIDCLogin(ctx context.Context, client *oidc.Client, state, browserState, code string) (string, *models.AuthSession, string, error) {
+	if a.db == nil || state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return "", nil, "", ErrInvalidOIDCState
+	}
+	sessionRepo := repository.NewAuthSessionRepository(a.db)
+	now := time.Now().UTC()
+	pending, err := sessionRepo.ConsumeOIDCState(ctx, models.HashAPIToken(state), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, "", ErrInvalidOIDCState
+		}
+		return "", nil, "", err
+	}
+
+	user, err := client.Exchange(ctx, code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if len(user.Roles) == 0 {
+		return "", nil, "", ErrNoRole
+	}
+
+	secret, err := models.RandomSecret()
+	if err != nil {
+		return "", nil, "", err
+	}
+	csrf, err := models.RandomSecret()
+	if err != nil {
+		return "", nil, "", err
+	}
+	session := &models.AuthSession{
+		SecretHash:     models.HashAPIToken(secret),
+		CSRFToken:      csrf,
+		CredentialHash: models.HashAPIToken(user.Subject),
+		Subject:        &user.Subject,
+		Roles:          models.StringList(user.Roles),
+		Scopes:         models.StringList(ScopesForRoles(user.Roles)),
+		Name:           user.Name,
+		ExpiresAt:      now.Add(oidcSessionTTL),
+	}
+	if user.Email != "" {
+		session.Email = &user.Email
+	}
+	if err := sessionRepo.CreateSession(ctx, session); err != nil {
+		return "", nil, "", err
+	}
+	return secret, session, pending.RedirectTo, nil
+}
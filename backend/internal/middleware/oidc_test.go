@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/oidc"
+	"wikikeeper-backend/internal/oidc/oidctest"
+)
+
+// loginWithIdP starts an OIDC login and returns the code and state the provider redirects back with
+func loginWithIdP(t *testing.T, auth *Authenticator, client *oidc.Client, redirectTo string) (code, state string) {
+	authURL, state, err := auth.BeginOIDCLogin(context.Background(), client, redirectTo)
+	require.NoError(t, err)
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	require.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code"), state
+}
+
+func TestOIDCLogin(t *testing.T) {
+	idp := oidctest.NewProvider("wikikeeper")
+	defer idp.Close()
+
+	cfg := &config.Config{
+		OIDCIssuer:        idp.URL,
+		OIDCClientID:      "wikikeeper",
+		OIDCRedirectURL:   "http://localhost:8000/api/auth/oidc/callback",
+		OIDCViewerGroups:  []string{"volunteers"},
+		OIDCCuratorGroups: []string{"archivists"},
+		OIDCAdminGroups:   []string{"sysops"},
+	}
+	db := setupTokenDB(t)
+	auth := NewAuthenticator(db, cfg)
+	client := oidc.NewClient(OIDCConfig(cfg), nil)
+	ctx := context.Background()
+
+	idp.SetUser(oidctest.User{Subject: "u1", Name: "alice", Email: "alice@example.org", Groups: []string{"volunteers", "archivists"}})
+	code, state := loginWithIdP(t, auth, client, "http://localhost:5173/admin")
+
+	_, _, _, err := auth.FinishOIDCLogin(ctx, client, state, "another-browser", code)
+	assert.ErrorIs(t, err, ErrInvalidOIDCState, "the login is bound to the browser that started it")
+
+	secret, session, redirectTo, err := auth.FinishOIDCLogin(ctx, client, state, state, code)
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:5173/admin", redirectTo)
+	assert.Equal(t, "alice", session.Name)
+
+	_, _, _, err = auth.FinishOIDCLogin(ctx, client, state, state, code)
+	assert.ErrorIs(t, err, ErrInvalidOIDCState, "states are single-use")
+
+	identity, err := auth.VerifySession(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", identity.Name)
+	assert.Equal(t, "alice@example.org", identity.Email)
+	assert.Equal(t, idp.URL+"#u1", identity.Subject)
+	assert.Equal(t, []string{oidc.RoleViewer, oidc.RoleCurator}, identity.Roles)
+	assert.True(t, identity.HasScope(models.ScopeAdminRead))
+	assert.True(t, identity.HasScope(models.ScopeAdminDelete))
+	assert.False(t, identity.HasScope(models.ScopeAdmin))
+	assert.NotEmpty(t, identity.CSRFToken)
+
+	withSession := func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: TokenCookie, Value: secret})
+	}
+	assert.Equal(t, http.StatusOK, serve(auth, models.ScopeWikisWrite, withSession))
+	assert.Equal(t, http.StatusForbidden, serve(auth, models.ScopeAdmin, withSession))
+
+	// Removing OIDC from the configuration ends its sessions
+	_, err = NewAuthenticator(db, &config.Config{AdminToken: "secret"}).VerifySession(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// Users without a mapped group get no session
+	idp.SetUser(oidctest.User{Subject: "u2", Name: "mallory", Groups: []string{"guests"}})
+	code, state = loginWithIdP(t, auth, client, "")
+	_, _, _, err = auth.FinishOIDCLogin(ctx, client, state, state, code)
+	assert.ErrorIs(t, err, ErrNoRole)
+}
+
+func TestRolesAndScopes(t *testing.T) {
+	assert.Equal(t, []string{models.ScopeAdminRead, models.ScopeWikisWrite, models.ScopeAdminCollect, models.ScopeAdminDelete},
+		ScopesForRoles([]string{oidc.RoleViewer, oidc.RoleCurator}))
+	assert.Equal(t, []string{oidc.RoleViewer, oidc.RoleCurator, oidc.RoleAdmin}, RolesForScopes([]string{models.ScopeAdmin}))
+	assert.Equal(t, []string{oidc.RoleViewer}, RolesForScopes([]string{models.ScopeAdminCollect}))
+	assert.Nil(t, RolesForScopes([]string{models.ScopeExport}))
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/ratelimit"
+)
+
+// Rate limit response headers, following the IETF RateLimit header fields draft
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimitHeaders lists the headers browsers must be allowed to read through CORS
+var RateLimitHeaders = []string{
+	HeaderRateLimitLimit, HeaderRateLimitRemaining, HeaderRateLimitReset, HeaderRateLimitPolicy, echo.HeaderRetryAfter,
+}
+
+// RateLimiter limits requests per route group. Clients sending a valid token are
+// limited per token, everyone else per IP address.
+type RateLimiter struct {
+	store  ratelimit.Store
+	auth   *Authenticator
+	limits map[string]ratelimit.Limit
+}
+
+// NewRateLimiter creates a rate limiter with the limits of each route group
+func NewRateLimiter(store ratelimit.Store, auth *Authenticator, limits map[string]ratelimit.Limit) *RateLimiter {
+	return &RateLimiter{store: store, auth: auth, limits: limits}
+}
+
+// Group creates middleware applying the limit of a route group. Groups without
+// an enabled limit, and a nil RateLimiter, let every request through.
+func (rl *RateLimiter) Group(group string) echo.MiddlewareFunc {
+	var limit ratelimit.Limit
+	if rl != nil {
+		limit = rl.limits[group]
+	}
+	if !limit.Enabled() {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	policy := fmt.Sprintf("%d;w=%d;burst=%d", limit.Requests, int(limit.Period.Seconds()), limit.Burst)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := group + ":" + rl.clientKey(c)
+			result, err := rl.store.Take(c.Request().Context(), key, limit, time.Now())
+			if err != nil {
+				// Fail open: an unavailable store must not take the API down
+				applogger.Log.Warn("[RateLimit] Store failed, allowing request", "group", group, "error", err)
+				return next(c)
+			}
+
+			h := c.Response().Header()
+			h.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+			h.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			h.Set(HeaderRateLimitReset, ceilSeconds(result.Reset))
+			h.Set(HeaderRateLimitPolicy, policy)
+			if !result.Allowed {
+				retryAfter := ceilSeconds(result.RetryAfter)
+				h.Set(echo.HeaderRetryAfter, retryAfter)
+				return c.JSON(http.StatusTooManyRequests, map[string]string{
+					"detail":      "Rate limit exceeded. Retry after " + retryAfter + " seconds.",
+					"retry_after": retryAfter,
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// clientKey identifies the client of a request by its token, or by IP address
+// when it sends no valid token
+func (rl *RateLimiter) clientKey(c echo.Context) string {
+	identity := CurrentIdentity(c)
+	if identity == nil && rl.auth != nil && HasCredentials(c) {
+		identity, _ = rl.auth.Authenticate(c)
+	}
+	if identity != nil {
+		if identity.TokenID != nil {
+			return "token:" + identity.TokenID.String()
+		}
+		return "token:" + identity.Name
+	}
+	return "ip:" + c.RealIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// IPExtractor returns how client IPs are read from requests. X-Forwarded-For is
+// only honored when sent by one of the trusted proxies, given as IPs or CIDR
+// ranges; "none" ignores the header and uses the peer address.
+func IPExtractor(trustedProxies []string) (echo.IPExtractor, error) {
+	if len(trustedProxies) == 1 && strings.EqualFold(strings.TrimSpace(trustedProxies[0]), "none") {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		options = append(options, echo.TrustIPRange(ipRange))
+	}
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
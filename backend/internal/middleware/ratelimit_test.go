@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/ratelimit"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limits := map[string]ratelimit.Limit{"submit": {Requests: 2, Period: time.Hour, Burst: 2}}
+	limiter := NewRateLimiter(ratelimit.NewMemoryStore(), NewAuthenticator(nil, &config.Config{AdminToken: "secret"}), limits)
+
+	e := echo.New()
+	e.IPExtractor = echo.ExtractIPDirect()
+	e.POST("/submit", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, limiter.Group("submit"))
+	e.POST("/free", func(c echo.Context) error { return c.NoContent(http.StatusOK) }, limiter.Group("unlimited"))
+
+	do := func(path, remoteAddr, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.RemoteAddr = remoteAddr
+		if token != "" {
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := do("/submit", "192.0.2.1:1000", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get(HeaderRateLimitLimit))
+	assert.Equal(t, "1", rec.Header().Get(HeaderRateLimitRemaining))
+	assert.Equal(t, "1800", rec.Header().Get(HeaderRateLimitReset))
+	assert.Equal(t, "2;w=3600;burst=2", rec.Header().Get(HeaderRateLimitPolicy))
+
+	assert.Equal(t, http.StatusOK, do("/submit", "192.0.2.1:1001", "").Code)
+	rec = do("/submit", "192.0.2.1:1002", "")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "1800", rec.Header().Get(echo.HeaderRetryAfter))
+
+	// Other clients, valid tokens and unlimited groups are unaffected; invalid tokens count against the IP
+	assert.Equal(t, http.StatusOK, do("/submit", "192.0.2.2:1000", "").Code)
+	assert.Equal(t, http.StatusOK, do("/submit", "192.0.2.1:1003", "secret").Code)
+	assert.Equal(t, http.StatusTooManyRequests, do("/submit", "192.0.2.1:1004", "wrong").Code)
+	assert.Equal(t, http.StatusOK, do("/free", "192.0.2.1:1005", "").Code)
+
+	var disabled *RateLimiter
+	assert.NotNil(t, disabled.Group("submit"), "a nil limiter lets requests through")
+}
+
+func TestIPExtractor(t *testing.T) {
+	extract, err := IPExtractor([]string{"10.0.0.0/8", "203.0.113.7"})
+	require.NoError(t, err)
+
+	request := func(remoteAddr, xff string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(echo.HeaderXForwardedFor, xff)
+		return req
+	}
+
+	assert.Equal(t, "198.51.100.1", extract(request("10.1.2.3:80", "198.51.100.1")))
+	assert.Equal(t, "198.51.100.1", extract(request("203.0.113.7:80", "198.51.100.1")))
+	assert.Equal(t, "198.51.100.1", extract(request("10.1.2.3:80", "1.1.1.1, 198.51.100.1, 10.9.9.9")),
+		"the rightmost untrusted address wins over spoofed ones")
+	assert.Equal(t, "198.51.100.9", extract(request("198.51.100.9:80", "1.1.1.1")),
+		"untrusted peers cannot spoof their address")
+	assert.Equal(t, "192.168.1.1", extract(request("192.168.1.1:80", "1.1.1.1")),
+		"private networks are only trusted when listed")
+
+	direct, err := IPExtractor([]string{"none"})
+	require.NoError(t, err)
+	assert.Equal(t, "10.1.2.3", direct(request("10.1.2.3:80", "198.51.100.1")))
+
+	_, err = IPExtractor([]string{"not-an-ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// HeaderCSRFToken carries the CSRF token of the session on state-changing requests
+const HeaderCSRFToken = "X-CSRF-Token"
+
+const (
+	loginCodeTTL = time.Minute
+	sessionTTL   = 30 * 24 * time.Hour
+)
+
+// ErrInvalidLoginCode is returned for unknown, used and expired login codes
+var ErrInvalidLoginCode = errors.New("invalid, used or expired login code")
+
+// CreateLoginCode checks a token and returns a single-use code that opens a session for it
+func (a *Authenticator) CreateLoginCode(ctx context.Context, raw string) (string, time.Time, error) {
+	identity, err := a.Verify(ctx, raw)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if a.db == nil {
+		return "", time.Time{}, errors.New("sessions need a database")
+	}
+
+	code, err := models.RandomSecret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expiresAt := time.Now().UTC().Add(loginCodeTTL)
+	err = repository.NewAuthSessionRepository(a.db).CreateLoginCode(ctx, &models.LoginCode{
+		CodeHash:       models.HashAPIToken(code),
+		TokenID:        identity.TokenID,
+		CredentialHash: models.HashAPIToken(raw),
+		Name:           identity.Name,
+		ExpiresAt:      expiresAt,
+	})
+	return code, expiresAt, err
+}
+
+// ExchangeLoginCode consumes a login code and opens a session. It returns the
+// secret to store in the session cookie.
+func (a *Authenticator) ExchangeLoginCode(ctx context.Context, code string) (string, *models.AuthSession, error) {
+	if a.db == nil {
+		return "", nil, ErrInvalidLoginCode
+	}
+	sessionRepo := repository.NewAuthSessionRepository(a.db)
+	now := time.Now().UTC()
+	login, err := sessionRepo.ConsumeLoginCode(ctx, models.HashAPIToken(code), now)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, ErrInvalidLoginCode
+		}
+		return "", nil, err
+	}
+
+	secret, err := models.RandomSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	csrf, err := models.RandomSecret()
+	if err != nil {
+		return "", nil, err
+	}
+	session := &models.AuthSession{
+		SecretHash:     models.HashAPIToken(secret),
+		CSRFToken:      csrf,
+		TokenID:        login.TokenID,
+		CredentialHash: login.CredentialHash,
+		Name:           login.Name,
+		ExpiresAt:      now.Add(sessionTTL),
+	}
+	// Sessions never outlive their token
+	if login.TokenID != nil {
+		token, err := repository.NewAPITokenRepository(a.db).GetByID(ctx, *login.TokenID)
+		if err != nil {
+			return "", nil, err
+		}
+		if token.ExpiresAt != nil && token.ExpiresAt.Before(session.ExpiresAt) {
+			session.ExpiresAt = token.ExpiresAt.UTC()
+		}
+	}
+	if err := sessionRepo.CreateSession(ctx, session); err != nil {
+		return "", nil, err
+	}
+	return secret, session, nil
+}
+
+// VerifySession checks a session secret. Sessions end with the token they were
+// opened with: when it is revoked or expires, or when ADMIN_TOKEN changes. OIDC
+// sessions end when they expire or when OIDC is no longer configured.
+func (a *Authenticator) VerifySession(ctx context.Context, secret string) (*Identity, error) {
+	if a.db == nil {
+		return nil, ErrInvalidToken
+	}
+	hash := models.HashAPIToken(secret)
+	session, err := repository.NewAuthSessionRepository(a.db).GetSessionByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if subtle.ConstantTimeCompare([]byte(session.SecretHash), []byte(hash)) != 1 || !now.Before(session.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+
+	identity := &Identity{Name: session.Name, SessionID: &session.ID, CSRFToken: session.CSRFToken}
+	if session.Subject != nil {
+		// OIDC sessions keep the roles mapped at login until they expire, or until
+		// OIDC is removed from the configuration
+		if !OIDCConfig(a.cfg).Enabled() {
+			return nil, ErrInvalidToken
+		}
+		identity.Subject = *session.Subject
+		identity.Roles = session.Roles
+		identity.Scopes = session.Scopes
+		if session.Email != nil {
+			identity.Email = *session.Email
+		}
+		return identity, nil
+	}
+	if session.TokenID == nil {
+		if a.cfg.AdminToken == "" ||
+			subtle.ConstantTimeCompare([]byte(models.HashAPIToken(a.cfg.AdminToken)), []byte(session.CredentialHash)) != 1 {
+			return nil, ErrInvalidToken
+		}
+		identity.Scopes = []string{models.ScopeAdmin}
+		return identity, nil
+	}
+
+	tokenRepo := repository.NewAPITokenRepository(a.db)
+	token, err := tokenRepo.GetByID(ctx, *session.TokenID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !token.Usable(now) {
+		return nil, ErrInvalidToken
+	}
+	if err := tokenRepo.Touch(ctx, token.ID, now); err != nil {
+		applogger.Log.Warn("[Auth] Failed to record token use", "token_id", token.ID, "error", err)
+	}
+	identity.TokenID = &token.ID
+	identity.Scopes = token.Scopes
+	return identity, nil
+}
+
+// EndSession deletes the session of a caller authenticated by cookie
+func (a *Authenticator) EndSession(ctx context.Context, identity *Identity) error {
+	if a.db == nil || identity == nil || identity.SessionID == nil {
+		return nil
+	}
+	return repository.NewAuthSessionRepository(a.db).DeleteSession(ctx, *identity.SessionID)
+}
+
+// CSRFProtect creates middleware requiring the session's CSRF token in the
+// X-CSRF-Token header of state-changing requests authenticated by the session
+// cookie. Requests with a bearer token, and without a valid session, pass.
+func CSRFProtect(auth *Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+			if BearerToken(c) != "" || SessionSecret(c) == "" {
+				return next(c)
+			}
+
+			identity, err := auth.Authenticate(c)
+			if err != nil || identity == nil || identity.SessionID == nil {
+				return next(c)
+			}
+			header := c.Request().Header.Get(HeaderCSRFToken)
+			if header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(identity.CSRFToken)) != 1 {
+				return c.JSON(http.StatusForbidden, map[string]string{
+					"detail": "Missing or invalid CSRF token. Send the csrf_token of /api/auth/check in the X-CSRF-Token header.",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// AllowedRedirect reports whether target is an absolute http(s) URL on one of
+// the allowed origins. A wildcard origin allows no redirects.
+func AllowedRedirect(target string, allowOrigins []string) bool {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" || u.User != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	origin := strings.ToLower(u.Scheme + "://" + u.Host)
+	for _, allowed := range allowOrigins {
+		if allowed != "*" && strings.ToLower(strings.TrimRight(allowed, "/")) == origin {
+			return true
+		}
+	}
+	return false
+}
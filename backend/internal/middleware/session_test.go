@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+func TestSessions(t *testing.T) {
+	db := setupTokenDB(t)
+	ctx := context.Background()
+	cfg := &config.Config{AdminToken: "secret"}
+	auth := NewAuthenticator(db, cfg)
+
+	_, _, err := auth.CreateLoginCode(ctx, "wrong")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	code, expiresAt, err := auth.CreateLoginCode(ctx, "secret")
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(loginCodeTTL), expiresAt, 5*time.Second)
+
+	secret, session, err := auth.ExchangeLoginCode(ctx, code)
+	require.NoError(t, err)
+	assert.NotEqual(t, code, secret)
+	_, _, err = auth.ExchangeLoginCode(ctx, code)
+	assert.ErrorIs(t, err, ErrInvalidLoginCode, "codes are single-use")
+
+	identity, err := auth.VerifySession(ctx, secret)
+	require.NoError(t, err)
+	assert.True(t, identity.HasScope(models.ScopeAdmin))
+	assert.Equal(t, session.ID, *identity.SessionID)
+	assert.Equal(t, session.CSRFToken, identity.CSRFToken)
+
+	// Sessions end when ADMIN_TOKEN changes
+	cfg.AdminToken = "rotated"
+	_, err = auth.VerifySession(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// and when their API token is revoked
+	token, raw, err := models.NewAPIToken("curator", []string{models.ScopeWikisWrite}, nil, "test")
+	require.NoError(t, err)
+	require.NoError(t, repository.NewAPITokenRepository(db).Create(ctx, token))
+	code, _, err = auth.CreateLoginCode(ctx, raw)
+	require.NoError(t, err)
+	secret, _, err = auth.ExchangeLoginCode(ctx, code)
+	require.NoError(t, err)
+	identity, err = auth.VerifySession(ctx, secret)
+	require.NoError(t, err)
+	assert.Equal(t, []string{models.ScopeWikisWrite}, identity.Scopes)
+
+	_, err = repository.NewAPITokenRepository(db).Revoke(ctx, token.ID, time.Now())
+	require.NoError(t, err)
+	_, err = auth.VerifySession(ctx, secret)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestCSRFProtect(t *testing.T) {
+	db := setupTokenDB(t)
+	ctx := context.Background()
+	auth := NewAuthenticator(db, &config.Config{AdminToken: "secret"})
+
+	code, _, err := auth.CreateLoginCode(ctx, "secret")
+	require.NoError(t, err)
+	secret, session, err := auth.ExchangeLoginCode(ctx, code)
+	require.NoError(t, err)
+
+	e := echo.New()
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.GET("/", ok, CSRFProtect(auth))
+	e.POST("/", ok, CSRFProtect(auth))
+
+	do := func(method string, prepare func(*http.Request)) int {
+		req := httptest.NewRequest(method, "/", nil)
+		prepare(req)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	withCookie := func(csrf string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.AddCookie(&http.Cookie{Name: TokenCookie, Value: secret})
+			if csrf != "" {
+				req.Header.Set(HeaderCSRFToken, csrf)
+			}
+		}
+	}
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, withCookie("")), "safe methods need no CSRF token")
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, withCookie("")))
+	assert.Equal(t, http.StatusForbidden, do(http.MethodPost, withCookie("forged")))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, withCookie(session.CSRFToken)))
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, bearer("secret")), "bearer tokens cannot be forged cross-site")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, func(*http.Request) {}), "anonymous requests pass")
+}
+
+func TestAllowedRedirect(t *testing.T) {
+	origins := []string{"https://wikikeeper.saveweb.org", "http://localhost:5173/"}
+
+	tests := []struct {
+		target string
+		want   bool
+	}{
+		{"https://wikikeeper.saveweb.org/wikis?page=2", true},
+		{"https://WikiKeeper.saveweb.org", true},
+		{"http://localhost:5173/admin", true},
+		{"http://wikikeeper.saveweb.org/", false},
+		{"https://wikikeeper.saveweb.org.evil.example/", false},
+		{"https://wikikeeper.saveweb.org@evil.example/", false},
+		{"//evil.example/", false},
+		{"/relative", false},
+		{"javascript:alert(1)", false},
+		{`https://evil.example/"><script>`, false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, AllowedRedirect(tt.target, origins), tt.target)
+	}
+
+	assert.False(t, AllowedRedirect("https://evil.example/", []string{"*"}), "wildcard origins allow no redirects")
+}
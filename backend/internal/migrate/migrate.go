@@ -0,0 +1,318 @@
+// Package migrate applies the versioned SQL migrations of the migrations
+// directory and records the schema version in the schema_migrations table.
+// The table has the layout of golang-migrate, so databases migrated with its
+// CLI keep their version.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"gorm.io/gorm"
+)
+
+// Migration is one schema change and its inverse
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string // Empty when the migration cannot be reverted
+}
+
+var (
+	// ErrDirty is returned when a migration failed half-way outside a transaction
+	// and the schema must be repaired by hand, then marked with Force
+	ErrDirty = errors.New("schema is dirty: a migration failed part-way; repair it and run 'wikikeeper migrate force VERSION'")
+	// ErrNoDown is returned when reverting a migration without a down file
+	ErrNoDown = errors.New("migration has no down file")
+)
+
+// VersionError is returned by Check when the schema version is not the one the binary expects
+type VersionError struct {
+	Current int64
+	Latest  int64
+}
+
+func (e *VersionError) Error() string {
+	if e.Current < e.Latest {
+		return fmt.Sprintf("schema version %d is behind %d: run 'wikikeeper migrate up'", e.Current, e.Latest)
+	}
+	return fmt.Sprintf("schema version %d is newer than %d known to this binary: upgrade it or run 'wikikeeper migrate down' with the newer binary", e.Current, e.Latest)
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Load reads the migrations of fsys in version order
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		sql, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[version]
+		if m == nil {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(sql)
+		} else {
+			m.Down = string(sql)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator applies migrations to a database
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New creates a migrator for the migrations of fsys
+func New(db *gorm.DB, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Status describes the schema of a database
+type Status struct {
+	Current int64       // Applied version, 0 for an empty database
+	Dirty   bool        // A migration to Current failed part-way
+	Latest  int64       // Newest version known to this binary
+	Pending []Migration // Migrations newer than Current
+}
+
+// Latest returns the newest version known to the migrator
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Migrations returns the known migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// Status reads the version of the database
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	current, dirty, err := m.version(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	status := &Status{Current: current, Dirty: dirty, Latest: m.Latest()}
+	for _, migration := range m.migrations {
+		if migration.Version > current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Check returns ErrDirty or a *VersionError unless the database has the latest version
+func (m *Migrator) Check(ctx context.Context) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if status.Dirty {
+		return ErrDirty
+	}
+	if status.Current != status.Latest {
+		return &VersionError{Current: status.Current, Latest: status.Latest}
+	}
+	return nil
+}
+
+// Up applies up to steps pending migrations, all of them when steps is 0, and
+// returns the migrations it applied
+func (m *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		current, dirty, err := m.version(db)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+			if steps > 0 && len(applied) == steps {
+				break
+			}
+			if err := m.apply(db, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the newest steps applied migrations and returns them, newest first
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(db *gorm.DB) error {
+		current, dirty, err := m.version(db)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return ErrDirty
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrNoDown)
+			}
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(db, migration.Down, previous); err != nil {
+				return fmt.Errorf("reverting migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force records version as applied and clean without running any migration,
+// for databases whose schema was created or repaired by hand
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+	return m.locked(ctx, func(db *gorm.DB) error {
+		if err := db.Exec(createTable).Error; err != nil {
+			return err
+		}
+		return db.Transaction(func(tx *gorm.DB) error {
+			return setVersion(tx, version, false)
+		})
+	})
+}
+
+func (m *Migrator) known(version int64) bool {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// apply runs sql and records version in one transaction, so a failed migration
+// leaves the schema as it was
+func (m *Migrator) apply(db *gorm.DB, sql string, version int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(sql).Error; err != nil {
+			return err
+		}
+		return setVersion(tx, version, false)
+	})
+}
+
+// createTable creates the schema_migrations table of golang-migrate
+const createTable = `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+
+// version reads the recorded version; a database without one is at version 0
+func (m *Migrator) version(db *gorm.DB) (int64, bool, error) {
+	if err := db.Exec(createTable).Error; err != nil {
+		return 0, false, err
+	}
+	var rows []struct {
+		Version int64
+		Dirty   bool
+	}
+	if err := db.Raw(`SELECT version, dirty FROM schema_migrations`).Scan(&rows).Error; err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// setVersion replaces the recorded version; version 0 leaves the table empty
+func setVersion(tx *gorm.DB, version int64, dirty bool) error {
+	if err := tx.Exec(`DELETE FROM schema_migrations`).Error; err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	return tx.Exec(`INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)`, version, dirty).Error
+}
+
+// lockID is the Postgres advisory lock held while migrating, so that servers
+// starting together do not run the same migration twice
+const lockID = 7_304_115_112
+
+// locked runs fn on one connection holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(db *gorm.DB) error) error {
+	if m.db.Dialector.Name() != "postgres" {
+		return fn(m.db.WithContext(ctx))
+	}
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	db := m.db.Session(&gorm.Session{NewDB: true, Context: ctx})
+	db.Statement.ConnPool = conn
+	return fn(db)
+}
@@ -0,0 +1,139 @@
+package migrate
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/migrations"
+)
+
+func testMigrations() fstest.MapFS {
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+	return fstest.MapFS{
+		"001_init.up.sql":            file(`CREATE TABLE wikis (id TEXT PRIMARY KEY);`),
+		"001_init.down.sql":          file(`DROP TABLE wikis;`),
+		"000002_add_stats.up.sql":    file(`CREATE TABLE stats (id INTEGER PRIMARY KEY); CREATE INDEX idx_stats ON stats (id);`),
+		"000002_add_stats.down.sql":  file(`DROP TABLE stats;`),
+		"000003_add_broken.up.sql":   file(`CREATE TABLE broken (id INTEGER); INSERT INTO missing VALUES (1);`),
+		"000003_add_broken.down.sql": file(`DROP TABLE broken;`),
+		"README.md":                  file(`not a migration`),
+	}
+}
+
+func setupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1) // Every connection of :memory: is its own database
+	return db
+}
+
+func hasTable(db *gorm.DB, name string) bool {
+	return db.Migrator().HasTable(name)
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations())
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+	assert.Equal(t, "add_stats", migrations[1].Name)
+
+	fsys := testMigrations()
+	delete(fsys, "000002_add_stats.up.sql")
+	_, err = Load(fsys)
+	assert.ErrorContains(t, err, "no up file")
+
+	fsys = testMigrations()
+	fsys["2_other.up.sql"] = &fstest.MapFile{Data: []byte(`SELECT 1`)}
+	_, err = Load(fsys)
+	assert.ErrorContains(t, err, "is used by")
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	all, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, all)
+	for i, m := range all {
+		assert.Equal(t, int64(i+1), m.Version, "versions are contiguous")
+		assert.NotEmpty(t, m.Down, "migration %d_%s can be reverted", m.Version, m.Name)
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	m, err := New(db, testMigrations())
+	require.NoError(t, err)
+
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.Current)
+	assert.Equal(t, int64(3), status.Latest)
+	assert.Len(t, status.Pending, 3)
+	var versionErr *VersionError
+	assert.ErrorAs(t, m.Check(ctx), &versionErr)
+
+	applied, err := m.Up(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, applied, 1)
+	assert.True(t, hasTable(db, "wikis"))
+
+	// The broken migration rolls back with its version, leaving the schema clean
+	applied, err = m.Up(ctx, 0)
+	assert.ErrorContains(t, err, "3_add_broken")
+	assert.Len(t, applied, 1)
+	assert.True(t, hasTable(db, "stats"))
+	assert.False(t, hasTable(db, "broken"))
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), status.Current)
+	assert.False(t, status.Dirty)
+	assert.Len(t, status.Pending, 1)
+
+	reverted, err := m.Down(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, int64(2), reverted[0].Version)
+	assert.False(t, hasTable(db, "stats"))
+
+	reverted, err = m.Down(ctx, 5)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.False(t, hasTable(db, "wikis"))
+	status, err = m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.Current)
+}
+
+func TestMigrator_Force(t *testing.T) {
+	db := setupDB(t)
+	ctx := context.Background()
+	m, err := New(db, testMigrations())
+	require.NoError(t, err)
+
+	assert.Error(t, m.Force(ctx, 9), "unknown versions are refused")
+	require.NoError(t, m.Force(ctx, 3))
+	assert.NoError(t, m.Check(ctx))
+	assert.False(t, hasTable(db, "wikis"), "forcing runs no migration")
+
+	require.NoError(t, db.Exec(`UPDATE schema_migrations SET dirty = true`).Error)
+	assert.ErrorIs(t, m.Check(ctx), ErrDirty)
+	_, err = m.Up(ctx, 0)
+	assert.ErrorIs(t, err, ErrDirty)
+	require.NoError(t, m.Force(ctx, 3))
+	assert.NoError(t, m.Check(ctx))
+
+	require.NoError(t, m.Force(ctx, 0))
+	status, err := m.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), status.Current)
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Scopes of API tokens
+const (
+	ScopeAdminRead    = "admin:read"    // View the trash, merges, check policies, notes and collection stats
+	ScopeWikisWrite   = "wikis:write"   // Curate wikis: tags, notes and check policies
+	ScopeAdminCollect = "admin:collect" // Trigger collections and archive checks, bypassing the per-wiki rate limit
+	ScopeAdminDelete  = "admin:delete"  // Trash, restore and merge wikis
+	ScopeExport       = "export"        // Read /api/export when EXPORT_REQUIRE_TOKEN is set
+	ScopeAdmin        = "admin"         // Everything, including webhooks and API tokens
+)
+
+// APIScopes lists every scope
+var APIScopes = []string{ScopeAdminRead, ScopeWikisWrite, ScopeAdminCollect, ScopeAdminDelete, ScopeExport, ScopeAdmin}
+
+// apiTokenPrefix starts every API token so leaked tokens are easy to recognize
+const apiTokenPrefix = "wkp_"
+
+// APIToken is a named credential for a person or bot. Only the SHA-256 hash of
+// the token is stored; the token itself is shown once when it is created.
+type APIToken struct {
+	UUIDKey
+	Name       string     `gorm:"type:varchar(255);not null" json:"name"`
+	TokenHash  string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"` // First characters of the token, to tell tokens apart
+	Scopes     StringList `gorm:"type:text;not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  string     `gorm:"type:varchar(255);not null" json:"created_by"`
+	CreatedAt  time.Time  `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// NewAPIToken generates a token and returns its record together with the token itself
+func NewAPIToken(name string, scopes []string, expiresAt *time.Time, createdBy string) (*APIToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("token name is required")
+	}
+	if err := ValidateScopes(scopes); err != nil {
+		return nil, "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	raw := apiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	return &APIToken{
+		Name:      name,
+		TokenHash: HashAPIToken(raw),
+		Prefix:    raw[:len(apiTokenPrefix)+6],
+		Scopes:    StringList(scopes),
+		ExpiresAt: expiresAt,
+		CreatedBy: createdBy,
+	}, raw, nil
+}
+
+// HashAPIToken returns the hex SHA-256 hash a token is stored and looked up by
+func HashAPIToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes checks that scopes is a non-empty list of known scopes
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required (%s)", strings.Join(APIScopes, ", "))
+	}
+	for _, scope := range scopes {
+		known := false
+		for _, s := range APIScopes {
+			known = known || s == scope
+		}
+		if !known {
+			return fmt.Errorf("unknown scope %q (want %s)", scope, strings.Join(APIScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether scopes grant scope; the admin scope grants every scope,
+// and the scopes changing wikis grant admin:read
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+		if scope == ScopeAdminRead && (s == ScopeWikisWrite || s == ScopeAdminCollect || s == ScopeAdminDelete) {
+			return true
+		}
+	}
+	return false
+}
+
+// Usable reports whether the token is neither revoked nor expired at now
+func (t *APIToken) Usable(now time.Time) bool {
+	return t.RevokedAt == nil && (t.ExpiresAt == nil || now.Before(*t.ExpiresAt))
+}
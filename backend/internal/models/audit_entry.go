@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditOutcome tells whether an audited action succeeded
+type AuditOutcome string
+
+const (
+	AuditOutcomeSuccess AuditOutcome = "success"
+	AuditOutcomeFailure AuditOutcome = "failure"
+)
+
+// AuditEntry records an admin action or state-changing public request: who did
+// what to which target, with which parameters, and how it ended
+type AuditEntry struct {
+	UUIDKey
+	Actor     string       `gorm:"type:varchar(255);not null;index" json:"actor"` // Token name, or "anonymous"
+	TokenID   *uuid.UUID   `gorm:"type:uuid" json:"token_id,omitempty"`
+	IP        string       `gorm:"type:varchar(64);not null" json:"ip"`
+	Action    string       `gorm:"type:varchar(64);not null;index" json:"action"` // e.g. wiki.delete
+	TargetID  *string      `gorm:"type:varchar(255);index" json:"target_id,omitempty"`
+	Params    JSONMap      `gorm:"type:text" json:"params,omitempty"` // Path, query and body parameters with secrets redacted
+	Outcome   AuditOutcome `gorm:"type:varchar(16);not null" json:"outcome"`
+	Status    int          `gorm:"not null" json:"status"` // HTTP status of the response
+	Error     *string      `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time    `gorm:"not null;default:now();index" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (AuditEntry) TableName() string {
+	return "audit_log"
+}
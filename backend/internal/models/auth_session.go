@@ -0,0 +1,75 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthSession is a browser session opened with an API token, the shared ADMIN_TOKEN
+// or an OpenID Connect login. The cookie holds a random secret of which only the hash
+// is stored, and state-changing requests authenticated by the cookie must echo
+// CSRFToken in a header.
+type AuthSession struct {
+	UUIDKey
+	SecretHash     string     `gorm:"type:char(64);not null;uniqueIndex" json:"-"`
+	CSRFToken      string     `gorm:"type:varchar(64);not null" json:"-"`
+	TokenID        *uuid.UUID `gorm:"type:uuid;index" json:"token_id,omitempty"`        // API token the session was opened with; nil for ADMIN_TOKEN and OIDC
+	CredentialHash string     `gorm:"type:char(64);not null" json:"-"`                  // Hash of the token, so sessions end when ADMIN_TOKEN changes
+	Subject        *string    `gorm:"type:varchar(512);index" json:"subject,omitempty"` // OIDC issuer and subject; nil for token sessions
+	Email          *string    `gorm:"type:varchar(255)" json:"email,omitempty"`
+	Roles          StringList `gorm:"type:text" json:"roles,omitempty"`  // OIDC roles, mapped from groups at login
+	Scopes         StringList `gorm:"type:text" json:"scopes,omitempty"` // Scopes of the OIDC roles
+	Name           string     `gorm:"type:varchar(255);not null" json:"name"`
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time  `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (AuthSession) TableName() string {
+	return "auth_sessions"
+}
+
+// LoginCode is a short-lived, single-use code standing for a token in the login redirect,
+// so tokens never appear in URLs
+type LoginCode struct {
+	CodeHash       string     `gorm:"type:char(64);primary_key" json:"-"`
+	TokenID        *uuid.UUID `gorm:"type:uuid" json:"token_id,omitempty"`
+	CredentialHash string     `gorm:"type:char(64);not null" json:"-"`
+	Name           string     `gorm:"type:varchar(255);not null" json:"name"`
+	ExpiresAt      time.Time  `gorm:"not null;index" json:"expires_at"`
+	CreatedAt      time.Time  `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (LoginCode) TableName() string {
+	return "auth_login_codes"
+}
+
+// OIDCState is a pending OpenID Connect login: the state sent to the provider, with
+// the nonce and PKCE verifier needed to redeem the code it returns
+type OIDCState struct {
+	StateHash    string    `gorm:"type:char(64);primary_key" json:"-"`
+	Nonce        string    `gorm:"type:varchar(64);not null" json:"-"`
+	CodeVerifier string    `gorm:"type:varchar(128);not null" json:"-"`
+	RedirectTo   string    `gorm:"type:text;not null" json:"redirect_to"` // Frontend URL to return to, "" for none
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (OIDCState) TableName() string {
+	return "auth_oidc_states"
+}
+
+// RandomSecret returns 32 random bytes in unpadded base64url, for session secrets,
+// CSRF tokens, login codes and OIDC states
+func RandomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
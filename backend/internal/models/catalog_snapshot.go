@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// CatalogSnapshot holds catalog-wide totals for one day, captured by the scheduler.
+// Page and edit totals sum the latest stats of every wiki as of the end of the day.
+type CatalogSnapshot struct {
+	Date time.Time `gorm:"type:date;primaryKey" json:"date"`
+
+	// Wiki counts
+	TotalWikis    int64 `gorm:"not null;default:0" json:"total_wikis"`
+	ActiveWikis   int64 `gorm:"not null;default:0" json:"active_wikis"`
+	OKWikis       int64 `gorm:"column:ok_wikis;not null;default:0" json:"ok_wikis"`
+	ErrorWikis    int64 `gorm:"not null;default:0" json:"error_wikis"`
+	OfflineWikis  int64 `gorm:"not null;default:0" json:"offline_wikis"`
+	PendingWikis  int64 `gorm:"not null;default:0" json:"pending_wikis"`
+	ArchivedWikis int64 `gorm:"not null;default:0" json:"archived_wikis"`
+
+	// Sums of the latest stats per wiki
+	TotalPages int64 `gorm:"not null;default:0" json:"total_pages"`
+	TotalEdits int64 `gorm:"not null;default:0" json:"total_edits"`
+
+	// Time the totals were computed; today's row is refreshed on every run
+	CapturedAt time.Time `gorm:"not null;default:now()" json:"captured_at"`
+}
+
+// TableName specifies the table name for GORM
+func (CatalogSnapshot) TableName() string {
+	return "catalog_snapshots"
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// EventFilter selects wiki events by type and by the wiki they are about.
+// Webhooks and chat notification routes share it.
+type EventFilter struct {
+	Events StringList `gorm:"type:text" json:"events"`                 // Event types to pass; empty passes all
+	Tag    *string    `gorm:"type:varchar(64)" json:"tag,omitempty"`   // Only wikis carrying this tag
+	Farm   *string    `gorm:"type:varchar(255)" json:"farm,omitempty"` // Only wikis on this domain or its subdomains, e.g. miraheze.org
+}
+
+// Matches reports whether an event of eventType about the wiki at wikiURL carrying
+// tags passes the filter
+func (f *EventFilter) Matches(eventType WikiEventType, wikiURL string, tags []string) bool {
+	if len(f.Events) > 0 {
+		wanted := false
+		for _, e := range f.Events {
+			if WikiEventType(e) == eventType {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			return false
+		}
+	}
+
+	if f.Tag != nil {
+		tagged := false
+		for _, tag := range tags {
+			if tag == *f.Tag {
+				tagged = true
+				break
+			}
+		}
+		if !tagged {
+			return false
+		}
+	}
+
+	if f.Farm != nil && !InFarm(wikiURL, *f.Farm) {
+		return false
+	}
+	return true
+}
+
+// InFarm reports whether the host of rawURL is farm or one of its subdomains
+func InFarm(rawURL, farm string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	farm = strings.ToLower(strings.Trim(farm, "."))
+	return farm != "" && (host == farm || strings.HasSuffix(host, "."+farm))
+}
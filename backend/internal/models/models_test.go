@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -213,3 +214,353 @@ func TestWikiPointerFields(t *testing.T) {
 		t.Error("Expected Sitename to be nil")
 	}
 }
+
+func TestStringListValueScan(t *testing.T) {
+	list := StringList{"Cite", "ParserFunctions"}
+	value, err := list.Value()
+	if err != nil {
+		t.Fatalf("Value failed: %v", err)
+	}
+	if value != `["Cite","ParserFunctions"]` {
+		t.Errorf("Expected JSON array, got %v", value)
+	}
+
+	var scanned StringList
+	if err := scanned.Scan([]byte(`["Cite","ParserFunctions"]`)); err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(scanned) != 2 || scanned[1] != "ParserFunctions" {
+		t.Errorf("Expected scanned list to round-trip, got %v", scanned)
+	}
+
+	if err := scanned.Scan(nil); err != nil || scanned != nil {
+		t.Errorf("Expected NULL to scan into nil, got %v (%v)", scanned, err)
+	}
+
+	if value, _ := StringList(nil).Value(); value != nil {
+		t.Errorf("Expected nil list to be stored as NULL, got %v", value)
+	}
+}
+
+func TestStatusTransitionEvent(t *testing.T) {
+	tests := []struct {
+		from, to WikiStatus
+		want     WikiEventType
+		changed  bool
+	}{
+		{WikiStatusOK, WikiStatusOK, "", false},
+		{WikiStatusOK, WikiStatusError, WikiEventWentOffline, true},
+		{WikiStatusOK, WikiStatusOffline, WikiEventWentOffline, true},
+		{WikiStatusOffline, WikiStatusOK, WikiEventRecovered, true},
+		{WikiStatusError, WikiStatusOK, WikiEventRecovered, true},
+		{WikiStatusPending, WikiStatusOK, WikiEventStatusChanged, true},
+		{WikiStatusPending, WikiStatusError, WikiEventStatusChanged, true},
+		{WikiStatusError, WikiStatusOffline, WikiEventStatusChanged, true},
+	}
+
+	for _, tt := range tests {
+		got, changed := StatusTransitionEvent(tt.from, tt.to)
+		if got != tt.want || changed != tt.changed {
+			t.Errorf("%s -> %s: expected (%q, %v), got (%q, %v)", tt.from, tt.to, tt.want, tt.changed, got, changed)
+		}
+	}
+}
+
+func TestNormalizeTagName(t *testing.T) {
+	valid := map[string]string{
+		"gaming":        "gaming",
+		" Fandom-Fork ": "fandom-fork",
+		"at-risk":       "at-risk",
+		"2024":          "2024",
+	}
+	for input, want := range valid {
+		got, err := NormalizeTagName(input)
+		if err != nil || got != want {
+			t.Errorf("NormalizeTagName(%q) = (%q, %v), expected %q", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "-leading", "with space", "under_score", "ü", strings.Repeat("a", 65)} {
+		if _, err := NormalizeTagName(input); err != ErrInvalidTagName {
+			t.Errorf("NormalizeTagName(%q) expected ErrInvalidTagName, got %v", input, err)
+		}
+	}
+}
+
+func TestCanonicalAPIURL(t *testing.T) {
+	tests := map[string]string{
+		"https://example.org/w/api.php":            "example.org/w/api.php",
+		"http://www.Example.org:80/w/api.php":      "example.org/w/api.php",
+		"https://example.org:443/w/api.php/":       "example.org/w/api.php",
+		"https://example.org/api.php?action=query": "example.org/api.php",
+		"https://example.org:8080/api.php":         "example.org:8080/api.php",
+		"example.org/api.php#frag":                 "example.org/api.php",
+		"":                                         "",
+		"https://":                                 "",
+	}
+	for input, want := range tests {
+		if got := CanonicalAPIURL(input); got != want {
+			t.Errorf("CanonicalAPIURL(%q) = %q, expected %q", input, got, want)
+		}
+	}
+
+	apiURL := "http://www.example.org/api.php"
+	wiki := &Wiki{APIURL: &apiURL}
+	if err := wiki.BeforeSave(nil); err != nil {
+		t.Fatalf("BeforeSave failed: %v", err)
+	}
+	if wiki.CanonicalAPIURL == nil || *wiki.CanonicalAPIURL != "example.org/api.php" {
+		t.Errorf("Expected BeforeSave to set the canonical API URL, got %v", wiki.CanonicalAPIURL)
+	}
+}
+
+func TestMovedURL(t *testing.T) {
+	tests := []struct {
+		rawURL, from, to string
+		want             string
+		moved            bool
+	}{
+		{"http://example.org/", "http://example.org/w/api.php", "https://example.org/w/api.php", "https://example.org/", true},
+		{"https://old.example/wiki/", "https://old.example/api.php", "https://new.example/api.php", "https://new.example/wiki/", true},
+		{"https://example.org/", "https://example.org/api.php", "https://example.org/w/api.php", "https://example.org/", false},
+		{"https://example.org/", "https://api.example.org/api.php", "https://api.other.org/api.php", "https://example.org/", false},
+		{"not a url", "https://example.org/api.php", "https://other.org/api.php", "not a url", false},
+	}
+	for _, tt := range tests {
+		got, moved := MovedURL(tt.rawURL, tt.from, tt.to)
+		if got != tt.want || moved != tt.moved {
+			t.Errorf("MovedURL(%q, %q, %q) = %q, %v, expected %q, %v", tt.rawURL, tt.from, tt.to, got, moved, tt.want, tt.moved)
+		}
+	}
+}
+
+func TestWikiCheckPolicy(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	wiki := &Wiki{}
+
+	wiki.ScheduleCheck(nil, time.Hour)
+	if wiki.NextCheckAt != nil {
+		t.Errorf("Expected a never checked wiki to stay due, got %v", wiki.NextCheckAt)
+	}
+	wiki.ScheduleCheck(&now, time.Hour)
+	if wiki.NextCheckAt == nil || !wiki.NextCheckAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected the default interval, got %v", wiki.NextCheckAt)
+	}
+
+	minutes := 30
+	wiki.CollectIntervalMinutes = &minutes
+	wiki.ArchiveCheckIntervalMinutes = &minutes
+	wiki.ScheduleCheck(&now, time.Hour)
+	wiki.ScheduleArchiveCheck(&now, time.Hour)
+	if !wiki.NextCheckAt.Equal(now.Add(30*time.Minute)) || !wiki.NextArchiveCheckAt.Equal(now.Add(30*time.Minute)) {
+		t.Errorf("Expected the wiki's own interval, got %v and %v", wiki.NextCheckAt, wiki.NextArchiveCheckAt)
+	}
+
+	if wiki.IsPaused(now) {
+		t.Error("Expected a wiki without paused_until not to be paused")
+	}
+	until := now.Add(time.Minute)
+	wiki.PausedUntil = &until
+	if !wiki.IsPaused(now) || wiki.IsPaused(until) {
+		t.Error("Expected the wiki to be paused until paused_until only")
+	}
+
+	for _, p := range []WikiPriority{WikiPriorityLow, WikiPriorityNormal, WikiPriorityHigh} {
+		if !p.Valid() {
+			t.Errorf("Expected priority %d to be valid", p)
+		}
+	}
+	if WikiPriority(2).Valid() || WikiPriority(-2).Valid() {
+		t.Error("Expected out-of-range priorities to be invalid")
+	}
+	if ValidateCheckInterval(MinCheckIntervalMinutes-1) == nil || ValidateCheckInterval(60) != nil {
+		t.Error("Unexpected ValidateCheckInterval result")
+	}
+}
+
+func TestStatsAnomaly(t *testing.T) {
+	prev := &WikiStats{Pages: 1000, Articles: 400, Edits: 20000}
+
+	if _, ok := StatsAnomaly(prev, &WikiStats{Pages: 990, Articles: 400, Edits: 20100}); ok {
+		t.Error("Expected small changes not to be an anomaly")
+	}
+	if _, ok := StatsAnomaly(&WikiStats{Pages: 10}, &WikiStats{Pages: 0}); ok {
+		t.Error("Expected drops on tiny wikis not to be an anomaly")
+	}
+	if _, ok := StatsAnomaly(nil, prev); ok {
+		t.Error("Expected the first collection not to be an anomaly")
+	}
+
+	message, ok := StatsAnomaly(prev, &WikiStats{Pages: 500, Articles: 400, Edits: 3})
+	if !ok {
+		t.Fatal("Expected large drops to be an anomaly")
+	}
+	if message != "pages dropped from 1000 to 500, edits dropped from 20000 to 3" {
+		t.Errorf("Unexpected message %q", message)
+	}
+}
+
+func TestStatsChanged(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	prev := &WikiStats{Time: base, Pages: 10, Edits: 100}
+	same := &WikiStats{Time: base.Add(time.Hour), Pages: 10, Edits: 100}
+
+	if StatsChanged(prev, same, 24*time.Hour) {
+		t.Error("Expected unchanged stats within the heartbeat not to be stored")
+	}
+	if !StatsChanged(prev, &WikiStats{Time: base.Add(time.Hour), Pages: 10, Edits: 100, Jobs: 1}, 24*time.Hour) {
+		t.Error("Expected a changed counter to be stored")
+	}
+	if !StatsChanged(prev, &WikiStats{Time: base.Add(24 * time.Hour), Pages: 10, Edits: 100}, 24*time.Hour) {
+		t.Error("Expected unchanged stats to be stored once the heartbeat passed")
+	}
+	if !StatsChanged(nil, same, 24*time.Hour) || !StatsChanged(prev, same, 0) {
+		t.Error("Expected first stats, and every collection without a heartbeat, to be stored")
+	}
+}
+
+func TestFillStatsGaps(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ms := func(v int) *int { return &v }
+	carry := &WikiStats{ID: 1, Time: base.Add(-time.Hour), Pages: 5}
+	stored := []*WikiStats{{ID: 3, Time: base.Add(2 * time.Hour), Pages: 7, ResponseTimeMs: ms(30)}}
+	checks := []*WikiCheck{
+		{Time: base.Add(2 * time.Hour), OK: true, ResponseTimeMs: ms(30)},
+		{Time: base, OK: true, ResponseTimeMs: ms(10)},
+		{Time: base.Add(time.Hour), OK: false},
+		{Time: base.Add(3 * time.Hour), OK: true, ResponseTimeMs: ms(40)},
+	}
+
+	filled := FillStatsGaps(stored, carry, checks)
+	if len(filled) != 3 {
+		t.Fatalf("Expected the stored row and two filled rows, got %d", len(filled))
+	}
+	first, last := filled[1], filled[2]
+	if !first.Time.Equal(base) || first.Pages != 5 || *first.ResponseTimeMs != 10 || first.ID != 0 {
+		t.Errorf("Unexpected row filled from the carried stats: %+v", first)
+	}
+	if !last.Time.Equal(base.Add(3*time.Hour)) || last.Pages != 7 || *last.ResponseTimeMs != 40 {
+		t.Errorf("Unexpected row filled from the stored stats: %+v", last)
+	}
+	if stored[0].Time != base.Add(2*time.Hour) || carry.Time != base.Add(-time.Hour) {
+		t.Error("Expected the given rows not to be modified")
+	}
+
+	if filled := FillStatsGaps(nil, nil, checks); len(filled) != 0 {
+		t.Error("Expected no rows without stats to carry")
+	}
+}
+
+func TestWikiStatsRollupMerge(t *testing.T) {
+	wikiID := uuid.New()
+	base := time.Date(2024, 1, 31, 22, 0, 0, 0, time.FixedZone("CET", 3600))
+	status := 503
+
+	rollup := NewStatsRollup(StatsTierMonthly, &WikiStats{WikiID: wikiID, Time: base.Add(time.Hour), Pages: 12})
+	if !rollup.PeriodStart.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected periods to start in UTC, got %s", rollup.PeriodStart)
+	}
+	rollup.Merge(NewStatsRollup(StatsTierMonthly, &WikiStats{WikiID: wikiID, Time: base, Pages: 15, HTTPStatus: &status}))
+	rollup.Merge(NewStatsRollup(StatsTierMonthly, &WikiStats{WikiID: wikiID, Time: base.Add(2 * time.Hour), Pages: 10}))
+
+	if rollup.Samples != 3 || rollup.Pages != (MetricRange{Last: 10, Min: 10, Max: 15}) {
+		t.Errorf("Unexpected rollup %d samples, pages %+v", rollup.Samples, rollup.Pages)
+	}
+	if !rollup.FirstTime.Equal(base) || !rollup.LastTime.Equal(base.Add(2*time.Hour)) || rollup.HTTPStatus != nil {
+		t.Error("Expected the rollup to span all samples and keep the availability of the last one")
+	}
+	if point := rollup.Point(); point.Pages != 10 || !point.Time.Equal(rollup.LastTime) {
+		t.Errorf("Unexpected point %+v", point)
+	}
+
+	stored := rollup
+	if !stored.Covers(rollup) {
+		t.Error("Expected an identical rollup to cover itself")
+	}
+	stored.Samples--
+	if stored.Covers(rollup) {
+		t.Error("Expected a rollup missing a sample not to cover")
+	}
+}
+
+func TestEventFilterMatches(t *testing.T) {
+	wikiURL := "https://test.miraheze.org/wiki/Main_Page"
+
+	all := &EventFilter{}
+	if !all.Matches(WikiEventWentOffline, wikiURL, nil) {
+		t.Error("Expected a webhook without filters to match every event")
+	}
+
+	filtered := &EventFilter{Events: StringList{string(WikiEventWentOffline), string(WikiEventArchiveFound)}}
+	if !filtered.Matches(WikiEventArchiveFound, wikiURL, nil) || filtered.Matches(WikiEventRecovered, wikiURL, nil) {
+		t.Error("Expected the event filter to apply")
+	}
+
+	tag := "at-risk"
+	tagged := &EventFilter{Tag: &tag}
+	if !tagged.Matches(WikiEventAnomaly, wikiURL, []string{"gaming", "at-risk"}) || tagged.Matches(WikiEventAnomaly, wikiURL, []string{"gaming"}) {
+		t.Error("Expected the tag scope to apply")
+	}
+
+	farm := "Miraheze.org"
+	onFarm := &EventFilter{Farm: &farm}
+	if !onFarm.Matches(WikiEventAnomaly, wikiURL, nil) || !onFarm.Matches(WikiEventAnomaly, "https://miraheze.org", nil) {
+		t.Error("Expected wikis on the farm to match")
+	}
+	if onFarm.Matches(WikiEventAnomaly, "https://notmiraheze.org", nil) || onFarm.Matches(WikiEventAnomaly, "not a url", nil) {
+		t.Error("Expected wikis off the farm not to match")
+	}
+}
+
+func TestNewAPIToken(t *testing.T) {
+	token, raw, err := NewAPIToken(" bot ", []string{ScopeWikisWrite, ScopeExport}, nil, "admin")
+	if err != nil {
+		t.Fatalf("NewAPIToken failed: %v", err)
+	}
+	if !strings.HasPrefix(raw, "wkp_") || !strings.HasPrefix(raw, token.Prefix) {
+		t.Errorf("Unexpected token %q with prefix %q", raw, token.Prefix)
+	}
+	if token.Name != "bot" || token.TokenHash != HashAPIToken(raw) || strings.Contains(token.TokenHash, raw) {
+		t.Errorf("Unexpected token record %+v", token)
+	}
+
+	_, other, _ := NewAPIToken("bot", []string{ScopeExport}, nil, "admin")
+	if other == raw {
+		t.Error("Expected tokens to be random")
+	}
+
+	if _, _, err := NewAPIToken("bot", nil, nil, "admin"); err == nil {
+		t.Error("Expected tokens without scopes to be rejected")
+	}
+	if _, _, err := NewAPIToken("bot", []string{"root"}, nil, "admin"); err == nil {
+		t.Error("Expected unknown scopes to be rejected")
+	}
+	if _, _, err := NewAPIToken(" ", []string{ScopeExport}, nil, "admin"); err == nil {
+		t.Error("Expected tokens without a name to be rejected")
+	}
+}
+
+func TestAPITokenScopesAndUsable(t *testing.T) {
+	if !HasScope([]string{ScopeExport}, ScopeExport) || HasScope([]string{ScopeExport}, ScopeAdminDelete) {
+		t.Error("Unexpected HasScope result")
+	}
+	if !HasScope([]string{ScopeAdmin}, ScopeAdminDelete) {
+		t.Error("Expected the admin scope to grant every scope")
+	}
+	if !HasScope([]string{ScopeAdminCollect}, ScopeAdminRead) || HasScope([]string{ScopeExport}, ScopeAdminRead) {
+		t.Error("Expected the scopes changing wikis, and only those, to grant admin:read")
+	}
+	if HasScope([]string{ScopeAdminRead}, ScopeWikisWrite) {
+		t.Error("Expected admin:read to grant nothing more")
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	later := now.Add(time.Minute)
+	if !(&APIToken{}).Usable(now) || !(&APIToken{ExpiresAt: &later}).Usable(now) {
+		t.Error("Expected unexpired tokens to be usable")
+	}
+	if (&APIToken{ExpiresAt: &expired}).Usable(now) || (&APIToken{RevokedAt: &expired}).Usable(now) {
+		t.Error("Expected expired and revoked tokens not to be usable")
+	}
+}
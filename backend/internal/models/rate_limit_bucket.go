@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the token bucket of one client for one route group, kept in
+// the database when several instances share rate limits
+type RateLimitBucket struct {
+	Key       string    `gorm:"type:varchar(255);primary_key" json:"key"` // Route group and client, e.g. submit:ip:192.0.2.1
+	Tokens    float64   `gorm:"not null" json:"tokens"`
+	UpdatedAt time.Time `gorm:"not null;index" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SubmissionStatus is the moderation state of a submitted wiki
+type SubmissionStatus string
+
+const (
+	SubmissionPending  SubmissionStatus = "pending"  // Awaiting the automatic pre-check
+	SubmissionChecking SubmissionStatus = "checking" // Pre-check in progress
+	SubmissionReview   SubmissionStatus = "review"   // Checked, awaiting a curator
+	SubmissionApproved SubmissionStatus = "approved" // Added to the tracked wikis
+	SubmissionRejected SubmissionStatus = "rejected"
+)
+
+// SubmissionAutoReviewer is the reviewer recorded for decisions of the auto-approval rules
+const SubmissionAutoReviewer = "auto"
+
+// Submission is a wiki submitted anonymously, held for moderation until it is
+// approved and added to the tracked wikis, or rejected
+type Submission struct {
+	UUIDKey
+	URL         string           `gorm:"type:text;not null;index" json:"url"` // Normalized wiki URL
+	APIURL      *string          `gorm:"type:text" json:"api_url,omitempty"`  // Given, or detected by the pre-check
+	WikiName    *string          `gorm:"type:varchar(255)" json:"wiki_name,omitempty"`
+	Status      SubmissionStatus `gorm:"type:varchar(16);not null;index" json:"status"`
+	SubmitterIP string           `gorm:"type:varchar(64);not null" json:"submitter_ip"`
+	ProofHash   *string          `gorm:"type:char(64);uniqueIndex" json:"-"` // Hash of the proof-of-work challenge, so each is used once
+
+	// Pre-check results
+	CheckedAt   *time.Time `json:"checked_at,omitempty"`
+	Reachable   bool       `gorm:"not null;default:false" json:"reachable"`                        // The API answered a siteinfo query
+	IsMediaWiki bool       `gorm:"column:is_mediawiki;not null;default:false" json:"is_mediawiki"` // The generator is MediaWiki
+	Sitename    *string    `gorm:"type:varchar(255)" json:"sitename,omitempty"`
+	Generator   *string    `gorm:"type:varchar(255)" json:"generator,omitempty"`
+	DuplicateOf *uuid.UUID `gorm:"type:uuid" json:"duplicate_of,omitempty"` // Tracked wiki with the same URL or API URL
+	IsAlias     bool       `gorm:"not null;default:false" json:"is_alias"`  // DuplicateOf matched a former or merged URL
+	CheckError  *string    `gorm:"type:text" json:"check_error,omitempty"`
+
+	// Moderation
+	WikiID     *uuid.UUID `gorm:"type:uuid" json:"wiki_id,omitempty"` // Wiki created on approval
+	ReviewedBy *string    `gorm:"type:varchar(255)" json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	Reason     *string    `gorm:"type:text" json:"reason,omitempty"` // Why it was approved or rejected
+
+	CreatedAt time.Time `gorm:"not null;default:now();index" json:"created_at"`
+	UpdatedAt time.Time `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Submission) TableName() string {
+	return "submissions"
+}
+
+// Decided reports whether the submission was approved or rejected
+func (s *Submission) Decided() bool {
+	return s.Status == SubmissionApproved || s.Status == SubmissionRejected
+}
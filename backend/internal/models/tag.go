@@ -0,0 +1,49 @@
+package models
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidTagName is returned for tag names that are not lower-case slugs
+var ErrInvalidTagName = errors.New("tag names must be 1-64 characters of a-z, 0-9 and '-', starting with a letter or digit")
+
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Tag labels wikis for curation, e.g. gaming, fandom-fork, at-risk or university
+type Tag struct {
+	UUIDKey
+	Name        string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	Description *string   `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// WikiTag links a wiki to a tag
+type WikiTag struct {
+	WikiID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"wiki_id"`
+	TagID     uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"tag_id"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WikiTag) TableName() string {
+	return "wiki_tags"
+}
+
+// NormalizeTagName trims and lower-cases a tag name and validates it
+func NormalizeTagName(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !tagNamePattern.MatchString(name) {
+		return "", ErrInvalidTagName
+	}
+	return name, nil
+}
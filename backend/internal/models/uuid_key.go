@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UUIDKey is the uuid primary key of a model. Postgres fills it with gen_random_uuid();
+// BeforeCreate fills it in Go, so rows can also be created on databases without it.
+type UUIDKey struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+}
+
+// BeforeCreate assigns an ID unless one is set
+func (k *UUIDKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook is an admin-registered endpoint that receives signed JSON POSTs for wiki events
+type Webhook struct {
+	UUIDKey
+	URL         string `gorm:"type:varchar(2048);not null" json:"url"`
+	Secret      string `gorm:"type:varchar(255);not null" json:"-"` // HMAC key, only returned when the webhook is created
+	EventFilter `gorm:"embedded"`
+	IsActive    bool      `gorm:"not null;default:true" json:"is_active"`
+	Description *string   `gorm:"type:text" json:"description,omitempty"`
+	CreatedBy   string    `gorm:"type:varchar(255);not null" json:"created_by"`
+	CreatedAt   time.Time `gorm:"not null;default:now()" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"not null;default:now()" json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // Waiting for its first or next attempt
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // The endpoint answered with a 2xx status
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"    // All attempts failed
+)
+
+// WebhookDelivery logs one event sent to one webhook, including its retries
+type WebhookDelivery struct {
+	UUIDKey
+	WebhookID      uuid.UUID             `gorm:"type:uuid;not null;index:idx_webhook_deliveries_webhook_created,priority:1" json:"webhook_id"`
+	EventID        *uuid.UUID            `gorm:"type:uuid" json:"event_id,omitempty"` // NULL for test pings
+	EventType      string                `gorm:"type:varchar(32);not null" json:"event_type"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"` // JSON body, identical for every attempt
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(20);not null;default:'pending';index:idx_webhook_deliveries_status_next,priority:1" json:"status"`
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`
+	ResponseStatus *int                  `json:"response_status,omitempty"`
+	Error          *string               `gorm:"type:text" json:"error,omitempty"`
+	NextAttemptAt  *time.Time            `gorm:"index:idx_webhook_deliveries_status_next,priority:2" json:"next_attempt_at,omitempty"`
+	CreatedAt      time.Time             `gorm:"not null;default:now();index:idx_webhook_deliveries_webhook_created,priority:2" json:"created_at"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,11 +21,13 @@ const (
 
 // Wiki represents a wiki site being tracked
 type Wiki struct {
-	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	URL      string    `gorm:"type:varchar(2048);not null;uniqueIndex" json:"url"`
-	APIURL   *string   `gorm:"type:varchar(2048);index" json:"api_url"`
-	IndexURL *string   `gorm:"type:varchar(2048)" json:"index_url,omitempty"`
-	WikiName *string   `gorm:"type:varchar(255)" json:"wiki_name,omitempty"`
+	ID     uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	URL    string    `gorm:"type:varchar(2048);not null;uniqueIndex" json:"url"`
+	APIURL *string   `gorm:"type:varchar(2048);index" json:"api_url"`
+	// CanonicalAPIURL is APIURL reduced by CanonicalAPIURL, maintained on save for duplicate lookups
+	CanonicalAPIURL *string `gorm:"column:api_url_canonical;type:varchar(2048);index" json:"-"`
+	IndexURL        *string `gorm:"type:varchar(2048)" json:"index_url,omitempty"`
+	WikiName        *string `gorm:"type:varchar(255)" json:"wiki_name,omitempty"`
 
 	// Metadata from siteinfo.general
 	Sitename         *string `gorm:"type:varchar(255);index" json:"sitename"`
@@ -33,10 +37,16 @@ type Wiki struct {
 	MediaWikiVersion *string `gorm:"type:varchar(50)" json:"mediawiki_version,omitempty"`
 	MaxPageID        *int    `json:"max_page_id,omitempty"`
 
+	// License and extensions from siteinfo.rightsinfo and siteinfo.extensions
+	License    *string    `gorm:"type:varchar(255)" json:"license,omitempty"`
+	LicenseURL *string    `gorm:"type:varchar(2048)" json:"license_url,omitempty"`
+	Extensions StringList `gorm:"type:text" json:"extensions,omitempty"`
+
 	// Status and tracking
 	Status       WikiStatus `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
 	HasArchive   bool       `gorm:"not null;default:false;index" json:"has_archive"`
 	APIAvailable bool       `gorm:"not null;default:true" json:"api_available"`
+	ReadOnly     bool       `gorm:"not null;default:false" json:"read_only"`
 
 	// Error tracking (for siteinfo checks)
 	LastError   *string    `gorm:"type:text" json:"last_error"`
@@ -55,9 +65,34 @@ type Wiki struct {
 	// Settings
 	IsActive bool `gorm:"not null;default:true" json:"is_active,omitempty"`
 
+	// Check policy. Schedulers pick wikis whose next check time has passed, highest priority first.
+	Priority                    WikiPriority `gorm:"type:smallint;not null;default:0;index" json:"priority"`
+	CollectIntervalMinutes      *int         `json:"collect_interval_minutes,omitempty"`       // Overrides WIKI_COLLECT_INTERVAL
+	ArchiveCheckIntervalMinutes *int         `json:"archive_check_interval_minutes,omitempty"` // Overrides WIKI_ARCHIVE_CHECK_INTERVAL
+	PausedUntil                 *time.Time   `json:"paused_until,omitempty"`
+	NextCheckAt                 *time.Time   `gorm:"index" json:"next_check_at,omitempty"`
+	NextArchiveCheckAt          *time.Time   `gorm:"index" json:"next_archive_check_at,omitempty"`
+
+	// Trash. Deleted wikis are hidden from every query until restored or purged.
+	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy    *string        `gorm:"type:varchar(255)" json:"deleted_by,omitempty"`
+	DeleteReason *string        `gorm:"type:text" json:"delete_reason,omitempty"`
+
 	// Relations
 	Stats    []WikiStats   `gorm:"foreignKey:WikiID;constraint:OnDelete:CASCADE" json:"-"`
 	Archives []WikiArchive `gorm:"foreignKey:WikiID;constraint:OnDelete:CASCADE" json:"-"`
+	Tags     []Tag         `gorm:"many2many:wiki_tags" json:"tags,omitempty"`
+}
+
+// BeforeSave hook to keep the canonical API URL in sync
+func (w *Wiki) BeforeSave(tx *gorm.DB) error {
+	w.CanonicalAPIURL = nil
+	if w.APIURL != nil {
+		if canonical := CanonicalAPIURL(*w.APIURL); canonical != "" {
+			w.CanonicalAPIURL = &canonical
+		}
+	}
+	return nil
 }
 
 // BeforeUpdate hook to set UpdatedAt
@@ -70,3 +105,31 @@ func (w *Wiki) BeforeUpdate(tx *gorm.DB) error {
 func (Wiki) TableName() string {
 	return "wikis"
 }
+
+// CanonicalAPIURL reduces an API URL to the form used to detect duplicate wikis:
+// lower-cased, without scheme, "www.", default port, query, fragment or trailing slash.
+// http://www.Example.org:80/w/api.php and https://example.org/w/api.php are the same wiki.
+// Migration 000007 backfills the column with an equivalent SQL expression.
+func CanonicalAPIURL(apiURL string) string {
+	s := strings.ToLower(strings.TrimSpace(apiURL))
+	if i := strings.IndexAny(s, "?#"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "://"); i >= 0 {
+		s = s[i+3:]
+	}
+	s = strings.TrimPrefix(s, "www.")
+
+	host, path := s, ""
+	if i := strings.Index(s, "/"); i >= 0 {
+		host, path = s[:i], s[i:]
+	}
+	host = strings.TrimSuffix(strings.TrimSuffix(host, ":80"), ":443")
+	if host == "" {
+		return ""
+	}
+	if _, err := url.Parse("https://" + host + path); err != nil {
+		return ""
+	}
+	return host + strings.TrimRight(path, "/")
+}
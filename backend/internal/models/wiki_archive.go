@@ -29,6 +29,10 @@ type WikiArchive struct {
 	HasImagesList     bool `gorm:"not null;default:false" json:"has_images_list"`
 	HasLegacyWikidump bool `gorm:"not null;default:false" json:"has_legacy_wikidump"`
 
+	// Removal tracking
+	RemovedAt    *time.Time `json:"removed_at,omitempty"`        // Set once the item is confirmed gone from Archive.org
+	MissedChecks int        `gorm:"not null;default:0" json:"-"` // Consecutive searches that did not list the item
+
 	// Timestamps
 	CreatedAt time.Time `gorm:"not null;default:now()" json:"created_at"`
 	UpdatedAt time.Time `gorm:"not null;default:now()" json:"updated_at"`
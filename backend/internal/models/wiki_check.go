@@ -0,0 +1,63 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WikiCheck records one collection of a wiki. Stats rows are only stored when a
+// counter changed, so the check log is what holds the availability of every check.
+type WikiCheck struct {
+	ID     int64     `gorm:"primaryKey;autoIncrement" json:"-"`
+	WikiID uuid.UUID `gorm:"type:uuid;not null;index:idx_wiki_checks_wiki_time,priority:1" json:"wiki_id"`
+	Time   time.Time `gorm:"not null;index:idx_wiki_checks_time;index:idx_wiki_checks_wiki_time,priority:2" json:"time"`
+	OK     bool      `gorm:"column:ok;not null" json:"ok"` // Siteinfo was fetched
+
+	ResponseTimeMs *int `json:"response_time_ms,omitempty"`
+	HTTPStatus     *int `json:"http_status,omitempty"`
+}
+
+// TableName specifies the table name for GORM
+func (WikiCheck) TableName() string {
+	return "wiki_checks"
+}
+
+// FillStatsGaps returns stored with a row added for every successful check that
+// stored no stats row of its own. The added rows hold the counters of the latest
+// stats row before the check, and the check's availability. carry is the latest
+// stats row before all of stored, if any.
+func FillStatsGaps(stored []*WikiStats, carry *WikiStats, checks []*WikiCheck) []*WikiStats {
+	if len(checks) == 0 {
+		return stored
+	}
+	rows := make([]*WikiStats, 0, len(stored)+1)
+	if carry != nil {
+		rows = append(rows, carry)
+	}
+	rows = append(rows, stored...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Time.Before(rows[j].Time) })
+	checks = append([]*WikiCheck(nil), checks...)
+	sort.SliceStable(checks, func(i, j int) bool { return checks[i].Time.Before(checks[j].Time) })
+
+	filled := stored
+	next := 0
+	var current *WikiStats
+	for _, check := range checks {
+		for next < len(rows) && !rows[next].Time.After(check.Time) {
+			current = rows[next]
+			next++
+		}
+		if !check.OK || current == nil || current.Time.Equal(check.Time) {
+			continue
+		}
+		point := *current
+		point.ID = 0
+		point.Time = check.Time
+		point.ResponseTimeMs = check.ResponseTimeMs
+		point.HTTPStatus = check.HTTPStatus
+		filled = append(filled, &point)
+	}
+	return filled
+}
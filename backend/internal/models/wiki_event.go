@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WikiEventType classifies a catalog event
+type WikiEventType string
+
+const (
+	WikiEventAdded          WikiEventType = "wiki_added"      // Wiki added to the catalog
+	WikiEventStatusChanged  WikiEventType = "status_changed"  // Any other status transition
+	WikiEventWentOffline    WikiEventType = "went_offline"    // Status changed from ok to error or offline
+	WikiEventRecovered      WikiEventType = "recovered"       // Status changed from error or offline to ok
+	WikiEventReadOnly       WikiEventType = "read_only"       // Wiki was locked (siteinfo reports readonly)
+	WikiEventWritable       WikiEventType = "writable"        // Wiki is no longer read-only
+	WikiEventArchiveFound   WikiEventType = "archive_found"   // A new Archive.org dump was found
+	WikiEventArchiveRemoved WikiEventType = "archive_removed" // A known Archive.org dump is no longer found
+	WikiEventAnomaly        WikiEventType = "anomaly"         // Collected statistics look implausible, e.g. a large page count drop
+	WikiEventMerged         WikiEventType = "merged"          // A duplicate wiki was merged into this one
+)
+
+// WikiEventTypes lists every event type, e.g. for validating webhook event filters
+var WikiEventTypes = []WikiEventType{
+	WikiEventAdded,
+	WikiEventStatusChanged,
+	WikiEventWentOffline,
+	WikiEventRecovered,
+	WikiEventReadOnly,
+	WikiEventWritable,
+	WikiEventArchiveFound,
+	WikiEventArchiveRemoved,
+	WikiEventAnomaly,
+	WikiEventMerged,
+}
+
+// Valid reports whether t is a known event type
+func (t WikiEventType) Valid() bool {
+	for _, known := range WikiEventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// WikiEvent records a status transition, archive upsert or other catalog change of a wiki
+type WikiEvent struct {
+	UUIDKey
+	WikiID       uuid.UUID     `gorm:"type:uuid;not null;index:idx_wiki_events_wiki_created,priority:1" json:"wiki_id"`
+	Type         WikiEventType `gorm:"type:varchar(32);not null;index:idx_wiki_events_type_created,priority:1" json:"type"`
+	FromStatus   *WikiStatus   `gorm:"type:varchar(20)" json:"from_status,omitempty"`
+	ToStatus     *WikiStatus   `gorm:"type:varchar(20)" json:"to_status,omitempty"`
+	IAIdentifier *string       `gorm:"type:varchar(255)" json:"ia_identifier,omitempty"`
+	Message      *string       `gorm:"type:text" json:"message,omitempty"`
+	CreatedAt    time.Time     `gorm:"not null;default:now();index:idx_wiki_events_wiki_created,priority:2;index:idx_wiki_events_type_created,priority:2" json:"created_at"`
+
+	// Relations
+	Wiki *Wiki `gorm:"foreignKey:WikiID;constraint:OnDelete:CASCADE" json:"wiki,omitempty"`
+}
+
+// StatusTransitionEvent classifies a status change; ok reports false when the status did not change
+func StatusTransitionEvent(from, to WikiStatus) (WikiEventType, bool) {
+	if from == to {
+		return "", false
+	}
+	down := func(s WikiStatus) bool { return s == WikiStatusError || s == WikiStatusOffline }
+	switch {
+	case from == WikiStatusOK && down(to):
+		return WikiEventWentOffline, true
+	case down(from) && to == WikiStatusOK:
+		return WikiEventRecovered, true
+	default:
+		return WikiEventStatusChanged, true
+	}
+}
+
+// TableName specifies the table name for GORM
+func (WikiEvent) TableName() string {
+	return "wiki_events"
+}
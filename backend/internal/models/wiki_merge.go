@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WikiMerge logs a duplicate wiki merged into a surviving wiki.
+// The merged URLs remain aliases of the survivor.
+type WikiMerge struct {
+	UUIDKey
+	WikiID       uuid.UUID `gorm:"type:uuid;not null;index" json:"wiki_id"` // Surviving wiki
+	MergedWikiID uuid.UUID `gorm:"type:uuid;not null" json:"merged_wiki_id"`
+	MergedURL    string    `gorm:"type:varchar(2048);not null;index" json:"merged_url"`
+	MergedAPIURL *string   `gorm:"type:varchar(2048)" json:"merged_api_url,omitempty"`
+
+	// Rows moved onto the surviving wiki
+	StatsMoved    int64 `gorm:"not null;default:0" json:"stats_moved"`
+	ArchivesMoved int64 `gorm:"not null;default:0" json:"archives_moved"`
+
+	Actor     string    `gorm:"type:varchar(255);not null" json:"actor"` // Admin name, or "collector" for automatic merges
+	Reason    *string   `gorm:"type:text" json:"reason,omitempty"`
+	CreatedAt time.Time `gorm:"not null;default:now()" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WikiMerge) TableName() string {
+	return "wiki_merges"
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WikiNote is a timestamped curator note about a wiki,
+// e.g. "owner says the host closes in June"
+type WikiNote struct {
+	UUIDKey
+	WikiID    uuid.UUID `gorm:"type:uuid;not null;index:idx_wiki_notes_wiki_created,priority:1" json:"wiki_id"`
+	Author    string    `gorm:"type:varchar(255);not null" json:"author"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `gorm:"not null;default:now();index:idx_wiki_notes_wiki_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WikiNote) TableName() string {
+	return "wiki_notes"
+}
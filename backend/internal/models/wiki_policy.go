@@ -0,0 +1,63 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// WikiPriority is the tier a wiki is scheduled in. Among wikis due for a check, higher tiers go first.
+type WikiPriority int
+
+const (
+	WikiPriorityLow    WikiPriority = -1
+	WikiPriorityNormal WikiPriority = 0
+	WikiPriorityHigh   WikiPriority = 1
+)
+
+// Bounds of per-wiki check intervals, in minutes
+const (
+	MinCheckIntervalMinutes = 10
+	MaxCheckIntervalMinutes = 365 * 24 * 60
+)
+
+// Valid reports whether p is a known priority tier
+func (p WikiPriority) Valid() bool {
+	return p >= WikiPriorityLow && p <= WikiPriorityHigh
+}
+
+// ValidateCheckInterval checks a per-wiki check interval in minutes
+func ValidateCheckInterval(minutes int) error {
+	if minutes < MinCheckIntervalMinutes || minutes > MaxCheckIntervalMinutes {
+		return fmt.Errorf("interval must be between %d and %d minutes", MinCheckIntervalMinutes, MaxCheckIntervalMinutes)
+	}
+	return nil
+}
+
+// IsPaused reports whether checks of the wiki are paused at now
+func (w *Wiki) IsPaused(now time.Time) bool {
+	return w.PausedUntil != nil && w.PausedUntil.After(now)
+}
+
+// ScheduleCheck sets NextCheckAt after a collection at checkedAt, using the wiki's own
+// interval or defaultInterval. Wikis that were never checked stay due immediately.
+func (w *Wiki) ScheduleCheck(checkedAt *time.Time, defaultInterval time.Duration) {
+	w.NextCheckAt = nextCheck(checkedAt, w.CollectIntervalMinutes, defaultInterval)
+}
+
+// ScheduleArchiveCheck sets NextArchiveCheckAt after an archive check at checkedAt,
+// using the wiki's own interval or defaultInterval
+func (w *Wiki) ScheduleArchiveCheck(checkedAt *time.Time, defaultInterval time.Duration) {
+	w.NextArchiveCheckAt = nextCheck(checkedAt, w.ArchiveCheckIntervalMinutes, defaultInterval)
+}
+
+func nextCheck(checkedAt *time.Time, intervalMinutes *int, defaultInterval time.Duration) *time.Time {
+	if checkedAt == nil {
+		return nil
+	}
+	interval := defaultInterval
+	if intervalMinutes != nil {
+		interval = time.Duration(*intervalMinutes) * time.Minute
+	}
+	next := checkedAt.Add(interval)
+	return &next
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -31,3 +33,52 @@ type WikiStats struct {
 func (WikiStats) TableName() string {
 	return "wiki_stats"
 }
+
+// StatsChanged reports whether cur must be stored after prev, the latest stored
+// stats of the wiki: when a counter changed, or when heartbeat has passed since
+// prev so that unchanged wikis still show up in the table. A heartbeat of zero
+// stores every collection.
+func StatsChanged(prev, cur *WikiStats, heartbeat time.Duration) bool {
+	if prev == nil || heartbeat <= 0 || cur.Time.Sub(prev.Time) >= heartbeat {
+		return true
+	}
+	return prev.Pages != cur.Pages || prev.Articles != cur.Articles || prev.Edits != cur.Edits ||
+		prev.Images != cur.Images || prev.Users != cur.Users || prev.ActiveUsers != cur.ActiveUsers ||
+		prev.Admins != cur.Admins || prev.Jobs != cur.Jobs
+}
+
+// Thresholds for StatsAnomaly: a counter that held at least anomalyMinCount
+// and lost at least anomalyDropRatio of it between two collections is suspect
+const (
+	anomalyMinCount  = 50
+	anomalyDropRatio = 0.2
+)
+
+// StatsAnomaly compares two consecutive collections of a wiki and describes an
+// implausible change, such as a mass deletion, a reinstall or a wrong API answering
+// for the wiki; ok reports false when nothing looks off
+func StatsAnomaly(prev, cur *WikiStats) (string, bool) {
+	if prev == nil || cur == nil {
+		return "", false
+	}
+	var drops []string
+	for _, counter := range []struct {
+		name      string
+		prev, cur int
+	}{
+		{"pages", prev.Pages, cur.Pages},
+		{"articles", prev.Articles, cur.Articles},
+		{"edits", prev.Edits, cur.Edits},
+	} {
+		if counter.prev < anomalyMinCount || counter.cur >= counter.prev {
+			continue
+		}
+		if float64(counter.prev-counter.cur) >= anomalyDropRatio*float64(counter.prev) {
+			drops = append(drops, fmt.Sprintf("%s dropped from %d to %d", counter.name, counter.prev, counter.cur))
+		}
+	}
+	if len(drops) == 0 {
+		return "", false
+	}
+	return strings.Join(drops, ", "), true
+}
@@ -0,0 +1,162 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StatsTier names a table of rolled-up stats. Raw wiki_stats rows past their
+// retention are rolled up into daily rows, and daily rows into monthly ones.
+type StatsTier string
+
+const (
+	StatsTierDaily   StatsTier = "daily"
+	StatsTierMonthly StatsTier = "monthly"
+)
+
+// Table returns the table holding the tier's rollups
+func (t StatsTier) Table() string {
+	return "wiki_stats_" + string(t)
+}
+
+// PeriodStart returns the start of the day or month containing tm, in UTC
+func (t StatsTier) PeriodStart(tm time.Time) time.Time {
+	tm = tm.UTC()
+	if t == StatsTierMonthly {
+		return time.Date(tm.Year(), tm.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(tm.Year(), tm.Month(), tm.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// PeriodEnd returns the start of the period following the one starting at start
+func (t StatsTier) PeriodEnd(start time.Time) time.Time {
+	if t == StatsTierMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// MetricRange summarises one counter over a period
+type MetricRange struct {
+	Last int `gorm:"not null;default:0" json:"last"`
+	Min  int `gorm:"not null;default:0" json:"min"`
+	Max  int `gorm:"not null;default:0" json:"max"`
+}
+
+func newMetricRange(v int) MetricRange {
+	return MetricRange{Last: v, Min: v, Max: v}
+}
+
+// merge widens r by o; takeLast adopts o's last value
+func (r *MetricRange) merge(o MetricRange, takeLast bool) {
+	if o.Min < r.Min {
+		r.Min = o.Min
+	}
+	if o.Max > r.Max {
+		r.Max = o.Max
+	}
+	if takeLast {
+		r.Last = o.Last
+	}
+}
+
+// WikiStatsRollup summarises the stats of a wiki over one day or month
+type WikiStatsRollup struct {
+	WikiID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"wiki_id"`
+	PeriodStart time.Time `gorm:"primaryKey" json:"period_start"`
+	Samples     int       `gorm:"not null;default:0" json:"samples"` // Raw rows the rollup covers
+	FirstTime   time.Time `gorm:"not null" json:"first_time"`
+	LastTime    time.Time `gorm:"not null" json:"last_time"`
+
+	Pages       MetricRange `gorm:"embedded;embeddedPrefix:pages_" json:"pages"`
+	Articles    MetricRange `gorm:"embedded;embeddedPrefix:articles_" json:"articles"`
+	Edits       MetricRange `gorm:"embedded;embeddedPrefix:edits_" json:"edits"`
+	Images      MetricRange `gorm:"embedded;embeddedPrefix:images_" json:"images"`
+	Users       MetricRange `gorm:"embedded;embeddedPrefix:users_" json:"users"`
+	ActiveUsers MetricRange `gorm:"embedded;embeddedPrefix:active_users_" json:"active_users"`
+	Admins      MetricRange `gorm:"embedded;embeddedPrefix:admins_" json:"admins"`
+	Jobs        MetricRange `gorm:"embedded;embeddedPrefix:jobs_" json:"jobs"`
+
+	// Availability of the last sample
+	ResponseTimeMs *int `json:"response_time_ms,omitempty"`
+	HTTPStatus     *int `json:"http_status,omitempty"`
+}
+
+// NewStatsRollup returns the rollup of a single raw stats row for the tier's period containing it
+func NewStatsRollup(tier StatsTier, s *WikiStats) WikiStatsRollup {
+	return WikiStatsRollup{
+		WikiID:         s.WikiID,
+		PeriodStart:    tier.PeriodStart(s.Time),
+		Samples:        1,
+		FirstTime:      s.Time,
+		LastTime:       s.Time,
+		Pages:          newMetricRange(s.Pages),
+		Articles:       newMetricRange(s.Articles),
+		Edits:          newMetricRange(s.Edits),
+		Images:         newMetricRange(s.Images),
+		Users:          newMetricRange(s.Users),
+		ActiveUsers:    newMetricRange(s.ActiveUsers),
+		Admins:         newMetricRange(s.Admins),
+		Jobs:           newMetricRange(s.Jobs),
+		ResponseTimeMs: s.ResponseTimeMs,
+		HTTPStatus:     s.HTTPStatus,
+	}
+}
+
+func (r *WikiStatsRollup) ranges() []*MetricRange {
+	return []*MetricRange{&r.Pages, &r.Articles, &r.Edits, &r.Images, &r.Users, &r.ActiveUsers, &r.Admins, &r.Jobs}
+}
+
+// Merge folds o, a rollup of the same wiki and period, into r
+func (r *WikiStatsRollup) Merge(o WikiStatsRollup) {
+	takeLast := !o.LastTime.Before(r.LastTime)
+	mine, theirs := r.ranges(), o.ranges()
+	for i := range mine {
+		mine[i].merge(*theirs[i], takeLast)
+	}
+	if takeLast {
+		r.LastTime = o.LastTime
+		r.ResponseTimeMs = o.ResponseTimeMs
+		r.HTTPStatus = o.HTTPStatus
+	}
+	if o.FirstTime.Before(r.FirstTime) {
+		r.FirstTime = o.FirstTime
+	}
+	r.Samples += o.Samples
+}
+
+// Covers reports whether r, as read back from the database, holds the summary o
+// that was stored; rows are only deleted once their rollup is verified this way
+func (r *WikiStatsRollup) Covers(o WikiStatsRollup) bool {
+	if r.WikiID != o.WikiID || r.Samples != o.Samples ||
+		!r.FirstTime.Equal(o.FirstTime) || !r.LastTime.Equal(o.LastTime) {
+		return false
+	}
+	mine, theirs := r.ranges(), o.ranges()
+	for i := range mine {
+		if *mine[i] != *theirs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Point returns the rollup as a stats row holding the period's last values,
+// so charts can draw rolled-up history like raw history
+func (r *WikiStatsRollup) Point() *WikiStats {
+	return &WikiStats{
+		WikiID:         r.WikiID,
+		Time:           r.LastTime,
+		Pages:          r.Pages.Last,
+		Articles:       r.Articles.Last,
+		Edits:          r.Edits.Last,
+		Images:         r.Images.Last,
+		Users:          r.Users.Last,
+		ActiveUsers:    r.ActiveUsers.Last,
+		Admins:         r.Admins.Last,
+		Jobs:           r.Jobs.Last,
+		ResponseTimeMs: r.ResponseTimeMs,
+		HTTPStatus:     r.HTTPStatus,
+	}
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WikiURLKind tells which of a wiki's URLs a WikiURL records
+type WikiURLKind string
+
+const (
+	WikiURLKindURL   WikiURLKind = "url"   // Wiki base URL
+	WikiURLKindAPI   WikiURLKind = "api"   // api.php URL
+	WikiURLKindIndex WikiURLKind = "index" // index.php URL
+)
+
+// WikiURL records a URL a wiki has used, so old URLs keep resolving after moves and merges
+type WikiURL struct {
+	UUIDKey
+	WikiID      uuid.UUID   `gorm:"type:uuid;not null;index" json:"wiki_id"`
+	Kind        WikiURLKind `gorm:"type:varchar(10);not null;uniqueIndex:idx_wiki_urls_kind_url,priority:1" json:"kind"`
+	URL         string      `gorm:"type:varchar(2048);not null;uniqueIndex:idx_wiki_urls_kind_url,priority:2;index" json:"url"`
+	FirstSeenAt time.Time   `gorm:"not null;default:now()" json:"first_seen_at"`
+	LastSeenAt  time.Time   `gorm:"not null;default:now()" json:"last_seen_at"`
+}
+
+// TableName specifies the table name for GORM
+func (WikiURL) TableName() string {
+	return "wiki_urls"
+}
+
+// MovedURL rebases rawURL onto the scheme and host of to when rawURL lives on the
+// scheme and host of from and to does not, e.g. the base URL of a wiki whose API moved
+// from http://example.org/w/api.php to https://example.org/w/api.php.
+func MovedURL(rawURL, from, to string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL, false
+	}
+	fromURL, err := url.Parse(from)
+	if err != nil || fromURL.Host == "" {
+		return rawURL, false
+	}
+	toURL, err := url.Parse(to)
+	if err != nil || toURL.Host == "" {
+		return rawURL, false
+	}
+
+	if u.Scheme != fromURL.Scheme || u.Host != fromURL.Host {
+		return rawURL, false
+	}
+	if fromURL.Scheme == toURL.Scheme && fromURL.Host == toURL.Host {
+		return rawURL, false
+	}
+	u.Scheme, u.Host = toURL.Scheme, toURL.Host
+	return u.String(), true
+}
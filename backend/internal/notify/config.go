@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+
+	"wikikeeper-backend/internal/models"
+)
+
+// Transport types
+const (
+	TypeMatrix  = "matrix"  // Matrix client-server API, posting m.notice messages to a room
+	TypeDiscord = "discord" // Discord channel webhook
+	TypeIRC     = "irc"     // IRC server, connecting per message
+)
+
+// Default rate limit of a channel
+const (
+	defaultRatePerMinute = 20
+	defaultBurst         = 5
+)
+
+// Config is the notifier configuration file, e.g.
+//
+//	{
+//	  "channels": {
+//	    "ops": {"type": "matrix", "homeserver": "https://matrix.org", "access_token": "...", "room_id": "!abc:matrix.org"},
+//	    "discord": {"type": "discord", "webhook_url": "https://discord.com/api/webhooks/...", "rate_per_minute": 10},
+//	    "irc": {"type": "irc", "server": "irc.libera.chat:6697", "tls": true, "nick": "wikikeeper", "target": "#saveweb"}
+//	  },
+//	  "routes": [
+//	    {"channel": "ops", "events": ["went_offline", "archive_removed", "anomaly"], "tag": "at-risk"},
+//	    {"channel": "discord", "events": ["archive_found"], "template": "New dump of {{.Name}}: {{.ArchiveURL}}"}
+//	  ],
+//	  "templates": {"recovered": "{{.Name}} is back: {{.URL}}"}
+//	}
+type Config struct {
+	Channels  map[string]ChannelConfig `json:"channels"`
+	Routes    []RouteConfig            `json:"routes"`
+	Templates map[string]string        `json:"templates"` // Message templates by event type, replacing the built-in ones
+}
+
+// ChannelConfig is a chat destination
+type ChannelConfig struct {
+	Type string `json:"type"` // matrix, discord or irc
+
+	// Matrix
+	Homeserver  string `json:"homeserver,omitempty"`
+	AccessToken string `json:"access_token,omitempty"`
+	RoomID      string `json:"room_id,omitempty"`
+
+	// Discord
+	WebhookURL string `json:"webhook_url,omitempty"`
+	Username   string `json:"username,omitempty"`
+
+	// IRC
+	Server   string `json:"server,omitempty"` // host:port
+	TLS      bool   `json:"tls,omitempty"`
+	Nick     string `json:"nick,omitempty"`
+	Password string `json:"password,omitempty"` // Server password (PASS)
+	Target   string `json:"target,omitempty"`   // #channel to join, or a nick to message
+
+	// Messages beyond the limit are dropped and counted in a later message
+	RatePerMinute float64 `json:"rate_per_minute,omitempty"` // Defaults to 20
+	Burst         int     `json:"burst,omitempty"`           // Defaults to 5
+}
+
+// RouteConfig sends events passing its filter to a channel. An event goes to each
+// channel at most once, rendered with the first matching route of that channel.
+type RouteConfig struct {
+	Channel string `json:"channel"`
+	models.EventFilter
+	Template string `json:"template,omitempty"` // Overrides the template of the event type
+}
+
+// LoadConfig reads and validates a notifier configuration file
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// Validate checks channels, routes and templates
+func (c *Config) Validate() error {
+	for name, ch := range c.Channels {
+		if err := ch.validate(); err != nil {
+			return fmt.Errorf("channel %q: %w", name, err)
+		}
+	}
+
+	for i, route := range c.Routes {
+		if _, ok := c.Channels[route.Channel]; !ok {
+			return fmt.Errorf("route %d: unknown channel %q", i, route.Channel)
+		}
+		for _, e := range route.Events {
+			if !models.WikiEventType(e).Valid() {
+				return fmt.Errorf("route %d: unknown event type %q", i, e)
+			}
+		}
+		if route.Template != "" {
+			if _, err := parseTemplate(fmt.Sprintf("route %d", i), route.Template); err != nil {
+				return err
+			}
+		}
+	}
+
+	for eventType, text := range c.Templates {
+		if !models.WikiEventType(eventType).Valid() {
+			return fmt.Errorf("template for unknown event type %q", eventType)
+		}
+		if _, err := parseTemplate(eventType, text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ChannelConfig) validate() error {
+	if c.RatePerMinute < 0 || c.Burst < 0 {
+		return fmt.Errorf("rate_per_minute and burst must not be negative")
+	}
+
+	var missing string
+	switch c.Type {
+	case TypeMatrix:
+		switch {
+		case c.Homeserver == "":
+			missing = "homeserver"
+		case c.AccessToken == "":
+			missing = "access_token"
+		case c.RoomID == "":
+			missing = "room_id"
+		}
+	case TypeDiscord:
+		if c.WebhookURL == "" {
+			missing = "webhook_url"
+		}
+	case TypeIRC:
+		switch {
+		case c.Server == "":
+			missing = "server"
+		case c.Nick == "":
+			missing = "nick"
+		case c.Target == "":
+			missing = "target"
+		}
+	default:
+		return fmt.Errorf("unknown type %q (want matrix, discord or irc)", c.Type)
+	}
+	if missing != "" {
+		return fmt.Errorf("%s is required for %s", missing, c.Type)
+	}
+	return nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,120 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxErrorBody bounds how much of an error response is quoted in errors
+const maxErrorBody = 512
+
+// Matrix posts m.notice messages to a room through the client-server API
+type Matrix struct {
+	client      *http.Client
+	homeserver  string
+	accessToken string
+	roomID      string
+	userAgent   string
+}
+
+// NewMatrix creates a Matrix transport for a room the access token's user has joined
+func NewMatrix(homeserver, accessToken, roomID string, timeout time.Duration, userAgent string) *Matrix {
+	return &Matrix{
+		client:      &http.Client{Timeout: timeout},
+		homeserver:  strings.TrimRight(homeserver, "/"),
+		accessToken: accessToken,
+		roomID:      roomID,
+		userAgent:   userAgent,
+	}
+}
+
+// Send implements Transport
+func (m *Matrix) Send(ctx context.Context, text string) error {
+	// The transaction ID makes retries of the same request idempotent on the homeserver
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		m.homeserver, url.PathEscape(m.roomID), uuid.NewString())
+	body := map[string]string{"msgtype": "m.notice", "body": text}
+	return postJSON(ctx, m.client, http.MethodPut, endpoint, body, map[string]string{
+		"Authorization": "Bearer " + m.accessToken,
+		"User-Agent":    m.userAgent,
+	})
+}
+
+// discordMaxContent is the longest message Discord accepts
+const discordMaxContent = 2000
+
+// Discord posts messages to a channel webhook
+type Discord struct {
+	client     *http.Client
+	webhookURL string
+	username   string
+	userAgent  string
+}
+
+// NewDiscord creates a Discord transport; username overrides the webhook's default name when set
+func NewDiscord(webhookURL, username string, timeout time.Duration, userAgent string) *Discord {
+	return &Discord{
+		client:     &http.Client{Timeout: timeout},
+		webhookURL: webhookURL,
+		username:   username,
+		userAgent:  userAgent,
+	}
+}
+
+// discordMessage is the body of a Discord webhook execution
+type discordMessage struct {
+	Content         string `json:"content"`
+	Username        string `json:"username,omitempty"`
+	AllowedMentions struct {
+		Parse []string `json:"parse"`
+	} `json:"allowed_mentions"`
+}
+
+// Send implements Transport
+func (d *Discord) Send(ctx context.Context, text string) error {
+	if runes := []rune(text); len(runes) > discordMaxContent {
+		text = string(runes[:discordMaxContent-1]) + "…"
+	}
+	// Wiki names are untrusted, so never let them ping @everyone or roles
+	msg := discordMessage{Content: text, Username: d.username}
+	msg.AllowedMentions.Parse = []string{}
+	return postJSON(ctx, d.client, http.MethodPost, d.webhookURL, msg, map[string]string{"User-Agent": d.userAgent})
+}
+
+// postJSON sends body as JSON and fails on non-2xx answers
+func postJSON(ctx context.Context, client *http.Client, method, endpoint string, body interface{}, headers map[string]string) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return nil
+}
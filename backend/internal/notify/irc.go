@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// ircMaxLine bounds the text of one PRIVMSG, leaving room for the prefix the
+// server adds within the 512 byte line limit
+const ircMaxLine = 400
+
+// IRC connects to a server for each message, registers, joins the target channel
+// and sends the message line by line
+type IRC struct {
+	server   string
+	useTLS   bool
+	nick     string
+	password string
+	target   string
+	timeout  time.Duration
+}
+
+// NewIRC creates an IRC transport. target is a #channel to join or a nick to message.
+func NewIRC(server string, useTLS bool, nick, password, target string, timeout time.Duration) *IRC {
+	return &IRC{server: server, useTLS: useTLS, nick: nick, password: password, target: target, timeout: timeout}
+}
+
+// Send implements Transport
+func (c *IRC) Send(ctx context.Context, text string) error {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var conn net.Conn
+	var err error
+	if c.useTLS {
+		host, _, _ := net.SplitHostPort(c.server)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", c.server)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.server)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(c.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	session := &ircSession{conn: conn, reader: bufio.NewReader(conn)}
+	if err := session.register(c.nick, c.password); err != nil {
+		return err
+	}
+	if isIRCChannel(c.target) {
+		if err := session.join(c.target); err != nil {
+			return err
+		}
+	}
+	for _, line := range ircLines(text) {
+		if err := session.write("PRIVMSG " + c.target + " :" + line); err != nil {
+			return err
+		}
+	}
+	return session.write("QUIT :done")
+}
+
+// ircSession is one connection to an IRC server
+type ircSession struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (s *ircSession) write(line string) error {
+	_, err := fmt.Fprintf(s.conn, "%s\r\n", line)
+	return err
+}
+
+// read returns the next message other than PING, which it answers
+func (s *ircSession) read() (prefix, command string, params []string, err error) {
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			return "", "", nil, err
+		}
+		prefix, command, params = parseIRCLine(strings.TrimRight(line, "\r\n"))
+		if command == "PING" {
+			if err := s.write("PONG :" + strings.Join(params, " ")); err != nil {
+				return "", "", nil, err
+			}
+			continue
+		}
+		return prefix, command, params, nil
+	}
+}
+
+// register sends the connection registration and waits for the welcome numeric,
+// appending underscores to the nick while it is in use
+func (s *ircSession) register(nick, password string) error {
+	if password != "" {
+		if err := s.write("PASS " + password); err != nil {
+			return err
+		}
+	}
+	if err := s.write("NICK " + nick); err != nil {
+		return err
+	}
+	if err := s.write("USER " + nick + " 0 * :WikiKeeper"); err != nil {
+		return err
+	}
+
+	for {
+		_, command, params, err := s.read()
+		if err != nil {
+			return fmt.Errorf("IRC registration: %w", err)
+		}
+		switch {
+		case command == "001":
+			return nil
+		case command == "433": // ERR_NICKNAMEINUSE
+			nick += "_"
+			if err := s.write("NICK " + nick); err != nil {
+				return err
+			}
+		case command == "ERROR" || isIRCError(command):
+			return fmt.Errorf("IRC registration: %s %s", command, strings.Join(params, " "))
+		}
+	}
+}
+
+// join joins a channel and waits for the end of its names list
+func (s *ircSession) join(target string) error {
+	if err := s.write("JOIN " + target); err != nil {
+		return err
+	}
+	for {
+		_, command, params, err := s.read()
+		if err != nil {
+			return fmt.Errorf("IRC join %s: %w", target, err)
+		}
+		switch {
+		case command == "366": // RPL_ENDOFNAMES
+			return nil
+		case command == "ERROR" || isIRCError(command):
+			return fmt.Errorf("IRC join %s: %s %s", target, command, strings.Join(params, " "))
+		}
+	}
+}
+
+// parseIRCLine splits a message into its prefix, command and parameters
+func parseIRCLine(line string) (prefix, command string, params []string) {
+	if strings.HasPrefix(line, ":") {
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			return line[1:], "", nil
+		}
+		prefix, line = line[1:end], line[end+1:]
+	}
+	trailing := ""
+	hasTrailing := false
+	if i := strings.Index(line, " :"); i >= 0 {
+		trailing, hasTrailing = line[i+2:], true
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return prefix, "", nil
+	}
+	command, params = fields[0], fields[1:]
+	if hasTrailing {
+		params = append(params, trailing)
+	}
+	return prefix, command, params
+}
+
+// isIRCError reports whether command is an error numeric (400-599)
+func isIRCError(command string) bool {
+	return len(command) == 3 && (command[0] == '4' || command[0] == '5') &&
+		command[1] >= '0' && command[1] <= '9' && command[2] >= '0' && command[2] <= '9'
+}
+
+func isIRCChannel(target string) bool {
+	return strings.HasPrefix(target, "#") || strings.HasPrefix(target, "&")
+}
+
+// ircLines splits text into non-empty lines of at most ircMaxLine bytes
+func ircLines(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\r", ""))
+		for len(line) > ircMaxLine {
+			cut := ircMaxLine
+			for cut > 0 && !isRuneStart(line[cut]) {
+				cut--
+			}
+			lines = append(lines, line[:cut])
+			line = line[cut:]
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
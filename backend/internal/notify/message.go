@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"strings"
+	"text/template"
+
+	"wikikeeper-backend/internal/models"
+)
+
+// Message is the data message templates are executed with
+type Message struct {
+	Event      *models.WikiEvent
+	Wiki       *models.Wiki // Nil when the wiki no longer exists
+	Tags       []string
+	Name       string // Sitename of the wiki, falling back to its URL
+	URL        string // Base URL of the wiki
+	ArchiveURL string // Archive.org item of archive events
+}
+
+// defaultTemplates are the built-in message templates by event type
+var defaultTemplates = map[models.WikiEventType]string{
+	models.WikiEventAdded:          `New wiki: {{.Name}} {{.URL}}`,
+	models.WikiEventStatusChanged:  `{{.Name}} changed status from {{.Event.FromStatus}} to {{.Event.ToStatus}} {{.URL}}`,
+	models.WikiEventWentOffline:    `{{.Name}} went offline ({{.Event.ToStatus}}){{with .Event.Message}}: {{.}}{{end}} {{.URL}}`,
+	models.WikiEventRecovered:      `{{.Name}} is back online {{.URL}}`,
+	models.WikiEventReadOnly:       `{{.Name}} is now read-only{{with .Event.Message}}: {{.}}{{end}} {{.URL}}`,
+	models.WikiEventWritable:       `{{.Name}} is writable again {{.URL}}`,
+	models.WikiEventArchiveFound:   `New Archive.org dump of {{.Name}}: {{.ArchiveURL}}`,
+	models.WikiEventArchiveRemoved: `Archive.org dump of {{.Name}} is no longer found: {{.ArchiveURL}}`,
+	models.WikiEventAnomaly:        `Anomaly on {{.Name}}: {{.Event.Message}} {{.URL}}`,
+	models.WikiEventMerged:         `{{.Name}}: {{.Event.Message}}`,
+}
+
+// fallbackTemplate renders event types without a template
+const fallbackTemplate = `{{.Name}}: {{.Event.Type}} {{.URL}}`
+
+// templates resolves the template of an event type: configured, then built-in
+type templates map[models.WikiEventType]*template.Template
+
+func newTemplates(overrides map[string]string) (templates, error) {
+	resolved := make(templates)
+	for eventType, text := range defaultTemplates {
+		tmpl, err := parseTemplate(string(eventType), text)
+		if err != nil {
+			return nil, err
+		}
+		resolved[eventType] = tmpl
+	}
+	for eventType, text := range overrides {
+		tmpl, err := parseTemplate(eventType, text)
+		if err != nil {
+			return nil, err
+		}
+		resolved[models.WikiEventType(eventType)] = tmpl
+	}
+	return resolved, nil
+}
+
+var fallback = template.Must(template.New("fallback").Parse(fallbackTemplate))
+
+func (t templates) get(eventType models.WikiEventType) *template.Template {
+	if tmpl, ok := t[eventType]; ok {
+		return tmpl
+	}
+	return fallback
+}
+
+// newMessage builds the template data of an event
+func newMessage(event *models.WikiEvent, wiki *models.Wiki, tags []string) *Message {
+	msg := &Message{Event: event, Wiki: wiki, Tags: tags, Name: event.WikiID.String()}
+	if wiki != nil {
+		msg.URL = wiki.URL
+		msg.Name = wiki.URL
+		if wiki.Sitename != nil && *wiki.Sitename != "" {
+			msg.Name = *wiki.Sitename
+		}
+	}
+	if event.IAIdentifier != nil {
+		msg.ArchiveURL = "https://archive.org/details/" + *event.IAIdentifier
+	}
+	return msg
+}
+
+// render executes tmpl and trims the result
+func render(tmpl *template.Template, msg *Message) (string, error) {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, msg); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
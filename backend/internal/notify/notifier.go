@@ -0,0 +1,288 @@
+// Package notify posts wiki events to chat channels on Matrix, Discord and IRC.
+//
+// Events recorded by the collectors and the archive service are routed to channels
+// by the routes of the configuration file, rendered with text/template and sent
+// under a per-channel rate limit so a large sweep cannot flood a room.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// Transport sends a rendered message to one chat destination
+type Transport interface {
+	Send(ctx context.Context, text string) error
+}
+
+const (
+	eventQueueSize  = 1000             // Events waiting to be routed
+	outboxSize      = 100              // Messages waiting per channel
+	summaryInterval = 30 * time.Second // How often suppressed messages are reported
+)
+
+// Notifier routes wiki events to chat channels
+type Notifier struct {
+	db        *gorm.DB
+	routes    []route
+	channels  []*channel
+	templates templates
+	timeout   time.Duration
+
+	events chan *models.WikiEvent
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// route is a configured route bound to its channel
+type route struct {
+	filter   models.EventFilter
+	channel  *channel
+	template *template.Template // Nil uses the template of the event type
+}
+
+// channel is a chat destination with its rate limit and outbox
+type channel struct {
+	name       string
+	transport  Transport
+	limiter    *rate.Limiter
+	outbox     chan string
+	suppressed atomic.Int64 // Messages dropped by the rate limit or a full outbox since the last report
+	now        func() time.Time
+}
+
+// New creates a notifier from cfg. Requests to chat services time out after timeout.
+func New(db *gorm.DB, cfg *Config, timeout time.Duration, userAgent string) (*Notifier, error) {
+	transports := make(map[string]Transport, len(cfg.Channels))
+	for name, ch := range cfg.Channels {
+		transport, err := newTransport(ch, timeout, userAgent)
+		if err != nil {
+			return nil, fmt.Errorf("channel %q: %w", name, err)
+		}
+		transports[name] = transport
+	}
+	return newNotifier(db, cfg, transports, timeout)
+}
+
+func newTransport(cfg ChannelConfig, timeout time.Duration, userAgent string) (Transport, error) {
+	switch cfg.Type {
+	case TypeMatrix:
+		return NewMatrix(cfg.Homeserver, cfg.AccessToken, cfg.RoomID, timeout, userAgent), nil
+	case TypeDiscord:
+		return NewDiscord(cfg.WebhookURL, cfg.Username, timeout, userAgent), nil
+	case TypeIRC:
+		return NewIRC(cfg.Server, cfg.TLS, cfg.Nick, cfg.Password, cfg.Target, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown type %q", cfg.Type)
+	}
+}
+
+func newNotifier(db *gorm.DB, cfg *Config, transports map[string]Transport, timeout time.Duration) (*Notifier, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	tmpls, err := newTemplates(cfg.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &Notifier{
+		db:        db,
+		templates: tmpls,
+		timeout:   timeout,
+		events:    make(chan *models.WikiEvent, eventQueueSize),
+		stopCh:    make(chan struct{}),
+	}
+
+	channels := make(map[string]*channel, len(cfg.Channels))
+	for name, chCfg := range cfg.Channels {
+		perMinute, burst := chCfg.RatePerMinute, chCfg.Burst
+		if perMinute == 0 {
+			perMinute = defaultRatePerMinute
+		}
+		if burst == 0 {
+			burst = defaultBurst
+		}
+		ch := &channel{
+			name:      name,
+			transport: transports[name],
+			limiter:   rate.NewLimiter(rate.Limit(perMinute/60), burst),
+			outbox:    make(chan string, outboxSize),
+			now:       time.Now,
+		}
+		channels[name] = ch
+		n.channels = append(n.channels, ch)
+	}
+
+	for _, r := range cfg.Routes {
+		bound := route{filter: r.EventFilter, channel: channels[r.Channel]}
+		if r.Template != "" {
+			if bound.template, err = parseTemplate(r.Channel, r.Template); err != nil {
+				return nil, err
+			}
+		}
+		n.routes = append(n.routes, bound)
+	}
+	return n, nil
+}
+
+// Start begins routing events and sending messages
+func (n *Notifier) Start(ctx context.Context) {
+	n.wg.Add(1)
+	go n.run(ctx)
+	for _, ch := range n.channels {
+		n.wg.Add(1)
+		go n.runChannel(ctx, ch)
+	}
+	applogger.Log.Info("[Notify] Notifier started", "channels", len(n.channels), "routes", len(n.routes))
+}
+
+// Stop stops the notifier; queued messages are dropped
+func (n *Notifier) Stop() {
+	close(n.stopCh)
+	n.wg.Wait()
+}
+
+// Handle queues an event for routing without blocking. It is meant to be
+// registered with services.AddEventListener.
+func (n *Notifier) Handle(event *models.WikiEvent) {
+	select {
+	case n.events <- event:
+	default:
+		applogger.Log.Warn("[Notify] Event queue full, dropping event", "event_id", event.ID, "type", event.Type)
+	}
+}
+
+func (n *Notifier) run(ctx context.Context) {
+	defer n.wg.Done()
+	for {
+		select {
+		case event := <-n.events:
+			n.dispatch(ctx, event)
+		case <-n.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch loads the wiki of an event and routes it
+func (n *Notifier) dispatch(ctx context.Context, event *models.WikiEvent) {
+	wiki, err := repository.NewWikiRepository(n.db).GetByID(ctx, event.WikiID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		applogger.Log.Warn("[Notify] Failed to load wiki", "wiki_id", event.WikiID, "error", err)
+		return
+	}
+
+	var tags []string
+	if wiki != nil {
+		wikiTags, err := repository.NewTagRepository(n.db).ListByWikiID(ctx, wiki.ID)
+		if err != nil {
+			applogger.Log.Warn("[Notify] Failed to load wiki tags", "wiki_id", wiki.ID, "error", err)
+			return
+		}
+		for _, tag := range wikiTags {
+			tags = append(tags, tag.Name)
+		}
+	}
+	n.route(event, wiki, tags)
+}
+
+// route renders an event for every channel with a matching route and queues the messages
+func (n *Notifier) route(event *models.WikiEvent, wiki *models.Wiki, tags []string) {
+	wikiURL := ""
+	if wiki != nil {
+		wikiURL = wiki.URL
+	}
+	msg := newMessage(event, wiki, tags)
+
+	sent := make(map[*channel]bool)
+	for _, r := range n.routes {
+		if sent[r.channel] || !r.filter.Matches(event.Type, wikiURL, tags) {
+			continue
+		}
+		sent[r.channel] = true
+
+		tmpl := r.template
+		if tmpl == nil {
+			tmpl = n.templates.get(event.Type)
+		}
+		text, err := render(tmpl, msg)
+		if err != nil {
+			applogger.Log.Warn("[Notify] Failed to render message", "channel", r.channel.name, "type", event.Type, "error", err)
+			continue
+		}
+		r.channel.enqueue(text)
+	}
+}
+
+func (n *Notifier) runChannel(ctx context.Context, ch *channel) {
+	defer n.wg.Done()
+	ticker := time.NewTicker(summaryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case text := <-ch.outbox:
+			ch.deliver(ctx, text, n.timeout)
+		case <-ticker.C:
+			ch.reportSuppressed(ctx, n.timeout)
+		case <-n.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enqueue queues a message without blocking, counting it as suppressed when the outbox is full
+func (ch *channel) enqueue(text string) {
+	select {
+	case ch.outbox <- text:
+	default:
+		ch.suppressed.Add(1)
+	}
+}
+
+// deliver sends a message if the rate limit allows it, mentioning earlier suppressed messages
+func (ch *channel) deliver(ctx context.Context, text string, timeout time.Duration) {
+	if !ch.limiter.AllowN(ch.now(), 1) {
+		ch.suppressed.Add(1)
+		return
+	}
+	if suppressed := ch.suppressed.Swap(0); suppressed > 0 {
+		text += fmt.Sprintf("\n(%d more notifications were suppressed by the rate limit)", suppressed)
+	}
+	ch.send(ctx, text, timeout)
+}
+
+// reportSuppressed sends a summary of suppressed messages once the rate limit allows it
+func (ch *channel) reportSuppressed(ctx context.Context, timeout time.Duration) {
+	if ch.suppressed.Load() == 0 || !ch.limiter.AllowN(ch.now(), 1) {
+		return
+	}
+	if suppressed := ch.suppressed.Swap(0); suppressed > 0 {
+		ch.send(ctx, fmt.Sprintf("%d notifications were suppressed by the rate limit", suppressed), timeout)
+	}
+}
+
+func (ch *channel) send(ctx context.Context, text string, timeout time.Duration) {
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if err := ch.transport.Send(sendCtx, text); err != nil {
+		applogger.Log.Warn("[Notify] Failed to send message", "channel", ch.name, "error", err)
+	}
+}
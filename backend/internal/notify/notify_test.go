@@ -0,0 +1,170 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/models"
+)
+
+// recorder is a Transport keeping the messages it was asked to send
+type recorder struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (r *recorder) Send(ctx context.Context, text string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, text)
+	return nil
+}
+
+// drain delivers everything queued for the channels of n synchronously
+func drain(n *Notifier) {
+	for _, ch := range n.channels {
+		for {
+			select {
+			case text := <-ch.outbox:
+				ch.deliver(context.Background(), text, time.Second)
+				continue
+			default:
+			}
+			break
+		}
+	}
+}
+
+func testWiki() *models.Wiki {
+	sitename := "Test Wiki"
+	return &models.Wiki{ID: uuid.New(), URL: "https://test.miraheze.org", Sitename: &sitename}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "notify.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	cfg, err := LoadConfig(write(`{
+		"channels": {"ops": {"type": "discord", "webhook_url": "https://discord.example/hook"}},
+		"routes": [{"channel": "ops", "events": ["went_offline"], "farm": "miraheze.org"}],
+		"templates": {"recovered": "{{.Name}} is back"}
+	}`))
+	require.NoError(t, err)
+	require.Len(t, cfg.Routes, 1)
+	assert.Equal(t, models.StringList{"went_offline"}, cfg.Routes[0].Events)
+	require.NotNil(t, cfg.Routes[0].Farm)
+
+	for name, content := range map[string]string{
+		"unknown field":   `{"channels": {}, "route": []}`,
+		"unknown type":    `{"channels": {"x": {"type": "slack"}}}`,
+		"missing setting": `{"channels": {"x": {"type": "matrix", "homeserver": "https://m.example"}}}`,
+		"unknown channel": `{"channels": {}, "routes": [{"channel": "nope"}]}`,
+		"unknown event":   `{"channels": {"x": {"type": "discord", "webhook_url": "u"}}, "routes": [{"channel": "x", "events": ["exploded"]}]}`,
+		"bad template":    `{"channels": {}, "templates": {"recovered": "{{.Name"}}`,
+	} {
+		_, err := LoadConfig(write(content))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestNotifier_Route(t *testing.T) {
+	tag := "at-risk"
+	farm := "miraheze.org"
+	cfg := &Config{
+		Channels: map[string]ChannelConfig{
+			"ops":     {Type: TypeDiscord, WebhookURL: "u"},
+			"archive": {Type: TypeDiscord, WebhookURL: "u"},
+		},
+		Routes: []RouteConfig{
+			{Channel: "ops", EventFilter: models.EventFilter{Events: models.StringList{"went_offline"}, Tag: &tag}},
+			{Channel: "ops", EventFilter: models.EventFilter{Farm: &farm}, Template: "farm: {{.Event.Type}}"},
+			{Channel: "archive", EventFilter: models.EventFilter{Events: models.StringList{"archive_found", "recovered"}}},
+		},
+		Templates: map[string]string{"recovered": "{{.Name}} recovered, tags {{range .Tags}}{{.}} {{end}}"},
+	}
+	ops, archive := &recorder{}, &recorder{}
+	n, err := newNotifier(nil, cfg, map[string]Transport{"ops": ops, "archive": archive}, time.Second)
+	require.NoError(t, err)
+
+	wiki := testWiki()
+	to := models.WikiStatusOffline
+	reason := "connection refused"
+
+	// Both ops routes match, but a channel gets each event once, from its first matching route
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventWentOffline, ToStatus: &to, Message: &reason}, wiki, []string{"at-risk"})
+	// Only the farm route matches, with its own template
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventAnomaly}, wiki, nil)
+	// The configured template of the event type replaces the built-in one
+	other := &models.Wiki{ID: uuid.New(), URL: "https://other.example.org"}
+	n.route(&models.WikiEvent{WikiID: other.ID, Type: models.WikiEventRecovered}, other, []string{"gaming"})
+	identifier := "wiki-test-20240101"
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventArchiveFound, IAIdentifier: &identifier}, wiki, nil)
+	// Deleted wikis still render
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventArchiveFound, IAIdentifier: &identifier}, nil, nil)
+	drain(n)
+
+	assert.Equal(t, []string{
+		"Test Wiki went offline (offline): connection refused https://test.miraheze.org",
+		"farm: anomaly",
+		"farm: archive_found",
+	}, ops.messages)
+	assert.Equal(t, []string{
+		"https://other.example.org recovered, tags gaming",
+		"New Archive.org dump of Test Wiki: https://archive.org/details/wiki-test-20240101",
+		"New Archive.org dump of " + wiki.ID.String() + ": https://archive.org/details/wiki-test-20240101",
+	}, archive.messages)
+}
+
+func TestNotifier_RateLimit(t *testing.T) {
+	cfg := &Config{
+		Channels: map[string]ChannelConfig{"ops": {Type: TypeDiscord, WebhookURL: "u", RatePerMinute: 1, Burst: 2}},
+		Routes:   []RouteConfig{{Channel: "ops"}},
+	}
+	ops := &recorder{}
+	n, err := newNotifier(nil, cfg, map[string]Transport{"ops": ops}, time.Second)
+	require.NoError(t, err)
+
+	now := time.Now()
+	ch := n.channels[0]
+	ch.now = func() time.Time { return now }
+
+	wiki := testWiki()
+	for i := 0; i < 5; i++ {
+		n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventRecovered}, wiki, nil)
+	}
+	drain(n)
+	assert.Len(t, ops.messages, 2, "the burst is sent, the rest suppressed")
+	assert.Equal(t, int64(3), ch.suppressed.Load())
+
+	// Nothing is reported while the limit is exhausted
+	ch.reportSuppressed(context.Background(), time.Second)
+	assert.Len(t, ops.messages, 2)
+
+	// Once a token is back, the next message mentions what was dropped
+	now = now.Add(time.Minute)
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventRecovered}, wiki, nil)
+	drain(n)
+	require.Len(t, ops.messages, 3)
+	assert.Contains(t, ops.messages[2], "(3 more notifications were suppressed by the rate limit)")
+	assert.Zero(t, ch.suppressed.Load())
+
+	// Without further events, the periodic report sends the count on its own
+	n.route(&models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventRecovered}, wiki, nil)
+	drain(n)
+	now = now.Add(time.Minute)
+	ch.reportSuppressed(context.Background(), time.Second)
+	require.Len(t, ops.messages, 4)
+	assert.Equal(t, "1 notifications were suppressed by the rate limit", ops.messages[3])
+}
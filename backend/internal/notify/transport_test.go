@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrix_Send(t *testing.T) {
+	var path, auth string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		path = r.URL.EscapedPath()
+		auth = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		if body["body"] == "fail" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errcode":"M_FORBIDDEN"}`))
+			return
+		}
+		w.Write([]byte(`{"event_id":"$1"}`))
+	}))
+	defer server.Close()
+
+	matrix := NewMatrix(server.URL+"/", "token", "!room:example.org", time.Second, "WikiKeeper-Test")
+	require.NoError(t, matrix.Send(context.Background(), "Test Wiki went offline"))
+	assert.True(t, strings.HasPrefix(path, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/"), path)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, map[string]string{"msgtype": "m.notice", "body": "Test Wiki went offline"}, body)
+
+	err := matrix.Send(context.Background(), "fail")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "M_FORBIDDEN")
+}
+
+func TestDiscord_Send(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	discord := NewDiscord(server.URL, "WikiKeeper", time.Second, "WikiKeeper-Test")
+	require.NoError(t, discord.Send(context.Background(), "@everyone "+strings.Repeat("x", 3000)))
+	assert.Equal(t, "WikiKeeper", body["username"])
+	assert.Len(t, []rune(body["content"].(string)), discordMaxContent)
+	assert.Equal(t, map[string]interface{}{"parse": []interface{}{}}, body["allowed_mentions"])
+}
+
+// ircServer is a minimal IRC server accepting one client and recording what it sends
+type ircServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	lines    []string
+	done     chan struct{}
+}
+
+func newIRCServer(t *testing.T, takenNick string) *ircServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &ircServer{listener: listener, done: make(chan struct{})}
+
+	go func() {
+		defer close(s.done)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reply := func(format string, args ...interface{}) { fmt.Fprintf(conn, format+"\r\n", args...) }
+
+		reader := bufio.NewReader(conn)
+		nick := ""
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			s.mu.Lock()
+			s.lines = append(s.lines, line)
+			s.mu.Unlock()
+
+			_, command, params := parseIRCLine(line)
+			switch command {
+			case "NICK":
+				if params[0] == takenNick {
+					reply(":irc.test 433 * %s :Nickname is already in use", params[0])
+					continue
+				}
+				nick = params[0]
+			case "USER":
+				reply("PING :irc.test")
+			case "PONG":
+				reply(":irc.test 001 %s :Welcome", nick)
+			case "JOIN":
+				reply(":%s!u@h JOIN %s", nick, params[0])
+				reply(":irc.test 366 %s %s :End of /NAMES list.", nick, params[0])
+			case "QUIT":
+				return
+			}
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *ircServer) received() []string {
+	<-s.done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lines
+}
+
+func TestIRC_Send(t *testing.T) {
+	server := newIRCServer(t, "wikikeeper")
+	irc := NewIRC(server.listener.Addr().String(), false, "wikikeeper", "secret", "#saveweb", 5*time.Second)
+
+	require.NoError(t, irc.Send(context.Background(), "Test Wiki went offline\r\n\nhttps://test.example.org"))
+	assert.Equal(t, []string{
+		"PASS secret",
+		"NICK wikikeeper",
+		"USER wikikeeper 0 * :WikiKeeper",
+		"NICK wikikeeper_",
+		"PONG :irc.test",
+		"JOIN #saveweb",
+		"PRIVMSG #saveweb :Test Wiki went offline",
+		"PRIVMSG #saveweb :https://test.example.org",
+		"QUIT :done",
+	}, server.received())
+}
+
+func TestIRC_SendRejected(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(conn, ":irc.test 465 * :You are banned from this server\r\n")
+		bufio.NewReader(conn).ReadString('\n')
+	}()
+
+	irc := NewIRC(listener.Addr().String(), false, "wikikeeper", "", "#saveweb", 5*time.Second)
+	err = irc.Send(context.Background(), "hello")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "banned")
+}
+
+func TestIRCLines(t *testing.T) {
+	long := strings.Repeat("é", ircMaxLine) // Two bytes per rune
+	lines := ircLines("first\n\n" + long)
+	require.Len(t, lines, 3)
+	assert.Equal(t, "first", lines[0])
+	assert.Len(t, lines[1], ircMaxLine)
+	assert.Equal(t, long, lines[1]+lines[2], "lines are split on rune boundaries")
+}
@@ -0,0 +1,258 @@
+// Package oidc signs users in with an OpenID Connect provider, using the
+// authorization code flow with PKCE, and maps their groups to roles.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config describes the provider and this client's registration with it
+type Config struct {
+	Issuer       string // Issuer URL; its /.well-known/openid-configuration is used for discovery
+	ClientID     string
+	ClientSecret string   // Empty for public clients, which rely on PKCE alone
+	RedirectURL  string   // URL of /api/auth/oidc/callback as registered with the provider
+	Scopes       []string // Requested scopes; openid is always included
+	GroupsClaim  string   // ID token claim listing the user's groups, "groups" by default
+	Roles        RoleMapping
+}
+
+// Enabled reports whether an issuer and client are configured
+func (c Config) Enabled() bool {
+	return c.Issuer != "" && c.ClientID != ""
+}
+
+// ErrInvalidToken is returned for ID tokens that fail verification
+var ErrInvalidToken = errors.New("invalid ID token")
+
+// discoveryTTL bounds how long provider metadata and keys are cached
+const discoveryTTL = time.Hour
+
+// metadata is the part of the provider's discovery document the client uses
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Client talks to one provider. Discovery and keys are fetched on first use
+// and cached, so a provider that is down does not stop the server starting.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu        sync.Mutex
+	meta      *metadata
+	keys      map[string]interface{} // Public keys by kid
+	fetchedAt time.Time
+}
+
+// NewClient creates a client; httpClient may be nil for a default with a 10 second timeout
+func NewClient(cfg Config, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if cfg.GroupsClaim == "" {
+		cfg.GroupsClaim = "groups"
+	}
+	return &Client{cfg: cfg, http: httpClient}
+}
+
+// User is the person an ID token was issued for
+type User struct {
+	Subject string // Issuer and subject, unique across providers
+	Name    string // Preferred username, name or email, for display and the audit log
+	Email   string
+	Groups  []string
+	Roles   []string // WikiKeeper roles the groups map to
+}
+
+// NewPKCE returns a random code verifier and its S256 challenge
+func NewPKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthCodeURL returns the provider URL to send the browser to
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(meta.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", c.cfg.ClientID)
+	q.Set("redirect_uri", c.cfg.RedirectURL)
+	q.Set("scope", strings.Join(c.scopes(), " "))
+	q.Set("state", state)
+	q.Set("nonce", nonce)
+	q.Set("code_challenge", challenge)
+	q.Set("code_challenge_method", "S256")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (c *Client) scopes() []string {
+	scopes := []string{"openid"}
+	for _, scope := range c.cfg.Scopes {
+		if scope != "openid" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// Exchange redeems an authorization code and returns the verified user of its ID token
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (*User, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {c.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request failed: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.Unmarshal(body, &tokens); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	claims, err := c.Verify(ctx, tokens.IDToken, nonce, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return c.user(claims), nil
+}
+
+// user reads the user of verified claims
+func (c *Client) user(claims map[string]interface{}) *User {
+	str := func(name string) string {
+		s, _ := claims[name].(string)
+		return s
+	}
+	user := &User{
+		Subject: str("iss") + "#" + str("sub"),
+		Email:   str("email"),
+		Groups:  stringList(claims[c.cfg.GroupsClaim]),
+	}
+	for _, name := range []string{"preferred_username", "name", "email", "sub"} {
+		if user.Name = str(name); user.Name != "" {
+			break
+		}
+	}
+	user.Roles = c.cfg.Roles.Roles(user.Groups)
+	return user
+}
+
+// stringList reads a claim holding a list of strings, or a single string
+func stringList(claim interface{}) []string {
+	switch v := claim.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// metadata returns the provider's discovery document, fetching it when not cached
+func (c *Client) metadata(ctx context.Context) (*metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil && time.Since(c.fetchedAt) < discoveryTTL {
+		return c.meta, nil
+	}
+
+	var meta metadata
+	wellKnown := strings.TrimSuffix(c.cfg.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, wellKnown, &meta); err != nil {
+		return nil, fmt.Errorf("OIDC discovery failed: %w", err)
+	}
+	if meta.Issuer != c.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC discovery failed: issuer %q does not match %q", meta.Issuer, c.cfg.Issuer)
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return nil, errors.New("OIDC discovery failed: endpoints missing from the discovery document")
+	}
+	c.meta = &meta
+	c.keys = nil
+	c.fetchedAt = time.Now()
+	return c.meta, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, target string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: HTTP %d", target, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
@@ -0,0 +1,117 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"wikikeeper-backend/internal/oidc/oidctest"
+)
+
+// authorize follows the provider's authorization redirect and returns the code and state
+func authorize(t *testing.T, authURL string) (code, state string) {
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func TestClient_Flow(t *testing.T) {
+	idp := oidctest.NewProvider("wikikeeper")
+	defer idp.Close()
+	idp.ClientSecret = "s3cret"
+	idp.SetUser(oidctest.User{Subject: "u1", Name: "alice", Email: "alice@example.org", Groups: []string{"staff", "archivists"}})
+
+	client := NewClient(Config{
+		Issuer:       idp.URL,
+		ClientID:     "wikikeeper",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://api.example.org/api/auth/oidc/callback",
+		Scopes:       []string{"openid", "profile", "groups"},
+		Roles:        RoleMapping{RoleCurator: {"archivists"}, RoleAdmin: {"sysops"}},
+	}, nil)
+	ctx := context.Background()
+
+	verifier, challenge, err := NewPKCE()
+	require.NoError(t, err)
+	authURL, err := client.AuthCodeURL(ctx, "state-1", "nonce-1", challenge)
+	require.NoError(t, err)
+	assert.Contains(t, authURL, "scope=openid+profile+groups")
+
+	code, state := authorize(t, authURL)
+	assert.Equal(t, "state-1", state)
+
+	_, err = client.Exchange(ctx, code, "wrong-verifier", "nonce-1")
+	assert.Error(t, err, "the code is bound to the PKCE challenge")
+
+	code, _ = authorize(t, authURL)
+	_, err = client.Exchange(ctx, code, verifier, "other-nonce")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	code, _ = authorize(t, authURL)
+	user, err := client.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"#u1", user.Subject)
+	assert.Equal(t, "alice", user.Name)
+	assert.Equal(t, "alice@example.org", user.Email)
+	assert.Equal(t, []string{RoleCurator}, user.Roles)
+}
+
+func TestClient_Verify(t *testing.T) {
+	idp := oidctest.NewProvider("wikikeeper")
+	defer idp.Close()
+	client := NewClient(Config{Issuer: idp.URL, ClientID: "wikikeeper"}, nil)
+	ctx := context.Background()
+	now := time.Now()
+
+	claims := func(change func(map[string]interface{})) string {
+		c := map[string]interface{}{
+			"iss": idp.URL, "sub": "u1", "aud": "wikikeeper", "nonce": "n",
+			"exp": now.Add(time.Hour).Unix(), "iat": now.Unix(),
+		}
+		if change != nil {
+			change(c)
+		}
+		token, err := idp.Sign(c)
+		require.NoError(t, err)
+		return token
+	}
+
+	_, err := client.Verify(ctx, claims(nil), "n", now)
+	assert.NoError(t, err)
+	_, err = client.Verify(ctx, claims(func(c map[string]interface{}) { c["aud"] = []string{"wikikeeper", "other"}; c["azp"] = "wikikeeper" }), "n", now)
+	assert.NoError(t, err)
+
+	for name, change := range map[string]func(map[string]interface{}){
+		"issuer":   func(c map[string]interface{}) { c["iss"] = "https://evil.example" },
+		"audience": func(c map[string]interface{}) { c["aud"] = "other" },
+		"azp":      func(c map[string]interface{}) { c["aud"] = []string{"wikikeeper", "other"} },
+		"expired":  func(c map[string]interface{}) { c["exp"] = now.Add(-time.Hour).Unix() },
+		"future":   func(c map[string]interface{}) { c["iat"] = now.Add(time.Hour).Unix() },
+		"nonce":    func(c map[string]interface{}) { c["nonce"] = "m" },
+		"subject":  func(c map[string]interface{}) { delete(c, "sub") },
+	} {
+		_, err := client.Verify(ctx, claims(change), "n", now)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	token := claims(nil)
+	_, err = client.Verify(ctx, token[:len(token)-4]+"AAAA", "n", now)
+	assert.ErrorIs(t, err, ErrInvalidToken, "tampered signature")
+}
+
+func TestRoleMapping(t *testing.T) {
+	mapping := RoleMapping{RoleViewer: {"volunteers"}, RoleCurator: {"archivists"}, RoleAdmin: {"sysops"}}
+	assert.Nil(t, mapping.Roles([]string{"unrelated"}))
+	assert.Equal(t, []string{RoleViewer}, mapping.Roles([]string{"volunteers"}))
+	assert.Equal(t, []string{RoleViewer, RoleAdmin}, mapping.Roles([]string{"sysops", "volunteers"}))
+}
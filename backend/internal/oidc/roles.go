@@ -0,0 +1,32 @@
+package oidc
+
+// WikiKeeper roles, from least to most privileged
+const (
+	RoleViewer  = "viewer"  // Sees the admin views without changing anything
+	RoleCurator = "curator" // Curates, collects, trashes and merges wikis
+	RoleAdmin   = "admin"   // Everything, including webhooks, API tokens and the audit log
+)
+
+// Roles lists every role, from least to most privileged
+var Roles = []string{RoleViewer, RoleCurator, RoleAdmin}
+
+// RoleMapping lists the provider groups granting each role
+type RoleMapping map[string][]string
+
+// Roles returns the roles granted by groups, from least to most privileged
+func (m RoleMapping) Roles(groups []string) []string {
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+	var roles []string
+	for _, role := range Roles {
+		for _, group := range m[role] {
+			if member[group] {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	return roles
+}
@@ -0,0 +1,328 @@
+package openapi
+
+import (
+	"database/sql"
+	_ "embed"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Version is the OpenAPI specification version documents are written against
+const Version = "3.0.3"
+
+// DocsHTML is the self-contained interactive viewer served at /docs
+//
+//go:embed docs.html
+var DocsHTML string
+
+// Document is an OpenAPI 3 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []Tag               `json:"tags,omitempty"`
+}
+
+// Info holds document metadata
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Tag groups operations in the viewer
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to operations
+type PathItem map[string]*Operation
+
+// Operation describes a single route
+type Operation struct {
+	Summary     string                `json:"summary"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter describes a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response by status code
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes an authentication method
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	Description  string `json:"description,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Schema is a subset of the OpenAPI schema object
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// New creates an empty document
+func New(title, version, description string) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version, Description: description},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+		},
+	}
+}
+
+// AddSchema registers a named component schema derived from v and returns a reference to it
+func (d *Document) AddSchema(name string, v interface{}) *Schema {
+	d.Components.Schemas[name] = SchemaOf(v)
+	return Ref(name)
+}
+
+// AddOperation registers an operation. Echo-style path parameters (":id") are
+// converted to OpenAPI templates ("{id}") and declared automatically.
+func (d *Document) AddOperation(method, path string, op *Operation) {
+	specPath, params := ConvertPath(path)
+	for _, name := range params {
+		op.Parameters = append([]Parameter{{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		}}, op.Parameters...)
+	}
+	if op.Responses == nil {
+		op.Responses = map[string]Response{}
+	}
+
+	item, ok := d.Paths[specPath]
+	if !ok {
+		item = PathItem{}
+		d.Paths[specPath] = item
+	}
+	item[strings.ToLower(method)] = op
+}
+
+// HasOperation reports whether the document describes method on an Echo-style path
+func (d *Document) HasOperation(method, path string) bool {
+	specPath, _ := ConvertPath(path)
+	item, ok := d.Paths[specPath]
+	if !ok {
+		return false
+	}
+	_, ok = item[strings.ToLower(method)]
+	return ok
+}
+
+// ConvertPath converts an Echo route path to an OpenAPI path template
+// and returns the names of its path parameters
+func ConvertPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			name := segment[1:]
+			params = append(params, name)
+			segments[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// Ref returns a reference to a component schema
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// ArrayOf returns an array schema of items
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: "array", Items: items}
+}
+
+// Object returns an object schema with the given properties
+func Object(properties map[string]*Schema) *Schema {
+	return &Schema{Type: "object", Properties: properties}
+}
+
+// JSONBody returns a JSON request body with the given schema
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{
+		Required: true,
+		Content:  map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// JSONResponse returns a JSON response with the given schema
+func JSONResponse(description string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{"application/json": {Schema: schema}},
+	}
+}
+
+// ContentResponse returns a response of the given content type
+func ContentResponse(description, contentType string, schema *Schema) Response {
+	return Response{
+		Description: description,
+		Content:     map[string]MediaType{contentType: {Schema: schema}},
+	}
+}
+
+// QueryParams derives query parameters from the `query` tags of a request struct
+func QueryParams(v interface{}) []Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		// Embedded request structs contribute their own parameters
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("query") == "" {
+			params = append(params, QueryParams(reflect.New(field.Type).Elem().Interface())...)
+			continue
+		}
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := schemaOfType(field.Type)
+		schema.Nullable = false
+		params = append(params, Parameter{
+			Name:   name,
+			In:     "query",
+			Schema: schema,
+		})
+	}
+	return params
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+	uuidType     = reflect.TypeOf(uuid.UUID{})
+)
+
+// SchemaOf derives a schema from the `json` tags of a Go value
+func SchemaOf(v interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(v))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	if t.Kind() == reflect.Ptr {
+		schema := schemaOfType(t.Elem())
+		schema.Nullable = true
+		return schema
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	}
+	// sql.NullTime and types defined from it, such as gorm.DeletedAt, marshal as a time or null
+	if t.Kind() == reflect.Struct && t.ConvertibleTo(nullTimeType) {
+		return &Schema{Type: "string", Format: "date-time", Nullable: true}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaOfType(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem())}
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return &Schema{}
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		// Embedded structs contribute their fields directly, as in encoding/json
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := schemaOfType(field.Type)
+			for name, prop := range embedded.Properties {
+				schema.Properties[name] = prop
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaOfType(field.Type)
+	}
+	return schema
+}
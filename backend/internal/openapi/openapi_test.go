@@ -0,0 +1,87 @@
+package openapi
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertPath(t *testing.T) {
+	path, params := ConvertPath("/api/datasets/:version/:file")
+	assert.Equal(t, "/api/datasets/{version}/{file}", path)
+	assert.Equal(t, []string{"version", "file"}, params)
+
+	path, params = ConvertPath("/health")
+	assert.Equal(t, "/health", path)
+	assert.Empty(t, params)
+}
+
+type base struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type sample struct {
+	base
+	Name      string            `json:"name"`
+	Count     int64             `json:"count"`
+	Note      *string           `json:"note"`
+	Tags      []string          `json:"tags"`
+	Labels    map[string]string `json:"labels"`
+	CreatedAt time.Time         `json:"created_at"`
+	DeletedAt sql.NullTime      `json:"deleted_at"`
+	Hidden    string            `json:"-"`
+	internal  string
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(sample{})
+	require.Equal(t, "object", schema.Type)
+
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid"}, schema.Properties["id"])
+	assert.Equal(t, &Schema{Type: "string"}, schema.Properties["name"])
+	assert.Equal(t, &Schema{Type: "integer", Format: "int64"}, schema.Properties["count"])
+	assert.Equal(t, &Schema{Type: "string", Nullable: true}, schema.Properties["note"])
+	assert.Equal(t, ArrayOf(&Schema{Type: "string"}), schema.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "string"}, schema.Properties["labels"].AdditionalProperties)
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created_at"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time", Nullable: true}, schema.Properties["deleted_at"])
+	assert.Len(t, schema.Properties, 8)
+}
+
+func TestAddOperation(t *testing.T) {
+	doc := New("Test", "1.0", "")
+	doc.AddOperation("GET", "/wikis/:id", &Operation{Summary: "Get"})
+
+	require.True(t, doc.HasOperation("GET", "/wikis/:id"))
+	assert.False(t, doc.HasOperation("POST", "/wikis/:id"))
+
+	op := doc.Paths["/wikis/{id}"]["get"]
+	require.Len(t, op.Parameters, 1)
+	assert.Equal(t, "id", op.Parameters[0].Name)
+	assert.Equal(t, "path", op.Parameters[0].In)
+	assert.True(t, op.Parameters[0].Required)
+	assert.NotNil(t, op.Responses)
+}
+
+func TestQueryParams(t *testing.T) {
+	type base struct {
+		Type string `query:"type"`
+	}
+	type request struct {
+		base
+		URL   string `query:"url"`
+		Limit int    `query:"limit"`
+		Other string
+	}
+
+	params := QueryParams(request{})
+	require.Len(t, params, 3)
+	assert.Equal(t, "type", params[0].Name)
+	assert.Equal(t, "url", params[1].Name)
+	assert.Equal(t, "integer", params[2].Schema.Type)
+	assert.Equal(t, "query", params[2].In)
+}
@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"wikikeeper-backend/internal/models"
+)
+
+// DBStore keeps buckets in the rate_limit_buckets table so instances behind a
+// load balancer share them. Each Take locks the row of its bucket.
+type DBStore struct {
+	db *gorm.DB
+}
+
+// NewDBStore creates a database-backed store
+func NewDBStore(db *gorm.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Take implements Store
+func (s *DBStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	now = now.UTC() // updated_at is a TIMESTAMP without time zone
+	var result Result
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var bucket models.RateLimitBucket
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("key = ?", key).
+			Take(&bucket).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Concurrent first requests race to insert; the loser reads the winner's bucket
+			bucket = models.RateLimitBucket{Key: key, Tokens: float64(limit.Burst), UpdatedAt: now}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&bucket).Error; err != nil {
+				return err
+			}
+			err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("key = ?", key).
+				Take(&bucket).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = take(bucket.Tokens, bucket.UpdatedAt, limit, now)
+		return tx.Model(&models.RateLimitBucket{}).
+			Where("key = ?", key).
+			Updates(map[string]interface{}{"tokens": tokens, "updated_at": now}).Error
+	})
+	return result, err
+}
+
+// DeleteIdle forgets buckets untouched since before, which are full by then
+// if before is at least the longest FillTime ago
+func (s *DBStore) DeleteIdle(ctx context.Context, before time.Time) (int64, error) {
+	result := s.db.WithContext(ctx).
+		Where("updated_at < ?", before).
+		Delete(&models.RateLimitBucket{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,178 @@
+// Package ratelimit implements token-bucket rate limits whose buckets live in
+// memory or, for deployments running several instances, in the database.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit allows Requests per Period on average and bursts of up to Burst requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	Burst    int
+}
+
+// ParseLimit parses limits written as requests/period[:burst], such as 60/1m or
+// 30/1h:10. The period may omit its count (60/m). Burst defaults to requests.
+// An empty string or "0" disables the limit and returns the zero Limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Limit{}, nil
+	}
+
+	spec, burstStr, hasBurst := strings.Cut(s, ":")
+	requestsStr, periodStr, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: want requests/period[:burst] such as 60/1m", s)
+	}
+	requests, err := strconv.Atoi(strings.TrimSpace(requestsStr))
+	if err != nil || requests <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: requests must be a positive integer", s)
+	}
+	periodStr = strings.TrimSpace(periodStr)
+	if periodStr != "" && (periodStr[0] < '0' || periodStr[0] > '9') {
+		periodStr = "1" + periodStr
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be a positive duration such as 1m", s)
+	}
+
+	limit := Limit{Requests: requests, Period: period, Burst: requests}
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", s)
+		}
+		limit.Burst = burst
+	}
+	return limit, nil
+}
+
+// Enabled reports whether the limit restricts anything
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0 && l.Burst > 0
+}
+
+// String formats the limit the way ParseLimit reads it
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s:%d", l.Requests, l.Period, l.Burst)
+}
+
+// rate returns the tokens added to a bucket per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// FillTime returns how long an empty bucket takes to fill up. A bucket left
+// alone that long is indistinguishable from a new one and may be forgotten.
+func (l Limit) FillTime() time.Duration {
+	if !l.Enabled() {
+		return 0
+	}
+	return time.Duration(float64(l.Burst) / l.rate() * float64(time.Second))
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed    bool
+	Limit      int           // Bucket size
+	Remaining  int           // Tokens left after this request
+	Reset      time.Duration // Until the bucket is full again
+	RetryAfter time.Duration // Until the next request is allowed; zero when Allowed
+}
+
+// Store keeps the buckets of rate-limited clients
+type Store interface {
+	// Take takes a token from the bucket of key, creating a full bucket for unseen keys
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
+
+// take refills a bucket holding tokens since updated and takes one token from it.
+// It returns the tokens left in the bucket.
+func take(tokens float64, updated time.Time, limit Limit, now time.Time) (float64, Result) {
+	rate := limit.rate()
+	burst := float64(limit.Burst)
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(burst, tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(tokens)
+	result.Reset = seconds((burst - tokens) / rate)
+	return tokens, result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// memoryBucket is a bucket of MemoryStore
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	fullAt  time.Time // When the bucket will be full and can be forgotten
+}
+
+// memorySweepInterval is how often MemoryStore forgets full buckets
+const memorySweepInterval = time.Minute
+
+// MemoryStore keeps buckets in the memory of one process
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*memoryBucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= memorySweepInterval {
+		for k, b := range s.buckets {
+			if !now.Before(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Burst), updated: now}
+		s.buckets[key] = b
+	}
+	tokens, result := take(b.tokens, b.updated, limit, now)
+	b.tokens, b.updated, b.fullAt = tokens, now, now.Add(result.Reset)
+	return result, nil
+}
+
+// Len returns the number of buckets held
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
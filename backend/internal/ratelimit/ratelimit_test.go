@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Limit
+	}{
+		{"", Limit{}},
+		{"0", Limit{}},
+		{"60/1m", Limit{Requests: 60, Period: time.Minute, Burst: 60}},
+		{"60/m", Limit{Requests: 60, Period: time.Minute, Burst: 60}},
+		{"30/1h:10", Limit{Requests: 30, Period: time.Hour, Burst: 10}},
+		{" 5 / 10s : 2 ", Limit{Requests: 5, Period: 10 * time.Second, Burst: 2}},
+	}
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"60", "x/1m", "-1/1m", "60/soon", "60/0s", "60/1m:0", "60/1m:x"} {
+		_, err := ParseLimit(bad)
+		assert.Error(t, err, bad)
+	}
+
+	limit, _ := ParseLimit("30/1h:10")
+	assert.Equal(t, 20*time.Minute, limit.FillTime())
+	assert.Equal(t, "30/1h0m0s:10", limit.String())
+	assert.False(t, Limit{}.Enabled())
+}
+
+// testStore exercises a store with a bucket of 2 that refills one token per second
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+	limit := Limit{Requests: 1, Period: time.Second, Burst: 2}
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	result, err := store.Take(ctx, "a", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Limit)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+
+	result, err = store.Take(ctx, "a", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result, err = store.Take(ctx, "a", limit, now.Add(500*time.Millisecond))
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// Other keys have their own bucket
+	result, err = store.Take(ctx, "b", limit, now)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	// Refilled after a second, but never beyond the burst
+	result, err = store.Take(ctx, "a", limit, now.Add(1500*time.Millisecond))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = store.Take(ctx, "a", limit, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	testStore(t, store)
+
+	// Full buckets are forgotten
+	_, err := store.Take(context.Background(), "c", Limit{Requests: 1, Period: time.Second, Burst: 1}, time.Now().Add(2*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestDBStore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE rate_limit_buckets (
+			key TEXT PRIMARY KEY,
+			tokens REAL NOT NULL,
+			updated_at DATETIME NOT NULL
+		)
+	`).Error)
+
+	store := NewDBStore(db)
+	testStore(t, store)
+
+	deleted, err := store.DeleteIdle(context.Background(), time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "only b was idle")
+}
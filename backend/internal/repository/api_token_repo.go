@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// lastUsedResolution bounds how often the last-used time of a token is written
+const lastUsedResolution = time.Minute
+
+// APITokenRepository handles api_tokens database operations
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAPITokenRepository creates a new API token repository
+func NewAPITokenRepository(db *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Create stores a token
+func (r *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// GetByHash retrieves a token by the hash of its value, including revoked and expired ones
+func (r *APITokenRepository) GetByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.WithContext(ctx).First(&token, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetByID retrieves a token by ID, including revoked and expired ones
+func (r *APITokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.WithContext(ctx).First(&token, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// List returns tokens, newest first; revoked tokens only when includeRevoked is set
+func (r *APITokenRepository) List(ctx context.Context, includeRevoked bool) ([]models.APIToken, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if !includeRevoked {
+		query = query.Where("revoked_at IS NULL")
+	}
+	var tokens []models.APIToken
+	if err := query.Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// Revoke revokes a token; found reports whether an unrevoked token with the ID existed
+func (r *APITokenRepository) Revoke(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", now)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Touch records that a token was used at now, at most once per lastUsedResolution
+func (r *APITokenRepository) Touch(ctx context.Context, id uuid.UUID, now time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, now.Add(-lastUsedResolution)).
+		Update("last_used_at", now).Error
+}
+
+// CountUsable counts tokens that are neither revoked nor expired at now
+func (r *APITokenRepository) CountUsable(ctx context.Context, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.APIToken{}).
+		Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now).
+		Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestAPITokenRepository(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAPITokenRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	expired := now.Add(-time.Hour)
+	bot, raw, err := models.NewAPIToken("bot", []string{models.ScopeWikisWrite}, nil, "admin")
+	require.NoError(t, err)
+	old, _, err := models.NewAPIToken("old", []string{models.ScopeExport}, &expired, "admin")
+	require.NoError(t, err)
+	require.NoError(t, repo.Create(ctx, bot))
+	require.NoError(t, repo.Create(ctx, old))
+
+	got, err := repo.GetByHash(ctx, models.HashAPIToken(raw))
+	require.NoError(t, err)
+	assert.Equal(t, bot.ID, got.ID)
+	assert.Equal(t, models.StringList{"wikis:write"}, got.Scopes)
+	_, err = repo.GetByHash(ctx, models.HashAPIToken("wkp_unknown"))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	count, err := repo.CountUsable(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count, "expired tokens are not usable")
+
+	require.NoError(t, repo.Touch(ctx, bot.ID, now))
+	require.NoError(t, repo.Touch(ctx, bot.ID, now.Add(time.Second)))
+	got, err = repo.GetByHash(ctx, bot.TokenHash)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+	assert.WithinDuration(t, now, *got.LastUsedAt, time.Millisecond, "uses within a minute are not written")
+
+	found, err := repo.Revoke(ctx, bot.ID, now)
+	require.NoError(t, err)
+	assert.True(t, found)
+	found, err = repo.Revoke(ctx, bot.ID, now)
+	require.NoError(t, err)
+	assert.False(t, found, "revoking twice reports not found")
+	found, err = repo.Revoke(ctx, uuid.New(), now)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	tokens, err := repo.List(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, old.ID, tokens[0].ID)
+	tokens, err = repo.List(ctx, true)
+	require.NoError(t, err)
+	assert.Len(t, tokens, 2)
+
+	count, err = repo.CountUsable(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -44,11 +45,11 @@ func (r *ArchiveRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.
 	return &archive, nil
 }
 
-// GetByWikiID retrieves all archives for a wiki
+// GetByWikiID retrieves the archives of a wiki that have not been removed from Archive.org
 func (r *ArchiveRepository) GetByWikiID(ctx context.Context, wikiID uuid.UUID) ([]*models.WikiArchive, error) {
 	var archives []*models.WikiArchive
 	err := r.db.WithContext(ctx).
-		Where("wiki_id = ?", wikiID).
+		Where("wiki_id = ? AND removed_at IS NULL", wikiID).
 		Order("dump_date DESC").
 		Find(&archives).Error
 	if err != nil {
@@ -57,6 +58,20 @@ func (r *ArchiveRepository) GetByWikiID(ctx context.Context, wikiID uuid.UUID) (
 	return archives, nil
 }
 
+// GetLatestByWikiID retrieves the newest archive of a wiki that has not been removed,
+// by dump date, falling back to the upload date
+func (r *ArchiveRepository) GetLatestByWikiID(ctx context.Context, wikiID uuid.UUID) (*models.WikiArchive, error) {
+	var archive models.WikiArchive
+	err := r.db.WithContext(ctx).
+		Where("wiki_id = ? AND removed_at IS NULL", wikiID).
+		Order("dump_date IS NULL, dump_date DESC, added_date DESC").
+		First(&archive).Error
+	if err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
 // GetByIAIdentifier retrieves an archive by Archive.org identifier
 func (r *ArchiveRepository) GetByIAIdentifier(ctx context.Context, iaIdentifier string) (*models.WikiArchive, error) {
 	var archive models.WikiArchive
@@ -95,6 +110,26 @@ func (r *ArchiveRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&models.WikiArchive{}, "id = ?", id).Error
 }
 
+// MarkMissed records that a search did not list an archive and returns the
+// number of consecutive searches that missed it
+func (r *ArchiveRepository) MarkMissed(ctx context.Context, archive *models.WikiArchive) (int, error) {
+	err := r.db.WithContext(ctx).Model(&models.WikiArchive{}).
+		Where("id = ?", archive.ID).
+		Update("missed_checks", gorm.Expr("missed_checks + 1")).Error
+	if err != nil {
+		return 0, err
+	}
+	return archive.MissedChecks + 1, nil
+}
+
+// MarkRemoved records that an archive was taken down or darked on Archive.org.
+// The row is kept so exports and merges still know about the item.
+func (r *ArchiveRepository) MarkRemoved(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.WikiArchive{}).
+		Where("id = ?", id).
+		Update("removed_at", at).Error
+}
+
 // DeleteByWikiID deletes all archives for a specific wiki
 func (r *ArchiveRepository) DeleteByWikiID(ctx context.Context, wikiID uuid.UUID) error {
 	return r.db.WithContext(ctx).
@@ -115,7 +150,8 @@ func (r *ArchiveRepository) ExistsByWikiAndIAIdentifier(
 	return count > 0, err
 }
 
-// UpsertByWikiAndIAIdentifier updates an archive if it exists, or creates it if it doesn't
+// UpsertByWikiAndIAIdentifier updates an archive if it exists, or creates it if it doesn't.
+// An archive found again is no longer missing or removed.
 func (r *ArchiveRepository) UpsertByWikiAndIAIdentifier(
 	ctx context.Context,
 	archive *models.WikiArchive,
@@ -128,12 +164,48 @@ func (r *ArchiveRepository) UpsertByWikiAndIAIdentifier(
 
 	if exists {
 		// Update existing
-		return r.db.WithContext(ctx).
-			Model(&models.WikiArchive{}).
-			Where("wiki_id = ? AND ia_identifier = ?", archive.WikiID, archive.IAIdentifier).
-			Updates(archive).Error
+		return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			existing := tx.Model(&models.WikiArchive{}).
+				Where("wiki_id = ? AND ia_identifier = ?", archive.WikiID, archive.IAIdentifier)
+			if err := existing.Session(&gorm.Session{}).Updates(archive).Error; err != nil {
+				return err
+			}
+			return existing.Session(&gorm.Session{}).
+				Updates(map[string]interface{}{"removed_at": nil, "missed_checks": 0}).Error
+		})
 	}
 
 	// Create new
 	return r.Create(ctx, archive)
 }
+
+// StreamForExport iterates over archives matching opts, ordered by updated_at, using a database cursor.
+// Wiki filters in opts restrict the export to archives of matching wikis.
+func (r *ArchiveRepository) StreamForExport(ctx context.Context, opts ExportOptions, fn func(*models.WikiArchive) error) error {
+	query := r.db.WithContext(ctx).Model(&models.WikiArchive{})
+	if hasWikiFilters(opts.ListOptions) {
+		query = query.Where("wiki_id IN (?)", NewWikiRepository(r.db).wikiIDSubquery(ctx, opts.ListOptions))
+	} else {
+		query = query.Where("wiki_id NOT IN (" + trashedWikiIDs + ")")
+	}
+	if opts.Since != nil {
+		query = query.Where("updated_at >= ?", *opts.Since)
+	}
+
+	rows, err := query.Order("updated_at ASC, id ASC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var archive models.WikiArchive
+		if err := r.db.ScanRows(rows, &archive); err != nil {
+			return err
+		}
+		if err := fn(&archive); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestArchiveRepository_GetLatestByWikiID(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	repo := NewArchiveRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, wiki))
+
+	_, err := repo.GetLatestByWikiID(ctx, wiki.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	older := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: wiki.ID, IAIdentifier: "wiki-old", DumpDate: &older}))
+	require.NoError(t, repo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: wiki.ID, IAIdentifier: "wiki-new", DumpDate: &newer}))
+	// Items without a dump date never outrank dated ones
+	require.NoError(t, repo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: wiki.ID, IAIdentifier: "wiki-undated", AddedDate: &newer}))
+
+	latest, err := repo.GetLatestByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "wiki-new", latest.IAIdentifier)
+}
+
+func TestArchiveRepository_MarkRemoved(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewArchiveRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, NewWikiRepository(db).Create(ctx, wiki))
+	require.NoError(t, repo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: wiki.ID, IAIdentifier: "wiki-20240101"}))
+
+	archive, err := repo.GetByWikiAndIAIdentifier(ctx, wiki.ID, "wiki-20240101")
+	require.NoError(t, err)
+	misses, err := repo.MarkMissed(ctx, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 1, misses)
+	archive, err = repo.GetByWikiAndIAIdentifier(ctx, wiki.ID, "wiki-20240101")
+	require.NoError(t, err)
+	misses, err = repo.MarkMissed(ctx, archive)
+	require.NoError(t, err)
+	assert.Equal(t, 2, misses)
+
+	require.NoError(t, repo.MarkRemoved(ctx, archive.ID, time.Now()))
+	archives, err := repo.GetByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Empty(t, archives, "removed archives are no longer listed")
+	_, err = repo.GetLatestByWikiID(ctx, wiki.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	var exported []string
+	require.NoError(t, repo.StreamForExport(ctx, ExportOptions{}, func(a *models.WikiArchive) error {
+		assert.NotNil(t, a.RemovedAt)
+		exported = append(exported, a.IAIdentifier)
+		return nil
+	}))
+	assert.Equal(t, []string{"wiki-20240101"}, exported, "exports keep removed archives")
+
+	// An archive found again is restored
+	require.NoError(t, repo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{WikiID: wiki.ID, IAIdentifier: "wiki-20240101"}))
+	archive, err = repo.GetByWikiAndIAIdentifier(ctx, wiki.ID, "wiki-20240101")
+	require.NoError(t, err)
+	assert.Nil(t, archive.RemovedAt)
+	assert.Zero(t, archive.MissedChecks)
+	archives, err = repo.GetByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Len(t, archives, 1)
+}
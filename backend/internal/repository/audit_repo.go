@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// AuditRepository handles audit_log database operations
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Create records an entry
+func (r *AuditRepository) Create(ctx context.Context, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+// AuditListOptions filters audit entries; zero fields match everything
+type AuditListOptions struct {
+	Actor    string
+	Action   string // Exact action, or a prefix ending in "." such as "wiki."
+	TargetID string
+	IP       string
+	Outcome  models.AuditOutcome
+	Since    *time.Time
+	Until    *time.Time
+	Page     int
+	PageSize int
+}
+
+// List returns the newest entries matching opts and the number of matching entries
+func (r *AuditRepository) List(ctx context.Context, opts AuditListOptions) ([]*models.AuditEntry, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditEntry{})
+	if opts.Actor != "" {
+		query = query.Where("actor = ?", opts.Actor)
+	}
+	if strings.HasSuffix(opts.Action, ".") {
+		query = query.Where("action LIKE ?", opts.Action+"%")
+	} else if opts.Action != "" {
+		query = query.Where("action = ?", opts.Action)
+	}
+	if opts.TargetID != "" {
+		query = query.Where("target_id = ?", opts.TargetID)
+	}
+	if opts.IP != "" {
+		query = query.Where("ip = ?", opts.IP)
+	}
+	if opts.Outcome != "" {
+		query = query.Where("outcome = ?", opts.Outcome)
+	}
+	if opts.Since != nil {
+		query = query.Where("created_at >= ?", *opts.Since)
+	}
+	if opts.Until != nil {
+		query = query.Where("created_at < ?", *opts.Until)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*models.AuditEntry
+	err := query.Order("created_at DESC").
+		Offset((opts.Page - 1) * opts.PageSize).
+		Limit(opts.PageSize).
+		Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
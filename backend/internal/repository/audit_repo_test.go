@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestAuditRepository_List(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAuditRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	wikiID := "5f0c6b1e-0000-4000-8000-000000000001"
+	entries := []*models.AuditEntry{
+		{Actor: "alice", IP: "192.0.2.1", Action: "wiki.delete", TargetID: &wikiID, Outcome: models.AuditOutcomeSuccess, Status: 200, CreatedAt: now.Add(-2 * time.Hour)},
+		{Actor: "alice", IP: "192.0.2.1", Action: "wiki.restore", TargetID: &wikiID, Outcome: models.AuditOutcomeSuccess, Status: 200, CreatedAt: now.Add(-time.Hour)},
+		{Actor: "anonymous", IP: "198.51.100.7", Action: "wiki.create", Outcome: models.AuditOutcomeFailure, Status: 400, CreatedAt: now},
+		{Actor: "bot", IP: "192.0.2.9", Action: "collect_all", Params: models.JSONMap{"query": map[string]interface{}{"x": "1"}}, Outcome: models.AuditOutcomeSuccess, Status: 202, CreatedAt: now},
+	}
+	for _, entry := range entries {
+		require.NoError(t, repo.Create(ctx, entry))
+	}
+
+	all, total, err := repo.List(ctx, AuditListOptions{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Equal(t, "wiki.delete", all[3].Action, "newest first")
+
+	found, total, err := repo.List(ctx, AuditListOptions{Action: "wiki.", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, found, 3)
+
+	found, total, err = repo.List(ctx, AuditListOptions{Actor: "alice", TargetID: wikiID, Action: "wiki.delete", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	require.Len(t, found, 1)
+
+	found, _, err = repo.List(ctx, AuditListOptions{Outcome: models.AuditOutcomeFailure, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "198.51.100.7", found[0].IP)
+
+	since := now.Add(-90 * time.Minute)
+	until := now.Add(-30 * time.Minute)
+	found, _, err = repo.List(ctx, AuditListOptions{Since: &since, Until: &until, Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "wiki.restore", found[0].Action)
+
+	found, _, err = repo.List(ctx, AuditListOptions{Actor: "bot", Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, map[string]interface{}{"x": "1"}, found[0].Params["query"])
+
+	page, total, err := repo.List(ctx, AuditListOptions{Page: 2, PageSize: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	assert.Len(t, page, 1)
+}
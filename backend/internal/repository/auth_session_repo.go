@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// AuthSessionRepository handles auth_sessions, auth_login_codes and auth_oidc_states database operations
+type AuthSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthSessionRepository creates a new auth session repository
+func NewAuthSessionRepository(db *gorm.DB) *AuthSessionRepository {
+	return &AuthSessionRepository{db: db}
+}
+
+// CreateLoginCode stores a login code
+func (r *AuthSessionRepository) CreateLoginCode(ctx context.Context, code *models.LoginCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// ConsumeLoginCode deletes the login code with the given hash and returns it.
+// Expired codes, and codes already consumed by a concurrent request, are not found.
+func (r *AuthSessionRepository) ConsumeLoginCode(ctx context.Context, hash string, now time.Time) (*models.LoginCode, error) {
+	var code models.LoginCode
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&code, "code_hash = ?", hash).Error; err != nil {
+			return err
+		}
+		result := tx.Where("code_hash = ?", hash).Delete(&models.LoginCode{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || !code.ExpiresAt.After(now) {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// CreateOIDCState stores a pending OIDC login
+func (r *AuthSessionRepository) CreateOIDCState(ctx context.Context, state *models.OIDCState) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+// ConsumeOIDCState deletes the pending OIDC login with the given state hash and
+// returns it. Expired and already consumed states are not found.
+func (r *AuthSessionRepository) ConsumeOIDCState(ctx context.Context, hash string, now time.Time) (*models.OIDCState, error) {
+	var state models.OIDCState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&state, "state_hash = ?", hash).Error; err != nil {
+			return err
+		}
+		result := tx.Where("state_hash = ?", hash).Delete(&models.OIDCState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 || !state.ExpiresAt.After(now) {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// CreateSession stores a session
+func (r *AuthSessionRepository) CreateSession(ctx context.Context, session *models.AuthSession) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+// GetSessionByHash retrieves a session by the hash of its secret, including expired ones
+func (r *AuthSessionRepository) GetSessionByHash(ctx context.Context, hash string) (*models.AuthSession, error) {
+	var session models.AuthSession
+	if err := r.db.WithContext(ctx).First(&session, "secret_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession ends a session
+func (r *AuthSessionRepository) DeleteSession(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&models.AuthSession{}, "id = ?", id).Error
+}
+
+// DeleteExpired removes sessions, login codes and pending OIDC logins that expired before now
+func (r *AuthSessionRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		sessions := tx.Where("expires_at < ?", now).Delete(&models.AuthSession{})
+		if sessions.Error != nil {
+			return sessions.Error
+		}
+		codes := tx.Where("expires_at < ?", now).Delete(&models.LoginCode{})
+		if codes.Error != nil {
+			return codes.Error
+		}
+		states := tx.Where("expires_at < ?", now).Delete(&models.OIDCState{})
+		if states.Error != nil {
+			return states.Error
+		}
+		deleted = sessions.RowsAffected + codes.RowsAffected + states.RowsAffected
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestAuthSessionRepository_LoginCodes(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAuthSessionRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	require.NoError(t, repo.CreateLoginCode(ctx, &models.LoginCode{CodeHash: "fresh", CredentialHash: "c", Name: "admin", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.CreateLoginCode(ctx, &models.LoginCode{CodeHash: "stale", CredentialHash: "c", Name: "admin", ExpiresAt: now.Add(-time.Minute)}))
+
+	code, err := repo.ConsumeLoginCode(ctx, "fresh", now)
+	require.NoError(t, err)
+	assert.Equal(t, "admin", code.Name)
+
+	_, err = repo.ConsumeLoginCode(ctx, "fresh", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "codes are single-use")
+	_, err = repo.ConsumeLoginCode(ctx, "stale", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "expired codes are rejected")
+	_, err = repo.ConsumeLoginCode(ctx, "stale", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "and deleted")
+}
+
+func TestAuthSessionRepository_Sessions(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAuthSessionRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	active := &models.AuthSession{SecretHash: "active", CSRFToken: "csrf", CredentialHash: "c", Name: "admin", ExpiresAt: now.Add(time.Hour)}
+	expired := &models.AuthSession{SecretHash: "expired", CSRFToken: "csrf", CredentialHash: "c", Name: "admin", ExpiresAt: now.Add(-time.Hour)}
+	require.NoError(t, repo.CreateSession(ctx, active))
+	require.NoError(t, repo.CreateSession(ctx, expired))
+
+	found, err := repo.GetSessionByHash(ctx, "active")
+	require.NoError(t, err)
+	assert.Equal(t, active.ID, found.ID)
+	assert.Equal(t, "csrf", found.CSRFToken)
+
+	deleted, err := repo.DeleteExpired(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	require.NoError(t, repo.DeleteSession(ctx, active.ID))
+	_, err = repo.GetSessionByHash(ctx, "active")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestAuthSessionRepository_OIDCStates(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewAuthSessionRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	require.NoError(t, repo.CreateOIDCState(ctx, &models.OIDCState{StateHash: "fresh", Nonce: "n", CodeVerifier: "v", RedirectTo: "https://example.org/", ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, repo.CreateOIDCState(ctx, &models.OIDCState{StateHash: "stale", Nonce: "n", CodeVerifier: "v", ExpiresAt: now.Add(-time.Minute)}))
+
+	state, err := repo.ConsumeOIDCState(ctx, "fresh", now)
+	require.NoError(t, err)
+	assert.Equal(t, "v", state.CodeVerifier)
+	assert.Equal(t, "https://example.org/", state.RedirectTo)
+
+	_, err = repo.ConsumeOIDCState(ctx, "fresh", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "states are single-use")
+	_, err = repo.ConsumeOIDCState(ctx, "stale", now)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "expired states are rejected")
+
+	subject := "https://idp.example#u1"
+	session := &models.AuthSession{
+		SecretHash: "oidc", CSRFToken: "csrf", CredentialHash: "c", Name: "alice", Subject: &subject,
+		Roles: models.StringList{"curator"}, Scopes: models.StringList{models.ScopeWikisWrite}, ExpiresAt: now.Add(time.Hour),
+	}
+	require.NoError(t, repo.CreateSession(ctx, session))
+	found, err := repo.GetSessionByHash(ctx, "oidc")
+	require.NoError(t, err)
+	require.NotNil(t, found.Subject)
+	assert.Equal(t, subject, *found.Subject)
+	assert.Equal(t, models.StringList{"curator"}, found.Roles)
+	assert.Equal(t, models.StringList{models.ScopeWikisWrite}, found.Scopes)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wikikeeper-backend/internal/models"
+)
+
+// CatalogSnapshotRepository handles catalog_snapshots database operations
+type CatalogSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewCatalogSnapshotRepository creates a new catalog snapshot repository
+func NewCatalogSnapshotRepository(db *gorm.DB) *CatalogSnapshotRepository {
+	return &CatalogSnapshotRepository{db: db}
+}
+
+// Capture computes the catalog totals for the UTC day containing at and stores them,
+// replacing an earlier capture of the same day. Stats are summed as of at.
+func (r *CatalogSnapshotRepository) Capture(ctx context.Context, at time.Time) (*models.CatalogSnapshot, error) {
+	at = at.UTC()
+	snapshot := &models.CatalogSnapshot{
+		Date:       time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC),
+		CapturedAt: at,
+	}
+
+	var counts struct {
+		TotalWikis    int64
+		ActiveWikis   int64
+		OKWikis       int64
+		ErrorWikis    int64
+		OfflineWikis  int64
+		PendingWikis  int64
+		ArchivedWikis int64
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		SELECT
+			COUNT(*) AS total_wikis,
+			COALESCE(SUM(CASE WHEN is_active = ? THEN 1 ELSE 0 END), 0) AS active_wikis,
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS ok_wikis,
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS error_wikis,
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS offline_wikis,
+			COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS pending_wikis,
+			COALESCE(SUM(CASE WHEN has_archive = ? THEN 1 ELSE 0 END), 0) AS archived_wikis
+		FROM wikis
+		WHERE created_at <= ? AND deleted_at IS NULL
+	`, true, models.WikiStatusOK, models.WikiStatusError, models.WikiStatusOffline, models.WikiStatusPending, true, at).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	snapshot.TotalWikis = counts.TotalWikis
+	snapshot.ActiveWikis = counts.ActiveWikis
+	snapshot.OKWikis = counts.OKWikis
+	snapshot.ErrorWikis = counts.ErrorWikis
+	snapshot.OfflineWikis = counts.OfflineWikis
+	snapshot.PendingWikis = counts.PendingWikis
+	snapshot.ArchivedWikis = counts.ArchivedWikis
+
+	var sums struct {
+		TotalPages int64
+		TotalEdits int64
+	}
+	if err := r.db.WithContext(ctx).Raw(`
+		WITH latest AS (
+			SELECT pages, edits,
+				ROW_NUMBER() OVER (PARTITION BY wiki_id ORDER BY time DESC) AS rn
+			FROM wiki_stats
+			WHERE time <= ? AND wiki_id NOT IN (`+trashedWikiIDs+`)
+		)
+		SELECT COALESCE(SUM(pages), 0) AS total_pages, COALESCE(SUM(edits), 0) AS total_edits
+		FROM latest
+		WHERE rn = 1
+	`, at).Scan(&sums).Error; err != nil {
+		return nil, err
+	}
+	snapshot.TotalPages = sums.TotalPages
+	snapshot.TotalEdits = sums.TotalEdits
+
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "date"}},
+		UpdateAll: true,
+	}).Create(snapshot).Error
+	if err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// List returns snapshots between from and to (inclusive, either may be nil), oldest first
+func (r *CatalogSnapshotRepository) List(ctx context.Context, from, to *time.Time) ([]*models.CatalogSnapshot, error) {
+	var snapshots []*models.CatalogSnapshot
+
+	query := r.db.WithContext(ctx).Model(&models.CatalogSnapshot{})
+	if from != nil {
+		query = query.Where("date >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("date <= ?", *to)
+	}
+
+	if err := query.Order("date ASC").Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
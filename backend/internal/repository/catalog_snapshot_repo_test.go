@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestCatalogSnapshotRepository_Capture(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	repo := NewCatalogSnapshotRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	ok := &models.Wiki{ID: uuid.New(), URL: "https://ok.com", Status: models.WikiStatusOK, HasArchive: true, IsActive: true}
+	failing := &models.Wiki{ID: uuid.New(), URL: "https://error.com", Status: models.WikiStatusError, IsActive: true}
+	require.NoError(t, wikiRepo.Create(ctx, ok))
+	require.NoError(t, wikiRepo.Create(ctx, failing))
+
+	// Only the latest row per wiki counts
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: ok.ID, Time: now.Add(-2 * time.Hour), Pages: 10, Edits: 100}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: ok.ID, Time: now.Add(-time.Hour), Pages: 20, Edits: 200}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: failing.ID, Time: now.Add(-time.Hour), Pages: 5, Edits: 50}))
+
+	snapshot, err := repo.Capture(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), snapshot.TotalWikis)
+	assert.Equal(t, int64(2), snapshot.ActiveWikis)
+	assert.Equal(t, int64(1), snapshot.OKWikis)
+	assert.Equal(t, int64(1), snapshot.ErrorWikis)
+	assert.Equal(t, int64(1), snapshot.ArchivedWikis)
+	assert.Equal(t, int64(25), snapshot.TotalPages)
+	assert.Equal(t, int64(250), snapshot.TotalEdits)
+
+	// A second capture on the same day replaces the first
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: failing.ID, Time: now, Pages: 7, Edits: 70}))
+	_, err = repo.Capture(ctx, now.Add(time.Second))
+	require.NoError(t, err)
+
+	// And an earlier day is kept separately
+	_, err = repo.Capture(ctx, now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+
+	snapshots, err := repo.List(ctx, nil, nil)
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+	assert.True(t, snapshots[0].Date.Before(snapshots[1].Date))
+	assert.Equal(t, int64(0), snapshots[0].TotalWikis, "wikis created today did not exist yesterday")
+	assert.Equal(t, int64(27), snapshots[1].TotalPages)
+
+	from := snapshots[1].Date
+	recent, err := repo.List(ctx, &from, nil)
+	require.NoError(t, err)
+	assert.Len(t, recent, 1)
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// CheckRepository handles wiki_checks database operations
+type CheckRepository struct {
+	db *gorm.DB
+}
+
+// NewCheckRepository creates a new check repository
+func NewCheckRepository(db *gorm.DB) *CheckRepository {
+	return &CheckRepository{db: db}
+}
+
+// Create records a check
+func (r *CheckRepository) Create(ctx context.Context, check *models.WikiCheck) error {
+	return r.db.WithContext(ctx).Create(check).Error
+}
+
+// ListSince returns the checks of a wiki at or after since, oldest first; a zero since returns all of them
+func (r *CheckRepository) ListSince(ctx context.Context, wikiID uuid.UUID, since time.Time) ([]*models.WikiCheck, error) {
+	var checks []*models.WikiCheck
+	query := r.db.WithContext(ctx).Where("wiki_id = ?", wikiID)
+	if !since.IsZero() {
+		query = query.Where("time >= ?", since)
+	}
+	if err := query.Order("time ASC").Find(&checks).Error; err != nil {
+		return nil, err
+	}
+	return checks, nil
+}
+
+// DeleteBefore deletes checks older than cutoff and returns how many were deleted
+func (r *CheckRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("time < ?", cutoff).Delete(&models.WikiCheck{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// EventRepository handles wiki_events database operations
+type EventRepository struct {
+	db *gorm.DB
+}
+
+// NewEventRepository creates a new event repository
+func NewEventRepository(db *gorm.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// Create records an event
+func (r *EventRepository) Create(ctx context.Context, event *models.WikiEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// EventListOptions filters events
+type EventListOptions struct {
+	WikiID *uuid.UUID
+	Types  []models.WikiEventType // Empty means all types
+	Limit  int
+}
+
+// List returns the newest events matching opts with their wiki preloaded
+func (r *EventRepository) List(ctx context.Context, opts EventListOptions) ([]*models.WikiEvent, error) {
+	if opts.Limit < 1 {
+		opts.Limit = 50
+	}
+
+	query := r.db.WithContext(ctx).Preload("Wiki").Where("wiki_id NOT IN (" + trashedWikiIDs + ")")
+	if opts.WikiID != nil {
+		query = query.Where("wiki_id = ?", *opts.WikiID)
+	}
+	if len(opts.Types) > 0 {
+		query = query.Where("type IN ?", opts.Types)
+	}
+
+	var events []*models.WikiEvent
+	err := query.Order("created_at DESC").Limit(opts.Limit).Find(&events).Error
+	if err != nil {
+		return nil, err
+	}
+	return events, nil
+}
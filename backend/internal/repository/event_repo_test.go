@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestEventRepository_List(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	repo := NewEventRepository(db)
+	ctx := context.Background()
+
+	first := &models.Wiki{ID: uuid.New(), URL: "https://first.com", Status: models.WikiStatusOK}
+	second := &models.Wiki{ID: uuid.New(), URL: "https://second.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, first))
+	require.NoError(t, wikiRepo.Create(ctx, second))
+
+	now := time.Now().UTC()
+	from, to := models.WikiStatusOK, models.WikiStatusOffline
+	identifier := "wiki-first.com-20240101"
+	require.NoError(t, repo.Create(ctx, &models.WikiEvent{WikiID: first.ID, Type: models.WikiEventAdded, CreatedAt: now.Add(-3 * time.Hour)}))
+	require.NoError(t, repo.Create(ctx, &models.WikiEvent{WikiID: first.ID, Type: models.WikiEventWentOffline, FromStatus: &from, ToStatus: &to, CreatedAt: now.Add(-2 * time.Hour)}))
+	require.NoError(t, repo.Create(ctx, &models.WikiEvent{WikiID: second.ID, Type: models.WikiEventArchiveFound, IAIdentifier: &identifier, CreatedAt: now.Add(-time.Hour)}))
+
+	// Newest first, with the wiki preloaded
+	events, err := repo.List(ctx, EventListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+	assert.Equal(t, models.WikiEventArchiveFound, events[0].Type)
+	require.NotNil(t, events[0].Wiki)
+	assert.Equal(t, "https://second.com", events[0].Wiki.URL)
+	assert.NotEqual(t, uuid.Nil, events[0].ID)
+
+	events, err = repo.List(ctx, EventListOptions{WikiID: &first.ID})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, models.WikiEventWentOffline, events[0].Type)
+	assert.Equal(t, models.WikiStatusOffline, *events[0].ToStatus)
+
+	events, err = repo.List(ctx, EventListOptions{Types: []models.WikiEventType{models.WikiEventAdded, models.WikiEventArchiveFound}, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, models.WikiEventArchiveFound, events[0].Type)
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wikikeeper-backend/internal/models"
+)
+
+// ErrMergeSameWiki is returned when a wiki would be merged into itself
+var ErrMergeSameWiki = errors.New("cannot merge a wiki into itself")
+
+// MergeRepository merges duplicate wikis and keeps the merge log
+type MergeRepository struct {
+	db *gorm.DB
+}
+
+// NewMergeRepository creates a new merge repository
+func NewMergeRepository(db *gorm.DB) *MergeRepository {
+	return &MergeRepository{db: db}
+}
+
+// Merge moves stats, archives, events, notes and tags of the duplicate onto the survivor,
+// deletes the duplicate and logs its URLs as aliases of the survivor, all in one transaction.
+// Archives the survivor already has are dropped from the duplicate instead of moved.
+func (r *MergeRepository) Merge(ctx context.Context, survivorID, duplicateID uuid.UUID, actor string, reason *string) (*models.WikiMerge, error) {
+	if survivorID == duplicateID {
+		return nil, ErrMergeSameWiki
+	}
+
+	var merge *models.WikiMerge
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var survivor, duplicate models.Wiki
+		if err := tx.First(&survivor, "id = ?", survivorID).Error; err != nil {
+			return err
+		}
+		if err := tx.First(&duplicate, "id = ?", duplicateID).Error; err != nil {
+			return err
+		}
+
+		// Archives: unique per (wiki_id, ia_identifier)
+		if err := tx.Where("wiki_id = ? AND ia_identifier IN (?)", duplicateID,
+			tx.Model(&models.WikiArchive{}).Select("ia_identifier").Where("wiki_id = ?", survivorID),
+		).Delete(&models.WikiArchive{}).Error; err != nil {
+			return err
+		}
+		archives := tx.Model(&models.WikiArchive{}).Where("wiki_id = ?", duplicateID).Update("wiki_id", survivorID)
+		if archives.Error != nil {
+			return archives.Error
+		}
+
+		stats := tx.Model(&models.WikiStats{}).Where("wiki_id = ?", duplicateID).Update("wiki_id", survivorID)
+		if stats.Error != nil {
+			return stats.Error
+		}
+		if err := moveRollups(tx, duplicateID, survivorID); err != nil {
+			return err
+		}
+
+		for _, model := range []interface{}{&models.WikiEvent{}, &models.WikiNote{}, &models.WikiMerge{}, &models.WikiURL{}, &models.WikiCheck{}} {
+			if err := tx.Model(model).Where("wiki_id = ?", duplicateID).Update("wiki_id", survivorID).Error; err != nil {
+				return err
+			}
+		}
+
+		// Tags: union of both wikis
+		var tagIDs []uuid.UUID
+		if err := tx.Model(&models.WikiTag{}).Where("wiki_id = ?", duplicateID).Pluck("tag_id", &tagIDs).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) > 0 {
+			links := make([]models.WikiTag, len(tagIDs))
+			for i, tagID := range tagIDs {
+				links[i] = models.WikiTag{WikiID: survivorID, TagID: tagID, CreatedAt: time.Now()}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("wiki_id = ?", duplicateID).Delete(&models.WikiTag{}).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"has_archive": survivor.HasArchive || duplicate.HasArchive,
+			"updated_at":  time.Now(),
+		}
+		if survivor.APIURL == nil && duplicate.APIURL != nil {
+			updates["api_url"] = duplicate.APIURL
+			updates["api_url_canonical"] = duplicate.CanonicalAPIURL
+			updates["index_url"] = duplicate.IndexURL
+		}
+		if err := tx.Model(&models.Wiki{}).Where("id = ?", survivorID).UpdateColumns(updates).Error; err != nil {
+			return err
+		}
+
+		// The duplicate's URLs remain aliases of the survivor
+		if err := recordWikiURLs(tx, survivorID, &duplicate, time.Now()); err != nil {
+			return err
+		}
+
+		// Everything was moved to the survivor, so the duplicate is deleted for good instead of trashed
+		if err := tx.Unscoped().Delete(&models.Wiki{}, "id = ?", duplicateID).Error; err != nil {
+			return err
+		}
+
+		merge = &models.WikiMerge{
+			WikiID:        survivorID,
+			MergedWikiID:  duplicateID,
+			MergedURL:     duplicate.URL,
+			MergedAPIURL:  duplicate.APIURL,
+			StatsMoved:    stats.RowsAffected,
+			ArchivesMoved: archives.RowsAffected,
+			Actor:         actor,
+			Reason:        reason,
+			CreatedAt:     time.Now(),
+		}
+		return tx.Create(merge).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return merge, nil
+}
+
+// ListByWikiID returns the merges into a wiki, newest first
+func (r *MergeRepository) ListByWikiID(ctx context.Context, wikiID uuid.UUID) ([]*models.WikiMerge, error) {
+	var merges []*models.WikiMerge
+	err := r.db.WithContext(ctx).
+		Where("wiki_id = ?", wikiID).
+		Order("created_at DESC").
+		Find(&merges).Error
+	if err != nil {
+		return nil, err
+	}
+	return merges, nil
+}
@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestWikiRepository_FindByCanonicalAPIURL(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	httpAPI := "http://www.example.org/w/api.php"
+	httpsAPI := "https://example.org/w/api.php"
+	other := "https://other.org/w/api.php"
+	first := &models.Wiki{ID: uuid.New(), URL: "http://www.example.org", APIURL: &httpAPI, Status: models.WikiStatusOK}
+	second := &models.Wiki{ID: uuid.New(), URL: "https://example.org", APIURL: &httpsAPI, Status: models.WikiStatusOK}
+	third := &models.Wiki{ID: uuid.New(), URL: "https://other.org", APIURL: &other, Status: models.WikiStatusOK}
+	for _, wiki := range []*models.Wiki{first, second, third} {
+		require.NoError(t, repo.Create(ctx, wiki))
+	}
+
+	duplicates, err := repo.FindByCanonicalAPIURL(ctx, httpsAPI, second.ID)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, first.ID, duplicates[0].ID)
+
+	// The column follows API URL changes
+	second.APIURL = &other
+	require.NoError(t, repo.Update(ctx, second))
+	duplicates, err = repo.FindByCanonicalAPIURL(ctx, httpsAPI, uuid.Nil)
+	require.NoError(t, err)
+	require.Len(t, duplicates, 1)
+	assert.Equal(t, first.ID, duplicates[0].ID)
+}
+
+func TestMergeRepository_Merge(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	archiveRepo := NewArchiveRepository(db)
+	tagRepo := NewTagRepository(db)
+	repo := NewMergeRepository(db)
+	ctx := context.Background()
+
+	apiURL := "https://example.org/w/api.php"
+	survivor := &models.Wiki{ID: uuid.New(), URL: "https://example.org", Status: models.WikiStatusOK}
+	duplicate := &models.Wiki{ID: uuid.New(), URL: "http://example.org/wiki", APIURL: &apiURL, HasArchive: true, Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, survivor))
+	require.NoError(t, wikiRepo.Create(ctx, duplicate))
+
+	now := time.Now().UTC()
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: survivor.ID, Time: now, Pages: 10}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: duplicate.ID, Time: now.Add(-time.Hour), Pages: 9}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: duplicate.ID, Time: now.Add(-2 * time.Hour), Pages: 8}))
+
+	require.NoError(t, archiveRepo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: survivor.ID, IAIdentifier: "shared"}))
+	require.NoError(t, archiveRepo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: duplicate.ID, IAIdentifier: "shared"}))
+	require.NoError(t, archiveRepo.UpsertByWikiAndIAIdentifier(ctx, &models.WikiArchive{ID: uuid.New(), WikiID: duplicate.ID, IAIdentifier: "only-duplicate"}))
+
+	_, err := tagRepo.AddToWiki(ctx, duplicate.ID, []string{"gaming"})
+	require.NoError(t, err)
+
+	_, err = repo.Merge(ctx, survivor.ID, survivor.ID, "admin", nil)
+	assert.ErrorIs(t, err, ErrMergeSameWiki)
+	_, err = repo.Merge(ctx, survivor.ID, uuid.New(), "admin", nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	reason := "same wiki over http"
+	merge, err := repo.Merge(ctx, survivor.ID, duplicate.ID, "admin", &reason)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), merge.StatsMoved)
+	assert.Equal(t, int64(1), merge.ArchivesMoved)
+	assert.Equal(t, "http://example.org/wiki", merge.MergedURL)
+
+	count, err := statsRepo.CountByWikiID(ctx, survivor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	archives, err := archiveRepo.GetByWikiID(ctx, survivor.ID)
+	require.NoError(t, err)
+	assert.Len(t, archives, 2)
+
+	tags, err := tagRepo.ListByWikiID(ctx, survivor.ID)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+
+	merged, err := wikiRepo.GetByID(ctx, survivor.ID)
+	require.NoError(t, err)
+	assert.True(t, merged.HasArchive)
+	require.NotNil(t, merged.APIURL, "the survivor adopts the duplicate's API URL when it has none")
+	assert.Equal(t, apiURL, *merged.APIURL)
+
+	_, err = wikiRepo.GetByID(ctx, duplicate.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	alias, err := NewWikiURLRepository(db).Resolve(ctx, []string{"http://example.org/wiki"})
+	require.NoError(t, err)
+	assert.Equal(t, survivor.ID, alias.WikiID)
+
+	merges, err := repo.ListByWikiID(ctx, survivor.ID)
+	require.NoError(t, err)
+	assert.Len(t, merges, 1)
+}
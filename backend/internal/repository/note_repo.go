@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// NoteRepository handles wiki_notes database operations
+type NoteRepository struct {
+	db *gorm.DB
+}
+
+// NewNoteRepository creates a new note repository
+func NewNoteRepository(db *gorm.DB) *NoteRepository {
+	return &NoteRepository{db: db}
+}
+
+// Create adds a note
+func (r *NoteRepository) Create(ctx context.Context, note *models.WikiNote) error {
+	return r.db.WithContext(ctx).Create(note).Error
+}
+
+// ListByWikiID returns the notes of a wiki, newest first
+func (r *NoteRepository) ListByWikiID(ctx context.Context, wikiID uuid.UUID) ([]*models.WikiNote, error) {
+	var notes []*models.WikiNote
+	err := r.db.WithContext(ctx).
+		Where("wiki_id = ?", wikiID).
+		Order("created_at DESC").
+		Find(&notes).Error
+	if err != nil {
+		return nil, err
+	}
+	return notes, nil
+}
+
+// Delete removes a note of a wiki and reports whether it existed
+func (r *NoteRepository) Delete(ctx context.Context, wikiID, noteID uuid.UUID) (bool, error) {
+	result := r.db.WithContext(ctx).Where("id = ? AND wiki_id = ?", noteID, wikiID).Delete(&models.WikiNote{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// RankingType selects a leaderboard
+type RankingType string
+
+const (
+	RankingLargest        RankingType = "largest"         // Highest latest pages or edits
+	RankingFastestGrowing RankingType = "fastest_growing" // Largest pages or edits increase over the window
+	RankingMostActive     RankingType = "most_active"     // Highest latest active users
+	RankingAtRisk         RankingType = "at_risk"         // Largest wikis without any archive
+	RankingLongestOffline RankingType = "longest_offline" // Failing wikis, longest without a successful collection
+)
+
+// RankingTypes lists all supported ranking types
+var RankingTypes = []RankingType{
+	RankingLargest,
+	RankingFastestGrowing,
+	RankingMostActive,
+	RankingAtRisk,
+	RankingLongestOffline,
+}
+
+// RankingMetrics lists the wiki_stats columns that largest, fastest_growing and at_risk can rank by
+var RankingMetrics = []string{"pages", "edits"}
+
+// RankingOptions controls a ranking query
+type RankingOptions struct {
+	Type   RankingType
+	Metric string // "pages" or "edits"; ignored by most_active and longest_offline
+	Days   int    // Window for fastest_growing
+	Limit  int
+}
+
+// RankingEntry is one row of a leaderboard. Value is the ranked quantity:
+// the metric for largest and at_risk, its increase for fastest_growing,
+// active users for most_active and seconds since the last successful collection for longest_offline.
+type RankingEntry struct {
+	Rank       int               `json:"rank"`
+	WikiID     uuid.UUID         `json:"wiki_id"`
+	URL        string            `json:"url"`
+	Sitename   *string           `json:"sitename"`
+	Status     models.WikiStatus `json:"status"`
+	HasArchive bool              `json:"has_archive"`
+	Value      int64             `json:"value"`
+	Current    *int64            `json:"current,omitempty"`     // fastest_growing: latest metric value
+	MeasuredAt *time.Time        `json:"measured_at,omitempty"` // Last time the stats used were seen
+	Since      *time.Time        `json:"since,omitempty"`       // longest_offline: last success, or creation if never collected
+}
+
+// RankingRepository computes leaderboards from wiki_stats with window functions
+type RankingRepository struct {
+	db *gorm.DB
+}
+
+// NewRankingRepository creates a new ranking repository
+func NewRankingRepository(db *gorm.DB) *RankingRepository {
+	return &RankingRepository{db: db}
+}
+
+type rankingRow struct {
+	WikiID     uuid.UUID
+	URL        string
+	Sitename   *string
+	Status     models.WikiStatus
+	HasArchive bool
+	Value      int64
+	Current    *int64
+	MeasuredAt *time.Time
+	LastOKAt   *time.Time
+	CreatedAt  *time.Time
+}
+
+// latestStatsCTE numbers each wiki's stats rows from newest to oldest; rn = 1 is the latest
+const latestStatsCTE = `
+	latest AS (
+		SELECT wiki_id, time, pages, edits, active_users,
+			ROW_NUMBER() OVER (PARTITION BY wiki_id ORDER BY time DESC) AS rn
+		FROM wiki_stats
+	)`
+
+// lastOKCTE numbers each wiki's successful checks from newest to oldest; rn = 1 is the latest.
+// Stats are only stored when they change, so the check log knows better than wiki_stats
+// when the latest stats were last seen.
+const lastOKCTE = `
+	last_ok AS (
+		SELECT wiki_id, time,
+			ROW_NUMBER() OVER (PARTITION BY wiki_id ORDER BY time DESC) AS rn
+		FROM wiki_checks
+		WHERE ok
+	)`
+
+// lastSuccess is the later of the latest stats row l and the latest successful check c,
+// falling back to wiki_stats for history before the check log or past its retention.
+// Get applies the same rule to measured_at.
+const lastSuccess = `CASE WHEN l.time IS NULL OR c.time > l.time THEN c.time ELSE l.time END`
+
+// Get computes a ranking
+func (r *RankingRepository) Get(ctx context.Context, opts RankingOptions) ([]RankingEntry, error) {
+	if opts.Limit < 1 {
+		opts.Limit = 20
+	}
+
+	var rows []rankingRow
+	var err error
+	switch opts.Type {
+	case RankingLargest:
+		err = r.rankLatest(ctx, opts.Metric, false, opts.Limit, &rows)
+	case RankingMostActive:
+		err = r.rankLatest(ctx, "active_users", false, opts.Limit, &rows)
+	case RankingAtRisk:
+		err = r.rankLatest(ctx, opts.Metric, true, opts.Limit, &rows)
+	case RankingFastestGrowing:
+		err = r.rankGrowth(ctx, opts.Metric, opts.Days, opts.Limit, &rows)
+	case RankingLongestOffline:
+		err = r.rankOffline(ctx, opts.Limit, &rows)
+	default:
+		return nil, fmt.Errorf("unknown ranking type %q", opts.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]RankingEntry, len(rows))
+	for i, row := range rows {
+		if row.LastOKAt != nil && (row.MeasuredAt == nil || row.LastOKAt.After(*row.MeasuredAt)) {
+			// Unchanged stats were seen again by a later check
+			row.MeasuredAt = row.LastOKAt
+		}
+		entries[i] = RankingEntry{
+			Rank:       i + 1,
+			WikiID:     row.WikiID,
+			URL:        row.URL,
+			Sitename:   row.Sitename,
+			Status:     row.Status,
+			HasArchive: row.HasArchive,
+			Value:      row.Value,
+			Current:    row.Current,
+			MeasuredAt: row.MeasuredAt,
+		}
+		if opts.Type == RankingLongestOffline {
+			since := row.CreatedAt
+			if row.MeasuredAt != nil {
+				since = row.MeasuredAt
+			}
+			if since != nil {
+				entries[i].Since = since
+				entries[i].Value = int64(now.Sub(*since).Seconds())
+			}
+		}
+	}
+	return entries, nil
+}
+
+// rankLatest ranks wikis by a column of their latest stats
+func (r *RankingRepository) rankLatest(ctx context.Context, column string, unarchivedOnly bool, limit int, rows *[]rankingRow) error {
+	if err := validateRankingColumn(column); err != nil {
+		return err
+	}
+
+	query := `WITH` + latestStatsCTE + `,` + lastOKCTE + `
+		SELECT w.id AS wiki_id, w.url, w.sitename, w.status, w.has_archive,
+			l.` + column + ` AS value, l.time AS measured_at, c.time AS last_ok_at
+		FROM latest l
+		INNER JOIN wikis w ON w.id = l.wiki_id
+		LEFT JOIN last_ok c ON c.wiki_id = l.wiki_id AND c.rn = 1
+		WHERE l.rn = 1 AND w.deleted_at IS NULL`
+	args := []interface{}{}
+	if unarchivedOnly {
+		query += ` AND w.has_archive = ?`
+		args = append(args, false)
+	}
+	query += `
+		ORDER BY value DESC, w.id
+		LIMIT ?`
+	args = append(args, limit)
+
+	return r.db.WithContext(ctx).Raw(query, args...).Scan(rows).Error
+}
+
+// statsPointsCTE lists the values of a column across the raw, daily and monthly
+// stats tiers as (wiki_id, time, value) points, each rollup standing for its last sample
+func statsPointsCTE(column string) string {
+	return `
+	points AS (
+		SELECT wiki_id, time, ` + column + ` AS value FROM wiki_stats
+		UNION ALL
+		SELECT wiki_id, last_time AS time, ` + column + `_last AS value FROM wiki_stats_daily
+		UNION ALL
+		SELECT wiki_id, last_time AS time, ` + column + `_last AS value FROM wiki_stats_monthly
+	)`
+}
+
+// rankGrowth ranks wikis by the increase of a column over the last days: from the
+// value at or just before the start of the window, in whichever tier holds it, to the
+// latest stats. Wikis first collected within the window start from their oldest value.
+func (r *RankingRepository) rankGrowth(ctx context.Context, column string, days, limit int, rows *[]rankingRow) error {
+	if err := validateRankingColumn(column); err != nil {
+		return err
+	}
+	if days < 1 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	query := `WITH` + latestStatsCTE + `,` + lastOKCTE + `,` + statsPointsCTE(column) + `,
+		before AS (
+			SELECT wiki_id, value, ROW_NUMBER() OVER (PARTITION BY wiki_id ORDER BY time DESC) AS rn
+			FROM points
+			WHERE time <= ?
+		),
+		oldest AS (
+			SELECT wiki_id, value, ROW_NUMBER() OVER (PARTITION BY wiki_id ORDER BY time) AS rn
+			FROM points
+		)
+		SELECT w.id AS wiki_id, w.url, w.sitename, w.status, w.has_archive,
+			l.` + column + ` - COALESCE(b.value, o.value) AS value, l.` + column + ` AS current, l.time AS measured_at, c.time AS last_ok_at
+		FROM latest l
+		INNER JOIN oldest o ON o.wiki_id = l.wiki_id AND o.rn = 1
+		LEFT JOIN before b ON b.wiki_id = l.wiki_id AND b.rn = 1
+		LEFT JOIN last_ok c ON c.wiki_id = l.wiki_id AND c.rn = 1
+		INNER JOIN wikis w ON w.id = l.wiki_id
+		WHERE l.rn = 1 AND l.` + column + ` - COALESCE(b.value, o.value) > 0 AND w.deleted_at IS NULL
+		ORDER BY value DESC, w.id
+		LIMIT ?`
+
+	return r.db.WithContext(ctx).Raw(query, since, limit).Scan(rows).Error
+}
+
+// rankOffline ranks failing wikis by how long ago their latest successful collection was,
+// taken from the check log; stats rows are only written when they change, so the
+// latest one may be much older than the last time the wiki was up
+func (r *RankingRepository) rankOffline(ctx context.Context, limit int, rows *[]rankingRow) error {
+	query := `WITH` + latestStatsCTE + `,` + lastOKCTE + `
+		SELECT w.id AS wiki_id, w.url, w.sitename, w.status, w.has_archive,
+			w.created_at, l.time AS measured_at, c.time AS last_ok_at
+		FROM wikis w
+		LEFT JOIN latest l ON l.wiki_id = w.id AND l.rn = 1
+		LEFT JOIN last_ok c ON c.wiki_id = w.id AND c.rn = 1
+		WHERE w.status IN ? AND w.deleted_at IS NULL
+		ORDER BY COALESCE(` + lastSuccess + `, w.created_at) ASC, w.id
+		LIMIT ?`
+
+	statuses := []models.WikiStatus{models.WikiStatusError, models.WikiStatusOffline}
+	return r.db.WithContext(ctx).Raw(query, statuses, limit).Scan(rows).Error
+}
+
+// validateRankingColumn guards the column names interpolated into ranking queries
+func validateRankingColumn(column string) error {
+	switch column {
+	case "pages", "edits", "active_users":
+		return nil
+	}
+	return fmt.Errorf("unknown ranking metric %q", column)
+}
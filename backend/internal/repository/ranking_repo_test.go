@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestRankingRepository_Get(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	rankingRepo := NewRankingRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	big := &models.Wiki{ID: uuid.New(), URL: "https://big.com", Status: models.WikiStatusOK, HasArchive: true}
+	growing := &models.Wiki{ID: uuid.New(), URL: "https://growing.com", Status: models.WikiStatusOK}
+	down := &models.Wiki{ID: uuid.New(), URL: "https://down.com", Status: models.WikiStatusError}
+	never := &models.Wiki{ID: uuid.New(), URL: "https://never.com", Status: models.WikiStatusOffline}
+	for _, wiki := range []*models.Wiki{big, growing, down, never} {
+		require.NoError(t, wikiRepo.Create(ctx, wiki))
+	}
+
+	samples := []struct {
+		wiki   *models.Wiki
+		age    time.Duration
+		pages  int
+		active int
+	}{
+		{big, 60 * 24 * time.Hour, 900, 1},
+		{big, 40 * 24 * time.Hour, 1000, 1},
+		{big, 10 * 24 * time.Hour, 1000, 2},
+		{big, time.Hour, 1000, 3},
+		{growing, 20 * 24 * time.Hour, 100, 9},
+		{growing, 5 * 24 * time.Hour, 300, 8},
+		{growing, time.Hour, 500, 7},
+		{down, 90 * 24 * time.Hour, 50, 0},
+	}
+	for _, s := range samples {
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{
+			WikiID:      s.wiki.ID,
+			Time:        now.Add(-s.age),
+			Pages:       s.pages,
+			ActiveUsers: s.active,
+		}))
+	}
+
+	largest, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingLargest, Metric: "pages", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, largest, 3)
+	assert.Equal(t, big.ID, largest[0].WikiID)
+	assert.Equal(t, int64(1000), largest[0].Value)
+	assert.Equal(t, 1, largest[0].Rank)
+	assert.Equal(t, growing.ID, largest[1].WikiID)
+
+	growth, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingFastestGrowing, Metric: "pages", Days: 30, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, growth, 1, "big grew only before the window and down has no samples in it")
+	assert.Equal(t, growing.ID, growth[0].WikiID)
+	assert.Equal(t, int64(400), growth[0].Value)
+	require.NotNil(t, growth[0].Current)
+	assert.Equal(t, int64(500), *growth[0].Current)
+
+	active, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingMostActive, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, growing.ID, active[0].WikiID)
+	assert.Equal(t, int64(7), active[0].Value)
+
+	atRisk, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingAtRisk, Metric: "pages", Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, atRisk, 2)
+	assert.Equal(t, growing.ID, atRisk[0].WikiID)
+	assert.Equal(t, down.ID, atRisk[1].WikiID)
+
+	offline, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingLongestOffline, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, offline, 2)
+	assert.Equal(t, down.ID, offline[0].WikiID, "last success 90 days ago comes before a wiki created just now")
+	assert.Equal(t, never.ID, offline[1].WikiID)
+	require.NotNil(t, offline[0].Since)
+	assert.InDelta(t, (90 * 24 * time.Hour).Seconds(), float64(offline[0].Value), 60)
+
+	_, err = rankingRepo.Get(ctx, RankingOptions{Type: RankingLargest, Metric: "pages; DROP TABLE wikis"})
+	assert.Error(t, err)
+	_, err = rankingRepo.Get(ctx, RankingOptions{Type: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestRankingRepository_GrowthAcrossTiers(t *testing.T) {
+	db := setupTestDB(t)
+	statsRepo := NewStatsRepository(db)
+	rankingRepo := NewRankingRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://old.com", Status: models.WikiStatusOK}
+	require.NoError(t, NewWikiRepository(db).Create(ctx, wiki))
+	for _, s := range []struct {
+		age   time.Duration
+		pages int
+	}{
+		{200 * 24 * time.Hour, 100},
+		{150 * 24 * time.Hour, 150},
+		{10 * 24 * time.Hour, 400},
+		{time.Hour, 500},
+	} {
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: now.Add(-s.age), Pages: s.pages}))
+	}
+
+	result, err := NewStatsRollupRepository(db).Apply(ctx, StatsRetention{RawDays: 90}, now)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.RawRows, "the samples older than 90 days are only in the daily tier")
+
+	for days, want := range map[int]int64{180: 400, 120: 350, 5: 100} {
+		growth, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingFastestGrowing, Metric: "pages", Days: days, Limit: 10})
+		require.NoError(t, err)
+		require.Len(t, growth, 1, "%d days", days)
+		assert.Equal(t, want, growth[0].Value, "%d days", days)
+	}
+}
+
+func TestRankingRepository_LastSuccessFromChecks(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	checkRepo := NewCheckRepository(db)
+	rankingRepo := NewRankingRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	quiet := &models.Wiki{ID: uuid.New(), URL: "https://quiet.com", Status: models.WikiStatusError}
+	legacy := &models.Wiki{ID: uuid.New(), URL: "https://legacy.com", Status: models.WikiStatusOffline}
+	for _, wiki := range []*models.Wiki{quiet, legacy} {
+		require.NoError(t, wikiRepo.Create(ctx, wiki))
+	}
+
+	// quiet's counters changed once within the window, and later checks saw them unchanged
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: quiet.ID, Time: now.AddDate(0, 0, -40), Pages: 100}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: quiet.ID, Time: now.AddDate(0, 0, -5), Pages: 150}))
+	lastOK := now.AddDate(0, 0, -1)
+	require.NoError(t, checkRepo.Create(ctx, &models.WikiCheck{WikiID: quiet.ID, Time: lastOK, OK: true}))
+	require.NoError(t, checkRepo.Create(ctx, &models.WikiCheck{WikiID: quiet.ID, Time: now.Add(-time.Hour)}))
+	// legacy has no checks, its history predates the check log
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: legacy.ID, Time: now.AddDate(0, 0, -30), Pages: 10}))
+
+	offline, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingLongestOffline, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, offline, 2)
+	assert.Equal(t, legacy.ID, offline[0].WikiID)
+	assert.Equal(t, quiet.ID, offline[1].WikiID)
+	require.NotNil(t, offline[1].Since)
+	assert.True(t, offline[1].Since.Equal(lastOK), "the last successful check, not the last stored stats")
+	assert.InDelta(t, (24 * time.Hour).Seconds(), float64(offline[1].Value), 60)
+
+	largest, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingLargest, Metric: "pages", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, largest, 1)
+	require.NotNil(t, largest[0].MeasuredAt)
+	assert.True(t, largest[0].MeasuredAt.Equal(lastOK))
+
+	growth, err := rankingRepo.Get(ctx, RankingOptions{Type: RankingFastestGrowing, Metric: "pages", Days: 30, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, growth, 1, "a single change within the window counts from the row before it")
+	assert.Equal(t, quiet.ID, growth[0].WikiID)
+	assert.Equal(t, int64(50), growth[0].Value)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -45,14 +47,17 @@ func (r *StatsRepository) GetByID(ctx context.Context, id int64) (*models.WikiSt
 	return &stats, nil
 }
 
-// GetByWikiID retrieves stats for a wiki within a time range
+// GetByWikiID retrieves stats for a wiki within a time range, newest first.
+// History that was rolled up into the daily and monthly tiers is returned as
+// one row per day or month holding its last values.
 func (r *StatsRepository) GetByWikiID(ctx context.Context, wikiID uuid.UUID, days int) ([]*models.WikiStats, error) {
 	var stats []*models.WikiStats
 
 	query := r.db.WithContext(ctx).Where("wiki_id = ?", wikiID)
 
+	var since time.Time
 	if days > 0 {
-		since := time.Now().AddDate(0, 0, -days)
+		since = time.Now().AddDate(0, 0, -days)
 		query = query.Where("time >= ?", since)
 	}
 
@@ -61,9 +66,60 @@ func (r *StatsRepository) GetByWikiID(ctx context.Context, wikiID uuid.UUID, day
 		return nil, err
 	}
 
+	// Unchanged stats are not stored again, so add a row for every check in between
+	checks, err := NewCheckRepository(r.db).ListSince(ctx, wikiID, since)
+	if err != nil {
+		return nil, err
+	}
+	var carry *models.WikiStats
+	if len(checks) > 0 && (len(stats) == 0 || checks[0].Time.Before(stats[len(stats)-1].Time)) {
+		if carry, err = r.latestBefore(ctx, wikiID, checks[0].Time); err != nil {
+			return nil, err
+		}
+	}
+	stats = models.FillStatsGaps(stats, carry, checks)
+
+	// Tiers never overlap except for the newest raw row of a wiki, which is kept
+	// raw; rollups are older than every other raw row
+	rollupRepo := NewStatsRollupRepository(r.db)
+	for _, tier := range []models.StatsTier{models.StatsTierDaily, models.StatsTierMonthly} {
+		rollups, err := rollupRepo.ListSince(ctx, tier, wikiID, since)
+		if err != nil {
+			return nil, err
+		}
+		for i := range rollups {
+			stats = append(stats, rollups[i].Point())
+		}
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].Time.After(stats[j].Time) })
+
 	return stats, nil
 }
 
+// latestBefore returns the latest stats of a wiki at or before t from any tier, or nil
+func (r *StatsRepository) latestBefore(ctx context.Context, wikiID uuid.UUID, t time.Time) (*models.WikiStats, error) {
+	var stats []*models.WikiStats
+	if err := r.db.WithContext(ctx).Where("wiki_id = ? AND time <= ?", wikiID, t).
+		Order("time DESC").Limit(1).Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	if len(stats) > 0 {
+		return stats[0], nil
+	}
+	for _, tier := range []models.StatsTier{models.StatsTierDaily, models.StatsTierMonthly} {
+		var rollups []models.WikiStatsRollup
+		err := r.db.WithContext(ctx).Table(tier.Table()).Where("wiki_id = ? AND last_time <= ?", wikiID, t).
+			Order("last_time DESC").Limit(1).Find(&rollups).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(rollups) > 0 {
+			return rollups[0].Point(), nil
+		}
+	}
+	return nil, nil
+}
+
 // GetLatestByWikiID retrieves the latest stats for a wiki
 func (r *StatsRepository) GetLatestByWikiID(ctx context.Context, wikiID uuid.UUID) (*models.WikiStats, error) {
 	var stats models.WikiStats
@@ -77,6 +133,77 @@ func (r *StatsRepository) GetLatestByWikiID(ctx context.Context, wikiID uuid.UUI
 	return &stats, nil
 }
 
+// GetLatestTime returns the time of the most recent stats entry of any wiki, or nil if there is none
+func (r *StatsRepository) GetLatestTime(ctx context.Context) (*time.Time, error) {
+	var times []time.Time
+	err := r.db.WithContext(ctx).Model(&models.WikiStats{}).
+		Order("time DESC").
+		Limit(1).
+		Pluck("time", &times).Error
+	if err != nil || len(times) == 0 {
+		return nil, err
+	}
+	return &times[0], nil
+}
+
+// GetWindowVersion returns the number of stats entries of a wiki within the last days,
+// counting checks and rollups, and the time of the newest one. Both change when GetByWikiID would return different rows.
+func (r *StatsRepository) GetWindowVersion(ctx context.Context, wikiID uuid.UUID, days int) (DataVersion, error) {
+	var version DataVersion
+
+	query := r.db.WithContext(ctx).Model(&models.WikiStats{}).Where("wiki_id = ?", wikiID)
+	if days > 0 {
+		since := time.Now().AddDate(0, 0, -days)
+		query = query.Where("time >= ?", since)
+	}
+	query = query.Session(&gorm.Session{})
+
+	if err := query.Count(&version.Count).Error; err != nil {
+		return version, err
+	}
+
+	var times []time.Time
+	if err := query.Order("time DESC").Limit(1).Pluck("time", &times).Error; err != nil {
+		return version, err
+	}
+	if len(times) > 0 {
+		version.LastModified = times[0]
+	}
+
+	// Checks add rows on read, and rolling up changes the row counts of the tiers
+	var since time.Time
+	if days > 0 {
+		since = time.Now().AddDate(0, 0, -days)
+	}
+	checks := r.db.WithContext(ctx).Model(&models.WikiCheck{}).Where("wiki_id = ?", wikiID)
+	if !since.IsZero() {
+		checks = checks.Where("time >= ?", since)
+	}
+	checks = checks.Session(&gorm.Session{})
+	var checkCount int64
+	if err := checks.Count(&checkCount).Error; err != nil {
+		return version, err
+	}
+	version.Count += checkCount
+	var checkTimes []time.Time
+	if err := checks.Order("time DESC").Limit(1).Pluck("time", &checkTimes).Error; err != nil {
+		return version, err
+	}
+	if len(checkTimes) > 0 && checkTimes[0].After(version.LastModified) {
+		version.LastModified = checkTimes[0]
+	}
+
+	rollupRepo := NewStatsRollupRepository(r.db)
+	for _, tier := range []models.StatsTier{models.StatsTierDaily, models.StatsTierMonthly} {
+		count, err := rollupRepo.countSince(ctx, tier, wikiID, since)
+		if err != nil {
+			return version, err
+		}
+		version.Count += count
+	}
+	return version, nil
+}
+
 // GetLatestForAllWikis retrieves the latest stats for all active wikis
 func (r *StatsRepository) GetLatestForAllWikis(ctx context.Context) ([]*models.WikiStats, error) {
 	var stats []*models.WikiStats
@@ -90,7 +217,7 @@ func (r *StatsRepository) GetLatestForAllWikis(ctx context.Context) ([]*models.W
 			GROUP BY wiki_id
 		) latest ON ws.wiki_id = latest.wiki_id AND ws.time = latest.max_time
 		INNER JOIN wikis w ON ws.wiki_id = w.id
-		WHERE w.is_active = true
+		WHERE w.is_active = true AND w.deleted_at IS NULL
 		ORDER BY ws.time DESC
 	`).Find(&stats).Error
 
@@ -101,16 +228,90 @@ func (r *StatsRepository) GetLatestForAllWikis(ctx context.Context) ([]*models.W
 	return stats, nil
 }
 
-// DeleteOlderThan deletes stats entries older than the given days
-func (r *StatsRepository) DeleteOlderThan(ctx context.Context, days int) error {
-	if days <= 0 {
-		return nil
+// statsCounterColumns lists the counters of wiki_stats, which the rollup tiers keep as <column>_last
+var statsCounterColumns = []string{"pages", "articles", "edits", "images", "users", "active_users", "admins", "jobs"}
+
+// StreamForExport iterates over stats rows matching opts, ordered by time, using a database cursor.
+// Wiki filters in opts restrict the export to stats of matching wikis. History that was
+// rolled up is exported as one row per day or month holding its last values, as GetByWikiID returns it.
+func (r *StatsRepository) StreamForExport(ctx context.Context, opts ExportOptions, fn func(*models.WikiStats) error) error {
+	filter := func(query *gorm.DB, timeColumn string) *gorm.DB {
+		if hasWikiFilters(opts.ListOptions) {
+			query = query.Where("wiki_id IN (?)", NewWikiRepository(r.db).wikiIDSubquery(ctx, opts.ListOptions))
+		} else {
+			query = query.Where("wiki_id NOT IN (" + trashedWikiIDs + ")")
+		}
+		if opts.Since != nil {
+			query = query.Where(timeColumn+" >= ?", *opts.Since)
+		}
+		if opts.From != nil {
+			query = query.Where(timeColumn+" >= ?", *opts.From)
+		}
+		if opts.To != nil {
+			query = query.Where(timeColumn+" < ?", *opts.To)
+		}
+		return query
 	}
 
-	cutoff := time.Now().AddDate(0, 0, -days)
-	return r.db.WithContext(ctx).
-		Where("time < ?", cutoff).
-		Delete(&models.WikiStats{}).Error
+	raw := filter(r.db.Table(models.WikiStats{}.TableName()).
+		Select("id, wiki_id, time, "+strings.Join(statsCounterColumns, ", ")+", response_time_ms, http_status"), "time")
+	rollupColumns := "0 AS id, wiki_id, last_time AS time"
+	for _, column := range statsCounterColumns {
+		rollupColumns += ", " + column + "_last AS " + column
+	}
+	rollupColumns += ", response_time_ms, http_status"
+	daily := filter(r.db.Table(models.StatsTierDaily.Table()).Select(rollupColumns), "last_time")
+	monthly := filter(r.db.Table(models.StatsTierMonthly.Table()).Select(rollupColumns), "last_time")
+
+	rows, err := r.db.WithContext(ctx).Raw(`
+		SELECT * FROM (?) raw
+		UNION ALL SELECT * FROM (?) daily
+		UNION ALL SELECT * FROM (?) monthly
+		ORDER BY time ASC, id ASC`, raw, daily, monthly).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stats models.WikiStats
+		if err := r.db.ScanRows(rows, &stats); err != nil {
+			return err
+		}
+		if err := fn(&stats); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// StreamLatest iterates over the latest stats row of every wiki using a database cursor
+func (r *StatsRepository) StreamLatest(ctx context.Context, fn func(*models.WikiStats) error) error {
+	rows, err := r.db.WithContext(ctx).Raw(`
+		SELECT ws.* FROM wiki_stats ws
+		INNER JOIN (
+			SELECT wiki_id, MAX(time) as max_time
+			FROM wiki_stats
+			GROUP BY wiki_id
+		) latest ON ws.wiki_id = latest.wiki_id AND ws.time = latest.max_time
+		WHERE ws.wiki_id NOT IN (` + trashedWikiIDs + `)
+		ORDER BY ws.wiki_id
+	`).Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var stats models.WikiStats
+		if err := r.db.ScanRows(rows, &stats); err != nil {
+			return err
+		}
+		if err := fn(&stats); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
 
 // DeleteByWikiID deletes all stats for a specific wiki
@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestStatsRepository_StreamForExport(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	ctx := context.Background()
+
+	okWiki := &models.Wiki{ID: uuid.New(), URL: "https://ok.com", Status: models.WikiStatusOK}
+	errWiki := &models.Wiki{ID: uuid.New(), URL: "https://error.com", Status: models.WikiStatusError}
+	require.NoError(t, wikiRepo.Create(ctx, okWiki))
+	require.NoError(t, wikiRepo.Create(ctx, errWiki))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		for _, wiki := range []*models.Wiki{okWiki, errWiki} {
+			require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{
+				WikiID: wiki.ID,
+				Time:   base.Add(time.Duration(i) * 24 * time.Hour),
+				Pages:  i,
+			}))
+		}
+	}
+
+	// Wiki filter and time range
+	status := models.WikiStatusOK
+	from := base.Add(24 * time.Hour)
+	var rows []*models.WikiStats
+	err := statsRepo.StreamForExport(ctx, ExportOptions{
+		ListOptions: ListOptions{Status: &status},
+		From:        &from,
+	}, func(s *models.WikiStats) error {
+		rows = append(rows, s)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	for _, row := range rows {
+		assert.Equal(t, okWiki.ID, row.WikiID)
+	}
+	assert.Equal(t, 1, rows[0].Pages)
+	assert.Equal(t, 2, rows[1].Pages)
+
+	// Rolled-up history is still exported, one row per day
+	result, err := NewStatsRollupRepository(db).Apply(ctx, StatsRetention{RawDays: 1}, base.Add(72*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), result.RawRows)
+	rows = nil
+	err = statsRepo.StreamForExport(ctx, ExportOptions{}, func(s *models.WikiStats) error {
+		rows = append(rows, s)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	for i, row := range rows {
+		assert.Equal(t, i/2, row.Pages)
+		assert.True(t, row.Time.Equal(base.Add(time.Duration(i/2)*24*time.Hour)), "ordered by time")
+	}
+}
+
+func TestStatsRepository_GetWindowVersion(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, wiki))
+
+	latest, err := statsRepo.GetLatestTime(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	old := now.AddDate(0, 0, -40)
+	for _, ts := range []time.Time{old, now.Add(-time.Hour), now} {
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: ts}))
+	}
+
+	version, err := statsRepo.GetWindowVersion(ctx, wiki.ID, 30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version.Count)
+	assert.True(t, now.Equal(version.LastModified))
+
+	version, err = statsRepo.GetWindowVersion(ctx, wiki.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version.Count)
+
+	latest, err = statsRepo.GetLatestTime(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.True(t, now.Equal(*latest))
+}
+
+func TestStatsRepository_GetByWikiID_FillsGaps(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	checkRepo := NewCheckRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, wiki))
+
+	// Hourly checks; stats were only stored when pages changed
+	now := time.Now().UTC().Truncate(time.Second)
+	stored := map[int]int{40 * 24: 0, 5: 1, 2: 2} // Hours ago -> pages
+	for hoursAgo, pages := range stored {
+		responseTime := 100 + hoursAgo
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{
+			WikiID:         wiki.ID,
+			Time:           now.Add(-time.Duration(hoursAgo) * time.Hour),
+			Pages:          pages,
+			ResponseTimeMs: &responseTime,
+		}))
+	}
+	for hoursAgo := 6; hoursAgo >= 0; hoursAgo-- {
+		responseTime := 100 + hoursAgo
+		require.NoError(t, checkRepo.Create(ctx, &models.WikiCheck{
+			WikiID:         wiki.ID,
+			Time:           now.Add(-time.Duration(hoursAgo) * time.Hour),
+			OK:             hoursAgo != 3,
+			ResponseTimeMs: &responseTime,
+		}))
+	}
+
+	stats, err := statsRepo.GetByWikiID(ctx, wiki.ID, 30)
+	require.NoError(t, err)
+	var hoursAgo, pages []int
+	for _, s := range stats {
+		hoursAgo = append(hoursAgo, int(now.Sub(s.Time.UTC()).Hours()))
+		pages = append(pages, s.Pages)
+		assert.Equal(t, 100+hoursAgo[len(hoursAgo)-1], *s.ResponseTimeMs)
+	}
+	assert.Equal(t, []int{0, 1, 2, 4, 5, 6}, hoursAgo, "one row per successful check, newest first")
+	assert.Equal(t, []int{2, 2, 2, 1, 1, 0}, pages, "the check before the window carries older stats")
+
+	version, err := statsRepo.GetWindowVersion(ctx, wiki.ID, 30)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2+7), version.Count)
+	assert.True(t, now.Equal(version.LastModified))
+
+	deleted, err := checkRepo.DeleteBefore(ctx, now.Add(-90*time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), deleted)
+	stats, err = statsRepo.GetByWikiID(ctx, wiki.ID, 30)
+	require.NoError(t, err)
+	assert.Len(t, stats, 2+2, "stored rows outlive their checks")
+}
@@ -0,0 +1,283 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wikikeeper-backend/internal/models"
+)
+
+// StatsRetention says how long each tier keeps its rows. Raw rows older than
+// RawDays are rolled up into daily rows, daily rows older than DailyDays into
+// monthly rows; monthly rows are kept forever. Zero keeps a tier forever.
+type StatsRetention struct {
+	RawDays   int
+	DailyDays int
+}
+
+// RawCutoff returns the time before which raw rows are rolled up at now
+func (r StatsRetention) RawCutoff(now time.Time) time.Time {
+	return models.StatsTierDaily.PeriodStart(now.AddDate(0, 0, -r.RawDays))
+}
+
+// RollupResult counts the rows a rollup run folded into the next tier
+type RollupResult struct {
+	RawRows   int64 // wiki_stats rows rolled up into wiki_stats_daily
+	DailyRows int64 // wiki_stats_daily rows rolled up into wiki_stats_monthly
+}
+
+// StatsRollupRepository handles the wiki_stats_daily and wiki_stats_monthly tiers
+type StatsRollupRepository struct {
+	db *gorm.DB
+}
+
+// NewStatsRollupRepository creates a new stats rollup repository
+func NewStatsRollupRepository(db *gorm.DB) *StatsRollupRepository {
+	return &StatsRollupRepository{db: db}
+}
+
+// notLatestStats excludes the newest raw row of each wiki: it stays in wiki_stats
+// so that queries for the latest stats keep working for wikis no longer collected
+const notLatestStats = `EXISTS (SELECT 1 FROM wiki_stats newer WHERE newer.wiki_id = wiki_stats.wiki_id AND newer.time > wiki_stats.time)`
+
+// Apply rolls up the rows that are past their retention at now. Every day and
+// month is rolled up in its own transaction, so an interrupted run keeps its progress.
+func (r *StatsRollupRepository) Apply(ctx context.Context, retention StatsRetention, now time.Time) (RollupResult, error) {
+	var result RollupResult
+	if retention.RawDays <= 0 {
+		return result, nil
+	}
+
+	before := retention.RawCutoff(now)
+	for {
+		var times []time.Time
+		err := r.db.WithContext(ctx).Model(&models.WikiStats{}).
+			Where("time < ? AND "+notLatestStats, before).
+			Order("time ASC").Limit(1).Pluck("time", &times).Error
+		if err != nil {
+			return result, err
+		}
+		if len(times) == 0 {
+			break
+		}
+		rolled, err := r.rollupRawDay(ctx, models.StatsTierDaily.PeriodStart(times[0]))
+		result.RawRows += rolled
+		if err != nil {
+			return result, err
+		}
+	}
+
+	if retention.DailyDays <= 0 {
+		return result, nil
+	}
+	before = models.StatsTierMonthly.PeriodStart(now.AddDate(0, 0, -retention.DailyDays))
+	for {
+		var starts []time.Time
+		err := r.db.WithContext(ctx).Table(models.StatsTierDaily.Table()).
+			Where("period_start < ?", before).
+			Order("period_start ASC").Limit(1).Pluck("period_start", &starts).Error
+		if err != nil || len(starts) == 0 {
+			return result, err
+		}
+		rolled, err := r.rollupDailyMonth(ctx, models.StatsTierMonthly.PeriodStart(starts[0]))
+		result.DailyRows += rolled
+		if err != nil {
+			return result, err
+		}
+	}
+}
+
+// rollupRawDay folds the raw rows of one day into wiki_stats_daily and deletes them
+func (r *StatsRollupRepository) rollupRawDay(ctx context.Context, day time.Time) (int64, error) {
+	var rolled int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var rows []*models.WikiStats
+		if err := tx.Where("time >= ? AND time < ? AND "+notLatestStats, day, models.StatsTierDaily.PeriodEnd(day)).
+			Order("wiki_id, time").Find(&rows).Error; err != nil {
+			return err
+		}
+		rollups := make([]models.WikiStatsRollup, len(rows))
+		ids := make([]int64, len(rows))
+		for i, row := range rows {
+			rollups[i] = models.NewStatsRollup(models.StatsTierDaily, row)
+			ids[i] = row.ID
+		}
+		if err := storeRollups(tx, models.StatsTierDaily, day, rollups); err != nil {
+			return err
+		}
+
+		for start := 0; start < len(ids); start += rollupDeleteBatch {
+			end := min(start+rollupDeleteBatch, len(ids))
+			result := tx.Where("id IN ?", ids[start:end]).Delete(&models.WikiStats{})
+			if result.Error != nil {
+				return result.Error
+			}
+			rolled += result.RowsAffected
+		}
+		if rolled != int64(len(ids)) {
+			return fmt.Errorf("stats rollup of %s: deleted %d raw rows, expected %d", day.Format("2006-01-02"), rolled, len(ids))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rolled, nil
+}
+
+// rollupDailyMonth folds the daily rows of one month into wiki_stats_monthly and deletes them
+func (r *StatsRollupRepository) rollupDailyMonth(ctx context.Context, month time.Time) (int64, error) {
+	var rolled int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		end := models.StatsTierMonthly.PeriodEnd(month)
+		var days []models.WikiStatsRollup
+		if err := tx.Table(models.StatsTierDaily.Table()).
+			Where("period_start >= ? AND period_start < ?", month, end).
+			Order("wiki_id, period_start").Find(&days).Error; err != nil {
+			return err
+		}
+		if err := storeRollups(tx, models.StatsTierMonthly, month, days); err != nil {
+			return err
+		}
+
+		result := tx.Table(models.StatsTierDaily.Table()).
+			Where("period_start >= ? AND period_start < ?", month, end).
+			Delete(&models.WikiStatsRollup{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rolled = result.RowsAffected
+		if rolled != int64(len(days)) {
+			return fmt.Errorf("stats rollup of %s: deleted %d daily rows, expected %d", month.Format("2006-01"), rolled, len(days))
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return rolled, nil
+}
+
+// rollupDeleteBatch bounds the IN lists used to delete rolled-up raw rows
+const rollupDeleteBatch = 500
+
+// storeRollups merges rollups, all of the tier's period starting at period, into
+// the rows already stored for it, then reads them back and checks that they hold
+// the merged summaries. Callers delete the rolled-up rows only after this succeeds.
+func storeRollups(tx *gorm.DB, tier models.StatsTier, period time.Time, rollups []models.WikiStatsRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	var existing []models.WikiStatsRollup
+	if err := tx.Table(tier.Table()).Where("period_start = ?", period).Find(&existing).Error; err != nil {
+		return err
+	}
+	merged := map[uuid.UUID]*models.WikiStatsRollup{}
+	var order []uuid.UUID
+	add := func(rollup models.WikiStatsRollup) {
+		if current, ok := merged[rollup.WikiID]; ok {
+			current.Merge(rollup)
+			return
+		}
+		rollup.PeriodStart = period
+		merged[rollup.WikiID] = &rollup
+		order = append(order, rollup.WikiID)
+	}
+	touched := map[uuid.UUID]bool{}
+	for _, rollup := range rollups {
+		touched[rollup.WikiID] = true
+	}
+	for _, rollup := range existing {
+		if touched[rollup.WikiID] {
+			add(rollup)
+		}
+	}
+	for _, rollup := range rollups {
+		add(rollup)
+	}
+
+	rows := make([]*models.WikiStatsRollup, len(order))
+	for i, wikiID := range order {
+		rows[i] = merged[wikiID]
+	}
+	if err := tx.Table(tier.Table()).Clauses(clause.OnConflict{UpdateAll: true}).
+		CreateInBatches(rows, 100).Error; err != nil {
+		return err
+	}
+
+	var stored []models.WikiStatsRollup
+	if err := tx.Table(tier.Table()).Where("period_start = ?", period).Find(&stored).Error; err != nil {
+		return err
+	}
+	verified := 0
+	for _, rollup := range stored {
+		if want, ok := merged[rollup.WikiID]; ok {
+			if !rollup.Covers(*want) {
+				return fmt.Errorf("%s rollup of wiki %s for %s does not match the rows it summarises", tier, rollup.WikiID, period.Format(time.RFC3339))
+			}
+			verified++
+		}
+	}
+	if verified != len(merged) {
+		return fmt.Errorf("%s rollup for %s: stored %d of %d wikis", tier, period.Format(time.RFC3339), verified, len(merged))
+	}
+	return nil
+}
+
+// moveRollups moves the rollups of one wiki to another within tx, merging
+// periods both wikis have
+func moveRollups(tx *gorm.DB, from, to uuid.UUID) error {
+	for _, tier := range []models.StatsTier{models.StatsTierDaily, models.StatsTierMonthly} {
+		var rollups []models.WikiStatsRollup
+		if err := tx.Table(tier.Table()).Where("wiki_id = ?", from).Find(&rollups).Error; err != nil {
+			return err
+		}
+		byPeriod := map[time.Time][]models.WikiStatsRollup{}
+		var periods []time.Time
+		for _, rollup := range rollups {
+			rollup.WikiID = to
+			period := rollup.PeriodStart.UTC()
+			if _, ok := byPeriod[period]; !ok {
+				periods = append(periods, period)
+			}
+			byPeriod[period] = append(byPeriod[period], rollup)
+		}
+		for _, period := range periods {
+			if err := storeRollups(tx, tier, period, byPeriod[period]); err != nil {
+				return err
+			}
+		}
+		if err := tx.Table(tier.Table()).Where("wiki_id = ?", from).Delete(&models.WikiStatsRollup{}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSince returns a wiki's rollups of a tier whose last sample is at or after
+// since, newest first; a zero since returns all of them
+func (r *StatsRollupRepository) ListSince(ctx context.Context, tier models.StatsTier, wikiID uuid.UUID, since time.Time) ([]models.WikiStatsRollup, error) {
+	var rollups []models.WikiStatsRollup
+	query := r.db.WithContext(ctx).Table(tier.Table()).Where("wiki_id = ?", wikiID)
+	if !since.IsZero() {
+		query = query.Where("last_time >= ?", since)
+	}
+	err := query.Order("period_start DESC").Find(&rollups).Error
+	return rollups, err
+}
+
+// countSince returns the number of rollups ListSince would return
+func (r *StatsRollupRepository) countSince(ctx context.Context, tier models.StatsTier, wikiID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Table(tier.Table()).Where("wiki_id = ?", wikiID)
+	if !since.IsZero() {
+		query = query.Where("last_time >= ?", since)
+	}
+	err := query.Count(&count).Error
+	return count, err
+}
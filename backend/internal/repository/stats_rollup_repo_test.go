@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestStatsRollupRepository_Apply(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	rollupRepo := NewStatsRollupRepository(db)
+	ctx := context.Background()
+
+	active := &models.Wiki{ID: uuid.New(), URL: "https://active.org", Status: models.WikiStatusOK}
+	gone := &models.Wiki{ID: uuid.New(), URL: "https://gone.org", Status: models.WikiStatusOffline}
+	require.NoError(t, wikiRepo.Create(ctx, active))
+	require.NoError(t, wikiRepo.Create(ctx, gone))
+
+	// Four samples a day from March 1 to April 30; gone stops after March 2
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	pages := 100
+	for tm := start; tm.Before(end); tm = tm.Add(6 * time.Hour) {
+		pages++
+		status := 200
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: active.ID, Time: tm, Pages: pages, HTTPStatus: &status}))
+		if tm.Before(start.AddDate(0, 0, 2)) {
+			require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: gone.ID, Time: tm, Pages: 7}))
+		}
+	}
+	before, err := statsRepo.GetByWikiID(ctx, active.ID, 0)
+	require.NoError(t, err)
+
+	// Raw rows before April 21 become daily rows; daily rows before April become monthly rows
+	result, err := rollupRepo.Apply(ctx, StatsRetention{RawDays: 10, DailyDays: 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(51*4+7), result.RawRows, "gone keeps its newest raw row")
+	assert.Equal(t, int64(31+2), result.DailyRows)
+
+	raw, err := statsRepo.CountByWikiID(ctx, active.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10*4), raw)
+	latest, err := statsRepo.GetLatestByWikiID(ctx, gone.ID)
+	require.NoError(t, err)
+	assert.Equal(t, start.Add(42*time.Hour), latest.Time.UTC())
+
+	monthly, err := rollupRepo.ListSince(ctx, models.StatsTierMonthly, active.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, monthly, 1)
+	march := monthly[0]
+	assert.Equal(t, start, march.PeriodStart.UTC())
+	assert.Equal(t, 31*4, march.Samples)
+	assert.Equal(t, models.MetricRange{Last: 101 + 31*4 - 1, Min: 101, Max: 101 + 31*4 - 1}, march.Pages)
+	assert.Equal(t, 200, *march.HTTPStatus)
+
+	daily, err := rollupRepo.ListSince(ctx, models.StatsTierDaily, active.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, daily, 20)
+	assert.Equal(t, time.Date(2024, 4, 20, 0, 0, 0, 0, time.UTC), daily[0].PeriodStart.UTC())
+	assert.Equal(t, 4, daily[0].Samples)
+	assert.Equal(t, daily[0].Pages.Max, daily[0].Pages.Last)
+
+	// Reads cover every tier, newest first, ending with the same values
+	after, err := statsRepo.GetByWikiID(ctx, active.ID, 0)
+	require.NoError(t, err)
+	assert.Len(t, after, 40+20+1)
+	assert.Equal(t, before[0].Pages, after[0].Pages)
+	for i := 1; i < len(after); i++ {
+		assert.False(t, after[i].Time.After(after[i-1].Time), "row %d is out of order", i)
+	}
+	assert.Equal(t, march.Pages.Last, after[len(after)-1].Pages)
+
+	version, err := statsRepo.GetWindowVersion(ctx, active.ID, 0)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(after)), version.Count)
+
+	result, err = rollupRepo.Apply(ctx, StatsRetention{RawDays: 10, DailyDays: 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, RollupResult{}, result, "a second run has nothing to do")
+
+	// Once gone is collected again, its kept row joins the existing March rollup
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: gone.ID, Time: now, Pages: 8}))
+	result, err = rollupRepo.Apply(ctx, StatsRetention{RawDays: 10, DailyDays: 20}, now)
+	require.NoError(t, err)
+	assert.Equal(t, RollupResult{RawRows: 1, DailyRows: 1}, result)
+	goneMonthly, err := rollupRepo.ListSince(ctx, models.StatsTierMonthly, gone.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, goneMonthly, 1)
+	assert.Equal(t, 8, goneMonthly[0].Samples)
+	assert.Equal(t, start.Add(42*time.Hour), goneMonthly[0].LastTime.UTC())
+}
+
+func TestStatsRollupRepository_Disabled(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.org", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, wiki))
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: old}))
+	require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: old.Add(time.Hour)}))
+
+	result, err := NewStatsRollupRepository(db).Apply(ctx, StatsRetention{}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, RollupResult{}, result)
+	count, err := statsRepo.CountByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
+
+func TestStatsRollupRepository_MergeWikis(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	rollupRepo := NewStatsRollupRepository(db)
+	ctx := context.Background()
+
+	survivor := &models.Wiki{ID: uuid.New(), URL: "https://example.org", Status: models.WikiStatusOK}
+	duplicate := &models.Wiki{ID: uuid.New(), URL: "http://example.org", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, survivor))
+	require.NoError(t, wikiRepo.Create(ctx, duplicate))
+
+	day := time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)
+	for i, wiki := range []*models.Wiki{survivor, duplicate} {
+		for h := 0; h < 3; h++ {
+			require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: day.Add(time.Duration(h*4+i) * time.Hour), Pages: 10*i + h}))
+		}
+	}
+	_, err := rollupRepo.Apply(ctx, StatsRetention{RawDays: 1}, day.AddDate(0, 0, 5))
+	require.NoError(t, err)
+
+	_, err = NewMergeRepository(db).Merge(ctx, survivor.ID, duplicate.ID, "admin", nil)
+	require.NoError(t, err)
+
+	daily, err := rollupRepo.ListSince(ctx, models.StatsTierDaily, survivor.ID, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, daily, 1)
+	assert.Equal(t, 4, daily[0].Samples, "the newest raw row of each wiki stays raw")
+	assert.Equal(t, models.MetricRange{Last: 11, Min: 0, Max: 11}, daily[0].Pages)
+	moved, err := rollupRepo.ListSince(ctx, models.StatsTierDaily, duplicate.ID, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, moved)
+}
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// SubmissionRepository handles submissions database operations
+type SubmissionRepository struct {
+	db *gorm.DB
+}
+
+// NewSubmissionRepository creates a new submission repository
+func NewSubmissionRepository(db *gorm.DB) *SubmissionRepository {
+	return &SubmissionRepository{db: db}
+}
+
+// openStatuses are the statuses of submissions not yet decided
+var openStatuses = []models.SubmissionStatus{models.SubmissionPending, models.SubmissionChecking, models.SubmissionReview}
+
+// Create stores a submission
+func (r *SubmissionRepository) Create(ctx context.Context, sub *models.Submission) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+// GetByID retrieves a submission by ID
+func (r *SubmissionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Submission, error) {
+	var sub models.Submission
+	if err := r.db.WithContext(ctx).First(&sub, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// GetOpenByURL retrieves the undecided submission of a URL, so a wiki submitted
+// twice is queued once
+func (r *SubmissionRepository) GetOpenByURL(ctx context.Context, url string) (*models.Submission, error) {
+	var sub models.Submission
+	err := r.db.WithContext(ctx).
+		Where("url = ? AND status IN ?", url, openStatuses).
+		Order("created_at").
+		First(&sub).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+// List returns submissions with the given status, or all when status is empty,
+// oldest first, and the number of matching submissions
+func (r *SubmissionRepository) List(ctx context.Context, status models.SubmissionStatus, page, pageSize int) ([]*models.Submission, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Submission{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var subs []*models.Submission
+	err := query.Order("created_at").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&subs).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return subs, total, nil
+}
+
+// ListCheckable returns the IDs of submissions awaiting their pre-check, including
+// checks left in progress since before staleBefore by a server that stopped
+func (r *SubmissionRepository) ListCheckable(ctx context.Context, staleBefore time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&models.Submission{}).
+		Where("status = ? OR (status = ? AND updated_at < ?)", models.SubmissionPending, models.SubmissionChecking, staleBefore).
+		Order("created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ClaimForCheck moves a submission awaiting its pre-check to checking. It reports
+// false when the submission is not awaiting a check, or another worker claimed it.
+func (r *SubmissionRepository) ClaimForCheck(ctx context.Context, id uuid.UUID, now, staleBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Submission{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, models.SubmissionPending, models.SubmissionChecking, staleBefore).
+		Updates(map[string]interface{}{"status": models.SubmissionChecking, "updated_at": now})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Transition saves sub if its stored status is one of from, so that concurrent
+// reviews and checks cannot both decide a submission. It reports whether it saved.
+func (r *SubmissionRepository) Transition(ctx context.Context, sub *models.Submission, from ...models.SubmissionStatus) (bool, error) {
+	sub.UpdatedAt = time.Now().UTC()
+	result := r.db.WithContext(ctx).Model(&models.Submission{}).
+		Where("id = ? AND status IN ?", sub.ID, from).
+		Select("*").Omit("id", "created_at", "proof_hash").
+		Updates(sub)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ProofUsed reports whether a submission was made with the proof of the given hash
+func (r *SubmissionRepository) ProofUsed(ctx context.Context, hash string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Submission{}).Where("proof_hash = ?", hash).Count(&count).Error
+	return count > 0, err
+}
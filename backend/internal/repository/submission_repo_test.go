@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestSubmissionRepository_Queue(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewSubmissionRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	proof := "p1"
+	first := &models.Submission{URL: "https://a.example.org", Status: models.SubmissionPending, SubmitterIP: "192.0.2.1", ProofHash: &proof}
+	require.NoError(t, repo.Create(ctx, first))
+	assert.Error(t, repo.Create(ctx, &models.Submission{URL: "https://b.example.org", Status: models.SubmissionPending, SubmitterIP: "192.0.2.1", ProofHash: &proof}),
+		"a proof is used once")
+	second := &models.Submission{URL: "https://b.example.org", Status: models.SubmissionPending, SubmitterIP: "192.0.2.2", CreatedAt: now.Add(time.Second)}
+	require.NoError(t, repo.Create(ctx, second))
+
+	open, err := repo.GetOpenByURL(ctx, "https://a.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, open.ID)
+
+	ids, err := repo.ListCheckable(ctx, now.Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2)
+
+	claimed, err := repo.ClaimForCheck(ctx, first.ID, now, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.True(t, claimed)
+	claimed, err = repo.ClaimForCheck(ctx, first.ID, now, now.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.False(t, claimed, "a check in progress is not claimed twice")
+
+	ids, err = repo.ListCheckable(ctx, now.Add(-time.Minute), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{second.ID}, ids)
+	ids, err = repo.ListCheckable(ctx, now.Add(time.Minute), 10)
+	require.NoError(t, err)
+	assert.Len(t, ids, 2, "stale checks are retried")
+
+	sub, err := repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionChecking, sub.Status)
+	sub.Status = models.SubmissionReview
+	sub.Reachable = true
+	saved, err := repo.Transition(ctx, sub, models.SubmissionChecking)
+	require.NoError(t, err)
+	assert.True(t, saved)
+
+	reason := "spam"
+	sub.Status = models.SubmissionRejected
+	sub.Reason = &reason
+	saved, err = repo.Transition(ctx, sub, models.SubmissionChecking)
+	require.NoError(t, err)
+	assert.False(t, saved, "the stored status no longer matches")
+	saved, err = repo.Transition(ctx, sub, models.SubmissionReview)
+	require.NoError(t, err)
+	assert.True(t, saved)
+
+	sub, err = repo.GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionRejected, sub.Status)
+	assert.True(t, sub.Reachable)
+	require.NotNil(t, sub.ProofHash)
+	assert.Equal(t, "p1", *sub.ProofHash)
+
+	_, err = repo.GetOpenByURL(ctx, "https://a.example.org")
+	assert.Error(t, err, "decided submissions are not open")
+
+	subs, total, err := repo.List(ctx, models.SubmissionRejected, 1, 20)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "spam", *subs[0].Reason)
+	subs, total, err = repo.List(ctx, "", 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, first.ID, subs[0].ID, "oldest first")
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wikikeeper-backend/internal/models"
+)
+
+// TagRepository handles tags and wiki_tags database operations
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// TagWithCount is a tag together with the number of wikis carrying it
+type TagWithCount struct {
+	models.Tag
+	WikiCount int64 `json:"wiki_count"`
+}
+
+// List returns all tags ordered by name with their wiki counts
+func (r *TagRepository) List(ctx context.Context) ([]TagWithCount, error) {
+	var tags []TagWithCount
+	err := r.db.WithContext(ctx).
+		Table("tags").
+		Select("tags.*, COUNT(wiki_tags.wiki_id) AS wiki_count").
+		Joins("LEFT JOIN wiki_tags ON wiki_tags.tag_id = tags.id AND wiki_tags.wiki_id NOT IN (" + trashedWikiIDs + ")").
+		Group("tags.id").
+		Order("tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// GetByName retrieves a tag by its normalized name
+func (r *TagRepository) GetByName(ctx context.Context, name string) (*models.Tag, error) {
+	var tag models.Tag
+	err := r.db.WithContext(ctx).First(&tag, "name = ?", name).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// Upsert creates a tag or updates the description of an existing one
+func (r *TagRepository) Upsert(ctx context.Context, name string, description *string) (*models.Tag, error) {
+	tag := &models.Tag{Name: name, Description: description}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description"}),
+	}).Create(tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByName(ctx, name)
+}
+
+// Delete removes a tag from all wikis and deletes it
+func (r *TagRepository) Delete(ctx context.Context, name string) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var tag models.Tag
+		if err := tx.First(&tag, "name = ?", name).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		var wikiIDs []uuid.UUID
+		if err := tx.Model(&models.WikiTag{}).Where("tag_id = ?", tag.ID).Pluck("wiki_id", &wikiIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", tag.ID).Delete(&models.WikiTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&tag).Error; err != nil {
+			return err
+		}
+		deleted = true
+		return touchWikis(tx, wikiIDs...)
+	})
+	return deleted, err
+}
+
+// ListByWikiID returns the tags of a wiki ordered by name
+func (r *TagRepository) ListByWikiID(ctx context.Context, wikiID uuid.UUID) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := r.db.WithContext(ctx).
+		Joins("JOIN wiki_tags ON wiki_tags.tag_id = tags.id").
+		Where("wiki_tags.wiki_id = ?", wikiID).
+		Order("tags.name ASC").
+		Find(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// AddToWiki tags a wiki, creating missing tags. Names must already be normalized.
+func (r *TagRepository) AddToWiki(ctx context.Context, wikiID uuid.UUID, names []string) ([]models.Tag, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return addWikiTags(tx, wikiID, names)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.ListByWikiID(ctx, wikiID)
+}
+
+// SetWikiTags replaces the tags of a wiki, creating missing tags. Names must already be normalized.
+func (r *TagRepository) SetWikiTags(ctx context.Context, wikiID uuid.UUID, names []string) ([]models.Tag, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("wiki_id = ?", wikiID).Delete(&models.WikiTag{}).Error; err != nil {
+			return err
+		}
+		return addWikiTags(tx, wikiID, names)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.ListByWikiID(ctx, wikiID)
+}
+
+// RemoveFromWiki removes a tag from a wiki and reports whether the wiki carried it
+func (r *TagRepository) RemoveFromWiki(ctx context.Context, wikiID uuid.UUID, name string) (bool, error) {
+	removed := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("wiki_id = ? AND tag_id IN (?)", wikiID, tx.Model(&models.Tag{}).Select("id").Where("name = ?", name)).
+			Delete(&models.WikiTag{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected > 0
+		if !removed {
+			return nil
+		}
+		return touchWikis(tx, wikiID)
+	})
+	return removed, err
+}
+
+// addWikiTags creates missing tags and links them to a wiki within tx
+func addWikiTags(tx *gorm.DB, wikiID uuid.UUID, names []string) error {
+	if len(names) > 0 {
+		tags := make([]models.Tag, len(names))
+		for i, name := range names {
+			tags[i] = models.Tag{Name: name}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+			return err
+		}
+
+		var tagIDs []uuid.UUID
+		if err := tx.Model(&models.Tag{}).Where("name IN ?", names).Pluck("id", &tagIDs).Error; err != nil {
+			return err
+		}
+		links := make([]models.WikiTag, len(tagIDs))
+		for i, tagID := range tagIDs {
+			links[i] = models.WikiTag{WikiID: wikiID, TagID: tagID, CreatedAt: time.Now()}
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error; err != nil {
+			return err
+		}
+	}
+	return touchWikis(tx, wikiID)
+}
+
+// touchWikis bumps updated_at of wikis whose tags changed, so HTTP validators and caches see the change
+func touchWikis(tx *gorm.DB, wikiIDs ...uuid.UUID) error {
+	if len(wikiIDs) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Wiki{}).Where("id IN ?", wikiIDs).UpdateColumn("updated_at", time.Now()).Error
+}
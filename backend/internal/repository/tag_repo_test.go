@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestTagRepository_WikiTags(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	repo := NewTagRepository(db)
+	ctx := context.Background()
+
+	first := &models.Wiki{ID: uuid.New(), URL: "https://first.com", Status: models.WikiStatusOK}
+	second := &models.Wiki{ID: uuid.New(), URL: "https://second.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, first))
+	require.NoError(t, wikiRepo.Create(ctx, second))
+
+	tags, err := repo.AddToWiki(ctx, first.ID, []string{"gaming", "at-risk"})
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "at-risk", tags[0].Name)
+
+	// Adding again is idempotent and reuses existing tags
+	_, err = repo.AddToWiki(ctx, first.ID, []string{"gaming"})
+	require.NoError(t, err)
+	_, err = repo.AddToWiki(ctx, second.ID, []string{"gaming"})
+	require.NoError(t, err)
+
+	all, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "at-risk", all[0].Name)
+	assert.Equal(t, int64(1), all[0].WikiCount)
+	assert.Equal(t, "gaming", all[1].Name)
+	assert.Equal(t, int64(2), all[1].WikiCount)
+
+	// Filter by tag; all tags must match
+	wikis, total, err := wikiRepo.List(ctx, ListOptions{Tags: []string{"gaming"}, IncludeTags: true})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, wiki := range wikis {
+		if wiki.ID == first.ID {
+			assert.Len(t, wiki.Tags, 2)
+		}
+	}
+	wikis, total, err = wikiRepo.List(ctx, ListOptions{Tags: []string{"gaming", "at-risk"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, first.ID, wikis[0].ID)
+
+	removed, err := repo.RemoveFromWiki(ctx, first.ID, "at-risk")
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = repo.RemoveFromWiki(ctx, first.ID, "at-risk")
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	tags, err = repo.SetWikiTags(ctx, second.ID, []string{"university"})
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "university", tags[0].Name)
+
+	deleted, err := repo.Delete(ctx, "gaming")
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	tags, err = repo.ListByWikiID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Empty(t, tags)
+}
+
+func TestTagRepository_Upsert(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewTagRepository(db)
+	ctx := context.Background()
+
+	description := "Forks of Fandom wikis"
+	tag, err := repo.Upsert(ctx, "fandom-fork", &description)
+	require.NoError(t, err)
+	assert.Equal(t, description, *tag.Description)
+
+	updated := "Community forks of Fandom wikis"
+	again, err := repo.Upsert(ctx, "fandom-fork", &updated)
+	require.NoError(t, err)
+	assert.Equal(t, tag.ID, again.ID)
+	assert.Equal(t, updated, *again.Description)
+}
+
+func TestNoteRepository(t *testing.T) {
+	db := setupTestDB(t)
+	wikiRepo := NewWikiRepository(db)
+	repo := NewNoteRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, wikiRepo.Create(ctx, wiki))
+
+	note := &models.WikiNote{WikiID: wiki.ID, Author: "alice", Body: "owner says the host closes in June"}
+	require.NoError(t, repo.Create(ctx, note))
+	assert.NotEqual(t, uuid.Nil, note.ID)
+
+	notes, err := repo.ListByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "alice", notes[0].Author)
+
+	deleted, err := repo.Delete(ctx, uuid.New(), note.ID)
+	require.NoError(t, err)
+	assert.False(t, deleted, "notes are only deleted through their own wiki")
+
+	deleted, err = repo.Delete(ctx, wiki.ID, note.ID)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+// WebhookRepository handles webhooks and webhook_deliveries database operations
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create registers a webhook
+func (r *WebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	// is_active has a database default, so a disabled webhook needs an explicit update
+	active := hook.IsActive
+	if err := r.db.WithContext(ctx).Create(hook).Error; err != nil {
+		return err
+	}
+	if !active {
+		hook.IsActive = false
+		return r.db.WithContext(ctx).Model(hook).Update("is_active", false).Error
+	}
+	return nil
+}
+
+// GetByID retrieves a webhook by ID
+func (r *WebhookRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Webhook, error) {
+	var hook models.Webhook
+	if err := r.db.WithContext(ctx).First(&hook, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// List returns all webhooks, oldest first
+func (r *WebhookRepository) List(ctx context.Context) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := r.db.WithContext(ctx).Order("created_at ASC").Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// ListActive returns the webhooks that receive deliveries
+func (r *WebhookRepository) ListActive(ctx context.Context) ([]models.Webhook, error) {
+	var hooks []models.Webhook
+	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// Update saves the editable fields of a webhook
+func (r *WebhookRepository) Update(ctx context.Context, hook *models.Webhook) error {
+	hook.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Model(hook).
+		Select("url", "events", "tag", "farm", "is_active", "description", "updated_at").
+		Updates(hook).Error
+}
+
+// Delete removes a webhook and its delivery log; found reports whether it existed
+func (r *WebhookRepository) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	var found bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Webhook{}, "id = ?", id)
+		found = result.RowsAffected > 0
+		return result.Error
+	})
+	return found, err
+}
+
+// CreateDeliveries queues deliveries
+func (r *WebhookRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is due, oldest first
+func (r *WebhookRepository) ListDueDeliveries(ctx context.Context, now time.Time, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+		Order("next_attempt_at ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// UpdateDelivery saves the outcome of a delivery attempt
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Model(delivery).
+		Select("status", "attempts", "response_status", "error", "next_attempt_at", "delivered_at").
+		Updates(delivery).Error
+}
+
+// ListDeliveries returns a page of a webhook's delivery log, newest first, optionally by status
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, status models.WebhookDeliveryStatus, page, pageSize int) ([]*models.WebhookDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	err := query.Order("created_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// RetryDelivery queues a delivery of a webhook for another attempt at now; found reports whether it exists
+func (r *WebhookRepository) RetryDelivery(ctx context.Context, webhookID, deliveryID uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND webhook_id = ?", deliveryID, webhookID).
+		Updates(map[string]interface{}{
+			"status":          models.WebhookDeliveryPending,
+			"next_attempt_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
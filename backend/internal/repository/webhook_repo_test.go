@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestWebhookRepository_CRUD(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWebhookRepository(db)
+	ctx := context.Background()
+
+	active := &models.Webhook{URL: "https://example.org/hook", Secret: "s1", IsActive: true, CreatedBy: "admin",
+		EventFilter: models.EventFilter{Events: models.StringList{string(models.WikiEventWentOffline)}}}
+	disabled := &models.Webhook{URL: "https://example.org/disabled", Secret: "s2", CreatedBy: "admin"}
+	require.NoError(t, repo.Create(ctx, active))
+	require.NoError(t, repo.Create(ctx, disabled))
+
+	hooks, err := repo.ListActive(ctx)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, active.ID, hooks[0].ID)
+	assert.Equal(t, models.StringList{"went_offline"}, hooks[0].Events)
+
+	farm := "miraheze.org"
+	disabled.IsActive = true
+	disabled.Farm = &farm
+	require.NoError(t, repo.Update(ctx, disabled))
+	got, err := repo.GetByID(ctx, disabled.ID)
+	require.NoError(t, err)
+	assert.True(t, got.IsActive)
+	require.NotNil(t, got.Farm)
+	assert.Equal(t, "s2", got.Secret, "updates never touch the secret")
+
+	all, err := repo.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	require.NoError(t, repo.CreateDeliveries(ctx, []*models.WebhookDelivery{
+		{WebhookID: active.ID, EventType: "went_offline", Payload: "{}", Status: models.WebhookDeliveryPending},
+	}))
+	found, err := repo.Delete(ctx, active.ID)
+	require.NoError(t, err)
+	assert.True(t, found)
+	_, err = repo.GetByID(ctx, active.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, total, err := repo.ListDeliveries(ctx, active.ID, "", 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total, "deleting a webhook drops its delivery log")
+
+	found, err = repo.Delete(ctx, uuid.New())
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestWebhookRepository_Deliveries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWebhookRepository(db)
+	ctx := context.Background()
+
+	hook := &models.Webhook{URL: "https://example.org/hook", Secret: "s", IsActive: true, CreatedBy: "admin"}
+	require.NoError(t, repo.Create(ctx, hook))
+
+	now := time.Now().UTC()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Minute)
+	due := &models.WebhookDelivery{WebhookID: hook.ID, EventType: "anomaly", Payload: "{}", Status: models.WebhookDeliveryPending, NextAttemptAt: &earlier}
+	notYet := &models.WebhookDelivery{WebhookID: hook.ID, EventType: "anomaly", Payload: "{}", Status: models.WebhookDeliveryPending, NextAttemptAt: &later}
+	require.NoError(t, repo.CreateDeliveries(ctx, []*models.WebhookDelivery{due, notYet}))
+
+	deliveries, err := repo.ListDueDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+
+	status := 500
+	due.Status = models.WebhookDeliveryFailed
+	due.Attempts = 6
+	due.ResponseStatus = &status
+	due.NextAttemptAt = nil
+	require.NoError(t, repo.UpdateDelivery(ctx, due))
+
+	deliveries, err = repo.ListDueDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Empty(t, deliveries)
+
+	failed, total, err := repo.ListDeliveries(ctx, hook.ID, models.WebhookDeliveryFailed, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, 6, failed[0].Attempts)
+
+	found, err := repo.RetryDelivery(ctx, hook.ID, due.ID, now)
+	require.NoError(t, err)
+	assert.True(t, found)
+	deliveries, err = repo.ListDueDeliveries(ctx, now, 10)
+	require.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+
+	found, err = repo.RetryDelivery(ctx, uuid.New(), due.ID, now)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
@@ -3,9 +3,11 @@ package repository
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"wikikeeper-backend/internal/models"
 )
 
@@ -61,22 +63,68 @@ type ListOptions struct {
 	Status    *models.WikiStatus
 	HasArchive *bool
 	Search    string // Search in sitename
+	Tags      []string // Only wikis carrying all of these tags
 	OrderBy   string // e.g., "updated_at DESC"
+	IncludeTags bool // Preload the tags of each wiki
 }
 
 func (r *WikiRepository) List(ctx context.Context, opts ListOptions) ([]*models.Wiki, int64, error) {
 	var wikis []*models.Wiki
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&models.Wiki{})
+	query := applyWikiFilters(r.db.WithContext(ctx).Model(&models.Wiki{}), opts)
 
-	// Apply filters
+	// Count total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	if opts.Page < 1 {
+		opts.Page = 1
+	}
+	if opts.PageSize < 1 {
+		opts.PageSize = 10
+	}
+	offset := (opts.Page - 1) * opts.PageSize
+
+	// Apply ordering
+	if opts.OrderBy != "" {
+		query = query.Order(opts.OrderBy)
+	} else {
+		query = query.Order("updated_at DESC")
+	}
+
+	if opts.IncludeTags {
+		query = query.Preload("Tags", func(db *gorm.DB) *gorm.DB { return db.Order("tags.name ASC") })
+	}
+
+	// Fetch results
+	err := query.Offset(offset).Limit(opts.PageSize).Find(&wikis).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return wikis, total, nil
+}
+
+// applyWikiFilters applies the status, archive and search filters of opts to a wikis query
+func applyWikiFilters(query *gorm.DB, opts ListOptions) *gorm.DB {
 	if opts.Status != nil {
 		query = query.Where("status = ?", *opts.Status)
 	}
 	if opts.HasArchive != nil {
 		query = query.Where("has_archive = ?", *opts.HasArchive)
 	}
+	if len(opts.Tags) > 0 {
+		query = query.Where("id IN (?)", query.Session(&gorm.Session{NewDB: true}).
+			Table("wiki_tags").
+			Select("wiki_tags.wiki_id").
+			Joins("JOIN tags ON tags.id = wiki_tags.tag_id").
+			Where("tags.name IN ?", opts.Tags).
+			Group("wiki_tags.wiki_id").
+			Having("COUNT(DISTINCT tags.id) = ?", len(opts.Tags)))
+	}
 	if opts.Search != "" {
 		// Remove protocol from search term to match URLs with or without http/https
 		cleanSearch := strings.TrimPrefix(opts.Search, "http://")
@@ -89,45 +137,282 @@ func (r *WikiRepository) List(ctx context.Context, opts ListOptions) ([]*models.
 		query = query.Where("sitename ILIKE ? OR url ILIKE ? OR url ILIKE ?",
 			searchPattern, searchPattern, cleanPattern)
 	}
+	return query
+}
+
+// hasWikiFilters reports whether opts restricts the set of wikis
+func hasWikiFilters(opts ListOptions) bool {
+	return opts.Status != nil || opts.HasArchive != nil || opts.Search != ""
+}
+
+// ExportOptions controls which rows the streaming export methods return
+type ExportOptions struct {
+	ListOptions            // Wiki filters, same as /api/wikis
+	Since       *time.Time // Resume point: only rows changed at or after this time
+	From        *time.Time // Stats only: start of the time range
+	To          *time.Time // Stats only: end of the time range
+}
+
+// wikiIDSubquery returns a subquery selecting the IDs of wikis matching the export filters
+func (r *WikiRepository) wikiIDSubquery(ctx context.Context, opts ListOptions) *gorm.DB {
+	return applyWikiFilters(r.db.WithContext(ctx).Model(&models.Wiki{}), opts).Select("id")
+}
+
+// StreamForExport iterates over wikis matching opts, ordered by updated_at, using a database cursor.
+// Rows are passed to fn one at a time so the full result set is never held in memory.
+func (r *WikiRepository) StreamForExport(ctx context.Context, opts ExportOptions, fn func(*models.Wiki) error) error {
+	query := applyWikiFilters(r.db.WithContext(ctx).Model(&models.Wiki{}), opts.ListOptions)
+	if opts.Since != nil {
+		query = query.Where("updated_at >= ?", *opts.Since)
+	}
 
-	// Count total
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	rows, err := query.Order("updated_at ASC, id ASC").Rows()
+	if err != nil {
+		return err
 	}
+	defer rows.Close()
 
-	// Apply pagination
-	if opts.Page < 1 {
-		opts.Page = 1
+	for rows.Next() {
+		var wiki models.Wiki
+		if err := r.db.ScanRows(rows, &wiki); err != nil {
+			return err
+		}
+		if err := fn(&wiki); err != nil {
+			return err
+		}
 	}
-	if opts.PageSize < 1 {
-		opts.PageSize = 10
+	return rows.Err()
+}
+
+// DataVersion identifies the state of a set of rows; used to derive HTTP validators
+type DataVersion struct {
+	Count        int64
+	LastModified time.Time // Zero if there are no rows
+}
+
+// GetVersion returns the number of wikis and the latest updated_at.
+// Any create, update or delete of a wiki changes the result.
+func (r *WikiRepository) GetVersion(ctx context.Context) (DataVersion, error) {
+	var version DataVersion
+	if err := r.db.WithContext(ctx).Model(&models.Wiki{}).Count(&version.Count).Error; err != nil {
+		return version, err
 	}
-	offset := (opts.Page - 1) * opts.PageSize
 
-	// Apply ordering
-	if opts.OrderBy != "" {
-		query = query.Order(opts.OrderBy)
-	} else {
-		query = query.Order("updated_at DESC")
+	var times []time.Time
+	if err := r.db.WithContext(ctx).Model(&models.Wiki{}).
+		Order("updated_at DESC").
+		Limit(1).
+		Pluck("updated_at", &times).Error; err != nil {
+		return version, err
+	}
+	if len(times) > 0 {
+		version.LastModified = times[0]
 	}
+	return version, nil
+}
 
-	// Fetch results
-	err := query.Offset(offset).Limit(opts.PageSize).Find(&wikis).Error
+// Update updates a wiki. Associations such as tags are managed by their own repositories.
+func (r *WikiRepository) Update(ctx context.Context, wiki *models.Wiki) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Save(wiki).Error
+}
+
+// trashedWikiIDs selects the IDs of wikis in the trash, for raw queries over
+// wiki_stats and other tables that GORM's soft delete scope does not reach
+const trashedWikiIDs = "SELECT id FROM wikis WHERE deleted_at IS NOT NULL"
+
+// Trash moves a wiki to the trash. Its stats, archives and events are kept until it is purged.
+func (r *WikiRepository) Trash(ctx context.Context, id uuid.UUID, actor string, reason *string) error {
+	result := r.db.WithContext(ctx).Model(&models.Wiki{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"deleted_at":    time.Now(),
+			"deleted_by":    actor,
+			"delete_reason": reason,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetTrashedByID retrieves a wiki in the trash by ID
+func (r *WikiRepository) GetTrashedByID(ctx context.Context, id uuid.UUID) (*models.Wiki, error) {
+	var wiki models.Wiki
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&wiki, "id = ?", id).Error
 	if err != nil {
+		return nil, err
+	}
+	return &wiki, nil
+}
+
+// GetTrashedByURL retrieves a wiki in the trash by URL. The URL stays taken while the wiki is in the trash.
+func (r *WikiRepository) GetTrashedByURL(ctx context.Context, url string) (*models.Wiki, error) {
+	var wiki models.Wiki
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		First(&wiki, "url = ?", url).Error
+	if err != nil {
+		return nil, err
+	}
+	return &wiki, nil
+}
+
+// ListTrash retrieves wikis in the trash, most recently deleted first
+func (r *WikiRepository) ListTrash(ctx context.Context, page, pageSize int) ([]*models.Wiki, int64, error) {
+	query := r.db.WithContext(ctx).Unscoped().Model(&models.Wiki{}).Where("deleted_at IS NOT NULL")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	var wikis []*models.Wiki
+	err := query.Order("deleted_at DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&wikis).Error
+	if err != nil {
+		return nil, 0, err
+	}
 	return wikis, total, nil
 }
 
-// Update updates a wiki
-func (r *WikiRepository) Update(ctx context.Context, wiki *models.Wiki) error {
-	return r.db.WithContext(ctx).Save(wiki).Error
+// Restore takes a wiki out of the trash
+func (r *WikiRepository) Restore(ctx context.Context, id uuid.UUID) (*models.Wiki, error) {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.Wiki{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{
+			"deleted_at":    nil,
+			"deleted_by":    nil,
+			"delete_reason": nil,
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.GetByID(ctx, id)
+}
+
+// PurgeTrashedBefore permanently deletes wikis that were moved to the trash before cutoff,
+// together with their stats, archives and everything else that references them
+func (r *WikiRepository) PurgeTrashedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var purged int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []uuid.UUID
+		if err := tx.Unscoped().Model(&models.Wiki{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// Delete dependents explicitly rather than relying on ON DELETE CASCADE
+		for _, model := range []interface{}{
+			&models.WikiStats{}, &models.WikiArchive{}, &models.WikiEvent{}, &models.WikiNote{},
+			&models.WikiTag{}, &models.WikiMerge{}, &models.WikiURL{}, &models.WikiCheck{},
+		} {
+			if err := tx.Where("wiki_id IN ?", ids).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		for _, tier := range []models.StatsTier{models.StatsTierDaily, models.StatsTierMonthly} {
+			if err := tx.Table(tier.Table()).Where("wiki_id IN ?", ids).Delete(&models.WikiStatsRollup{}).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&models.Wiki{})
+		if result.Error != nil {
+			return result.Error
+		}
+		purged = result.RowsAffected
+		return nil
+	})
+	return purged, err
+}
+
+// CheckKind selects which of the two schedules a query works on
+type CheckKind string
+
+const (
+	CheckCollect CheckKind = "next_check_at"
+	CheckArchive CheckKind = "next_archive_check_at"
+)
+
+// schedulable restricts a query to wikis the schedulers may check at now:
+// active, not paused and, for archive checks, with a known API URL
+func schedulable(query *gorm.DB, kind CheckKind, now time.Time) *gorm.DB {
+	query = query.Where("is_active = ? AND (paused_until IS NULL OR paused_until <= ?)", true, now)
+	if kind == CheckArchive {
+		query = query.Where("api_url IS NOT NULL")
+	}
+	return query
+}
+
+// ListDue returns schedulable wikis whose next check of kind is due at now,
+// highest priority first, then the longest overdue
+func (r *WikiRepository) ListDue(ctx context.Context, kind CheckKind, now time.Time, limit int) ([]*models.Wiki, error) {
+	column := string(kind)
+	var wikis []*models.Wiki
+	err := schedulable(r.db.WithContext(ctx), kind, now).
+		Where("("+column+" IS NULL OR "+column+" <= ?)", now).
+		Order("priority DESC").
+		Order(column + " ASC NULLS FIRST").
+		Limit(limit).
+		Find(&wikis).Error
+	if err != nil {
+		return nil, err
+	}
+	return wikis, nil
+}
+
+// NextDueAt returns the earliest time a schedulable wiki is due for a check of kind.
+// It returns nil if there is nothing to check. Wikis paused at now are not considered.
+func (r *WikiRepository) NextDueAt(ctx context.Context, kind CheckKind, now time.Time) (*time.Time, error) {
+	column := string(kind)
+	var wikis []*models.Wiki
+	err := schedulable(r.db.WithContext(ctx), kind, now).
+		Order(column + " ASC NULLS FIRST").
+		Limit(1).
+		Find(&wikis).Error
+	if err != nil || len(wikis) == 0 {
+		return nil, err
+	}
+	if kind == CheckArchive {
+		if wikis[0].NextArchiveCheckAt == nil {
+			return &now, nil
+		}
+		return wikis[0].NextArchiveCheckAt, nil
+	}
+	if wikis[0].NextCheckAt == nil {
+		return &now, nil
+	}
+	return wikis[0].NextCheckAt, nil
 }
 
-// Delete deletes a wiki (cascades to stats and archives)
-func (r *WikiRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Delete(&models.Wiki{}, "id = ?", id).Error
+// UpdatePolicy saves the check policy and next check times of a wiki
+func (r *WikiRepository) UpdatePolicy(ctx context.Context, wiki *models.Wiki) error {
+	return r.db.WithContext(ctx).Model(wiki).
+		Select("priority", "collect_interval_minutes", "archive_check_interval_minutes", "paused_until",
+			"is_active", "next_check_at", "next_archive_check_at", "updated_at").
+		Updates(wiki).Error
+}
+
+// ScheduleNext sets only the next check time of kind for a wiki, so a failed
+// check can be pushed back without saving the rest of a possibly stale row
+func (r *WikiRepository) ScheduleNext(ctx context.Context, id uuid.UUID, kind CheckKind, at *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Wiki{}).
+		Where("id = ?", id).
+		Update(string(kind), at).Error
 }
 
 // GetPendingForUpdate retrieves wikis that need to be checked, ordered by last_check_at
@@ -144,10 +429,28 @@ func (r *WikiRepository) GetPendingForUpdate(ctx context.Context, limit int) ([]
 	return wikis, nil
 }
 
-// ExistsByURL checks if a wiki with the given URL exists
+// FindByCanonicalAPIURL returns other wikis whose API URL canonicalizes like apiURL, oldest first
+func (r *WikiRepository) FindByCanonicalAPIURL(ctx context.Context, apiURL string, excludeID uuid.UUID) ([]*models.Wiki, error) {
+	canonical := models.CanonicalAPIURL(apiURL)
+	if canonical == "" {
+		return nil, nil
+	}
+
+	var wikis []*models.Wiki
+	err := r.db.WithContext(ctx).
+		Where("api_url_canonical = ? AND id <> ?", canonical, excludeID).
+		Order("created_at ASC").
+		Find(&wikis).Error
+	if err != nil {
+		return nil, err
+	}
+	return wikis, nil
+}
+
+// ExistsByURL checks if a wiki with the given URL exists, in the trash or not
 func (r *WikiRepository) ExistsByURL(ctx context.Context, url string) (bool, error) {
 	var count int64
-	err := r.db.WithContext(ctx).Model(&models.Wiki{}).Where("url = ?", url).Count(&count).Error
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Wiki{}).Where("url = ?", url).Count(&count).Error
 	return count > 0, err
 }
 
@@ -206,6 +509,7 @@ func (r *WikiRepository) GetSummaryStats(ctx context.Context) (map[string]int64,
 			FROM wiki_stats ws2
 			WHERE ws2.wiki_id = ws1.wiki_id
 		)
+		AND ws1.wiki_id NOT IN (` + trashedWikiIDs + `)
 	`).Scan(&pageSum).Error; err != nil {
 		return nil, err
 	}
@@ -224,6 +528,7 @@ func (r *WikiRepository) GetSummaryStats(ctx context.Context) (map[string]int64,
 			FROM wiki_stats ws2
 			WHERE ws2.wiki_id = ws1.wiki_id
 		)
+		AND ws1.wiki_id NOT IN (` + trashedWikiIDs + `)
 	`).Scan(&editSum).Error; err != nil {
 		return nil, err
 	}
@@ -24,6 +24,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			id TEXT PRIMARY KEY,
 			url TEXT NOT NULL UNIQUE,
 			api_url TEXT,
+			api_url_canonical TEXT,
 			index_url TEXT,
 			wiki_name TEXT,
 			sitename TEXT,
@@ -32,15 +33,31 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			db_version TEXT,
 			media_wiki_version TEXT,
 			max_page_id INTEGER,
+			license TEXT,
+			license_url TEXT,
+			extensions TEXT,
 			status TEXT NOT NULL DEFAULT 'pending',
 			has_archive INTEGER NOT NULL DEFAULT 0,
 			api_available INTEGER NOT NULL DEFAULT 1,
+			read_only INTEGER NOT NULL DEFAULT 0,
 			last_error TEXT,
 			last_error_at DATETIME,
+			archive_last_check_at DATETIME,
+			archive_last_error TEXT,
+			archive_last_error_at DATETIME,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			last_check_at DATETIME,
-			is_active INTEGER NOT NULL DEFAULT 1
+			is_active INTEGER NOT NULL DEFAULT 1,
+			priority INTEGER NOT NULL DEFAULT 0,
+			collect_interval_minutes INTEGER,
+			archive_check_interval_minutes INTEGER,
+			paused_until DATETIME,
+			next_check_at DATETIME,
+			next_archive_check_at DATETIME,
+			deleted_at DATETIME,
+			deleted_by TEXT,
+			delete_reason TEXT
 		)
 	`)
 
@@ -80,6 +97,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			has_titles_list INTEGER NOT NULL DEFAULT 0,
 			has_images_list INTEGER NOT NULL DEFAULT 0,
 			has_legacy_wikidump INTEGER NOT NULL DEFAULT 0,
+			removed_at DATETIME,
+			missed_checks INTEGER NOT NULL DEFAULT 0,
 			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
 			UNIQUE(wiki_id, ia_identifier),
@@ -87,6 +106,311 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		)
 	`)
 
+	db.Exec(`
+		CREATE TABLE wiki_urls (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			url TEXT NOT NULL,
+			first_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(kind, url),
+			FOREIGN KEY (wiki_id) REFERENCES wikis(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_merges (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			merged_wiki_id TEXT NOT NULL,
+			merged_url TEXT NOT NULL,
+			merged_api_url TEXT,
+			stats_moved INTEGER NOT NULL DEFAULT 0,
+			archives_moved INTEGER NOT NULL DEFAULT 0,
+			actor TEXT NOT NULL,
+			reason TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (wiki_id) REFERENCES wikis(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE tags (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_tags (
+			wiki_id TEXT NOT NULL,
+			tag_id TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (wiki_id, tag_id),
+			FOREIGN KEY (wiki_id) REFERENCES wikis(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_notes (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			author TEXT NOT NULL,
+			body TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (wiki_id) REFERENCES wikis(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_events (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			from_status TEXT,
+			to_status TEXT,
+			ia_identifier TEXT,
+			message TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (wiki_id) REFERENCES wikis(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE catalog_snapshots (
+			date DATE PRIMARY KEY,
+			total_wikis INTEGER NOT NULL DEFAULT 0,
+			active_wikis INTEGER NOT NULL DEFAULT 0,
+			ok_wikis INTEGER NOT NULL DEFAULT 0,
+			error_wikis INTEGER NOT NULL DEFAULT 0,
+			offline_wikis INTEGER NOT NULL DEFAULT 0,
+			pending_wikis INTEGER NOT NULL DEFAULT 0,
+			archived_wikis INTEGER NOT NULL DEFAULT 0,
+			total_pages INTEGER NOT NULL DEFAULT 0,
+			total_edits INTEGER NOT NULL DEFAULT 0,
+			captured_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE webhooks (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			secret TEXT NOT NULL,
+			events TEXT,
+			tag TEXT,
+			farm TEXT,
+			is_active INTEGER NOT NULL DEFAULT 1,
+			description TEXT,
+			created_by TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE webhook_deliveries (
+			id TEXT PRIMARY KEY,
+			webhook_id TEXT NOT NULL,
+			event_id TEXT,
+			event_type TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			response_status INTEGER,
+			error TEXT,
+			next_attempt_at DATETIME,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			delivered_at DATETIME,
+			FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE api_tokens (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			prefix TEXT NOT NULL,
+			scopes TEXT NOT NULL,
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			revoked_at DATETIME,
+			created_by TEXT NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE auth_sessions (
+			id TEXT PRIMARY KEY,
+			secret_hash TEXT NOT NULL UNIQUE,
+			csrf_token TEXT NOT NULL,
+			token_id TEXT,
+			credential_hash TEXT NOT NULL,
+			subject TEXT,
+			email TEXT,
+			roles TEXT,
+			scopes TEXT,
+			name TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE auth_login_codes (
+			code_hash TEXT PRIMARY KEY,
+			token_id TEXT,
+			credential_hash TEXT NOT NULL,
+			name TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE audit_log (
+			id TEXT PRIMARY KEY,
+			actor TEXT NOT NULL,
+			token_id TEXT,
+			ip TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_id TEXT,
+			params TEXT,
+			outcome TEXT NOT NULL,
+			status INTEGER NOT NULL,
+			error TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE auth_oidc_states (
+			state_hash TEXT PRIMARY KEY,
+			nonce TEXT NOT NULL,
+			code_verifier TEXT NOT NULL,
+			redirect_to TEXT NOT NULL,
+			expires_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE submissions (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			api_url TEXT,
+			wiki_name TEXT,
+			status TEXT NOT NULL,
+			submitter_ip TEXT NOT NULL,
+			proof_hash TEXT UNIQUE,
+			checked_at DATETIME,
+			reachable INTEGER NOT NULL DEFAULT 0,
+			is_mediawiki INTEGER NOT NULL DEFAULT 0,
+			sitename TEXT,
+			generator TEXT,
+			duplicate_of TEXT,
+			is_alias INTEGER NOT NULL DEFAULT 0,
+			check_error TEXT,
+			wiki_id TEXT,
+			reviewed_by TEXT,
+			reviewed_at DATETIME,
+			reason TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_stats_daily (
+			wiki_id TEXT NOT NULL,
+			period_start DATETIME NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			first_time DATETIME NOT NULL,
+			last_time DATETIME NOT NULL,
+			pages_last INTEGER NOT NULL DEFAULT 0,
+			pages_min INTEGER NOT NULL DEFAULT 0,
+			pages_max INTEGER NOT NULL DEFAULT 0,
+			articles_last INTEGER NOT NULL DEFAULT 0,
+			articles_min INTEGER NOT NULL DEFAULT 0,
+			articles_max INTEGER NOT NULL DEFAULT 0,
+			edits_last INTEGER NOT NULL DEFAULT 0,
+			edits_min INTEGER NOT NULL DEFAULT 0,
+			edits_max INTEGER NOT NULL DEFAULT 0,
+			images_last INTEGER NOT NULL DEFAULT 0,
+			images_min INTEGER NOT NULL DEFAULT 0,
+			images_max INTEGER NOT NULL DEFAULT 0,
+			users_last INTEGER NOT NULL DEFAULT 0,
+			users_min INTEGER NOT NULL DEFAULT 0,
+			users_max INTEGER NOT NULL DEFAULT 0,
+			active_users_last INTEGER NOT NULL DEFAULT 0,
+			active_users_min INTEGER NOT NULL DEFAULT 0,
+			active_users_max INTEGER NOT NULL DEFAULT 0,
+			admins_last INTEGER NOT NULL DEFAULT 0,
+			admins_min INTEGER NOT NULL DEFAULT 0,
+			admins_max INTEGER NOT NULL DEFAULT 0,
+			jobs_last INTEGER NOT NULL DEFAULT 0,
+			jobs_min INTEGER NOT NULL DEFAULT 0,
+			jobs_max INTEGER NOT NULL DEFAULT 0,
+			response_time_ms INTEGER,
+			http_status INTEGER,
+			PRIMARY KEY (wiki_id, period_start)
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_stats_monthly (
+			wiki_id TEXT NOT NULL,
+			period_start DATETIME NOT NULL,
+			samples INTEGER NOT NULL DEFAULT 0,
+			first_time DATETIME NOT NULL,
+			last_time DATETIME NOT NULL,
+			pages_last INTEGER NOT NULL DEFAULT 0,
+			pages_min INTEGER NOT NULL DEFAULT 0,
+			pages_max INTEGER NOT NULL DEFAULT 0,
+			articles_last INTEGER NOT NULL DEFAULT 0,
+			articles_min INTEGER NOT NULL DEFAULT 0,
+			articles_max INTEGER NOT NULL DEFAULT 0,
+			edits_last INTEGER NOT NULL DEFAULT 0,
+			edits_min INTEGER NOT NULL DEFAULT 0,
+			edits_max INTEGER NOT NULL DEFAULT 0,
+			images_last INTEGER NOT NULL DEFAULT 0,
+			images_min INTEGER NOT NULL DEFAULT 0,
+			images_max INTEGER NOT NULL DEFAULT 0,
+			users_last INTEGER NOT NULL DEFAULT 0,
+			users_min INTEGER NOT NULL DEFAULT 0,
+			users_max INTEGER NOT NULL DEFAULT 0,
+			active_users_last INTEGER NOT NULL DEFAULT 0,
+			active_users_min INTEGER NOT NULL DEFAULT 0,
+			active_users_max INTEGER NOT NULL DEFAULT 0,
+			admins_last INTEGER NOT NULL DEFAULT 0,
+			admins_min INTEGER NOT NULL DEFAULT 0,
+			admins_max INTEGER NOT NULL DEFAULT 0,
+			jobs_last INTEGER NOT NULL DEFAULT 0,
+			jobs_min INTEGER NOT NULL DEFAULT 0,
+			jobs_max INTEGER NOT NULL DEFAULT 0,
+			response_time_ms INTEGER,
+			http_status INTEGER,
+			PRIMARY KEY (wiki_id, period_start)
+		)
+	`)
+
+	db.Exec(`
+		CREATE TABLE wiki_checks (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			wiki_id TEXT NOT NULL,
+			time DATETIME NOT NULL,
+			ok INTEGER NOT NULL DEFAULT 0,
+			response_time_ms INTEGER,
+			http_status INTEGER
+		)
+	`)
+
 	return db
 }
 
@@ -304,7 +628,7 @@ func TestWikiRepository_Update(t *testing.T) {
 	assert.Equal(t, models.WikiStatusOK, found.Status)
 }
 
-func TestWikiRepository_Delete(t *testing.T) {
+func TestWikiRepository_Trash(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewWikiRepository(db)
 	ctx := context.Background()
@@ -315,14 +639,105 @@ func TestWikiRepository_Delete(t *testing.T) {
 		Status: models.WikiStatusOK,
 	}
 	require.NoError(t, repo.Create(ctx, wiki))
+	require.NoError(t, NewStatsRepository(db).Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: time.Now(), Pages: 42}))
 
-	// Delete
-	err := repo.Delete(ctx, wiki.ID)
+	reason := "spam"
+	require.NoError(t, repo.Trash(ctx, wiki.ID, "admin", &reason))
+	assert.ErrorIs(t, repo.Trash(ctx, wiki.ID, "admin", nil), gorm.ErrRecordNotFound)
+
+	// Hidden from lookups, lists and summaries
+	_, err := repo.GetByID(ctx, wiki.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	wikis, total, err := repo.List(ctx, ListOptions{Page: 1, PageSize: 10})
+	require.NoError(t, err)
+	assert.Empty(t, wikis)
+	assert.Zero(t, total)
+	summary, err := repo.GetSummaryStats(ctx)
 	require.NoError(t, err)
+	assert.Zero(t, summary["total_pages"])
 
-	// Verify deletion
-	_, err = repo.GetByID(ctx, wiki.ID)
-	assert.Error(t, err)
+	// The URL stays taken
+	exists, err := repo.ExistsByURL(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.True(t, exists)
+	trashed, err := repo.GetTrashedByURL(ctx, "https://example.com")
+	require.NoError(t, err)
+	assert.Equal(t, wiki.ID, trashed.ID)
+
+	// History is kept
+	count, err := NewStatsRepository(db).CountByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	trash, total, err := repo.ListTrash(ctx, 1, 10)
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	assert.Equal(t, int64(1), total)
+	assert.True(t, trash[0].DeletedAt.Valid)
+	require.NotNil(t, trash[0].DeletedBy)
+	assert.Equal(t, "admin", *trash[0].DeletedBy)
+	require.NotNil(t, trash[0].DeleteReason)
+	assert.Equal(t, reason, *trash[0].DeleteReason)
+}
+
+func TestWikiRepository_Restore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK}
+	require.NoError(t, repo.Create(ctx, wiki))
+
+	_, err := repo.Restore(ctx, wiki.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound, "only wikis in the trash can be restored")
+
+	require.NoError(t, repo.Trash(ctx, wiki.ID, "admin", nil))
+	restored, err := repo.Restore(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, wiki.ID, restored.ID)
+	assert.False(t, restored.DeletedAt.Valid)
+	assert.Nil(t, restored.DeletedBy)
+
+	_, total, err := repo.ListTrash(ctx, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestWikiRepository_PurgeTrashedBefore(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	statsRepo := NewStatsRepository(db)
+	ctx := context.Background()
+
+	old := &models.Wiki{ID: uuid.New(), URL: "https://old.example", Status: models.WikiStatusOK}
+	recent := &models.Wiki{ID: uuid.New(), URL: "https://recent.example", Status: models.WikiStatusOK}
+	live := &models.Wiki{ID: uuid.New(), URL: "https://live.example", Status: models.WikiStatusOK}
+	for _, wiki := range []*models.Wiki{old, recent, live} {
+		require.NoError(t, repo.Create(ctx, wiki))
+		require.NoError(t, statsRepo.Create(ctx, &models.WikiStats{WikiID: wiki.ID, Time: time.Now()}))
+	}
+	require.NoError(t, repo.Trash(ctx, old.ID, "admin", nil))
+	require.NoError(t, repo.Trash(ctx, recent.ID, "admin", nil))
+	require.NoError(t, db.Unscoped().Model(&models.Wiki{}).Where("id = ?", old.ID).
+		Update("deleted_at", time.Now().AddDate(0, 0, -40)).Error)
+
+	purged, err := repo.PurgeTrashedBefore(ctx, time.Now().AddDate(0, 0, -30))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	_, err = repo.GetTrashedByID(ctx, old.ID)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	count, err := statsRepo.CountByWikiID(ctx, old.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+
+	_, err = repo.GetTrashedByID(ctx, recent.ID)
+	assert.NoError(t, err)
+	_, err = repo.GetByID(ctx, live.ID)
+	assert.NoError(t, err)
+	count, err = statsRepo.CountByWikiID(ctx, recent.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
 }
 
 func TestWikiRepository_ExistsByURL(t *testing.T) {
@@ -377,3 +792,182 @@ func TestWikiRepository_GetPendingForUpdate(t *testing.T) {
 	require.NoError(t, err)
 	assert.GreaterOrEqual(t, len(wikis), 1)
 }
+
+func TestWikiRepository_StreamForExport(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		wiki := &models.Wiki{
+			ID:     uuid.New(),
+			URL:    fmt.Sprintf("https://wiki%d.com", i),
+			Status: models.WikiStatusOK,
+		}
+		require.NoError(t, repo.Create(ctx, wiki))
+		require.NoError(t, db.Exec("UPDATE wikis SET updated_at = ? WHERE id = ?",
+			base.Add(time.Duration(i)*time.Hour), wiki.ID).Error)
+	}
+
+	var urls []string
+	err := repo.StreamForExport(ctx, ExportOptions{}, func(w *models.Wiki) error {
+		urls = append(urls, w.URL)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://wiki0.com", "https://wiki1.com", "https://wiki2.com"}, urls)
+
+	// Resume from the second wiki
+	since := base.Add(time.Hour)
+	urls = nil
+	err = repo.StreamForExport(ctx, ExportOptions{Since: &since}, func(w *models.Wiki) error {
+		urls = append(urls, w.URL)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://wiki1.com", "https://wiki2.com"}, urls)
+}
+
+func TestWikiRepository_GetVersion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	version, err := repo.GetVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), version.Count)
+	assert.True(t, version.LastModified.IsZero())
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusPending}
+	require.NoError(t, repo.Create(ctx, wiki))
+
+	created, err := repo.GetVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), created.Count)
+	assert.False(t, created.LastModified.IsZero())
+
+	time.Sleep(10 * time.Millisecond)
+	wiki.Status = models.WikiStatusOK
+	require.NoError(t, repo.Update(ctx, wiki))
+
+	updated, err := repo.GetVersion(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), updated.Count)
+	assert.True(t, updated.LastModified.After(created.LastModified))
+}
+
+func TestWikiRepository_ListDue(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	apiURL := "https://example.org/api.php"
+
+	never := &models.Wiki{ID: uuid.New(), URL: "https://never.example", APIURL: &apiURL, IsActive: true}
+	overdue := &models.Wiki{ID: uuid.New(), URL: "https://overdue.example", APIURL: &apiURL, IsActive: true, NextCheckAt: &past, NextArchiveCheckAt: &past}
+	high := &models.Wiki{ID: uuid.New(), URL: "https://high.example", IsActive: true, Priority: models.WikiPriorityHigh, NextCheckAt: &past}
+	notYet := &models.Wiki{ID: uuid.New(), URL: "https://notyet.example", APIURL: &apiURL, IsActive: true, NextCheckAt: &future, NextArchiveCheckAt: &future}
+	paused := &models.Wiki{ID: uuid.New(), URL: "https://paused.example", APIURL: &apiURL, IsActive: true, PausedUntil: &future}
+	inactive := &models.Wiki{ID: uuid.New(), URL: "https://inactive.example", APIURL: &apiURL}
+	for _, wiki := range []*models.Wiki{never, overdue, high, notYet, paused, inactive} {
+		wiki.Status = models.WikiStatusOK
+		require.NoError(t, repo.Create(ctx, wiki))
+	}
+	// GORM skips false for columns with a default, so deactivate explicitly
+	require.NoError(t, db.Model(&models.Wiki{}).Where("id = ?", inactive.ID).Update("is_active", false).Error)
+
+	due, err := repo.ListDue(ctx, CheckCollect, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 3)
+	assert.Equal(t, high.ID, due[0].ID, "higher priority first")
+	assert.Equal(t, never.ID, due[1].ID, "never checked before overdue")
+	assert.Equal(t, overdue.ID, due[2].ID)
+
+	// Archive checks need an API URL
+	due, err = repo.ListDue(ctx, CheckArchive, now, 10)
+	require.NoError(t, err)
+	require.Len(t, due, 2)
+	assert.Equal(t, never.ID, due[0].ID)
+	assert.Equal(t, overdue.ID, due[1].ID)
+
+	due, err = repo.ListDue(ctx, CheckCollect, now, 1)
+	require.NoError(t, err)
+	assert.Len(t, due, 1)
+
+	next, err := repo.NextDueAt(ctx, CheckCollect, now)
+	require.NoError(t, err)
+	require.NotNil(t, next)
+	assert.False(t, next.After(now), "a never checked wiki is due now")
+}
+
+func TestWikiRepository_UpdatePolicy(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK, IsActive: true}
+	require.NoError(t, repo.Create(ctx, wiki))
+
+	now := time.Now()
+	minutes := 60
+	wiki.Priority = models.WikiPriorityHigh
+	wiki.CollectIntervalMinutes = &minutes
+	wiki.PausedUntil = &now
+	wiki.IsActive = false
+	wiki.ScheduleCheck(&now, time.Hour)
+	wiki.Status = models.WikiStatusError // Not part of the policy
+	require.NoError(t, repo.UpdatePolicy(ctx, wiki))
+
+	saved, err := repo.GetByID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WikiPriorityHigh, saved.Priority)
+	require.NotNil(t, saved.CollectIntervalMinutes)
+	assert.Equal(t, 60, *saved.CollectIntervalMinutes)
+	assert.NotNil(t, saved.PausedUntil)
+	assert.False(t, saved.IsActive)
+	require.NotNil(t, saved.NextCheckAt)
+	assert.WithinDuration(t, now.Add(time.Hour), *saved.NextCheckAt, time.Second)
+	assert.Equal(t, models.WikiStatusOK, saved.Status)
+
+	// Clearing the policy stores NULLs
+	wiki.CollectIntervalMinutes = nil
+	wiki.PausedUntil = nil
+	require.NoError(t, repo.UpdatePolicy(ctx, wiki))
+	saved, err = repo.GetByID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Nil(t, saved.CollectIntervalMinutes)
+	assert.Nil(t, saved.PausedUntil)
+}
+
+func TestWikiRepository_ScheduleNext(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiRepository(db)
+	ctx := context.Background()
+
+	wiki := &models.Wiki{ID: uuid.New(), URL: "https://example.com", Status: models.WikiStatusOK, IsActive: true}
+	require.NoError(t, repo.Create(ctx, wiki))
+
+	// A stale copy must not overwrite other columns
+	wiki.Status = models.WikiStatusError
+	next := time.Now().Add(time.Hour)
+	require.NoError(t, repo.ScheduleNext(ctx, wiki.ID, CheckCollect, &next))
+
+	saved, err := repo.GetByID(ctx, wiki.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved.NextCheckAt)
+	assert.WithinDuration(t, next, *saved.NextCheckAt, time.Second)
+	assert.Nil(t, saved.NextArchiveCheckAt)
+	assert.Equal(t, models.WikiStatusOK, saved.Status)
+
+	due, err := repo.ListDue(ctx, CheckCollect, time.Now(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, due)
+
+	require.NoError(t, repo.ScheduleNext(ctx, wiki.ID, CheckArchive, &next))
+	saved, err = repo.GetByID(ctx, wiki.ID)
+	require.NoError(t, err)
+	require.NotNil(t, saved.NextArchiveCheckAt)
+	assert.WithinDuration(t, next, *saved.NextArchiveCheckAt, time.Second)
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"wikikeeper-backend/internal/models"
+)
+
+// WikiURLRepository handles wiki_urls database operations
+type WikiURLRepository struct {
+	db *gorm.DB
+}
+
+// NewWikiURLRepository creates a new wiki URL repository
+func NewWikiURLRepository(db *gorm.DB) *WikiURLRepository {
+	return &WikiURLRepository{db: db}
+}
+
+// RecordWiki records the current URL, API URL and index URL of a wiki as seen at seenAt
+func (r *WikiURLRepository) RecordWiki(ctx context.Context, wiki *models.Wiki, seenAt time.Time) error {
+	return recordWikiURLs(r.db.WithContext(ctx), wiki.ID, wiki, seenAt)
+}
+
+// ListByWikiID returns the URLs a wiki has used, most recently seen first
+func (r *WikiURLRepository) ListByWikiID(ctx context.Context, wikiID uuid.UUID, kinds ...models.WikiURLKind) ([]*models.WikiURL, error) {
+	query := r.db.WithContext(ctx).Where("wiki_id = ?", wikiID)
+	if len(kinds) > 0 {
+		query = query.Where("kind IN ?", kinds)
+	}
+
+	var urls []*models.WikiURL
+	if err := query.Order("last_seen_at DESC").Find(&urls).Error; err != nil {
+		return nil, err
+	}
+	return urls, nil
+}
+
+// Resolve returns the most recently seen record of any of the candidate URLs, of any kind
+func (r *WikiURLRepository) Resolve(ctx context.Context, candidates []string) (*models.WikiURL, error) {
+	if len(candidates) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var url models.WikiURL
+	err := r.db.WithContext(ctx).
+		Where("url IN ?", candidates).
+		Order("last_seen_at DESC").
+		First(&url).Error
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+// recordWikiURLs upserts the URLs of wiki as belonging to wikiID. A URL seen on another
+// wiki before moves to wikiID; first_seen_at of known URLs is kept.
+func recordWikiURLs(db *gorm.DB, wikiID uuid.UUID, wiki *models.Wiki, seenAt time.Time) error {
+	urls := []models.WikiURL{{WikiID: wikiID, Kind: models.WikiURLKindURL, URL: wiki.URL, FirstSeenAt: seenAt, LastSeenAt: seenAt}}
+	if wiki.APIURL != nil && *wiki.APIURL != "" {
+		urls = append(urls, models.WikiURL{WikiID: wikiID, Kind: models.WikiURLKindAPI, URL: *wiki.APIURL, FirstSeenAt: seenAt, LastSeenAt: seenAt})
+	}
+	if wiki.IndexURL != nil && *wiki.IndexURL != "" {
+		urls = append(urls, models.WikiURL{WikiID: wikiID, Kind: models.WikiURLKindIndex, URL: *wiki.IndexURL, FirstSeenAt: seenAt, LastSeenAt: seenAt})
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "kind"}, {Name: "url"}},
+		DoUpdates: clause.AssignmentColumns([]string{"wiki_id", "last_seen_at"}),
+	}).Create(&urls).Error
+}
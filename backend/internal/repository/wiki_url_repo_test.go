@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"wikikeeper-backend/internal/models"
+)
+
+func TestWikiURLRepository_RecordWikiAndResolve(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiURLRepository(db)
+	ctx := context.Background()
+
+	oldAPI := "http://example.org/w/api.php"
+	oldIndex := "http://example.org/w/index.php"
+	wiki := &models.Wiki{ID: uuid.New(), URL: "http://example.org/", APIURL: &oldAPI, IndexURL: &oldIndex, Status: models.WikiStatusOK}
+	require.NoError(t, NewWikiRepository(db).Create(ctx, wiki))
+
+	firstSeen := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, repo.RecordWiki(ctx, wiki, firstSeen))
+
+	// The wiki moves to https
+	newAPI := "https://example.org/w/api.php"
+	newIndex := "https://example.org/w/index.php"
+	wiki.URL, wiki.APIURL, wiki.IndexURL = "https://example.org/", &newAPI, &newIndex
+	require.NoError(t, NewWikiRepository(db).Update(ctx, wiki))
+	require.NoError(t, repo.RecordWiki(ctx, wiki, time.Now()))
+	require.NoError(t, repo.RecordWiki(ctx, wiki, time.Now()))
+
+	urls, err := repo.ListByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Len(t, urls, 6)
+
+	apiURLs, err := repo.ListByWikiID(ctx, wiki.ID, models.WikiURLKindAPI)
+	require.NoError(t, err)
+	require.Len(t, apiURLs, 2)
+	assert.Equal(t, newAPI, apiURLs[0].URL, "most recently seen first")
+	assert.Equal(t, oldAPI, apiURLs[1].URL)
+	assert.WithinDuration(t, firstSeen, apiURLs[1].FirstSeenAt, time.Second)
+
+	alias, err := repo.Resolve(ctx, []string{"http://example.org/", "http://example.org"})
+	require.NoError(t, err)
+	assert.Equal(t, wiki.ID, alias.WikiID)
+	assert.Equal(t, models.WikiURLKindURL, alias.Kind)
+
+	_, err = repo.Resolve(ctx, []string{"https://unknown.org/"})
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repo.Resolve(ctx, nil)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestWikiURLRepository_RecordWikiTakesOverURL(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewWikiURLRepository(db)
+	ctx := context.Background()
+
+	first := &models.Wiki{ID: uuid.New(), URL: "https://first.example/", Status: models.WikiStatusOK}
+	require.NoError(t, NewWikiRepository(db).Create(ctx, first))
+	firstSeen := time.Now().Add(-time.Hour)
+	require.NoError(t, repo.RecordWiki(ctx, first, firstSeen))
+
+	// A URL released by one wiki and later used by another belongs to the latter
+	second := &models.Wiki{ID: uuid.New(), URL: "https://first.example/", Status: models.WikiStatusOK}
+	require.NoError(t, repo.RecordWiki(ctx, second, time.Now()))
+
+	alias, err := repo.Resolve(ctx, []string{"https://first.example/"})
+	require.NoError(t, err)
+	assert.Equal(t, second.ID, alias.WikiID)
+	assert.WithinDuration(t, firstSeen, alias.FirstSeenAt, time.Second)
+}
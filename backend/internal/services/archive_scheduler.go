@@ -57,11 +57,7 @@ func (s *ArchiveScheduler) Start(ctx context.Context) {
 
 	applogger.Log.Info("[ArchiveScheduler] Started with interval: %v", interval)
 
-	// Run initial archive check
-	s.wg.Add(1)
-	go s.run(ctx)
-
-	// Start periodic archive checking
+	// Start periodic archive checking; wikis already due are checked right away
 	s.wg.Add(1)
 	go s.periodicRun(ctx)
 }
@@ -96,16 +92,10 @@ func (s *ArchiveScheduler) run(ctx context.Context) {
 
 	startTime := time.Now()
 
-	// Get wikis that need archive checking
-	// Priority: NULL archive_last_check_at first (never checked), then oldest archive_last_check_at
+	// Get active, unpaused wikis with an API URL that are due for an archive check
+	// Priority: highest tier first, then never checked, then the longest overdue
 	wikiRepo := repository.NewWikiRepository(s.db)
-	wikis, _, err := wikiRepo.List(ctx, repository.ListOptions{
-		Page:     1,
-		PageSize: int(s.config.ArchiveCheckBatchSize),
-		Status:   nil, // Get all statuses
-		// Order by archive_last_check_at ASC (NULL first, then oldest)
-		OrderBy: "archive_last_check_at ASC NULLS FIRST",
-	})
+	wikis, err := wikiRepo.ListDue(ctx, repository.CheckArchive, time.Now(), s.config.ArchiveCheckBatchSize)
 	if err != nil {
 		applogger.Log.Info("[ArchiveScheduler] Failed to get wikis: %v", err)
 		return
@@ -152,6 +142,13 @@ func (s *ArchiveScheduler) run(ctx context.Context) {
 		if err != nil {
 			applogger.Log.Info("[ArchiveScheduler] Failed to check wiki %s: %v", wiki.ID, err)
 			s.archiveService.UpdateWikiArchiveError(ctx, s.db, wiki.ID, err)
+			// Push the next check back even if saving the error failed,
+			// so a failing wiki isn't picked up again right away
+			now := time.Now()
+			wiki.ScheduleArchiveCheck(&now, s.archiveService.checkInterval)
+			if err := wikiRepo.ScheduleNext(ctx, wiki.ID, repository.CheckArchive, wiki.NextArchiveCheckAt); err != nil {
+				applogger.Log.Warn("[ArchiveScheduler] Failed to schedule next check", "wiki_id", wiki.ID, "error", err)
+			}
 			errorCount++
 		} else {
 			applogger.Log.Info("[ArchiveScheduler] Archive check completed: found=%d, imported=%d, updated=%d", found, imported, updated)
@@ -176,7 +173,7 @@ func (s *ArchiveScheduler) run(ctx context.Context) {
 		successCount, errorCount, skippedCount, elapsed.Round(time.Second))
 }
 
-// periodicRun runs archive checks continuously with backoff based on archive_last_check_at
+// periodicRun runs archive checks whenever wikis are due, sleeping until the next one is
 func (s *ArchiveScheduler) periodicRun(ctx context.Context) {
 	defer s.wg.Done()
 
@@ -189,47 +186,27 @@ func (s *ArchiveScheduler) periodicRun(ctx context.Context) {
 			applogger.Log.Info("[ArchiveScheduler] Context cancelled")
 			return
 		default:
-			// Check the oldest archive_last_check_at before running
 			wikiRepo := repository.NewWikiRepository(s.db)
-			wikis, _, err := wikiRepo.List(ctx, repository.ListOptions{
-				Page:     1,
-				PageSize: 1,
-				Status:   nil,
-				OrderBy:  "archive_last_check_at ASC NULLS FIRST",
-			})
+			next, err := wikiRepo.NextDueAt(ctx, repository.CheckArchive, time.Now())
 			if err != nil {
-				applogger.Log.Info("[ArchiveScheduler] Failed to check wikis: %v", err)
+				applogger.Log.Error("[ArchiveScheduler] Failed to check wikis", "error", err)
 				time.Sleep(10 * time.Second)
 				continue
 			}
 
-			// Check if we need to back off
-			if len(wikis) > 0 && wikis[0].ArchiveLastCheckAt != nil {
-				timeSinceLastCheck := time.Since(*wikis[0].ArchiveLastCheckAt)
-				backoffThreshold := 3 * 24 * time.Hour // 3 days
-
-				if timeSinceLastCheck < backoffThreshold {
-					// Calculate backoff time based on how recent the last check was
-					// More recent = longer backoff (up to 60s max)
-					hoursSinceCheck := timeSinceLastCheck.Hours()
-					var backoffTime time.Duration
-					if hoursSinceCheck < 24 {
-						backoffTime = 60 * time.Second // checked within 24h, max backoff
-					} else if hoursSinceCheck < 48 {
-						backoffTime = 45 * time.Second // checked within 48h
-					} else {
-						backoffTime = 30 * time.Second // checked within 72h
-					}
-					applogger.Log.Info("[ArchiveScheduler] Backing off, recent update detected",
-						"last_check", wikis[0].ArchiveLastCheckAt,
-						"hours_since", hoursSinceCheck,
-						"backoff", backoffTime)
-					time.Sleep(backoffTime)
-					continue
+			if wait := dueWait(next, time.Now()); wait > 0 {
+				applogger.Log.Debug("[ArchiveScheduler] No wikis due", "next_due", next, "wait", wait)
+				select {
+				case <-time.After(wait):
+				case <-s.stopCh:
+				case <-ctx.Done():
+					return
 				}
+				continue
 			}
 
 			applogger.Log.Info("[ArchiveScheduler] Triggering archive check")
+			s.wg.Add(1) // Balanced by the Done in run
 			s.run(ctx)
 
 			// Small delay to avoid tight loop
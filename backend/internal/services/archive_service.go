@@ -14,6 +14,7 @@ import (
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"wikikeeper-backend/internal/cache"
 	applogger "wikikeeper-backend/internal/logger"
 	"wikikeeper-backend/internal/models"
 	"wikikeeper-backend/internal/repository"
@@ -21,20 +22,23 @@ import (
 
 // ArchiveService checks Archive.org for wiki backups
 type ArchiveService struct {
-	timeout    time.Duration
-	userAgent  string
-	checkDelay time.Duration // Delay between Archive.org checks
+	timeout       time.Duration
+	userAgent     string
+	checkDelay    time.Duration // Delay between Archive.org checks
+	checkInterval time.Duration // Time between checks of one wiki, unless its policy sets its own
 }
 
-// NewArchiveService creates a new Archive service instance
-func NewArchiveService(timeout time.Duration, userAgent string, checkDelay float64) *ArchiveService {
+// NewArchiveService creates a new Archive service instance.
+// checkDelay is in seconds, checkInterval in minutes.
+func NewArchiveService(timeout time.Duration, userAgent string, checkDelay, checkInterval float64) *ArchiveService {
 	if userAgent == "" {
 		userAgent = "WikiKeeper/1.0"
 	}
 	return &ArchiveService{
-		timeout:    timeout,
-		userAgent:  userAgent,
-		checkDelay: time.Duration(checkDelay * float64(time.Second)),
+		timeout:       timeout,
+		userAgent:     userAgent,
+		checkDelay:    time.Duration(checkDelay * float64(time.Second)),
+		checkInterval: time.Duration(checkInterval * float64(time.Minute)),
 	}
 }
 
@@ -82,9 +86,25 @@ type archiveMetadata struct {
 	ItemSize interface{} `json:"item_size"` // Can be int64 or string
 }
 
-// CheckArchive searches Archive.org for wiki backups
-func (s *ArchiveService) CheckArchive(ctx context.Context, apiURL, indexURL string) ([]*ArchiveInfo, error) {
-	applogger.Log.Info("[Archive] Checking Archive.org for", "api_url", apiURL)
+// maxArchiveResults bounds the number of items fetched by one Archive.org search
+const maxArchiveResults = 100
+
+// maxArchiveQueryURLs bounds the number of originalurl terms in one Archive.org search
+const maxArchiveQueryURLs = 20
+
+// CheckArchive searches Archive.org for wiki backups. Dumps made under former
+// API or index URLs of the wiki are found through aliasURLs.
+func (s *ArchiveService) CheckArchive(ctx context.Context, apiURL, indexURL string, aliasURLs ...string) ([]*ArchiveInfo, error) {
+	results, err := s.findArchiveItems(ctx, apiURL, indexURL, aliasURLs...)
+	if err != nil {
+		return nil, err
+	}
+	return s.parseArchiveItems(ctx, results), nil
+}
+
+// findArchiveItems runs the Archive.org search behind CheckArchive without fetching item metadata
+func (s *ArchiveService) findArchiveItems(ctx context.Context, apiURL, indexURL string, aliasURLs ...string) ([]archiveSearchResultDoc, error) {
+	applogger.Log.Info("[Archive] Checking Archive.org for", "api_url", apiURL, "aliases", len(aliasURLs))
 
 	if apiURL == "" {
 		return nil, fmt.Errorf("API URL is required")
@@ -95,16 +115,7 @@ func (s *ArchiveService) CheckArchive(ctx context.Context, apiURL, indexURL stri
 		indexURL = strings.Replace(apiURL, "api.php", "index.php", 1)
 	}
 
-	// Build search query
-	// Search for items matching either api_url or index_url
-	// Try both http and https versions since archive.org might use different protocol
-	apiURLHTTP := strings.Replace(apiURL, "https://", "http://", 1)
-	apiURLHTTPS := strings.Replace(apiURL, "http://", "https://", 1)
-	indexURLHTTP := strings.Replace(indexURL, "https://", "http://", 1)
-	indexURLHTTPS := strings.Replace(indexURL, "http://", "https://", 1)
-
-	query := fmt.Sprintf(`(originalurl:"%s" OR originalurl:"%s" OR originalurl:"%s" OR originalurl:"%s")`,
-		apiURLHTTP, apiURLHTTPS, indexURLHTTP, indexURLHTTPS)
+	query := archiveSearchQuery(append([]string{apiURL, indexURL}, aliasURLs...))
 	searchURL := s.buildSearchURL(query)
 
 	applogger.Log.Info("[Archive] Search URL", "url", searchURL)
@@ -116,7 +127,11 @@ func (s *ArchiveService) CheckArchive(ctx context.Context, apiURL, indexURL stri
 	}
 
 	applogger.Log.Info("[Archive] Found X results for the apiURL", "x", len(results), "api_url", apiURL)
+	return results, nil
+}
 
+// parseArchiveItems parses search results, skipping items whose metadata cannot be read
+func (s *ArchiveService) parseArchiveItems(ctx context.Context, results []archiveSearchResultDoc) []*ArchiveInfo {
 	var archives []*ArchiveInfo
 
 	// Process each result
@@ -131,17 +146,48 @@ func (s *ArchiveService) CheckArchive(ctx context.Context, apiURL, indexURL stri
 			archives = append(archives, info)
 		}
 	}
+	return archives
+}
 
-	return archives, nil
+// archiveSearchQuery builds a search matching items whose originalurl is any of urls,
+// under both http and https since dumps record whichever the wiki used at the time
+func archiveSearchQuery(urls []string) string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, u := range urls {
+		for _, variant := range []string{
+			strings.Replace(u, "https://", "http://", 1),
+			strings.Replace(u, "http://", "https://", 1),
+		} {
+			if variant == "" || seen[variant] || len(terms) >= maxArchiveQueryURLs {
+				continue
+			}
+			seen[variant] = true
+			terms = append(terms, fmt.Sprintf(`originalurl:"%s"`, variant))
+		}
+	}
+	return "(" + strings.Join(terms, " OR ") + ")"
 }
 
 // CollectArchives checks and stores archive info for a wiki
 func (s *ArchiveService) CollectArchives(ctx context.Context, db *gorm.DB, wikiID uuid.UUID, apiURL, indexURL string) (found, imported, updated int, err error) {
-	archives, err := s.CheckArchive(ctx, apiURL, indexURL)
+	// Former API and index URLs, e.g. from before a move to https or of merged duplicates
+	var aliasURLs []string
+	known, err := repository.NewWikiURLRepository(db).ListByWikiID(ctx, wikiID, models.WikiURLKindAPI, models.WikiURLKindIndex)
+	if err != nil {
+		applogger.Log.Warn("[Archive] Failed to load former wiki URLs", "wiki_id", wikiID, "error", err)
+	}
+	for _, u := range known {
+		aliasURLs = append(aliasURLs, u.URL)
+	}
+
+	results, err := s.findArchiveItems(ctx, apiURL, indexURL, aliasURLs...)
 	if err != nil {
 		return 0, 0, 0, err
 	}
+	s.removeMissingArchives(ctx, db, wikiID, results)
 
+	archives := s.parseArchiveItems(ctx, results)
 	found = len(archives)
 
 	if found == 0 {
@@ -172,20 +218,28 @@ func (s *ArchiveService) CollectArchives(ctx context.Context, db *gorm.DB, wikiI
 			HasLegacyWikidump: archiveInfo.HasLegacyWikidump,
 		}
 
+		// Check whether this is a new or reappearing archive before the upsert stores it
+		existing, err := archiveRepo.GetByWikiAndIAIdentifier(ctx, wikiID, archiveInfo.IAIdentifier)
+		exists := err == nil && existing.RemovedAt == nil
+
 		// Use Upsert to handle both new and existing archives
 		if err := archiveRepo.UpsertByWikiAndIAIdentifier(ctx, wikiArchive); err != nil {
 			applogger.Log.Info("[Archive] Failed to upsert archive %s: %v", archiveInfo.IAIdentifier, err)
 			continue
 		}
 
-		// Check if this was a new or existing archive
-		exists, _ := archiveRepo.ExistsByWikiAndIAIdentifier(ctx, wikiID, archiveInfo.IAIdentifier)
 		if exists {
 			updated++
 			applogger.Log.Info("[Archive] Updated archive: %s", archiveInfo.IAIdentifier)
 		} else {
 			imported++
 			applogger.Log.Info("[Archive] Imported archive: %s", archiveInfo.IAIdentifier)
+			identifier := archiveInfo.IAIdentifier
+			RecordEvent(ctx, db, &models.WikiEvent{
+				WikiID:       wikiID,
+				Type:         models.WikiEventArchiveFound,
+				IAIdentifier: &identifier,
+			})
 		}
 	}
 
@@ -196,6 +250,66 @@ func (s *ArchiveService) CollectArchives(ctx context.Context, db *gorm.DB, wikiI
 	return found, imported, updated, nil
 }
 
+// archiveRemovalMisses is the number of consecutive searches that must miss an archive
+// before it is marked removed, when the metadata API cannot tell whether the item exists
+const archiveRemovalMisses = 3
+
+// removeMissingArchives marks stored archives of a wiki that a complete search no
+// longer finds as removed, e.g. items taken down or darked on Archive.org, and records
+// an archive_removed event for each. A miss alone is not enough: the item must be
+// gone according to the metadata API, or missed by archiveRemovalMisses searches in a row.
+func (s *ArchiveService) removeMissingArchives(ctx context.Context, db *gorm.DB, wikiID uuid.UUID, results []archiveSearchResultDoc) {
+	if len(results) >= maxArchiveResults {
+		// The search was cut off, so a missing item may just be beyond the limit
+		return
+	}
+
+	archiveRepo := repository.NewArchiveRepository(db)
+	stored, err := archiveRepo.GetByWikiID(ctx, wikiID)
+	if err != nil {
+		applogger.Log.Warn("[Archive] Failed to load stored archives", "wiki_id", wikiID, "error", err)
+		return
+	}
+
+	current := make(map[string]bool, len(results))
+	for _, result := range results {
+		current[result.Identifier] = true
+	}
+	for _, archive := range stored {
+		if current[archive.IAIdentifier] {
+			continue
+		}
+		switch s.fetchItemState(ctx, archive.IAIdentifier) {
+		case archiveItemPresent:
+			// Still on Archive.org, e.g. matched through an alias beyond the query cap
+			// or added by a merge or import
+			continue
+		case archiveItemUnknown:
+			misses, err := archiveRepo.MarkMissed(ctx, archive)
+			if err != nil {
+				applogger.Log.Warn("[Archive] Failed to record missed archive", "identifier", archive.IAIdentifier, "error", err)
+				continue
+			}
+			if misses < archiveRemovalMisses {
+				applogger.Log.Info("[Archive] Archive not found by search", "wiki_id", wikiID, "identifier", archive.IAIdentifier, "misses", misses)
+				continue
+			}
+		}
+
+		if err := archiveRepo.MarkRemoved(ctx, archive.ID, time.Now()); err != nil {
+			applogger.Log.Warn("[Archive] Failed to mark archive removed", "identifier", archive.IAIdentifier, "error", err)
+			continue
+		}
+		applogger.Log.Info("[Archive] Archive no longer found", "wiki_id", wikiID, "identifier", archive.IAIdentifier)
+		identifier := archive.IAIdentifier
+		RecordEvent(ctx, db, &models.WikiEvent{
+			WikiID:       wikiID,
+			Type:         models.WikiEventArchiveRemoved,
+			IAIdentifier: &identifier,
+		})
+	}
+}
+
 // updateWikiArchiveStatus updates the has_archive field for a wiki
 func (s *ArchiveService) updateWikiArchiveStatus(ctx context.Context, db *gorm.DB, wikiID uuid.UUID, hasArchive bool) {
 	wikiRepo := repository.NewWikiRepository(db)
@@ -208,13 +322,16 @@ func (s *ArchiveService) updateWikiArchiveStatus(ctx context.Context, db *gorm.D
 	now := time.Now()
 	wiki.HasArchive = hasArchive
 	wiki.ArchiveLastCheckAt = &now
+	wiki.ScheduleArchiveCheck(&now, s.checkInterval)
 	// Clear previous archive error on successful check
 	wiki.ArchiveLastError = nil
 	wiki.ArchiveLastErrorAt = nil
 
 	if err := wikiRepo.Update(ctx, wiki); err != nil {
 		applogger.Log.Info("[Archive] Failed to update wiki has_archive status: %v", err)
+		return
 	}
+	cache.Invalidate()
 }
 
 // UpdateWikiArchiveError records an archive check error (exported for handler use)
@@ -231,10 +348,13 @@ func (s *ArchiveService) UpdateWikiArchiveError(ctx context.Context, db *gorm.DB
 	wiki.ArchiveLastError = &errMsg
 	wiki.ArchiveLastErrorAt = &now
 	wiki.ArchiveLastCheckAt = &now
+	wiki.ScheduleArchiveCheck(&now, s.checkInterval)
 
 	if updateErr := wikiRepo.Update(ctx, wiki); updateErr != nil {
 		applogger.Log.Info("[Archive] Failed to update wiki archive error: %v", updateErr)
+		return
 	}
+	cache.Invalidate()
 }
 
 // buildSearchURL constructs Archive.org Scrape API URL
@@ -248,7 +368,6 @@ func (s *ArchiveService) buildSearchURL(query string) string {
 
 // searchArchive performs Archive.org search using Scrape API with cursor pagination
 func (s *ArchiveService) searchArchive(ctx context.Context, searchURL string) ([]archiveSearchResultDoc, error) {
-	const maxResults = 100 // Maximum number of archives to fetch
 	var allDocs []archiveSearchResultDoc
 	cursor := ""
 	query := "" // Extract query from searchURL for pagination
@@ -314,8 +433,8 @@ func (s *ArchiveService) searchArchive(ctx context.Context, searchURL string) ([
 		}
 
 		// Check if we've reached the max results limit
-		if len(allDocs) >= maxResults {
-			applogger.Log.Info("[Archive] Reached max results limit", "max", maxResults)
+		if len(allDocs) >= maxArchiveResults {
+			applogger.Log.Info("[Archive] Reached max results limit", "max", maxArchiveResults)
 			break
 		}
 
@@ -475,6 +594,56 @@ func (s *ArchiveService) fetchMetadata(ctx context.Context, identifier string) (
 	return &metadata, nil
 }
 
+// archiveItemState is what the metadata API says about an Archive.org item
+type archiveItemState int
+
+const (
+	archiveItemUnknown archiveItemState = iota // The metadata API could not be read
+	archiveItemPresent                         // The item exists and is not dark
+	archiveItemGone                            // The item does not exist or is dark
+)
+
+// fetchItemState asks the metadata API whether an item still exists
+func (s *ArchiveService) fetchItemState(ctx context.Context, identifier string) archiveItemState {
+	metadataURL := fmt.Sprintf("https://archive.org/metadata/%s", identifier)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", metadataURL, nil)
+	if err != nil {
+		return archiveItemUnknown
+	}
+
+	req.Header.Set("User-Agent", s.userAgent)
+
+	client := &http.Client{Timeout: s.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		applogger.Log.Info("[Archive] Failed to fetch item state", "identifier", identifier, "error", err)
+		return archiveItemUnknown
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusGone:
+		return archiveItemGone
+	default:
+		return archiveItemUnknown
+	}
+
+	var item struct {
+		IsDark   bool                   `json:"is_dark"`
+		Metadata map[string]interface{} `json:"metadata"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return archiveItemUnknown
+	}
+	// The metadata API answers {} for items that do not exist
+	if item.IsDark || len(item.Metadata) == 0 {
+		return archiveItemGone
+	}
+	return archiveItemPresent
+}
+
 // checkFileContents checks files for dump type indicators
 func (s *ArchiveService) checkFileContents(info *ArchiveInfo, files []struct {
 	Name string      `json:"name"`
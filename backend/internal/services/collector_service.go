@@ -2,11 +2,13 @@ package services
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"wikikeeper-backend/internal/cache"
 	"wikikeeper-backend/internal/config"
 	applogger "wikikeeper-backend/internal/logger"
 	"wikikeeper-backend/internal/models"
@@ -59,12 +61,12 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 			applogger.Log.Info("[Collector] Existing API failed (%v), re-detecting...", err)
 			client, err = s.mwService.Initialize(ctx, wiki.URL)
 			if err != nil {
-				s.UpdateWikiStatus(ctx, wikiID, models.WikiStatusError, err)
+				s.checkFailed(ctx, wikiID, err)
 				return NewCollectorError("initialize_mediawiki", err)
 			}
 			siteinfo, err = s.mwService.FetchSiteinfo(ctx, client)
 			if err != nil {
-				s.UpdateWikiStatus(ctx, wikiID, models.WikiStatusError, err)
+				s.checkFailed(ctx, wikiID, err)
 				return NewCollectorError("fetch_siteinfo", err)
 			}
 		}
@@ -72,29 +74,39 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 		// No existing API URL, need to detect
 		client, err = s.mwService.Initialize(ctx, wiki.URL)
 		if err != nil {
-			s.UpdateWikiStatus(ctx, wikiID, models.WikiStatusError, err)
+			s.checkFailed(ctx, wikiID, err)
 			return NewCollectorError("initialize_mediawiki", err)
 		}
 
 		siteinfo, err = s.mwService.FetchSiteinfo(ctx, client)
 		if err != nil {
-			s.UpdateWikiStatus(ctx, wikiID, models.WikiStatusError, err)
+			s.checkFailed(ctx, wikiID, err)
 			return NewCollectorError("fetch_siteinfo", err)
 		}
 	}
 
 	// Update wiki with siteinfo
 	now := time.Now()
+	prevStatus, prevReadOnly := wiki.Status, wiki.ReadOnly
+	previous := models.Wiki{ID: wiki.ID, URL: wiki.URL, APIURL: wiki.APIURL, IndexURL: wiki.IndexURL}
 	wiki.Sitename = &siteinfo.General.Sitename
 	wiki.Lang = &siteinfo.General.Lang
 	wiki.DBType = &siteinfo.General.DBType
 	wiki.DBVersion = &siteinfo.General.DBVersion
 	wiki.MediaWikiVersion = &siteinfo.General.Generator
 	wiki.MaxPageID = siteinfo.General.MaxPageID
+	wiki.License = optionalString(siteinfo.Rights.Text)
+	wiki.LicenseURL = optionalString(siteinfo.Rights.URL)
+	if len(siteinfo.Extensions) > 0 {
+		wiki.Extensions = siteinfo.Extensions
+	}
 	wiki.APIURL = client.APIURL
 	wiki.IndexURL = client.IndexURL
+	s.followMove(ctx, wiki, &previous, client)
 	wiki.APIAvailable = true
+	wiki.ReadOnly = siteinfo.General.ReadOnly
 	wiki.LastCheckAt = &now
+	wiki.ScheduleCheck(&now, s.collectInterval())
 	wiki.Status = models.WikiStatusOK
 	// Clear previous error on successful collection
 	wiki.LastError = nil
@@ -105,7 +117,7 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 		if removed, err := s.HandleDuplicateAPIURL(ctx, wiki, *client.APIURL); err != nil {
 			applogger.Log.Info("[Collector] Warning: duplicate check failed: %v", err)
 		} else if removed {
-			applogger.Log.Info("[Collector] Wiki %s deleted as duplicate", wikiID)
+			applogger.Log.Info("[Collector] Wiki merged into an older duplicate", "wiki_id", wikiID)
 			return NewCollectorError("duplicate_check", ErrWikiDeleted)
 		}
 	}
@@ -114,11 +126,19 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 	if err := wikiRepo.Update(ctx, wiki); err != nil {
 		return NewCollectorError("update_wiki", err)
 	}
+	recordWikiTransitions(ctx, s.db, wiki, prevStatus, prevReadOnly, siteinfo.General.ReadOnlyReason)
+	if err := repository.NewWikiURLRepository(s.db).RecordWiki(ctx, wiki, now); err != nil {
+		applogger.Log.Warn("[Collector] Failed to record wiki URLs", "wiki_id", wikiID, "error", err)
+	}
 
-	// Create stats record
+	// Record the check, and the stats if they changed
 	statsRepo := repository.NewStatsRepository(s.db)
 	responseTime := siteinfo.ResponseTime
 	httpStatus := siteinfo.HTTPStatus
+	check := &models.WikiCheck{WikiID: wikiID, Time: now, OK: true, ResponseTimeMs: &responseTime, HTTPStatus: &httpStatus}
+	if err := repository.NewCheckRepository(s.db).Create(ctx, check); err != nil {
+		applogger.Log.Warn("[Collector] Failed to record check", "wiki_id", wikiID, "error", err)
+	}
 	stats := &models.WikiStats{
 		WikiID:        wikiID,
 		Time:          now,
@@ -134,8 +154,20 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 		HTTPStatus:     &httpStatus,
 	}
 
-	if err := statsRepo.Create(ctx, stats); err != nil {
-		return NewCollectorError("create_stats", err)
+	previousStats, err := statsRepo.GetLatestByWikiID(ctx, wikiID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		applogger.Log.Warn("[Collector] Failed to load previous stats", "wiki_id", wikiID, "error", err)
+	}
+
+	if models.StatsChanged(previousStats, stats, s.statsHeartbeat()) {
+		if err := statsRepo.Create(ctx, stats); err != nil {
+			return NewCollectorError("create_stats", err)
+		}
+	}
+	cache.Invalidate()
+
+	if message, ok := models.StatsAnomaly(previousStats, stats); ok {
+		RecordEvent(ctx, s.db, &models.WikiEvent{WikiID: wikiID, Type: models.WikiEventAnomaly, Message: &message})
 	}
 
 	applogger.Log.Info("[Collector] Collection completed for %s: %d pages, %d edits",
@@ -144,6 +176,58 @@ func (s *CollectorService) CollectSingleWiki(ctx context.Context, wikiID uuid.UU
 	return nil
 }
 
+// followMove moves the base URL of a wiki along when its API moved to https or another
+// domain, so the wiki is listed under the URL it is reachable at. The previous URLs
+// stay in wiki_urls and keep resolving to the wiki.
+func (s *CollectorService) followMove(ctx context.Context, wiki *models.Wiki, previous *models.Wiki, client *MediaWikiClient) {
+	if client.APIURL == nil {
+		return
+	}
+	// Without a previous API URL only a detected scheme upgrade says the base URL moved,
+	// since the API of a new wiki may live on another host
+	from := wiki.URL
+	if previous.APIURL != nil {
+		from = *previous.APIURL
+	} else if !client.WasRedirected {
+		return
+	}
+
+	movedURL, moved := models.MovedURL(wiki.URL, from, *client.APIURL)
+	if !moved {
+		return
+	}
+	exists, err := repository.NewWikiRepository(s.db).ExistsByURL(ctx, movedURL)
+	if err != nil || exists {
+		// The duplicate check merges the wikis if they share the API
+		return
+	}
+
+	// Make sure the URLs the wiki moves away from are on record
+	if err := repository.NewWikiURLRepository(s.db).RecordWiki(ctx, previous, time.Now()); err != nil {
+		applogger.Log.Warn("[Collector] Failed to record previous wiki URLs", "wiki_id", wiki.ID, "error", err)
+		return
+	}
+	applogger.Log.Info("[Collector] Wiki moved", "wiki_id", wiki.ID, "from", wiki.URL, "to", movedURL)
+	wiki.URL = movedURL
+}
+
+// checkFailed records a failed check and puts the wiki into the error status. The check
+// keeps the HTTP status and response time of the failed request when the wiki answered.
+func (s *CollectorService) checkFailed(ctx context.Context, wikiID uuid.UUID, err error) {
+	check := &models.WikiCheck{WikiID: wikiID, Time: time.Now()}
+	var response *ResponseError
+	if errors.As(err, &response) {
+		responseTime := int(response.ResponseTime.Milliseconds())
+		httpStatus := response.StatusCode
+		check.ResponseTimeMs = &responseTime
+		check.HTTPStatus = &httpStatus
+	}
+	if createErr := repository.NewCheckRepository(s.db).Create(ctx, check); createErr != nil {
+		applogger.Log.Warn("[Collector] Failed to record check", "wiki_id", wikiID, "error", createErr)
+	}
+	s.UpdateWikiStatus(ctx, wikiID, models.WikiStatusError, err)
+}
+
 // UpdateWikiStatus updates wiki status and error information
 func (s *CollectorService) UpdateWikiStatus(ctx context.Context, wikiID uuid.UUID, status models.WikiStatus, err error) {
 	wikiRepo := repository.NewWikiRepository(s.db)
@@ -154,8 +238,10 @@ func (s *CollectorService) UpdateWikiStatus(ctx context.Context, wikiID uuid.UUI
 	}
 
 	now := time.Now()
+	prevStatus := wiki.Status
 	wiki.Status = status
 	wiki.LastCheckAt = &now
+	wiki.ScheduleCheck(&now, s.collectInterval())
 
 	if err != nil && status == models.WikiStatusError {
 		errMsg := err.Error()
@@ -166,61 +252,67 @@ func (s *CollectorService) UpdateWikiStatus(ctx context.Context, wikiID uuid.UUI
 
 	if updateErr := wikiRepo.Update(ctx, wiki); updateErr != nil {
 		applogger.Log.Info("[Collector] Failed to update wiki status: %v", updateErr)
+		return
 	}
+	recordWikiTransitions(ctx, s.db, wiki, prevStatus, wiki.ReadOnly, "")
+	cache.Invalidate()
 }
 
-// HandleDuplicateAPIURL checks for and removes duplicate wikis with the same API URL
+// statsHeartbeat returns the time after which unchanged stats are stored again
+func (s *CollectorService) statsHeartbeat() time.Duration {
+	return time.Duration(s.config.StatsHeartbeatInterval * float64(time.Minute))
+}
+
+// collectInterval returns the time between collections of wikis without an interval of their own
+func (s *CollectorService) collectInterval() time.Duration {
+	return time.Duration(s.config.WikiCollectInterval * float64(time.Minute))
+}
+
+// HandleDuplicateAPIURL merges wikis sharing the canonical API URL of wiki. The oldest wiki survives.
+// It reports true when wiki itself was merged into an older duplicate and no longer exists.
 func (s *CollectorService) HandleDuplicateAPIURL(ctx context.Context, wiki *models.Wiki, apiURL string) (bool, error) {
 	wikiRepo := repository.NewWikiRepository(s.db)
 
-	// Find other wikis with the same API URL
-	duplicates, _, err := wikiRepo.List(ctx, repository.ListOptions{
-		PageSize: 100,
-	})
+	duplicates, err := wikiRepo.FindByCanonicalAPIURL(ctx, apiURL, wiki.ID)
 	if err != nil {
 		return false, err
 	}
 
-	// Check for duplicates (excluding current wiki)
+	reason := "Duplicate API URL " + apiURL
 	for _, dup := range duplicates {
-		if dup.ID == wiki.ID {
-			continue
-		}
-		if dup.APIURL != nil && *dup.APIURL == apiURL {
-			// Found duplicate - remove the one created later
-			if dup.CreatedAt.Before(wiki.CreatedAt) {
-				// Current wiki is newer, delete it
-				applogger.Log.Info("[Collector] Duplicate API URL found: %s already exists (created %v, current %v)",
-					apiURL, dup.CreatedAt, wiki.CreatedAt)
-				return true, nil
-			} else {
-				// Duplicate is newer, delete it
-				applogger.Log.Info("[Collector] Removing duplicate wiki %s with API URL %s", dup.ID, apiURL)
-				if delErr := wikiRepo.Delete(ctx, dup.ID); delErr != nil {
-					applogger.Log.Info("[Collector] Failed to delete duplicate: %v", delErr)
-				}
+		if dup.CreatedAt.Before(wiki.CreatedAt) {
+			// Current wiki is newer, merge it into the existing one
+			if _, err := MergeWikis(ctx, s.db, dup.ID, wiki.ID, collectorActor, &reason); err != nil {
+				return false, err
 			}
+			return true, nil
+		}
+
+		// Duplicate is newer, merge it into the current wiki
+		if _, err := MergeWikis(ctx, s.db, wiki.ID, dup.ID, collectorActor, &reason); err != nil {
+			applogger.Log.Warn("[Collector] Failed to merge duplicate", "wiki_id", wiki.ID, "duplicate_id", dup.ID, "error", err)
+			continue
 		}
+		// Keep the in-memory wiki in line with the merged row, it is saved after this check
+		wiki.HasArchive = wiki.HasArchive || dup.HasArchive
 	}
 
 	return false, nil
 }
 
-// CollectBatch collects stats for multiple active wikis
+// CollectBatch collects stats for up to limit wikis that are due for collection
 func (s *CollectorService) CollectBatch(ctx context.Context, limit int, delay time.Duration) ([]*models.WikiStats, error) {
-	applogger.Log.Info("[Collector] Starting batch collection (limit=%d, delay=%v)", limit, delay)
+	applogger.Log.Info("[Collector] Starting batch collection", "limit", limit, "delay", delay)
 
 	wikiRepo := repository.NewWikiRepository(s.db)
 
-	// Get active wikis
-	wikis, total, err := wikiRepo.List(ctx, repository.ListOptions{
-		PageSize: limit,
-	})
+	// Get active, unpaused wikis that are due, highest priority first
+	wikis, err := wikiRepo.ListDue(ctx, repository.CheckCollect, time.Now(), limit)
 	if err != nil {
 		return nil, NewCollectorError("list_wikis", err)
 	}
 
-	applogger.Log.Info("[Collector] Found %d active wikis (total: %d)", len(wikis), total)
+	applogger.Log.Info("[Collector] Found due wikis", "count", len(wikis))
 
 	var results []*models.WikiStats
 	statsRepo := repository.NewStatsRepository(s.db)
@@ -231,7 +323,7 @@ func (s *CollectorService) CollectBatch(ctx context.Context, limit int, delay ti
 			continue
 		}
 
-		// Get the created stats
+		// Get the current stats, which may have been stored by an earlier check
 		stats, err := statsRepo.GetLatestByWikiID(ctx, wiki.ID)
 		if err == nil && stats != nil {
 			results = append(results, stats)
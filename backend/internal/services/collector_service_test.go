@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// setupCollectorDB creates the tables a collection writes to, without wiki_checks
+func setupCollectorDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`
+		CREATE TABLE wikis (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL UNIQUE,
+			api_url TEXT,
+			api_url_canonical TEXT,
+			index_url TEXT,
+			wiki_name TEXT,
+			sitename TEXT,
+			lang TEXT,
+			db_type TEXT,
+			db_version TEXT,
+			media_wiki_version TEXT,
+			max_page_id INTEGER,
+			license TEXT,
+			license_url TEXT,
+			extensions TEXT,
+			status TEXT NOT NULL DEFAULT 'pending',
+			has_archive INTEGER NOT NULL DEFAULT 0,
+			api_available INTEGER NOT NULL DEFAULT 1,
+			read_only INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			last_error_at DATETIME,
+			archive_last_check_at DATETIME,
+			archive_last_error TEXT,
+			archive_last_error_at DATETIME,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_check_at DATETIME,
+			is_active INTEGER NOT NULL DEFAULT 1,
+			priority INTEGER NOT NULL DEFAULT 0,
+			collect_interval_minutes INTEGER,
+			archive_check_interval_minutes INTEGER,
+			paused_until DATETIME,
+			next_check_at DATETIME,
+			next_archive_check_at DATETIME,
+			deleted_at DATETIME,
+			deleted_by TEXT,
+			delete_reason TEXT
+		)
+	`).Error)
+
+	require.NoError(t, db.Exec(`
+		CREATE TABLE wiki_stats (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			wiki_id TEXT NOT NULL,
+			time DATETIME NOT NULL,
+			pages INTEGER NOT NULL DEFAULT 0,
+			articles INTEGER NOT NULL DEFAULT 0,
+			edits INTEGER NOT NULL DEFAULT 0,
+			images INTEGER NOT NULL DEFAULT 0,
+			users INTEGER NOT NULL DEFAULT 0,
+			active_users INTEGER NOT NULL DEFAULT 0,
+			admins INTEGER NOT NULL DEFAULT 0,
+			jobs INTEGER NOT NULL DEFAULT 0,
+			response_time_ms INTEGER,
+			http_status INTEGER
+		)
+	`).Error)
+
+	require.NoError(t, db.Exec(`
+		CREATE TABLE wiki_urls (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			url TEXT NOT NULL,
+			first_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(kind, url)
+		)
+	`).Error)
+
+	require.NoError(t, db.Exec(`
+		CREATE TABLE wiki_events (
+			id TEXT PRIMARY KEY,
+			wiki_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			from_status TEXT,
+			to_status TEXT,
+			ia_identifier TEXT,
+			message TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+
+	return db
+}
+
+// TestCollectorService_CollectSingleWiki_CheckInsertFails tests that stats are stored when the check can't be recorded
+func TestCollectorService_CollectSingleWiki_CheckInsertFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"query":{
+			"general":{"sitename":"Test Wiki","lang":"en","generator":"MediaWiki 1.39.0","dbtype":"mysql","dbversion":"8.0"},
+			"statistics":{"pages":120,"articles":40,"edits":900,"images":3,"users":12,"activeusers":2,"admins":1,"jobs":0}
+		}}`))
+	}))
+	defer server.Close()
+
+	db := setupCollectorDB(t)
+	ctx := context.Background()
+
+	apiURL, indexURL := server.URL+"/api.php", server.URL+"/index.php"
+	wiki := &models.Wiki{ID: uuid.New(), URL: server.URL, APIURL: &apiURL, IndexURL: &indexURL, Status: models.WikiStatusPending, IsActive: true}
+	require.NoError(t, repository.NewWikiRepository(db).Create(ctx, wiki))
+
+	cfg := &config.Config{WikiCollectInterval: 60, StatsHeartbeatInterval: 1440}
+	collector := NewCollectorService(db, NewMediaWikiService(5*time.Second, "WikiKeeper-Test/1.0"), cfg)
+
+	require.NoError(t, collector.CollectSingleWiki(ctx, wiki.ID))
+
+	stats, err := repository.NewStatsRepository(db).GetLatestByWikiID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 120, stats.Pages)
+	assert.Equal(t, 900, stats.Edits)
+
+	updated, err := repository.NewWikiRepository(db).GetByID(ctx, wiki.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.WikiStatusOK, updated.Status)
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Service-level error definitions
@@ -35,6 +36,24 @@ func (e *ServiceError) Unwrap() error {
 	return e.Err
 }
 
+// ResponseError is an error of a request that got an HTTP response, carrying its
+// status and response time so failed checks can record them
+type ResponseError struct {
+	StatusCode   int
+	ResponseTime time.Duration
+	Err          error
+}
+
+// Error returns the message of the underlying error
+func (e *ResponseError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
 // NewMediaWikiError creates a new MediaWiki-related error
 func NewMediaWikiError(op, url string, err error) *ServiceError {
 	return &ServiceError{
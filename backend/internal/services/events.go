@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+	"wikikeeper-backend/internal/webhook"
+)
+
+// EventListener is called with every recorded event. It must not block.
+type EventListener func(event *models.WikiEvent)
+
+var (
+	listenersMu sync.RWMutex
+	listeners   []EventListener
+)
+
+// AddEventListener registers a listener for recorded events, e.g. the chat notifiers
+func AddEventListener(listener EventListener) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, listener)
+}
+
+// RecordEvent stores a wiki event, queues it for matching webhooks and passes it to
+// the event listeners. Failures are logged and never fail the caller.
+func RecordEvent(ctx context.Context, db *gorm.DB, event *models.WikiEvent) {
+	if err := repository.NewEventRepository(db).Create(ctx, event); err != nil {
+		applogger.Log.Warn("[Events] Failed to record event", "wiki_id", event.WikiID, "type", event.Type, "error", err)
+		return
+	}
+	if err := webhook.Enqueue(ctx, db, event); err != nil {
+		applogger.Log.Warn("[Events] Failed to queue webhook deliveries", "event_id", event.ID, "type", event.Type, "error", err)
+	}
+
+	listenersMu.RLock()
+	defer listenersMu.RUnlock()
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
+// recordWikiTransitions records status and read-only changes of a wiki after it was updated
+func recordWikiTransitions(ctx context.Context, db *gorm.DB, wiki *models.Wiki, prevStatus models.WikiStatus, prevReadOnly bool, readOnlyReason string) {
+	if eventType, changed := models.StatusTransitionEvent(prevStatus, wiki.Status); changed {
+		from, to := prevStatus, wiki.Status
+		RecordEvent(ctx, db, &models.WikiEvent{
+			WikiID:     wiki.ID,
+			Type:       eventType,
+			FromStatus: &from,
+			ToStatus:   &to,
+			Message:    wiki.LastError,
+		})
+	}
+
+	if wiki.ReadOnly != prevReadOnly {
+		event := &models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventWritable}
+		if wiki.ReadOnly {
+			event.Type = models.WikiEventReadOnly
+			event.Message = optionalString(readOnlyReason)
+		}
+		RecordEvent(ctx, db, event)
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/metrics"
+)
+
+// Job is a periodic maintenance task run by the JobScheduler
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+}
+
+// JobScheduler runs registered maintenance jobs on their own intervals.
+// Each job runs once at start and then every Interval; runs of the same job never overlap.
+type JobScheduler struct {
+	jobs    []Job
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	running bool
+}
+
+// NewJobScheduler creates a new job scheduler instance
+func NewJobScheduler() *JobScheduler {
+	return &JobScheduler{
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Register adds a job. Jobs must be registered before Start.
+func (s *JobScheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Start begins running all registered jobs
+func (s *JobScheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		applogger.Log.Warn("job scheduler already running")
+		return
+	}
+	s.running = true
+
+	for _, job := range s.jobs {
+		applogger.Log.Info("job scheduled", "job", job.Name, "interval", job.Interval)
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+}
+
+// Stop gracefully stops the scheduler, waiting for running jobs to finish
+func (s *JobScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.running {
+		return
+	}
+
+	close(s.stopCh)
+	s.wg.Wait()
+	s.running = false
+	applogger.Log.Info("job scheduler stopped")
+}
+
+// loop runs a single job until the scheduler stops
+func (s *JobScheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runJob(ctx, job)
+
+		select {
+		case <-ticker.C:
+		case <-s.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runJob executes one run of a job and records its outcome
+func (s *JobScheduler) runJob(ctx context.Context, job Job) {
+	startTime := time.Now()
+	err := job.Run(ctx)
+	elapsed := time.Since(startTime)
+
+	if err != nil {
+		applogger.Log.Error("job failed", "job", job.Name, "duration", elapsed.Round(time.Millisecond), "error", err)
+		metrics.JobRunsTotal.WithLabelValues(job.Name, "error").Inc()
+		return
+	}
+
+	applogger.Log.Info("job completed", "job", job.Name, "duration", elapsed.Round(time.Millisecond))
+	metrics.JobRunsTotal.WithLabelValues(job.Name, "success").Inc()
+}
@@ -3,10 +3,12 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -42,6 +44,8 @@ type MediaWikiClient struct {
 type SiteInfo struct {
 	General      SiteInfoGeneral
 	Statistics   SiteInfoStatistics
+	Rights       SiteInfoRights
+	Extensions   []string // Sorted names of installed extensions
 	ResponseTime int   // Response time in milliseconds
 	HTTPStatus   int   // HTTP status code
 }
@@ -56,6 +60,8 @@ type SiteInfoGeneral struct {
 	BaseURL       string  `json:"baseurl"`
 	MainPage      string  `json:"mainpage"`
 	MaxPageID     *int    `json:"maxpageid,omitempty"`
+	ReadOnly       bool   `json:"readonly"`
+	ReadOnlyReason string `json:"readonlyreason"`
 }
 
 // SiteInfoStatistics contains wiki statistics from siteinfo
@@ -70,11 +76,19 @@ type SiteInfoStatistics struct {
 	Jobs        int `json:"jobs"`
 }
 
+// SiteInfoRights contains the content license from siteinfo.rightsinfo
+type SiteInfoRights struct {
+	Text string `json:"text"`
+	URL  string `json:"url"`
+}
+
 // API response structures
 type mediawikiResponse struct {
 	Query struct {
-		General     map[string]interface{} `json:"general"`
-		Statistics  map[string]interface{} `json:"statistics"`
+		General     map[string]interface{}   `json:"general"`
+		Statistics  map[string]interface{}   `json:"statistics"`
+		Rightsinfo  map[string]interface{}   `json:"rightsinfo"`
+		Extensions  []map[string]interface{} `json:"extensions"`
 	} `json:"query"`
 	Error *struct {
 		Code    string `json:"code"`
@@ -124,9 +138,10 @@ func (s *MediaWikiService) FetchSiteinfo(ctx context.Context, client *MediaWikiC
 		return nil, NewMediaWikiError("fetch_siteinfo", client.URL, ErrMediaWikiNotFound)
 	}
 
-	// Build API request URL with both general and statistics
+	// Build API request URL with general info, statistics, license and extensions.
+	// Older MediaWiki versions ignore unknown siprop values with a warning.
 	apiURL := *client.APIURL
-	reqURL := fmt.Sprintf("%s?action=query&meta=siteinfo&siprop=general|statistics&format=json", apiURL)
+	reqURL := fmt.Sprintf("%s?action=query&meta=siteinfo&siprop=general|statistics|rightsinfo|extensions&format=json", apiURL)
 
 	start := time.Now()
 	resp, err := s.makeRequest(ctx, reqURL)
@@ -135,33 +150,38 @@ func (s *MediaWikiService) FetchSiteinfo(ctx context.Context, client *MediaWikiC
 	}
 	defer resp.Body.Close()
 	elapsed := time.Since(start)
+	failed := func(op string, err error) error {
+		return NewMediaWikiError(op, client.URL, &ResponseError{StatusCode: resp.StatusCode, ResponseTime: elapsed, Err: err})
+	}
 
 	// Parse response
 	var mwResp mediawikiResponse
 	if err := json.NewDecoder(resp.Body).Decode(&mwResp); err != nil {
-		return nil, NewMediaWikiError("parse_response", client.URL, fmt.Errorf("JSON decode: %w", err))
+		return nil, failed("parse_response", fmt.Errorf("JSON decode: %w", err))
 	}
 
 	// Check for API errors
 	if mwResp.Error != nil {
-		return nil, NewMediaWikiError("api_error", client.URL, fmt.Errorf("%s: %s", mwResp.Error.Code, mwResp.Error.Info))
+		return nil, failed("api_error", fmt.Errorf("%s: %s", mwResp.Error.Code, mwResp.Error.Info))
 	}
 
 	// Parse general info
 	general, err := parseSiteInfoGeneral(mwResp.Query.General)
 	if err != nil {
-		return nil, NewMediaWikiError("parse_general", client.URL, err)
+		return nil, failed("parse_general", err)
 	}
 
 	// Parse statistics
 	stats, err := parseSiteInfoStatistics(mwResp.Query.Statistics)
 	if err != nil {
-		return nil, NewMediaWikiError("parse_statistics", client.URL, err)
+		return nil, failed("parse_statistics", err)
 	}
 
 	siteinfo := &SiteInfo{
 		General:      *general,
 		Statistics:   *stats,
+		Rights:       parseSiteInfoRights(mwResp.Query.Rightsinfo),
+		Extensions:   parseSiteInfoExtensions(mwResp.Query.Extensions),
 		ResponseTime: int(elapsed.Milliseconds()),
 		HTTPStatus:   resp.StatusCode,
 	}
@@ -192,6 +212,7 @@ func (s *MediaWikiService) detectAPIURL(ctx context.Context, baseURL string) (ap
 	var lastErr error
 	var lastHTTPStatus int
 	var lastRespBody string
+	var lastResponse *ResponseError // Status and response time of the last candidate that answered
 
 	for _, candidate := range candidates {
 		// Check for permanent redirects on the API URL
@@ -244,9 +265,14 @@ func (s *MediaWikiService) detectAPIURL(ctx context.Context, baseURL string) (ap
 
 		// Test API URL (either original or if redirect didn't work)
 		testURL := candidate.apiURL + "?action=query&meta=siteinfo&format=json"
+		start := time.Now()
 		resp, err := s.makeRequest(ctx, testURL)
 		if err != nil {
 			lastErr = err
+			var response *ResponseError
+			if errors.As(err, &response) {
+				lastResponse = response
+			}
 			continue
 		}
 		defer resp.Body.Close()
@@ -255,6 +281,7 @@ func (s *MediaWikiService) detectAPIURL(ctx context.Context, baseURL string) (ap
 		lastHTTPStatus = resp.StatusCode
 		body, _ := io.ReadAll(resp.Body)
 		lastRespBody = string(body)
+		lastResponse = &ResponseError{StatusCode: resp.StatusCode, ResponseTime: time.Since(start)}
 
 		// Check if response is valid JSON
 		var result map[string]interface{}
@@ -287,6 +314,13 @@ func (s *MediaWikiService) detectAPIURL(ctx context.Context, baseURL string) (ap
 	}
 	errMsg += ")"
 
+	if lastResponse != nil {
+		return "", "", NewMediaWikiError("detect_api", baseURL, &ResponseError{
+			StatusCode:   lastResponse.StatusCode,
+			ResponseTime: lastResponse.ResponseTime,
+			Err:          errors.New(errMsg),
+		})
+	}
 	return "", "", NewMediaWikiError("detect_api", baseURL, fmt.Errorf(errMsg))
 }
 
@@ -392,6 +426,7 @@ func (s *MediaWikiService) makeRequest(ctx context.Context, url string) (*http.R
 	req.Header.Set("Accept", "application/json")
 
 	client := &http.Client{Timeout: s.timeout}
+	start := time.Now()
 	resp, err := client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request failed: %w", err)
@@ -410,7 +445,11 @@ func (s *MediaWikiService) makeRequest(ctx context.Context, url string) (*http.R
 		bodyStr = strings.ReplaceAll(bodyStr, "\n", " ")
 		bodyStr = strings.ReplaceAll(bodyStr, "\r", " ")
 		bodyStr = strings.TrimSpace(bodyStr)
-		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, bodyStr)
+		return nil, &ResponseError{
+			StatusCode:   resp.StatusCode,
+			ResponseTime: time.Since(start),
+			Err:          fmt.Errorf("HTTP %d: %s", resp.StatusCode, bodyStr),
+		}
 	}
 
 	return resp, nil
@@ -453,6 +492,14 @@ func parseSiteInfoGeneral(data map[string]interface{}) (*SiteInfoGeneral, error)
 	general.MainPage = getString("mainpage")
 	general.MaxPageID = getInt("maxpageid")
 
+	// formatversion=1 reports a locked wiki as "readonly": "", formatversion=2 as "readonly": true
+	if v, ok := data["readonly"]; ok {
+		if b, isBool := v.(bool); !isBool || b {
+			general.ReadOnly = true
+			general.ReadOnlyReason = getString("readonlyreason")
+		}
+	}
+
 	return general, nil
 }
 
@@ -488,3 +535,32 @@ func parseSiteInfoStatistics(data map[string]interface{}) (*SiteInfoStatistics,
 
 	return stats, nil
 }
+
+// parseSiteInfoRights parses the license from siteinfo.rightsinfo
+func parseSiteInfoRights(data map[string]interface{}) SiteInfoRights {
+	var rights SiteInfoRights
+	if text, ok := data["text"].(string); ok {
+		rights.Text = strings.TrimSpace(text)
+	}
+	if u, ok := data["url"].(string); ok {
+		rights.URL = strings.TrimSpace(u)
+	}
+	return rights
+}
+
+// parseSiteInfoExtensions returns the sorted, de-duplicated names of installed extensions
+func parseSiteInfoExtensions(data []map[string]interface{}) []string {
+	seen := make(map[string]bool, len(data))
+	names := make([]string, 0, len(data))
+	for _, ext := range data {
+		name, ok := ext["name"].(string)
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -2,13 +2,24 @@ package services
 
 import (
 	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	applogger "wikikeeper-backend/internal/logger"
 )
 
+func TestMain(m *testing.M) {
+	applogger.Init("ERROR")
+	os.Exit(m.Run())
+}
+
 // TestMediaWikiService_Initialize_RealAPI tests API detection with real Wikipedia
 func TestMediaWikiService_Initialize_RealAPI(t *testing.T) {
 	// Skip in short mode
@@ -172,3 +183,28 @@ func TestMediaWikiService_Timeout(t *testing.T) {
 		t.Logf("Timeout working correctly: %v", err)
 	}
 }
+
+// TestMediaWikiService_FetchSiteinfo_ResponseError tests that failed requests keep their HTTP status
+func TestMediaWikiService_FetchSiteinfo_ResponseError(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(`{"error":{"code":"readonly","info":"The wiki is in read-only mode"}}`))
+	}))
+	defer server.Close()
+
+	service := NewMediaWikiService(5*time.Second, "WikiKeeper-Test/1.0")
+	client := service.CreateClientWithURL(server.URL, server.URL+"/api.php", server.URL+"/index.php")
+
+	_, err := service.FetchSiteinfo(context.Background(), client)
+	var response *ResponseError
+	require.True(t, errors.As(err, &response), "error: %v", err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	// API errors come with a 200 response
+	status = http.StatusOK
+	_, err = service.FetchSiteinfo(context.Background(), client)
+	require.True(t, errors.As(err, &response), "error: %v", err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Contains(t, err.Error(), "readonly")
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/cache"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// collectorActor is recorded as the actor of merges done during collection
+const collectorActor = "collector"
+
+// MergeWikis merges a duplicate wiki into the surviving wiki, records a merged event and logs the merge
+func MergeWikis(ctx context.Context, db *gorm.DB, survivorID, duplicateID uuid.UUID, actor string, reason *string) (*models.WikiMerge, error) {
+	merge, err := repository.NewMergeRepository(db).Merge(ctx, survivorID, duplicateID, actor, reason)
+	if err != nil {
+		return nil, err
+	}
+	cache.Invalidate()
+
+	message := fmt.Sprintf("Merged %s (%d stats, %d archives moved)", merge.MergedURL, merge.StatsMoved, merge.ArchivesMoved)
+	RecordEvent(ctx, db, &models.WikiEvent{WikiID: survivorID, Type: models.WikiEventMerged, Message: &message})
+
+	applogger.Log.Info("[Merge] Wiki merged",
+		"survivor_id", survivorID,
+		"merged_id", duplicateID,
+		"merged_url", merge.MergedURL,
+		"stats_moved", merge.StatsMoved,
+		"archives_moved", merge.ArchivesMoved,
+		"actor", actor,
+	)
+	return merge, nil
+}
@@ -62,11 +62,7 @@ func (s *CollectionScheduler) Start(ctx context.Context) {
 
 	applogger.Log.Info("scheduler started", "interval", interval)
 
-	// Run initial collection
-	s.wg.Add(1)
-	go s.run(ctx)
-
-	// Start periodic collection
+	// Start periodic collection; wikis already due are collected right away
 	s.wg.Add(1)
 	go s.periodicRun(ctx)
 }
@@ -101,16 +97,10 @@ func (s *CollectionScheduler) run(ctx context.Context) {
 
 	startTime := time.Now()
 
-	// Get active wikis that need collection
-	// Priority: NULL last_check_at first (never checked), then oldest last_check_at
+	// Get active, unpaused wikis that are due for collection
+	// Priority: highest tier first, then never checked, then the longest overdue
 	wikiRepo := repository.NewWikiRepository(s.db)
-	wikis, _, err := wikiRepo.List(ctx, repository.ListOptions{
-		Page:     1,
-		PageSize: int(s.config.CollectBatchSize),
-		Status:   nil, // Get all statuses
-		// Order by last_check_at ASC (NULL first, then oldest)
-		OrderBy:  "last_check_at ASC NULLS FIRST",
-	})
+	wikis, err := wikiRepo.ListDue(ctx, repository.CheckCollect, time.Now(), s.config.CollectBatchSize)
 	if err != nil {
 		applogger.Log.Error("failed to get wikis", "error", err)
 		return
@@ -138,16 +128,18 @@ func (s *CollectionScheduler) run(ctx context.Context) {
 		default:
 		}
 
-		// Skip inactive wikis
-		if !wiki.IsActive {
-			continue
-		}
-
 		applogger.Log.Info("processing wiki", "index", i+1, "total", totalWikis, "url", wiki.URL)
 
 		// Collect siteinfo
 		if err := collector.CollectSingleWiki(ctx, wiki.ID); err != nil {
 			applogger.Log.Error("failed to collect wiki", "id", wiki.ID, "url", wiki.URL, "error", err)
+			// The collector schedules the next check itself, unless saving the wiki failed;
+			// push it back here too so a failing wiki isn't picked up again right away
+			now := time.Now()
+			wiki.ScheduleCheck(&now, collector.collectInterval())
+			if err := wikiRepo.ScheduleNext(ctx, wiki.ID, repository.CheckCollect, wiki.NextCheckAt); err != nil {
+				applogger.Log.Warn("failed to schedule next check", "id", wiki.ID, "error", err)
+			}
 			errorCount++
 			metrics.CollectionWikisFailed.Inc()
 		} else {
@@ -167,7 +159,7 @@ func (s *CollectionScheduler) run(ctx context.Context) {
 		"duration", elapsed.Round(time.Second))
 }
 
-// periodicRun runs collection continuously with backoff based on last_check_at
+// periodicRun runs collection whenever wikis are due, sleeping until the next one is
 func (s *CollectionScheduler) periodicRun(ctx context.Context) {
 	defer s.wg.Done()
 
@@ -180,47 +172,29 @@ func (s *CollectionScheduler) periodicRun(ctx context.Context) {
 			applogger.Log.Info("context cancelled")
 			return
 		default:
-			// Check the oldest last_check_at before running
 			wikiRepo := repository.NewWikiRepository(s.db)
-			wikis, _, err := wikiRepo.List(ctx, repository.ListOptions{
-				Page:     1,
-				PageSize: 1,
-				Status:   nil,
-				OrderBy:  "last_check_at ASC NULLS FIRST",
-			})
+			next, err := wikiRepo.NextDueAt(ctx, repository.CheckCollect, time.Now())
 			if err != nil {
 				applogger.Log.Error("failed to check wikis", "error", err)
 				time.Sleep(10 * time.Second)
 				continue
 			}
 
-			// Check if we need to back off
-			if len(wikis) > 0 && wikis[0].LastCheckAt != nil {
-				timeSinceLastCheck := time.Since(*wikis[0].LastCheckAt)
-				backoffThreshold := 3 * 24 * time.Hour // 3 days
-
-				if timeSinceLastCheck < backoffThreshold {
-					// Calculate backoff time based on how recent the last check was
-					// More recent = longer backoff (up to 60s max)
-					hoursSinceCheck := timeSinceLastCheck.Hours()
-					var backoffTime time.Duration
-					if hoursSinceCheck < 24 {
-						backoffTime = 60 * time.Second // checked within 24h, max backoff
-					} else if hoursSinceCheck < 48 {
-						backoffTime = 45 * time.Second // checked within 48h
-					} else {
-						backoffTime = 30 * time.Second // checked within 72h
-					}
-					applogger.Log.Info("backing off, recent update detected",
-						"last_check", wikis[0].LastCheckAt,
-						"hours_since", hoursSinceCheck,
-						"backoff", backoffTime)
-					time.Sleep(backoffTime)
-					continue
+			// Wait for the next wiki to become due, rechecking at least every minute
+			// so policy changes and new wikis are picked up
+			if wait := dueWait(next, time.Now()); wait > 0 {
+				applogger.Log.Debug("no wikis due", "next_due", next, "wait", wait)
+				select {
+				case <-time.After(wait):
+				case <-s.stopCh:
+				case <-ctx.Done():
+					return
 				}
+				continue
 			}
 
 			applogger.Log.Info("triggering collection")
+			s.wg.Add(1) // Balanced by the Done in run
 			s.run(ctx)
 
 			// Small delay to avoid tight loop
@@ -254,3 +228,15 @@ func (s *CollectionScheduler) TriggerManualRun(ctx context.Context) {
 	s.wg.Add(1)
 	go s.run(ctx)
 }
+
+// dueWait returns how long a scheduler should sleep before the next check falls due, at most a minute
+func dueWait(next *time.Time, now time.Time) time.Duration {
+	if next == nil {
+		return time.Minute
+	}
+	wait := next.Sub(now)
+	if wait > time.Minute {
+		return time.Minute
+	}
+	return wait
+}
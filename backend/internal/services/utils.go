@@ -39,3 +39,11 @@ func isValidURL(rawURL string) bool {
 	}
 	return u.Scheme != "" && u.Host != ""
 }
+
+// optionalString returns nil for an empty string and a pointer to s otherwise
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/cache"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// AddWiki starts tracking a wiki: it stores it, records its URLs for later lookups
+// and records an added event
+func AddWiki(ctx context.Context, db *gorm.DB, wiki *models.Wiki) error {
+	if err := repository.NewWikiRepository(db).Create(ctx, wiki); err != nil {
+		return err
+	}
+	if err := repository.NewWikiURLRepository(db).RecordWiki(ctx, wiki, time.Now()); err != nil {
+		applogger.Log.Warn("[Wiki] Failed to record URLs", "wiki_id", wiki.ID, "error", err)
+	}
+	RecordEvent(ctx, db, &models.WikiEvent{WikiID: wiki.ID, Type: models.WikiEventAdded})
+	cache.Invalidate()
+	return nil
+}
@@ -0,0 +1,252 @@
+package submission
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+)
+
+// Guard kinds, set with SUBMISSION_CHALLENGE
+const (
+	GuardNone    = "none"
+	GuardPoW     = "pow"
+	GuardCaptcha = "captcha"
+)
+
+var (
+	// ErrProofRequired is returned when a submission carries no proof
+	ErrProofRequired = errors.New("a solved challenge is required to submit a wiki")
+	// ErrInvalidProof is returned for wrong, expired and foreign proofs
+	ErrInvalidProof = errors.New("invalid or expired challenge solution")
+)
+
+// Challenge tells the submitter what to prove before submitting
+type Challenge struct {
+	Kind string `json:"kind"`
+
+	// Proof of work: find a nonce such that SHA-256(challenge + nonce) starts
+	// with difficulty zero bits
+	Challenge  string     `json:"challenge,omitempty"`
+	Difficulty int        `json:"difficulty,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+
+	// Captcha: the site key to render the provider's widget with
+	SiteKey string `json:"site_key,omitempty"`
+}
+
+// Proof is the submitter's answer to a challenge
+type Proof struct {
+	Challenge       string `json:"challenge,omitempty"`
+	Nonce           string `json:"nonce,omitempty"`
+	CaptchaResponse string `json:"captcha_response,omitempty"`
+}
+
+// Guard discourages automated submissions
+type Guard interface {
+	// Challenge returns a new challenge for a submitter
+	Challenge() (*Challenge, error)
+	// Verify checks a proof. It returns a key identifying the proof when the
+	// guard relies on the caller to accept each proof only once.
+	Verify(ctx context.Context, proof Proof, ip string) (string, error)
+}
+
+// NewGuard returns the guard configured by cfg. A captcha without a verify URL
+// and secret, or an unknown kind, falls back to proof of work rather than
+// letting submissions through unchecked. httpClient may be nil for a default.
+func NewGuard(cfg *config.Config, httpClient *http.Client) Guard {
+	switch cfg.SubmissionChallenge {
+	case "", GuardNone:
+		return noGuard{}
+	case GuardCaptcha:
+		if cfg.SubmissionCaptchaVerify != "" && cfg.SubmissionCaptchaSecret != "" {
+			if httpClient == nil {
+				httpClient = &http.Client{Timeout: 10 * time.Second}
+			}
+			return &captchaGuard{
+				verifyURL: cfg.SubmissionCaptchaVerify,
+				secret:    cfg.SubmissionCaptchaSecret,
+				siteKey:   cfg.SubmissionCaptchaSiteKey,
+				http:      httpClient,
+			}
+		}
+		applogger.Log.Error("[Submission] Captcha needs SUBMISSION_CAPTCHA_VERIFY_URL and SUBMISSION_CAPTCHA_SECRET, using proof of work")
+	case GuardPoW:
+	default:
+		applogger.Log.Error("[Submission] Unknown SUBMISSION_CHALLENGE, using proof of work", "challenge", cfg.SubmissionChallenge)
+	}
+
+	secret := []byte(cfg.SubmissionChallengeSecret)
+	if len(secret) == 0 {
+		secret = processSecret()
+	}
+	difficulty := cfg.SubmissionPoWDifficulty
+	if difficulty <= 0 {
+		difficulty = defaultDifficulty
+	}
+	return &powGuard{secret: secret, difficulty: difficulty, now: time.Now}
+}
+
+// noGuard accepts every submission
+type noGuard struct{}
+
+func (noGuard) Challenge() (*Challenge, error) {
+	return &Challenge{Kind: GuardNone}, nil
+}
+
+func (noGuard) Verify(context.Context, Proof, string) (string, error) {
+	return "", nil
+}
+
+const (
+	defaultDifficulty = 16
+	challengeTTL      = 10 * time.Minute
+	maxNonceLength    = 64
+)
+
+var (
+	processSecretOnce  sync.Once
+	processSecretValue []byte
+)
+
+// processSecret returns a random key shared by the guards of this process, used
+// when no secret is configured; challenges then do not survive a restart
+func processSecret() []byte {
+	processSecretOnce.Do(func() {
+		processSecretValue = make([]byte, 32)
+		if _, err := rand.Read(processSecretValue); err != nil {
+			panic(fmt.Sprintf("submission: no randomness for the challenge secret: %v", err))
+		}
+	})
+	return processSecretValue
+}
+
+// powGuard issues signed, expiring proof-of-work challenges, so no state is kept
+// until a solution is used
+type powGuard struct {
+	secret     []byte
+	difficulty int
+	now        func() time.Time
+}
+
+func (g *powGuard) Challenge() (*Challenge, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	expiresAt := g.now().Add(challengeTTL).UTC().Truncate(time.Second)
+	payload := strconv.FormatInt(expiresAt.Unix(), 10) + "." + hex.EncodeToString(random)
+	return &Challenge{
+		Kind:       GuardPoW,
+		Challenge:  payload + "." + g.sign(payload),
+		Difficulty: g.difficulty,
+		ExpiresAt:  &expiresAt,
+	}, nil
+}
+
+func (g *powGuard) sign(payload string) string {
+	mac := hmac.New(sha256.New, g.secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns the SHA-256 of the challenge as the key, so each challenge is
+// accepted once
+func (g *powGuard) Verify(_ context.Context, proof Proof, _ string) (string, error) {
+	if proof.Challenge == "" || proof.Nonce == "" {
+		return "", ErrProofRequired
+	}
+	if len(proof.Nonce) > maxNonceLength {
+		return "", ErrInvalidProof
+	}
+	cut := strings.LastIndex(proof.Challenge, ".")
+	if cut < 0 || !hmac.Equal([]byte(proof.Challenge[cut+1:]), []byte(g.sign(proof.Challenge[:cut]))) {
+		return "", ErrInvalidProof
+	}
+	expiry, _, _ := strings.Cut(proof.Challenge, ".")
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !g.now().Before(time.Unix(unix, 0)) {
+		return "", ErrInvalidProof
+	}
+	if LeadingZeroBits(sha256.Sum256([]byte(proof.Challenge+proof.Nonce))) < g.difficulty {
+		return "", ErrInvalidProof
+	}
+	sum := sha256.Sum256([]byte(proof.Challenge))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// LeadingZeroBits counts the zero bits a hash starts with
+func LeadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// captchaGuard verifies captcha responses with the provider's siteverify
+// endpoint, which hCaptcha, Turnstile and reCAPTCHA share
+type captchaGuard struct {
+	verifyURL string
+	secret    string
+	siteKey   string
+	http      *http.Client
+}
+
+func (g *captchaGuard) Challenge() (*Challenge, error) {
+	return &Challenge{Kind: GuardCaptcha, SiteKey: g.siteKey}, nil
+}
+
+// Verify returns no key: providers accept each response only once themselves
+func (g *captchaGuard) Verify(ctx context.Context, proof Proof, ip string) (string, error) {
+	if proof.CaptchaResponse == "" {
+		return "", ErrProofRequired
+	}
+	form := url.Values{"secret": {g.secret}, "response": {proof.CaptchaResponse}}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("captcha verification failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("captcha verification failed: HTTP %d", resp.StatusCode)
+	}
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return "", fmt.Errorf("captcha verification failed: %w", err)
+	}
+	if !result.Success {
+		return "", ErrInvalidProof
+	}
+	return "", nil
+}
@@ -0,0 +1,285 @@
+package submission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+	"wikikeeper-backend/internal/services"
+)
+
+// ErrChanged is returned when a submission was decided or claimed by someone else
+// since it was read
+var ErrChanged = errors.New("submission was changed concurrently")
+
+const (
+	// staleCheck is how long a pre-check may stay in progress before it is retried
+	staleCheck = 10 * time.Minute
+	// checkBatchSize bounds the submissions CheckPending checks per run
+	checkBatchSize = 20
+)
+
+// Probe is what the pre-check learned from the submitted wiki
+type Probe struct {
+	APIURL    string
+	Sitename  string
+	Generator string
+}
+
+// ProbeFunc queries a wiki's API; apiURL is empty when it must be detected
+type ProbeFunc func(ctx context.Context, wikiURL, apiURL string) (*Probe, error)
+
+// Match is a tracked wiki a submission duplicates
+type Match struct {
+	WikiID  uuid.UUID
+	IsAlias bool // Matched a former or merged URL rather than a current one
+	Trashed bool
+}
+
+// LookupFunc finds the tracked wiki with one of the given URLs, returning nil
+// when there is none
+type LookupFunc func(ctx context.Context, wikiURL, apiURL string) (*Match, error)
+
+// Rules are the auto-approval rules applied after the pre-check
+type Rules struct {
+	AutoApprove    bool     // Approve new wikis whose API answers as MediaWiki
+	BlockedDomains []string // Reject wikis on these domains and their subdomains
+}
+
+// RulesFromConfig returns the rules configured by cfg
+func RulesFromConfig(cfg *config.Config) Rules {
+	return Rules{AutoApprove: cfg.SubmissionAutoApprove, BlockedDomains: cfg.SubmissionBlockedDomains}
+}
+
+// blockedDomain returns the blocked domain host is on, or ""
+func (r Rules) blockedDomain(host string) string {
+	for _, domain := range r.BlockedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "."))
+		if domain != "" && (host == domain || strings.HasSuffix(host, "."+domain)) {
+			return domain
+		}
+	}
+	return ""
+}
+
+// Queue pre-checks and decides submissions
+type Queue struct {
+	db      *gorm.DB
+	probe   ProbeFunc
+	lookup  LookupFunc
+	rules   Rules
+	addWiki func(ctx context.Context, db *gorm.DB, wiki *models.Wiki) error
+}
+
+// NewQueue creates a queue that probes wikis with probe and finds duplicates with lookup
+func NewQueue(db *gorm.DB, probe ProbeFunc, lookup LookupFunc, rules Rules) *Queue {
+	return &Queue{db: db, probe: probe, lookup: lookup, rules: rules, addWiki: services.AddWiki}
+}
+
+// MediaWikiProbe probes wikis with the MediaWiki API client
+func MediaWikiProbe(mw *services.MediaWikiService) ProbeFunc {
+	return func(ctx context.Context, wikiURL, apiURL string) (*Probe, error) {
+		var client *services.MediaWikiClient
+		if apiURL != "" {
+			client = mw.CreateClientWithURL(wikiURL, apiURL, "")
+		} else {
+			var err error
+			if client, err = mw.Initialize(ctx, wikiURL); err != nil {
+				return nil, err
+			}
+		}
+		info, err := mw.FetchSiteinfo(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		return &Probe{APIURL: *client.APIURL, Sitename: info.General.Sitename, Generator: info.General.Generator}, nil
+	}
+}
+
+// CheckPending pre-checks submissions awaiting a check, and checks abandoned by a
+// stopped server. It is run periodically as a scheduler job.
+func (q *Queue) CheckPending(ctx context.Context) error {
+	ids, err := repository.NewSubmissionRepository(q.db).ListCheckable(ctx, time.Now().UTC().Add(-staleCheck), checkBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := q.Check(ctx, id); err != nil && !errors.Is(err, ErrChanged) {
+			applogger.Log.Warn("[Submission] Pre-check failed", "submission_id", id, "error", err)
+		}
+	}
+	return nil
+}
+
+// Check claims a submission awaiting its pre-check, checks it and applies the
+// rules. It returns ErrChanged when the submission is not awaiting a check.
+func (q *Queue) Check(ctx context.Context, id uuid.UUID) (*models.Submission, error) {
+	repo := repository.NewSubmissionRepository(q.db)
+	now := time.Now().UTC()
+	claimed, err := repo.ClaimForCheck(ctx, id, now, now.Add(-staleCheck))
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrChanged
+	}
+	sub, err := repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	reason, err := q.precheck(ctx, sub)
+	if err != nil {
+		// Leave the claim to go stale, so the check is retried
+		return nil, err
+	}
+	sub.CheckedAt = &now
+
+	switch {
+	case sub.DuplicateOf != nil || reason != "":
+		if reason == "" {
+			reason = "Already tracked"
+		}
+		err = q.Reject(ctx, sub, models.SubmissionAutoReviewer, &reason)
+	case q.rules.AutoApprove && sub.Reachable && sub.IsMediaWiki:
+		reason = "Passed the pre-check"
+		err = q.Approve(ctx, sub, models.SubmissionAutoReviewer, &reason)
+	default:
+		sub.Status = models.SubmissionReview
+		err = q.save(ctx, sub, models.SubmissionChecking)
+	}
+	if err != nil {
+		return nil, err
+	}
+	applogger.Log.Info("[Submission] Pre-checked", "submission_id", sub.ID, "url", sub.URL, "status", sub.Status,
+		"reachable", sub.Reachable, "mediawiki", sub.IsMediaWiki)
+	return sub, nil
+}
+
+// precheck records on sub whether its API answers, whether it is a MediaWiki and
+// which tracked wiki it duplicates. It returns a reason to reject sub by rule.
+// Errors are of the database; failures to reach the wiki are results.
+func (q *Queue) precheck(ctx context.Context, sub *models.Submission) (string, error) {
+	sub.Reachable, sub.IsMediaWiki, sub.CheckError = false, false, nil
+	sub.DuplicateOf, sub.IsAlias = nil, false
+
+	if domain := q.rules.blockedDomain(Host(sub.URL)); domain != "" {
+		return fmt.Sprintf("Domain %s is blocked", domain), nil
+	}
+	apiURL := ""
+	if sub.APIURL != nil {
+		apiURL = *sub.APIURL
+	}
+	if duplicate, err := q.duplicate(ctx, sub, apiURL); duplicate || err != nil {
+		return "", err
+	}
+
+	probe, err := q.probe(ctx, sub.URL, apiURL)
+	if err != nil {
+		message := err.Error()
+		sub.CheckError = &message
+		return "", nil
+	}
+	sub.Reachable = true
+	sub.IsMediaWiki = strings.HasPrefix(probe.Generator, "MediaWiki")
+	sub.Sitename = optionalString(probe.Sitename)
+	sub.Generator = optionalString(probe.Generator)
+	if probe.APIURL != "" && probe.APIURL != apiURL {
+		// The detected API may belong to a wiki tracked under another URL
+		sub.APIURL = &probe.APIURL
+		if _, err := q.duplicate(ctx, sub, probe.APIURL); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// duplicate records the tracked wiki sub duplicates, reporting whether there is one
+func (q *Queue) duplicate(ctx context.Context, sub *models.Submission, apiURL string) (bool, error) {
+	match, err := q.lookup(ctx, sub.URL, apiURL)
+	if err != nil || match == nil {
+		return false, err
+	}
+	sub.DuplicateOf = &match.WikiID
+	sub.IsAlias = match.IsAlias
+	return true, nil
+}
+
+// Approve adds the submitted wiki to the tracked wikis and records the decision
+func (q *Queue) Approve(ctx context.Context, sub *models.Submission, reviewer string, reason *string) error {
+	from := sub.Status
+	now := time.Now().UTC()
+	wiki := &models.Wiki{
+		ID:       uuid.New(),
+		URL:      sub.URL,
+		Status:   models.WikiStatusPending,
+		WikiName: sub.WikiName,
+		APIURL:   sub.APIURL,
+	}
+	sub.Status = models.SubmissionApproved
+	sub.WikiID = &wiki.ID
+	sub.ReviewedBy = &reviewer
+	sub.ReviewedAt = &now
+	sub.Reason = reason
+	// Decide first, so that concurrent approvals cannot add the wiki twice
+	if err := q.save(ctx, sub, from); err != nil {
+		return err
+	}
+
+	if err := q.addWiki(ctx, q.db, wiki); err != nil {
+		sub.Status, sub.WikiID, sub.ReviewedBy, sub.ReviewedAt, sub.Reason = from, nil, nil, nil, nil
+		if revertErr := q.save(ctx, sub, models.SubmissionApproved); revertErr != nil {
+			applogger.Log.Error("[Submission] Failed to reopen submission", "submission_id", sub.ID, "error", revertErr)
+		}
+		return err
+	}
+	applogger.Log.Info("[Submission] Approved", "submission_id", sub.ID, "wiki_id", wiki.ID, "url", sub.URL, "reviewer", reviewer)
+	return nil
+}
+
+// Reject records that the submitted wiki will not be tracked
+func (q *Queue) Reject(ctx context.Context, sub *models.Submission, reviewer string, reason *string) error {
+	from := sub.Status
+	now := time.Now().UTC()
+	sub.Status = models.SubmissionRejected
+	sub.ReviewedBy = &reviewer
+	sub.ReviewedAt = &now
+	sub.Reason = reason
+	if err := q.save(ctx, sub, from); err != nil {
+		return err
+	}
+	applogger.Log.Info("[Submission] Rejected", "submission_id", sub.ID, "url", sub.URL, "reviewer", reviewer)
+	return nil
+}
+
+// save stores sub if its stored status is still from
+func (q *Queue) save(ctx context.Context, sub *models.Submission, from models.SubmissionStatus) error {
+	saved, err := repository.NewSubmissionRepository(q.db).Transition(ctx, sub, from)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		return ErrChanged
+	}
+	return nil
+}
+
+// optionalString returns nil for an empty string and a pointer to s otherwise
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
@@ -0,0 +1,47 @@
+// Package submission moderates wikis submitted by anonymous visitors. Each
+// submission is pre-checked automatically, decided by the auto-approval rules
+// when the result is clear, and otherwise left for a curator to review.
+package submission
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"wikikeeper-backend/internal/services"
+)
+
+// ErrInvalidURL is returned for submitted URLs that cannot be parsed
+var ErrInvalidURL = errors.New("invalid URL format")
+
+// ParseURL normalizes a submitted URL. A URL ending in /api.php is taken as the
+// wiki's API URL and also returned, with the wiki URL being its directory.
+func ParseURL(raw string) (wikiURL, apiURL string, err error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasSuffix(strings.TrimSuffix(raw, "/"), "/api.php") {
+		if wikiURL = services.NormalizeURL(raw); wikiURL == "" {
+			return "", "", ErrInvalidURL
+		}
+		return wikiURL, "", nil
+	}
+
+	apiURL = strings.TrimSuffix(raw, "/")
+	if !strings.HasPrefix(apiURL, "http://") && !strings.HasPrefix(apiURL, "https://") {
+		apiURL = "https://" + apiURL
+	}
+	// The trailing slash marks the wiki URL as a directory
+	wikiURL = strings.TrimSuffix(apiURL, "api.php")
+	if _, err := url.Parse(wikiURL); err != nil {
+		return "", "", ErrInvalidURL
+	}
+	return wikiURL, apiURL, nil
+}
+
+// Host returns the lower-case host name of a URL, or "" when it has none
+func Host(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
@@ -0,0 +1,294 @@
+package submission
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+
+	"wikikeeper-backend/internal/config"
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+func TestMain(m *testing.M) {
+	applogger.Init("ERROR")
+	os.Exit(m.Run())
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`
+		CREATE TABLE submissions (
+			id TEXT PRIMARY KEY,
+			url TEXT NOT NULL,
+			api_url TEXT,
+			wiki_name TEXT,
+			status TEXT NOT NULL,
+			submitter_ip TEXT NOT NULL,
+			proof_hash TEXT UNIQUE,
+			checked_at DATETIME,
+			reachable INTEGER NOT NULL DEFAULT 0,
+			is_mediawiki INTEGER NOT NULL DEFAULT 0,
+			sitename TEXT,
+			generator TEXT,
+			duplicate_of TEXT,
+			is_alias INTEGER NOT NULL DEFAULT 0,
+			check_error TEXT,
+			wiki_id TEXT,
+			reviewed_by TEXT,
+			reviewed_at DATETIME,
+			reason TEXT,
+			created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`).Error)
+	return db
+}
+
+func TestParseURL(t *testing.T) {
+	for raw, want := range map[string][2]string{
+		"example.org/wiki":                 {"https://example.org", ""},
+		" https://example.org/w/index.php": {"https://example.org/w", ""},
+		"https://example.org/w/api.php":    {"https://example.org/w/", "https://example.org/w/api.php"},
+		"example.org/api.php/":             {"https://example.org/", "https://example.org/api.php"},
+	} {
+		wikiURL, apiURL, err := ParseURL(raw)
+		require.NoError(t, err, raw)
+		assert.Equal(t, want[0], wikiURL, raw)
+		assert.Equal(t, want[1], apiURL, raw)
+	}
+
+	_, _, err := ParseURL("https://exa mple.org/")
+	assert.ErrorIs(t, err, ErrInvalidURL)
+}
+
+// solve finds a nonce for a proof-of-work challenge
+func solve(c *Challenge) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		if LeadingZeroBits(sha256.Sum256([]byte(c.Challenge+nonce))) >= c.Difficulty {
+			return nonce
+		}
+	}
+}
+
+func TestPoWGuard(t *testing.T) {
+	guard := NewGuard(&config.Config{SubmissionChallenge: GuardPoW, SubmissionPoWDifficulty: 8}, nil).(*powGuard)
+	ctx := context.Background()
+
+	challenge, err := guard.Challenge()
+	require.NoError(t, err)
+	assert.Equal(t, GuardPoW, challenge.Kind)
+	assert.Equal(t, 8, challenge.Difficulty)
+	nonce := solve(challenge)
+
+	_, err = guard.Verify(ctx, Proof{}, "")
+	assert.ErrorIs(t, err, ErrProofRequired)
+	key, err := guard.Verify(ctx, Proof{Challenge: challenge.Challenge, Nonce: nonce}, "")
+	require.NoError(t, err)
+	sum := sha256.Sum256([]byte(challenge.Challenge))
+	assert.Equal(t, hex.EncodeToString(sum[:]), key, "the key identifies the challenge, whatever the nonce")
+
+	forged := "9999999999" + challenge.Challenge[len("9999999999"):]
+	_, err = guard.Verify(ctx, Proof{Challenge: forged, Nonce: solve(&Challenge{Challenge: forged, Difficulty: 8})}, "")
+	assert.ErrorIs(t, err, ErrInvalidProof, "the expiry is signed")
+
+	other := NewGuard(&config.Config{SubmissionChallenge: GuardPoW, SubmissionChallengeSecret: "s3cret"}, nil)
+	_, err = other.Verify(ctx, Proof{Challenge: challenge.Challenge, Nonce: nonce}, "")
+	assert.ErrorIs(t, err, ErrInvalidProof, "challenges are bound to the secret")
+
+	guard.now = func() time.Time { return time.Now().Add(challengeTTL + time.Second) }
+	_, err = guard.Verify(ctx, Proof{Challenge: challenge.Challenge, Nonce: nonce}, "")
+	assert.ErrorIs(t, err, ErrInvalidProof, "challenges expire")
+
+	guard.now = time.Now
+	guard.difficulty = 64
+	_, err = guard.Verify(ctx, Proof{Challenge: challenge.Challenge, Nonce: nonce}, "")
+	assert.ErrorIs(t, err, ErrInvalidProof, "the hash must meet the difficulty")
+}
+
+func TestCaptchaGuard(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "s3cret", r.PostForm.Get("secret"))
+		assert.Equal(t, "192.0.2.1", r.PostForm.Get("remoteip"))
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("response") == "human" {
+			w.Write([]byte(`{"success": true}`))
+		} else {
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer server.Close()
+
+	guard := NewGuard(&config.Config{
+		SubmissionChallenge:      GuardCaptcha,
+		SubmissionCaptchaVerify:  server.URL,
+		SubmissionCaptchaSecret:  "s3cret",
+		SubmissionCaptchaSiteKey: "site-key",
+	}, nil)
+	ctx := context.Background()
+
+	challenge, err := guard.Challenge()
+	require.NoError(t, err)
+	assert.Equal(t, &Challenge{Kind: GuardCaptcha, SiteKey: "site-key"}, challenge)
+
+	_, err = guard.Verify(ctx, Proof{}, "192.0.2.1")
+	assert.ErrorIs(t, err, ErrProofRequired)
+	_, err = guard.Verify(ctx, Proof{CaptchaResponse: "bot"}, "192.0.2.1")
+	assert.ErrorIs(t, err, ErrInvalidProof)
+	key, err := guard.Verify(ctx, Proof{CaptchaResponse: "human"}, "192.0.2.1")
+	require.NoError(t, err)
+	assert.Empty(t, key)
+
+	_, isPoW := NewGuard(&config.Config{SubmissionChallenge: GuardCaptcha}, nil).(*powGuard)
+	assert.True(t, isPoW, "an unconfigured captcha falls back to proof of work")
+	_, isNone := NewGuard(&config.Config{}, nil).(noGuard)
+	assert.True(t, isNone)
+}
+
+// fakeWiki is a wiki the fake probe and lookup know
+type fakeWiki struct {
+	probe *Probe
+	match *Match
+}
+
+func newTestQueue(t *testing.T, rules Rules, wikis map[string]fakeWiki) (*Queue, *[]*models.Wiki) {
+	probe := func(ctx context.Context, wikiURL, apiURL string) (*Probe, error) {
+		if w, ok := wikis[wikiURL]; ok && w.probe != nil {
+			return w.probe, nil
+		}
+		return nil, errors.New("no MediaWiki API found")
+	}
+	lookup := func(ctx context.Context, wikiURL, apiURL string) (*Match, error) {
+		for _, u := range []string{wikiURL, apiURL} {
+			if w, ok := wikis[u]; ok && w.match != nil {
+				return w.match, nil
+			}
+		}
+		return nil, nil
+	}
+	queue := NewQueue(setupTestDB(t), probe, lookup, rules)
+	var added []*models.Wiki
+	queue.addWiki = func(ctx context.Context, db *gorm.DB, wiki *models.Wiki) error {
+		added = append(added, wiki)
+		return nil
+	}
+	return queue, &added
+}
+
+func submit(t *testing.T, q *Queue, url string) *models.Submission {
+	sub := &models.Submission{URL: url, Status: models.SubmissionPending, SubmitterIP: "192.0.2.1"}
+	require.NoError(t, repository.NewSubmissionRepository(q.db).Create(context.Background(), sub))
+	return sub
+}
+
+func TestQueue_Check(t *testing.T) {
+	tracked := uuid.New()
+	mediawiki := &Probe{APIURL: "https://new.example.org/w/api.php", Sitename: "New Wiki", Generator: "MediaWiki 1.41.0"}
+	queue, added := newTestQueue(t, Rules{AutoApprove: true, BlockedDomains: []string{"spam.example"}}, map[string]fakeWiki{
+		"https://new.example.org":             {probe: mediawiki},
+		"https://other.example.org":           {probe: &Probe{APIURL: "https://other.example.org/api.php", Generator: "DokuWiki"}},
+		"https://old.example.org":             {match: &Match{WikiID: tracked, IsAlias: true}},
+		"https://mirror.example.org":          {probe: &Probe{APIURL: "https://tracked.example.org/api.php", Generator: "MediaWiki 1.39.0"}},
+		"https://tracked.example.org/api.php": {match: &Match{WikiID: tracked}},
+		"https://wiki.spam.example":           {probe: mediawiki},
+	})
+	ctx := context.Background()
+
+	sub, err := queue.Check(ctx, submit(t, queue, "https://new.example.org").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionApproved, sub.Status)
+	assert.True(t, sub.Reachable)
+	assert.True(t, sub.IsMediaWiki)
+	assert.Equal(t, "New Wiki", *sub.Sitename)
+	assert.Equal(t, models.SubmissionAutoReviewer, *sub.ReviewedBy)
+	require.Len(t, *added, 1)
+	assert.Equal(t, *sub.WikiID, (*added)[0].ID)
+	assert.Equal(t, "https://new.example.org/w/api.php", *(*added)[0].APIURL, "the detected API URL is kept")
+
+	_, err = queue.Check(ctx, sub.ID)
+	assert.ErrorIs(t, err, ErrChanged, "decided submissions are not checked again")
+
+	sub, err = queue.Check(ctx, submit(t, queue, "https://other.example.org").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionReview, sub.Status, "wikis that are not MediaWikis are left for a curator")
+	assert.True(t, sub.Reachable)
+	assert.False(t, sub.IsMediaWiki)
+
+	sub, err = queue.Check(ctx, submit(t, queue, "https://down.example.org").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionReview, sub.Status)
+	assert.False(t, sub.Reachable)
+	assert.Equal(t, "no MediaWiki API found", *sub.CheckError)
+
+	sub, err = queue.Check(ctx, submit(t, queue, "https://old.example.org").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionRejected, sub.Status)
+	assert.Equal(t, tracked, *sub.DuplicateOf)
+	assert.True(t, sub.IsAlias)
+
+	sub, err = queue.Check(ctx, submit(t, queue, "https://mirror.example.org").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionRejected, sub.Status, "the detected API URL is tracked")
+	assert.Equal(t, tracked, *sub.DuplicateOf)
+	assert.False(t, sub.IsAlias)
+
+	sub, err = queue.Check(ctx, submit(t, queue, "https://wiki.spam.example").ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionRejected, sub.Status)
+	assert.Equal(t, "Domain spam.example is blocked", *sub.Reason)
+	assert.False(t, sub.Reachable, "blocked wikis are not contacted")
+
+	assert.Len(t, *added, 1)
+}
+
+func TestQueue_Review(t *testing.T) {
+	queue, added := newTestQueue(t, Rules{}, map[string]fakeWiki{
+		"https://new.example.org": {probe: &Probe{APIURL: "https://new.example.org/api.php", Generator: "MediaWiki 1.41.0"}},
+	})
+	ctx := context.Background()
+	first := submit(t, queue, "https://new.example.org")
+	second := submit(t, queue, "https://down.example.org")
+
+	require.NoError(t, queue.CheckPending(ctx))
+	sub, err := repository.NewSubmissionRepository(queue.db).GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionReview, sub.Status, "without auto-approval every wiki is reviewed")
+	assert.Empty(t, *added)
+
+	stale, err := repository.NewSubmissionRepository(queue.db).GetByID(ctx, first.ID)
+	require.NoError(t, err)
+	require.NoError(t, queue.Approve(ctx, sub, "alice", nil))
+	assert.ErrorIs(t, queue.Reject(ctx, stale, "bob", nil), ErrChanged, "a submission is decided once")
+	require.Len(t, *added, 1)
+	assert.Equal(t, "https://new.example.org/api.php", *(*added)[0].APIURL)
+
+	sub, err = repository.NewSubmissionRepository(queue.db).GetByID(ctx, second.ID)
+	require.NoError(t, err)
+	reason := "Not a wiki"
+	require.NoError(t, queue.Reject(ctx, sub, "bob", &reason))
+
+	queue.addWiki = func(context.Context, *gorm.DB, *models.Wiki) error { return errors.New("duplicate key") }
+	sub = submit(t, queue, "https://third.example.org")
+	assert.Error(t, queue.Approve(ctx, sub, "alice", nil))
+	sub, err = repository.NewSubmissionRepository(queue.db).GetByID(ctx, sub.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.SubmissionPending, sub.Status, "a failed approval reopens the submission")
+	assert.Nil(t, sub.WikiID)
+}
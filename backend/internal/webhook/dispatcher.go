@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	applogger "wikikeeper-backend/internal/logger"
+	"wikikeeper-backend/internal/models"
+	"wikikeeper-backend/internal/repository"
+)
+
+// Payload is the JSON body of a delivery
+type Payload struct {
+	Event *models.WikiEvent `json:"event"`
+	Wiki  *WikiSummary      `json:"wiki,omitempty"`
+}
+
+// WikiSummary describes the wiki an event is about, as of when the event was recorded
+type WikiSummary struct {
+	ID         uuid.UUID         `json:"id"`
+	URL        string            `json:"url"`
+	APIURL     *string           `json:"api_url,omitempty"`
+	Sitename   *string           `json:"sitename,omitempty"`
+	Status     models.WikiStatus `json:"status"`
+	ReadOnly   bool              `json:"read_only"`
+	HasArchive bool              `json:"has_archive"`
+	Tags       []string          `json:"tags"`
+}
+
+// pingPayload is the JSON body of test deliveries
+type pingPayload struct {
+	Event     string    `json:"event"`
+	WebhookID uuid.UUID `json:"webhook_id"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
+// dueBatchSize bounds the deliveries attempted per DeliverDue round
+const dueBatchSize = 100
+
+// Enqueue queues a delivery of event to every active webhook whose filter and
+// scope match it. The deliveries are sent by Dispatcher.DeliverDue.
+func Enqueue(ctx context.Context, db *gorm.DB, event *models.WikiEvent) error {
+	hookRepo := repository.NewWebhookRepository(db)
+	hooks, err := hookRepo.ListActive(ctx)
+	if err != nil || len(hooks) == 0 {
+		return err
+	}
+
+	payload := Payload{Event: event}
+	wikiURL := ""
+	var tags []string
+	wiki, err := repository.NewWikiRepository(db).GetByID(ctx, event.WikiID)
+	switch {
+	case err == nil:
+		wikiURL = wiki.URL
+		wikiTags, err := repository.NewTagRepository(db).ListByWikiID(ctx, wiki.ID)
+		if err != nil {
+			return err
+		}
+		tags = make([]string, 0, len(wikiTags))
+		for _, tag := range wikiTags {
+			tags = append(tags, tag.Name)
+		}
+		payload.Wiki = &WikiSummary{
+			ID:         wiki.ID,
+			URL:        wiki.URL,
+			APIURL:     wiki.APIURL,
+			Sitename:   wiki.Sitename,
+			Status:     wiki.Status,
+			ReadOnly:   wiki.ReadOnly,
+			HasArchive: wiki.HasArchive,
+			Tags:       tags,
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var deliveries []*models.WebhookDelivery
+	for i := range hooks {
+		if !hooks[i].Matches(event.Type, wikiURL, tags) {
+			continue
+		}
+		eventID := event.ID
+		deliveries = append(deliveries, &models.WebhookDelivery{
+			WebhookID:     hooks[i].ID,
+			EventID:       &eventID,
+			EventType:     string(event.Type),
+			Payload:       string(body),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return hookRepo.CreateDeliveries(ctx, deliveries)
+}
+
+// Dispatcher sends queued deliveries and records their outcome
+type Dispatcher struct {
+	db     *gorm.DB
+	sender *Sender
+}
+
+// NewDispatcher creates a dispatcher
+func NewDispatcher(db *gorm.DB, sender *Sender) *Dispatcher {
+	return &Dispatcher{db: db, sender: sender}
+}
+
+// DeliverDue attempts every pending delivery whose next attempt is due
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	hookRepo := repository.NewWebhookRepository(d.db)
+	hooks := make(map[uuid.UUID]*models.Webhook)
+
+	for {
+		deliveries, err := hookRepo.ListDueDeliveries(ctx, time.Now(), dueBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			hook, ok := hooks[delivery.WebhookID]
+			if !ok {
+				if hook, err = hookRepo.GetByID(ctx, delivery.WebhookID); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				hooks[delivery.WebhookID] = hook
+			}
+			if err := d.attempt(ctx, hookRepo, hook, delivery); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) < dueBatchSize {
+			return nil
+		}
+	}
+}
+
+// Ping sends a test delivery to a webhook right away and logs it like any other delivery
+func (d *Dispatcher) Ping(ctx context.Context, hook *models.Webhook) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	body, err := json.Marshal(pingPayload{Event: PingEvent, WebhookID: hook.ID, SentAt: now.UTC()})
+	if err != nil {
+		return nil, err
+	}
+
+	hookRepo := repository.NewWebhookRepository(d.db)
+	delivery := &models.WebhookDelivery{
+		WebhookID:     hook.ID,
+		EventType:     PingEvent,
+		Payload:       string(body),
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+	if err := hookRepo.CreateDeliveries(ctx, []*models.WebhookDelivery{delivery}); err != nil {
+		return nil, err
+	}
+	if err := d.attempt(ctx, hookRepo, hook, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// attempt sends a delivery once and schedules its retry or marks it finished.
+// Test pings are not retried.
+func (d *Dispatcher) attempt(ctx context.Context, hookRepo *repository.WebhookRepository, hook *models.Webhook, delivery *models.WebhookDelivery) error {
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.Error = nil
+
+	var sendErr error
+	switch {
+	case hook == nil:
+		sendErr = errors.New("webhook no longer exists")
+	case !hook.IsActive && delivery.EventType != PingEvent:
+		sendErr = errors.New("webhook is disabled")
+	default:
+		var status int
+		status, sendErr = d.sender.Send(ctx, hook.URL, hook.Secret, delivery.ID, delivery.EventType, []byte(delivery.Payload))
+		if status != 0 {
+			delivery.ResponseStatus = &status
+		}
+	}
+
+	now := time.Now()
+	if sendErr == nil {
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	} else {
+		message := sendErr.Error()
+		delivery.Error = &message
+		wait, retry := RetryDelay(delivery.Attempts)
+		if retry && hook != nil && hook.IsActive && delivery.EventType != PingEvent {
+			next := now.Add(wait)
+			delivery.NextAttemptAt = &next
+		} else {
+			delivery.Status = models.WebhookDeliveryFailed
+			delivery.NextAttemptAt = nil
+		}
+		applogger.Log.Warn("[Webhook] Delivery attempt failed", "webhook_id", delivery.WebhookID,
+			"delivery_id", delivery.ID, "attempt", delivery.Attempts, "error", sendErr)
+	}
+	return hookRepo.UpdateDelivery(ctx, delivery)
+}
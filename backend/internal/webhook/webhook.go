@@ -0,0 +1,133 @@
+// Package webhook delivers wiki events to admin-registered endpoints as signed JSON POSTs.
+//
+// Every request carries the headers
+//
+//	X-WikiKeeper-Event:     event type, e.g. went_offline, or ping
+//	X-WikiKeeper-Delivery:  delivery ID, stable across retries
+//	X-WikiKeeper-Timestamp: Unix time of the attempt
+//	X-WikiKeeper-Signature: sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret>
+//
+// Receivers should recompute the signature, compare it in constant time and reject
+// stale timestamps. Deliveries answered with anything but a 2xx status are retried
+// with growing delays and logged in webhook_deliveries.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Request headers
+const (
+	EventHeader     = "X-WikiKeeper-Event"
+	DeliveryHeader  = "X-WikiKeeper-Delivery"
+	TimestampHeader = "X-WikiKeeper-Timestamp"
+	SignatureHeader = "X-WikiKeeper-Signature"
+)
+
+// PingEvent is the event type of test deliveries
+const PingEvent = "ping"
+
+// retryDelays are the waits after each failed attempt; a delivery fails for good
+// once every delay was used up
+var retryDelays = [...]time.Duration{
+	time.Minute,
+	5 * time.Minute,
+	30 * time.Minute,
+	2 * time.Hour,
+	6 * time.Hour,
+}
+
+// MaxAttempts is the number of attempts made before a delivery fails for good
+const MaxAttempts = len(retryDelays) + 1
+
+// RetryDelay returns the wait after the given failed attempt (1-based), and false
+// when no attempts are left
+func RetryDelay(attempt int) (time.Duration, bool) {
+	if attempt < 1 || attempt > len(retryDelays) {
+		return 0, false
+	}
+	return retryDelays[attempt-1], true
+}
+
+// Sign computes the signature header value of body sent at timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature header value in constant time
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// GenerateSecret returns a random signing secret
+func GenerateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// maxErrorBody bounds how much of an error response is kept in the delivery log
+const maxErrorBody = 512
+
+// Sender POSTs signed payloads
+type Sender struct {
+	client    *http.Client
+	userAgent string
+	now       func() time.Time
+}
+
+// NewSender creates a sender whose requests time out after timeout
+func NewSender(timeout time.Duration, userAgent string) *Sender {
+	return &Sender{
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
+		now:       time.Now,
+	}
+}
+
+// Send POSTs body to url and returns the response status. Non-2xx answers are
+// returned as errors together with their status.
+func (s *Sender) Send(ctx context.Context, url, secret string, deliveryID uuid.UUID, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := s.now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", s.userAgent)
+	req.Header.Set(EventHeader, eventType)
+	req.Header.Set(DeliveryHeader, deliveryID.String())
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("endpoint answered HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBody))
+	return resp.StatusCode, nil
+}
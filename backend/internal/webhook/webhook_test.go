@@ -0,0 +1,84 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"event":{"type":"went_offline"}}`)
+	signature := Sign("secret", 1700000000, body)
+
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, signature)
+	assert.True(t, Verify("secret", 1700000000, body, signature))
+	assert.False(t, Verify("other", 1700000000, body, signature), "the secret is part of the signature")
+	assert.False(t, Verify("secret", 1700000001, body, signature), "the timestamp is part of the signature")
+	assert.False(t, Verify("secret", 1700000000, []byte(`{}`), signature))
+}
+
+func TestRetryDelay(t *testing.T) {
+	previous := time.Duration(0)
+	for attempt := 1; attempt < MaxAttempts; attempt++ {
+		wait, ok := RetryDelay(attempt)
+		require.True(t, ok, "attempt %d", attempt)
+		assert.Greater(t, wait, previous)
+		previous = wait
+	}
+	_, ok := RetryDelay(MaxAttempts)
+	assert.False(t, ok, "the last attempt is not retried")
+}
+
+func TestSender_Send(t *testing.T) {
+	deliveryID := uuid.New()
+	body := []byte(`{"event":{"type":"archive_found"}}`)
+
+	var received http.Header
+	var receivedBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		receivedBody, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/fail" {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sender := NewSender(5*time.Second, "WikiKeeper-Test")
+	status, err := sender.Send(context.Background(), server.URL+"/ok", "secret", deliveryID, "archive_found", body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	assert.Equal(t, body, receivedBody)
+	assert.Equal(t, "application/json", received.Get("Content-Type"))
+	assert.Equal(t, "WikiKeeper-Test", received.Get("User-Agent"))
+	assert.Equal(t, "archive_found", received.Get(EventHeader))
+	assert.Equal(t, deliveryID.String(), received.Get(DeliveryHeader))
+	timestamp, err := strconv.ParseInt(received.Get(TimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.True(t, Verify("secret", timestamp, receivedBody, received.Get(SignatureHeader)))
+
+	status, err = sender.Send(context.Background(), server.URL+"/fail", "secret", deliveryID, "archive_found", body)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Contains(t, err.Error(), "try later")
+}
+
+func TestGenerateSecret(t *testing.T) {
+	first, err := GenerateSecret()
+	require.NoError(t, err)
+	second, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, first, 64)
+	assert.NotEqual(t, first, second)
+}
@@ -0,0 +1,3 @@
+-- Remove daily catalog snapshots
+
+DROP TABLE IF EXISTS catalog_snapshots;
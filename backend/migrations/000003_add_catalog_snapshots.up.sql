@@ -0,0 +1,21 @@
+-- Daily catalog-wide totals for /api/stats/history
+CREATE TABLE IF NOT EXISTS catalog_snapshots (
+    date DATE PRIMARY KEY,
+
+    -- Wiki counts
+    total_wikis BIGINT NOT NULL DEFAULT 0,
+    active_wikis BIGINT NOT NULL DEFAULT 0,
+    ok_wikis BIGINT NOT NULL DEFAULT 0,
+    error_wikis BIGINT NOT NULL DEFAULT 0,
+    offline_wikis BIGINT NOT NULL DEFAULT 0,
+    pending_wikis BIGINT NOT NULL DEFAULT 0,
+    archived_wikis BIGINT NOT NULL DEFAULT 0,
+
+    -- Sums of the latest stats per wiki
+    total_pages BIGINT NOT NULL DEFAULT 0,
+    total_edits BIGINT NOT NULL DEFAULT 0,
+
+    captured_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE catalog_snapshots IS 'Catalog totals per day, refreshed by the catalog_snapshot job';
@@ -0,0 +1,5 @@
+-- Remove license and extension metadata from wikis table
+
+ALTER TABLE wikis DROP COLUMN IF EXISTS extensions;
+ALTER TABLE wikis DROP COLUMN IF EXISTS license_url;
+ALTER TABLE wikis DROP COLUMN IF EXISTS license;
@@ -0,0 +1,9 @@
+-- Add license and extension metadata from siteinfo to wikis table
+
+ALTER TABLE wikis ADD COLUMN license VARCHAR(255);
+ALTER TABLE wikis ADD COLUMN license_url VARCHAR(2048);
+ALTER TABLE wikis ADD COLUMN extensions TEXT;
+
+COMMENT ON COLUMN wikis.license IS 'Content license from siteinfo.rightsinfo';
+COMMENT ON COLUMN wikis.license_url IS 'Content license URL from siteinfo.rightsinfo';
+COMMENT ON COLUMN wikis.extensions IS 'JSON array of installed extension names from siteinfo.extensions';
@@ -0,0 +1,7 @@
+-- Remove catalog events and read-only tracking
+
+DROP INDEX IF EXISTS idx_wiki_events_type_created;
+DROP INDEX IF EXISTS idx_wiki_events_wiki_created;
+DROP TABLE IF EXISTS wiki_events;
+
+ALTER TABLE wikis DROP COLUMN IF EXISTS read_only;
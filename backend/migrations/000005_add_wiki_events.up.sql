@@ -0,0 +1,21 @@
+-- Track read-only wikis and record catalog events for feeds
+
+ALTER TABLE wikis ADD COLUMN read_only BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN wikis.read_only IS 'Whether siteinfo reported the wiki as read-only on the last check';
+
+CREATE TABLE IF NOT EXISTS wiki_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    type VARCHAR(32) NOT NULL,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20),
+    ia_identifier VARCHAR(255),
+    message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_events_wiki_created ON wiki_events(wiki_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_wiki_events_type_created ON wiki_events(type, created_at DESC);
+
+COMMENT ON TABLE wiki_events IS 'Status transitions, archive upserts and other catalog events';
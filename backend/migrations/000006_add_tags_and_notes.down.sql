@@ -0,0 +1,8 @@
+-- Remove curation tags and notes
+
+DROP INDEX IF EXISTS idx_wiki_notes_wiki_created;
+DROP TABLE IF EXISTS wiki_notes;
+
+DROP INDEX IF EXISTS idx_wiki_tags_tag_id;
+DROP TABLE IF EXISTS wiki_tags;
+DROP TABLE IF EXISTS tags;
@@ -0,0 +1,30 @@
+-- Curation: many-to-many tags and timestamped admin notes
+
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS wiki_tags (
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (wiki_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_tags_tag_id ON wiki_tags(tag_id);
+
+CREATE TABLE IF NOT EXISTS wiki_notes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    author VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_notes_wiki_created ON wiki_notes(wiki_id, created_at DESC);
+
+COMMENT ON TABLE tags IS 'Curation labels such as gaming, fandom-fork, at-risk or university';
+COMMENT ON TABLE wiki_notes IS 'Timestamped admin notes about a wiki';
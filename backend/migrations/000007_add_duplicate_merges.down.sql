@@ -0,0 +1,8 @@
+-- Remove the merge log and canonical API URLs
+
+DROP INDEX IF EXISTS idx_wiki_merges_merged_url;
+DROP INDEX IF EXISTS idx_wiki_merges_wiki_id;
+DROP TABLE IF EXISTS wiki_merges;
+
+DROP INDEX IF EXISTS idx_wikis_api_url_canonical;
+ALTER TABLE wikis DROP COLUMN IF EXISTS api_url_canonical;
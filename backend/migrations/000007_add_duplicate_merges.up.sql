@@ -0,0 +1,37 @@
+-- Indexed duplicate lookup by canonical API URL and a log of merged duplicates
+
+ALTER TABLE wikis ADD COLUMN api_url_canonical VARCHAR(2048);
+
+-- Same reduction as models.CanonicalAPIURL
+UPDATE wikis SET api_url_canonical = NULLIF(
+    regexp_replace(
+        regexp_replace(
+            regexp_replace(
+                regexp_replace(lower(api_url), '[?#].*$', ''),
+                '^[a-z][a-z0-9+.-]*://', ''),
+            '^www\.', ''),
+        '(:80|:443)?(/.*?)?/*$', '\2'),
+    '')
+WHERE api_url IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_wikis_api_url_canonical ON wikis(api_url_canonical);
+
+COMMENT ON COLUMN wikis.api_url_canonical IS 'api_url without scheme, www., default port, query or trailing slash, lower-cased';
+
+CREATE TABLE IF NOT EXISTS wiki_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    merged_wiki_id UUID NOT NULL,
+    merged_url VARCHAR(2048) NOT NULL,
+    merged_api_url VARCHAR(2048),
+    stats_moved BIGINT NOT NULL DEFAULT 0,
+    archives_moved BIGINT NOT NULL DEFAULT 0,
+    actor VARCHAR(255) NOT NULL,
+    reason TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_merges_wiki_id ON wiki_merges(wiki_id);
+CREATE INDEX IF NOT EXISTS idx_wiki_merges_merged_url ON wiki_merges(merged_url);
+
+COMMENT ON TABLE wiki_merges IS 'Duplicate wikis merged into a surviving wiki; merged URLs are aliases of the survivor';
@@ -0,0 +1,5 @@
+-- Remove the URL history
+
+DROP INDEX IF EXISTS idx_wiki_urls_url;
+DROP INDEX IF EXISTS idx_wiki_urls_wiki_id;
+DROP TABLE IF EXISTS wiki_urls;
@@ -0,0 +1,38 @@
+-- Every URL, API URL and index URL a wiki has used
+
+CREATE TABLE IF NOT EXISTS wiki_urls (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL,
+    url VARCHAR(2048) NOT NULL,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CONSTRAINT idx_wiki_urls_kind_url UNIQUE (kind, url)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_urls_wiki_id ON wiki_urls(wiki_id);
+CREATE INDEX IF NOT EXISTS idx_wiki_urls_url ON wiki_urls(url);
+
+-- Current URLs of every wiki
+INSERT INTO wiki_urls (wiki_id, kind, url, first_seen_at, last_seen_at)
+SELECT id, 'url', url, created_at, COALESCE(last_check_at, updated_at) FROM wikis
+ON CONFLICT (kind, url) DO NOTHING;
+
+INSERT INTO wiki_urls (wiki_id, kind, url, first_seen_at, last_seen_at)
+SELECT id, 'api', api_url, created_at, COALESCE(last_check_at, updated_at) FROM wikis WHERE api_url IS NOT NULL
+ON CONFLICT (kind, url) DO NOTHING;
+
+INSERT INTO wiki_urls (wiki_id, kind, url, first_seen_at, last_seen_at)
+SELECT id, 'index', index_url, created_at, COALESCE(last_check_at, updated_at) FROM wikis WHERE index_url IS NOT NULL
+ON CONFLICT (kind, url) DO NOTHING;
+
+-- URLs of merged duplicates
+INSERT INTO wiki_urls (wiki_id, kind, url, first_seen_at, last_seen_at)
+SELECT wiki_id, 'url', merged_url, created_at, created_at FROM wiki_merges
+ON CONFLICT (kind, url) DO NOTHING;
+
+INSERT INTO wiki_urls (wiki_id, kind, url, first_seen_at, last_seen_at)
+SELECT wiki_id, 'api', merged_api_url, created_at, created_at FROM wiki_merges WHERE merged_api_url IS NOT NULL
+ON CONFLICT (kind, url) DO NOTHING;
+
+COMMENT ON TABLE wiki_urls IS 'URLs, API URLs and index URLs a wiki has used, with first and last seen times';
@@ -0,0 +1,8 @@
+-- Permanently delete trashed wikis before dropping the columns that hide them
+DELETE FROM wikis WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_wikis_deleted_at;
+
+ALTER TABLE wikis DROP COLUMN IF EXISTS delete_reason;
+ALTER TABLE wikis DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE wikis DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,11 @@
+-- Soft deletion: deleted wikis stay in the trash with their history until purged
+
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(255);
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS delete_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_wikis_deleted_at ON wikis(deleted_at);
+
+COMMENT ON COLUMN wikis.deleted_at IS 'When the wiki was moved to the trash; NULL for live wikis';
+COMMENT ON COLUMN wikis.deleted_by IS 'Who moved the wiki to the trash';
+COMMENT ON COLUMN wikis.delete_reason IS 'Why the wiki was moved to the trash';
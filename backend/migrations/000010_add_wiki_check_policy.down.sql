@@ -0,0 +1,12 @@
+-- Remove per-wiki check policy
+
+DROP INDEX IF EXISTS idx_wikis_next_archive_check_at;
+DROP INDEX IF EXISTS idx_wikis_next_check_at;
+DROP INDEX IF EXISTS idx_wikis_priority;
+
+ALTER TABLE wikis DROP COLUMN IF EXISTS next_archive_check_at;
+ALTER TABLE wikis DROP COLUMN IF EXISTS next_check_at;
+ALTER TABLE wikis DROP COLUMN IF EXISTS paused_until;
+ALTER TABLE wikis DROP COLUMN IF EXISTS archive_check_interval_minutes;
+ALTER TABLE wikis DROP COLUMN IF EXISTS collect_interval_minutes;
+ALTER TABLE wikis DROP COLUMN IF EXISTS priority;
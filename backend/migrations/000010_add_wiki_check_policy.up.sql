@@ -0,0 +1,23 @@
+-- Per-wiki check policy and the next check times the schedulers pick work by
+
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS priority SMALLINT NOT NULL DEFAULT 0;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS collect_interval_minutes INTEGER;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS archive_check_interval_minutes INTEGER;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS paused_until TIMESTAMP;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS next_check_at TIMESTAMP;
+ALTER TABLE wikis ADD COLUMN IF NOT EXISTS next_archive_check_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_wikis_priority ON wikis(priority);
+CREATE INDEX IF NOT EXISTS idx_wikis_next_check_at ON wikis(next_check_at);
+CREATE INDEX IF NOT EXISTS idx_wikis_next_archive_check_at ON wikis(next_archive_check_at);
+
+-- Keep the previous cadence: the schedulers waited until the oldest check was three days old
+UPDATE wikis SET next_check_at = last_check_at + INTERVAL '3 days' WHERE last_check_at IS NOT NULL;
+UPDATE wikis SET next_archive_check_at = archive_last_check_at + INTERVAL '3 days' WHERE archive_last_check_at IS NOT NULL;
+
+COMMENT ON COLUMN wikis.priority IS 'Scheduling tier: -1 low, 0 normal, 1 high';
+COMMENT ON COLUMN wikis.collect_interval_minutes IS 'Minutes between collections; NULL uses the global default';
+COMMENT ON COLUMN wikis.archive_check_interval_minutes IS 'Minutes between archive checks; NULL uses the global default';
+COMMENT ON COLUMN wikis.paused_until IS 'No scheduled checks before this time';
+COMMENT ON COLUMN wikis.next_check_at IS 'When the wiki is due for collection; NULL means now';
+COMMENT ON COLUMN wikis.next_archive_check_at IS 'When the wiki is due for an archive check; NULL means now';
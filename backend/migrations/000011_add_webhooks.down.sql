@@ -0,0 +1,6 @@
+-- Remove outbound webhooks
+
+DROP INDEX IF EXISTS idx_webhook_deliveries_status_next;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_created;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
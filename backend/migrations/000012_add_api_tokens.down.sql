@@ -0,0 +1,3 @@
+-- Remove API tokens
+
+DROP TABLE IF EXISTS api_tokens;
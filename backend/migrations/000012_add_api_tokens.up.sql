@@ -0,0 +1,17 @@
+-- Named API tokens with scopes; only SHA-256 hashes of the tokens are stored
+
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    prefix VARCHAR(16) NOT NULL,
+    scopes TEXT NOT NULL,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_by VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN api_tokens.token_hash IS 'Hex SHA-256 of the token';
+COMMENT ON COLUMN api_tokens.scopes IS 'JSON array of scopes: wikis:write, admin:collect, admin:delete, export, admin';
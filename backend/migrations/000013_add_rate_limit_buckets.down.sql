@@ -0,0 +1,3 @@
+-- Remove rate limit buckets
+
+DROP TABLE IF EXISTS rate_limit_buckets;
@@ -0,0 +1,11 @@
+-- Token buckets of rate-limited clients, shared by every instance when RATE_LIMIT_STORE=postgres
+
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
+
+COMMENT ON COLUMN rate_limit_buckets.key IS 'Route group and client, e.g. submit:ip:192.0.2.1 or api:token:<id>';
@@ -0,0 +1,4 @@
+-- Remove browser sessions and login codes
+
+DROP TABLE IF EXISTS auth_login_codes;
+DROP TABLE IF EXISTS auth_sessions;
@@ -0,0 +1,29 @@
+-- Browser sessions and the one-time login codes exchanged for them; secrets are stored hashed
+
+CREATE TABLE IF NOT EXISTS auth_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    secret_hash CHAR(64) NOT NULL UNIQUE,
+    csrf_token VARCHAR(64) NOT NULL,
+    token_id UUID REFERENCES api_tokens(id) ON DELETE CASCADE,
+    credential_hash CHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_token_id ON auth_sessions(token_id);
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_expires_at ON auth_sessions(expires_at);
+
+CREATE TABLE IF NOT EXISTS auth_login_codes (
+    code_hash CHAR(64) PRIMARY KEY,
+    token_id UUID REFERENCES api_tokens(id) ON DELETE CASCADE,
+    credential_hash CHAR(64) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_login_codes_expires_at ON auth_login_codes(expires_at);
+
+COMMENT ON COLUMN auth_sessions.secret_hash IS 'Hex SHA-256 of the session cookie';
+COMMENT ON COLUMN auth_sessions.credential_hash IS 'Hex SHA-256 of the token the session was opened with';
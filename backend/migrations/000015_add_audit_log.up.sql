@@ -0,0 +1,24 @@
+-- Audit log of admin actions and state-changing public requests
+
+CREATE TABLE IF NOT EXISTS audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    actor VARCHAR(255) NOT NULL,
+    token_id UUID,
+    ip VARCHAR(64) NOT NULL,
+    action VARCHAR(64) NOT NULL,
+    target_id VARCHAR(255),
+    params TEXT,
+    outcome VARCHAR(16) NOT NULL,
+    status INTEGER NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_actor ON audit_log(actor);
+CREATE INDEX IF NOT EXISTS idx_audit_log_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_log_target_id ON audit_log(target_id);
+
+COMMENT ON COLUMN audit_log.actor IS 'Name of the API token or ADMIN_TOKEN holder, or anonymous';
+COMMENT ON COLUMN audit_log.params IS 'JSON object of path, query and body parameters with secrets redacted';
+COMMENT ON COLUMN audit_log.outcome IS 'success or failure';
@@ -0,0 +1,9 @@
+-- Remove OpenID Connect logins; their sessions cannot be told apart once the columns are gone
+
+DROP TABLE IF EXISTS auth_oidc_states;
+DELETE FROM auth_sessions WHERE subject IS NOT NULL;
+DROP INDEX IF EXISTS idx_auth_sessions_subject;
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS scopes;
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS roles;
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS email;
+ALTER TABLE auth_sessions DROP COLUMN IF EXISTS subject;
@@ -0,0 +1,23 @@
+-- OpenID Connect logins: sessions carry the user's roles and scopes, and pending
+-- logins keep their state, nonce and PKCE verifier until the provider redirects back
+
+ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS subject VARCHAR(512);
+ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS roles TEXT;
+ALTER TABLE auth_sessions ADD COLUMN IF NOT EXISTS scopes TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_auth_sessions_subject ON auth_sessions(subject);
+
+CREATE TABLE IF NOT EXISTS auth_oidc_states (
+    state_hash CHAR(64) PRIMARY KEY,
+    nonce VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    redirect_to TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_oidc_states_expires_at ON auth_oidc_states(expires_at);
+
+COMMENT ON COLUMN auth_sessions.subject IS 'OIDC issuer and subject; NULL for sessions opened with a token';
+COMMENT ON COLUMN auth_sessions.scopes IS 'JSON array of the scopes of the OIDC roles, mapped at login';
@@ -0,0 +1,3 @@
+-- Remove the submission moderation queue
+
+DROP TABLE IF EXISTS submissions;
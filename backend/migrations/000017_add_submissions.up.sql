@@ -0,0 +1,36 @@
+-- Moderation queue for anonymous wiki submissions
+
+CREATE TABLE IF NOT EXISTS submissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    url TEXT NOT NULL,
+    api_url TEXT,
+    wiki_name VARCHAR(255),
+    status VARCHAR(16) NOT NULL,
+    submitter_ip VARCHAR(64) NOT NULL,
+    proof_hash CHAR(64) UNIQUE,
+
+    checked_at TIMESTAMP,
+    reachable BOOLEAN NOT NULL DEFAULT FALSE,
+    is_mediawiki BOOLEAN NOT NULL DEFAULT FALSE,
+    sitename VARCHAR(255),
+    generator VARCHAR(255),
+    duplicate_of UUID REFERENCES wikis(id) ON DELETE SET NULL,
+    is_alias BOOLEAN NOT NULL DEFAULT FALSE,
+    check_error TEXT,
+
+    wiki_id UUID REFERENCES wikis(id) ON DELETE SET NULL,
+    reviewed_by VARCHAR(255),
+    reviewed_at TIMESTAMP,
+    reason TEXT,
+
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_submissions_status CHECK (status IN ('pending', 'checking', 'review', 'approved', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_submissions_status_created ON submissions(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_submissions_url ON submissions(url);
+
+COMMENT ON COLUMN submissions.proof_hash IS 'Hex SHA-256 of the proof-of-work challenge, so each challenge is used once';
+COMMENT ON COLUMN submissions.duplicate_of IS 'Tracked wiki with the same URL, API URL or a former URL (is_alias)';
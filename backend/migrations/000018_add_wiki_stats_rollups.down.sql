@@ -0,0 +1,4 @@
+-- Remove the wiki_stats rollup tiers
+
+DROP TABLE IF EXISTS wiki_stats_monthly;
+DROP TABLE IF EXISTS wiki_stats_daily;
@@ -0,0 +1,86 @@
+-- Rollup tiers for wiki_stats: raw rows past their retention are summarised per day,
+-- and daily rows per month
+
+CREATE TABLE IF NOT EXISTS wiki_stats_daily (
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    period_start TIMESTAMP NOT NULL, -- Start of the day, UTC
+    samples INTEGER NOT NULL DEFAULT 0,
+    first_time TIMESTAMP NOT NULL,
+    last_time TIMESTAMP NOT NULL,
+
+    -- Last, min and max of each counter over the day
+    pages_last INTEGER NOT NULL DEFAULT 0,
+    pages_min INTEGER NOT NULL DEFAULT 0,
+    pages_max INTEGER NOT NULL DEFAULT 0,
+    articles_last INTEGER NOT NULL DEFAULT 0,
+    articles_min INTEGER NOT NULL DEFAULT 0,
+    articles_max INTEGER NOT NULL DEFAULT 0,
+    edits_last INTEGER NOT NULL DEFAULT 0,
+    edits_min INTEGER NOT NULL DEFAULT 0,
+    edits_max INTEGER NOT NULL DEFAULT 0,
+    images_last INTEGER NOT NULL DEFAULT 0,
+    images_min INTEGER NOT NULL DEFAULT 0,
+    images_max INTEGER NOT NULL DEFAULT 0,
+    users_last INTEGER NOT NULL DEFAULT 0,
+    users_min INTEGER NOT NULL DEFAULT 0,
+    users_max INTEGER NOT NULL DEFAULT 0,
+    active_users_last INTEGER NOT NULL DEFAULT 0,
+    active_users_min INTEGER NOT NULL DEFAULT 0,
+    active_users_max INTEGER NOT NULL DEFAULT 0,
+    admins_last INTEGER NOT NULL DEFAULT 0,
+    admins_min INTEGER NOT NULL DEFAULT 0,
+    admins_max INTEGER NOT NULL DEFAULT 0,
+    jobs_last INTEGER NOT NULL DEFAULT 0,
+    jobs_min INTEGER NOT NULL DEFAULT 0,
+    jobs_max INTEGER NOT NULL DEFAULT 0,
+
+    -- Availability of the last sample
+    response_time_ms INTEGER,
+    http_status INTEGER,
+
+    PRIMARY KEY (wiki_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_stats_daily_period ON wiki_stats_daily(period_start);
+
+CREATE TABLE IF NOT EXISTS wiki_stats_monthly (
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    period_start TIMESTAMP NOT NULL, -- Start of the month, UTC
+    samples INTEGER NOT NULL DEFAULT 0,
+    first_time TIMESTAMP NOT NULL,
+    last_time TIMESTAMP NOT NULL,
+
+    -- Last, min and max of each counter over the month
+    pages_last INTEGER NOT NULL DEFAULT 0,
+    pages_min INTEGER NOT NULL DEFAULT 0,
+    pages_max INTEGER NOT NULL DEFAULT 0,
+    articles_last INTEGER NOT NULL DEFAULT 0,
+    articles_min INTEGER NOT NULL DEFAULT 0,
+    articles_max INTEGER NOT NULL DEFAULT 0,
+    edits_last INTEGER NOT NULL DEFAULT 0,
+    edits_min INTEGER NOT NULL DEFAULT 0,
+    edits_max INTEGER NOT NULL DEFAULT 0,
+    images_last INTEGER NOT NULL DEFAULT 0,
+    images_min INTEGER NOT NULL DEFAULT 0,
+    images_max INTEGER NOT NULL DEFAULT 0,
+    users_last INTEGER NOT NULL DEFAULT 0,
+    users_min INTEGER NOT NULL DEFAULT 0,
+    users_max INTEGER NOT NULL DEFAULT 0,
+    active_users_last INTEGER NOT NULL DEFAULT 0,
+    active_users_min INTEGER NOT NULL DEFAULT 0,
+    active_users_max INTEGER NOT NULL DEFAULT 0,
+    admins_last INTEGER NOT NULL DEFAULT 0,
+    admins_min INTEGER NOT NULL DEFAULT 0,
+    admins_max INTEGER NOT NULL DEFAULT 0,
+    jobs_last INTEGER NOT NULL DEFAULT 0,
+    jobs_min INTEGER NOT NULL DEFAULT 0,
+    jobs_max INTEGER NOT NULL DEFAULT 0,
+
+    -- Availability of the last sample
+    response_time_ms INTEGER,
+    http_status INTEGER,
+
+    PRIMARY KEY (wiki_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_stats_monthly_period ON wiki_stats_monthly(period_start);
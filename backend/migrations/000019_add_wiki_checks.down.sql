@@ -0,0 +1,3 @@
+-- Remove the check log
+
+DROP TABLE IF EXISTS wiki_checks;
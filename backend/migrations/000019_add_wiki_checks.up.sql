@@ -0,0 +1,14 @@
+-- Check log: one row per collection of a wiki. wiki_stats only stores a row when a
+-- counter changed or the heartbeat interval passed; the stats API fills the gaps from here.
+
+CREATE TABLE IF NOT EXISTS wiki_checks (
+    id BIGSERIAL PRIMARY KEY,
+    wiki_id UUID NOT NULL REFERENCES wikis(id) ON DELETE CASCADE,
+    time TIMESTAMP NOT NULL,
+    ok BOOLEAN NOT NULL DEFAULT FALSE,
+    response_time_ms INTEGER,
+    http_status INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS idx_wiki_checks_time ON wiki_checks(time);
+CREATE INDEX IF NOT EXISTS idx_wiki_checks_wiki_time ON wiki_checks(wiki_id, time DESC);
@@ -0,0 +1,4 @@
+-- Remove the archive removal tracking
+
+ALTER TABLE wiki_archives DROP COLUMN IF EXISTS missed_checks;
+ALTER TABLE wiki_archives DROP COLUMN IF EXISTS removed_at;
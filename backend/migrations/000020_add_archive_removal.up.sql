@@ -0,0 +1,8 @@
+-- Archives no longer found on Archive.org are marked removed instead of deleted.
+-- missed_checks counts consecutive searches that did not list the item.
+
+ALTER TABLE wiki_archives ADD COLUMN removed_at TIMESTAMP;
+ALTER TABLE wiki_archives ADD COLUMN missed_checks INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN wiki_archives.removed_at IS 'When the item was confirmed taken down or darked on Archive.org';
+COMMENT ON COLUMN wiki_archives.missed_checks IS 'Consecutive archive checks whose search did not list the item';
@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL schema migrations, so the binaries carry the
+// schema they expect. Files are named VERSION_NAME.up.sql and VERSION_NAME.down.sql.
+package migrations
+
+import "embed"
+
+// FS holds the migration files
+//
+//go:embed *.sql
+var FS embed.FS
@@ -12,11 +12,28 @@ interface RequestOptions {
 
 export class ApiClient {
 	private baseUrl: string;
+	private csrfToken: Promise<string | undefined> | undefined;
 
 	constructor(baseUrl: string = API_BASE) {
 		this.baseUrl = baseUrl;
 	}
 
+	/**
+	 * CSRF token of the admin session cookie, which state-changing requests must send
+	 */
+	private getCSRFToken(): Promise<string | undefined> {
+		if (!this.csrfToken) {
+			this.csrfToken = fetch(`${this.baseUrl}/api/auth/check`, { credentials: 'include' })
+				.then((response) => response.json())
+				.then((data) => data.csrf_token as string | undefined)
+				.catch(() => {
+					this.csrfToken = undefined;
+					return undefined;
+				});
+		}
+		return this.csrfToken;
+	}
+
 	private getHeaders(): Record<string, string> {
 		return {
 			'Content-Type': 'application/json',
@@ -25,13 +42,21 @@ export class ApiClient {
 
 	async request<T>(endpoint: string, options: RequestOptions = {}): Promise<T> {
 		const url = `${this.baseUrl}${endpoint}`;
+		const method = options.method || 'GET';
+		const headers: Record<string, string> = {
+			...this.getHeaders(),
+			...options.headers,
+		};
+		if (method !== 'GET') {
+			const csrfToken = await this.getCSRFToken();
+			if (csrfToken) {
+				headers['X-CSRF-Token'] = csrfToken;
+			}
+		}
 		const config: RequestInit = {
-			method: options.method || 'GET',
+			method,
 			credentials: 'include',
-			headers: {
-				...this.getHeaders(),
-				...options.headers,
-			},
+			headers,
 		};
 
 		if (options.body) {
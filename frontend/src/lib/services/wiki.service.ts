@@ -1,5 +1,5 @@
 import { apiClient } from '../apiClient';
-import type { Wiki, WikiCreate, WikiStats, WikiArchive, WikiFilters, ArchiveCheckResult, PaginatedResponse, WikiStatsResponse, WikiArchiveResponse } from '$lib/types';
+import type { Wiki, WikiCreate, Submission, SubmissionChallenge, WikiStats, WikiArchive, WikiFilters, ArchiveCheckResult, PaginatedResponse, WikiStatsResponse, WikiArchiveResponse } from '$lib/types';
 
 export const wikiService = {
 	async list(filters?: WikiFilters): Promise<PaginatedResponse<Wiki>> {
@@ -20,8 +20,17 @@ export const wikiService = {
 		return apiClient.get<Wiki>(`/api/wikis/${id}`);
 	},
 
-	async create(data: WikiCreate): Promise<Wiki> {
-		return apiClient.post<Wiki>('/api/wikis', data);
+	/** Adds a wiki, or submits it for moderation when the caller may not add wikis */
+	async create(data: WikiCreate): Promise<Wiki | Submission> {
+		return apiClient.post<Wiki | Submission>('/api/wikis', data);
+	},
+
+	async getSubmissionChallenge(): Promise<SubmissionChallenge> {
+		return apiClient.get<SubmissionChallenge>('/api/submissions/challenge');
+	},
+
+	async getSubmission(id: string): Promise<Submission> {
+		return apiClient.get<Submission>(`/api/submissions/${id}`);
 	},
 
 	async delete(id: string): Promise<{ detail: string; wiki_id: string }> {
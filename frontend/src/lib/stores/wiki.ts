@@ -1,5 +1,6 @@
 import { writable, derived } from 'svelte/store';
 import { wikiService } from '$lib/services';
+import { isSubmission } from '$lib/utils/submission';
 import type { Wiki, WikiCreate, WikiFilters } from '$lib/types';
 
 interface WikiState {
@@ -64,13 +65,14 @@ const createWikiStore = () => {
 		create: async (data: WikiCreate) => {
 			update((s) => ({ ...s, loading: true, error: null }));
 			try {
-				const newWiki = await wikiService.create(data);
+				const created = await wikiService.create(data);
 				update((s) => ({
 					...s,
-					wikis: [newWiki, ...s.wikis],
+					// Submitted wikis are listed once approved
+					wikis: isSubmission(created) ? s.wikis : [created, ...s.wikis],
 					loading: false
 				}));
-				return newWiki;
+				return created;
 			} catch (error) {
 				update((s) => ({
 					...s,
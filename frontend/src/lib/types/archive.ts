@@ -14,6 +14,7 @@ export interface WikiArchive {
 	has_titles_list: boolean;
 	has_images_list: boolean;
 	has_legacy_wikidump: boolean;
+	removed_at?: string | null;
 	created_at: string;
 	updated_at: string;
 }
@@ -28,6 +28,33 @@ export interface Wiki {
 export interface WikiCreate {
 	url: string;
 	wiki_name?: string;
+	// Solution of the submission challenge, needed without a wikis:write token
+	challenge?: string;
+	nonce?: string;
+	captcha_response?: string;
+}
+
+export type SubmissionState = 'pending' | 'checking' | 'review' | 'approved' | 'rejected';
+
+/** A wiki submitted for moderation, as returned by POST /api/wikis with status 202 */
+export interface Submission {
+	id: string;
+	url: string;
+	status: SubmissionState;
+	wiki_id?: string;
+	duplicate_of?: string;
+	reason?: string;
+	created_at: string;
+	checked_at?: string;
+	reviewed_at?: string;
+}
+
+export interface SubmissionChallenge {
+	kind: 'none' | 'pow' | 'captcha';
+	challenge?: string;
+	difficulty?: number;
+	expires_at?: string;
+	site_key?: string;
 }
 
 export interface WikiFilters {
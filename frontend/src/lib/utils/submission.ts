@@ -0,0 +1,36 @@
+import type { Submission, SubmissionChallenge, Wiki } from '$lib/types';
+
+/** Tells a submission queued for moderation from a wiki added directly */
+export function isSubmission(result: Wiki | Submission): result is Submission {
+	return !('has_archive' in result);
+}
+
+function leadingZeroBits(hash: Uint8Array): number {
+	let bits = 0;
+	for (const byte of hash) {
+		if (byte !== 0) {
+			return bits + Math.clz32(byte) - 24;
+		}
+		bits += 8;
+	}
+	return bits;
+}
+
+/**
+ * Finds a nonce such that SHA-256(challenge + nonce) starts with the challenge's
+ * number of zero bits. Hashes in batches so the page stays responsive.
+ */
+export async function solveChallenge(challenge: SubmissionChallenge): Promise<string> {
+	const prefix = challenge.challenge ?? '';
+	const difficulty = challenge.difficulty ?? 0;
+	const encoder = new TextEncoder();
+	for (let nonce = 0; ; nonce++) {
+		const digest = await crypto.subtle.digest('SHA-256', encoder.encode(prefix + nonce));
+		if (leadingZeroBits(new Uint8Array(digest)) >= difficulty) {
+			return nonce.toString();
+		}
+		if (nonce % 1000 === 999) {
+			await new Promise((resolve) => setTimeout(resolve, 0));
+		}
+	}
+}
@@ -98,5 +98,8 @@ echo "  Wiki archives: $archives_count"
 echo ""
 echo -e "${GREEN}=== Database re-import complete ===${NC}"
 echo ""
-echo "You can now start the backend:"
+echo "Check the schema version, then start the backend:"
+echo "  docker compose run --rm backend ./wikikeeper migrate status"
+echo "  docker compose run --rm backend ./wikikeeper migrate up"
 echo "  docker compose up -d backend"
+echo "Backups without a schema_migrations table need 'wikikeeper migrate force VERSION' first."